@@ -75,7 +75,7 @@ type AmqpHandler struct {
 // CryptoContext interface to access crypto functions
 type CryptoContext interface {
 	GetTLSConfig() (config *tls.Config, err error)
-	DecryptMetadata(input []byte) (output []byte, err error)
+	DecryptMetadata(input []byte, subject string) (output []byte, err error)
 }
 
 // Message AMQP message with correlation ID
@@ -643,28 +643,21 @@ func (handler *AmqpHandler) decodeDesiredStatus(
 		CertificateChains: encodedStatus.CertificateChains,
 		Certificates:      encodedStatus.Certificates}
 
-	if err = handler.decodeData(encodedStatus.BoardConfig, &decodedStatus.BoardConfig); err != nil {
-		return nil, err
-	}
-
-	if err = handler.decodeData(encodedStatus.Services, &decodedStatus.Services); err != nil {
-		return nil, err
-	}
-
-	if err = handler.decodeData(encodedStatus.Layers, &decodedStatus.Layers); err != nil {
-		return nil, err
-	}
-
-	if err = handler.decodeData(encodedStatus.Components, &decodedStatus.Components); err != nil {
-		return nil, err
-	}
-
-	if err = handler.decodeData(encodedStatus.FOTASchedule, &decodedStatus.FOTASchedule); err != nil {
-		return nil, err
-	}
-
-	if err = handler.decodeData(encodedStatus.SOTASchedule, &decodedStatus.SOTASchedule); err != nil {
-		return nil, err
+	for _, item := range []struct {
+		name   string
+		data   []byte
+		result interface{}
+	}{
+		{"boardConfig", encodedStatus.BoardConfig, &decodedStatus.BoardConfig},
+		{"services", encodedStatus.Services, &decodedStatus.Services},
+		{"layers", encodedStatus.Layers, &decodedStatus.Layers},
+		{"components", encodedStatus.Components, &decodedStatus.Components},
+		{"fotaSchedule", encodedStatus.FOTASchedule, &decodedStatus.FOTASchedule},
+		{"sotaSchedule", encodedStatus.SOTASchedule, &decodedStatus.SOTASchedule},
+	} {
+		if err = handler.decodeData(item.data, item.result, cloudprotocol.DesiredStatusType+"/"+item.name); err != nil {
+			return nil, err
+		}
 	}
 
 	return decodedStatus, nil
@@ -676,7 +669,8 @@ func (handler *AmqpHandler) decodeRenewCertsNotification(
 	var secret cloudprotocol.UnitSecret
 
 	if len(encodedNotification.UnitSecureData) > 0 {
-		if err = handler.decodeData(encodedNotification.UnitSecureData, &secret); err != nil {
+		if err = handler.decodeData(
+			encodedNotification.UnitSecureData, &secret, cloudprotocol.RenewCertsNotificationType); err != nil {
 			return nil, err
 		}
 
@@ -694,19 +688,21 @@ func (handler *AmqpHandler) decodeEnvVars(
 	encodedEnvVars *cloudprotocol.OverrideEnvVars) (decodedEnvVars *cloudprotocol.DecodedOverrideEnvVars, err error) {
 	decodedEnvVars = &cloudprotocol.DecodedOverrideEnvVars{}
 
-	if err = handler.decodeData(encodedEnvVars.OverrideEnvVars, decodedEnvVars); err != nil {
+	if err = handler.decodeData(
+		encodedEnvVars.OverrideEnvVars, decodedEnvVars, cloudprotocol.OverrideEnvVarsType); err != nil {
 		return nil, err
 	}
 
 	return decodedEnvVars, nil
 }
 
-func (handler *AmqpHandler) decodeData(data []byte, result interface{}) (err error) {
+// decodeData decrypts and unmarshals data, data name identifies decrypted data in crypto audit log
+func (handler *AmqpHandler) decodeData(data []byte, result interface{}, dataName string) (err error) {
 	if len(data) == 0 {
 		return nil
 	}
 
-	decryptData, err := handler.cryptoContext.DecryptMetadata(data)
+	decryptData, err := handler.cryptoContext.DecryptMetadata(data, dataName)
 	if err != nil {
 		return aoserrors.Wrap(err)
 	}
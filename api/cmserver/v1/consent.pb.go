@@ -0,0 +1,319 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.27.1
+// 	protoc        v3.6.1
+// source: cmserver/v1/consent.proto
+
+package cmserver
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type UpdateType int32
+
+const (
+	UpdateType_FOTA UpdateType = 0
+	UpdateType_SOTA UpdateType = 1
+)
+
+// Enum value maps for UpdateType.
+var (
+	UpdateType_name = map[int32]string{
+		0: "FOTA",
+		1: "SOTA",
+	}
+	UpdateType_value = map[string]int32{
+		"FOTA": 0,
+		"SOTA": 1,
+	}
+)
+
+func (x UpdateType) Enum() *UpdateType {
+	p := new(UpdateType)
+	*p = x
+	return p
+}
+
+func (x UpdateType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (UpdateType) Descriptor() protoreflect.EnumDescriptor {
+	return file_cmserver_v1_consent_proto_enumTypes[0].Descriptor()
+}
+
+func (UpdateType) Type() protoreflect.EnumType {
+	return &file_cmserver_v1_consent_proto_enumTypes[0]
+}
+
+func (x UpdateType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use UpdateType.Descriptor instead.
+func (UpdateType) EnumDescriptor() ([]byte, []int) {
+	return file_cmserver_v1_consent_proto_rawDescGZIP(), []int{0}
+}
+
+type PostponeUpdateRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type     UpdateType           `protobuf:"varint,1,opt,name=type,proto3,enum=cmserver.v1.UpdateType" json:"type,omitempty"`
+	Reason   string               `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	Duration *durationpb.Duration `protobuf:"bytes,3,opt,name=duration,proto3" json:"duration,omitempty"`
+}
+
+func (x *PostponeUpdateRequest) Reset() {
+	*x = PostponeUpdateRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_cmserver_v1_consent_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PostponeUpdateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PostponeUpdateRequest) ProtoMessage() {}
+
+func (x *PostponeUpdateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cmserver_v1_consent_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PostponeUpdateRequest.ProtoReflect.Descriptor instead.
+func (*PostponeUpdateRequest) Descriptor() ([]byte, []int) {
+	return file_cmserver_v1_consent_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PostponeUpdateRequest) GetType() UpdateType {
+	if x != nil {
+		return x.Type
+	}
+	return UpdateType_FOTA
+}
+
+func (x *PostponeUpdateRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *PostponeUpdateRequest) GetDuration() *durationpb.Duration {
+	if x != nil {
+		return x.Duration
+	}
+	return nil
+}
+
+type DeclineUpdateRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type   UpdateType `protobuf:"varint,1,opt,name=type,proto3,enum=cmserver.v1.UpdateType" json:"type,omitempty"`
+	Reason string     `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+}
+
+func (x *DeclineUpdateRequest) Reset() {
+	*x = DeclineUpdateRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_cmserver_v1_consent_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeclineUpdateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeclineUpdateRequest) ProtoMessage() {}
+
+func (x *DeclineUpdateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cmserver_v1_consent_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeclineUpdateRequest.ProtoReflect.Descriptor instead.
+func (*DeclineUpdateRequest) Descriptor() ([]byte, []int) {
+	return file_cmserver_v1_consent_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DeclineUpdateRequest) GetType() UpdateType {
+	if x != nil {
+		return x.Type
+	}
+	return UpdateType_FOTA
+}
+
+func (x *DeclineUpdateRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+var File_cmserver_v1_consent_proto protoreflect.FileDescriptor
+
+var file_cmserver_v1_consent_proto_rawDesc = []byte{
+	0x0a, 0x19, 0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2f, 0x76, 0x31, 0x2f, 0x63, 0x6f,
+	0x6e, 0x73, 0x65, 0x6e, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0b, 0x63, 0x6d, 0x73,
+	0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x1a, 0x1e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1b, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x65, 0x6d, 0x70, 0x74, 0x79, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x93, 0x01, 0x0a, 0x15, 0x50, 0x6f, 0x73, 0x74, 0x70, 0x6f,
+	0x6e, 0x65, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x2b, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x17, 0x2e,
+	0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x70, 0x64, 0x61,
+	0x74, 0x65, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x16, 0x0a, 0x06,
+	0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65,
+	0x61, 0x73, 0x6f, 0x6e, 0x12, 0x35, 0x0a, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x52, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x5b, 0x0a, 0x14, 0x44,
+	0x65, 0x63, 0x6c, 0x69, 0x6e, 0x65, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x2b, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x17, 0x2e, 0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65,
+	0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x2a, 0x20, 0x0a, 0x0a, 0x55, 0x70, 0x64, 0x61,
+	0x74, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x08, 0x0a, 0x04, 0x46, 0x4f, 0x54, 0x41, 0x10, 0x00,
+	0x12, 0x08, 0x0a, 0x04, 0x53, 0x4f, 0x54, 0x41, 0x10, 0x01, 0x32, 0xb4, 0x01, 0x0a, 0x14, 0x55,
+	0x70, 0x64, 0x61, 0x74, 0x65, 0x43, 0x6f, 0x6e, 0x73, 0x65, 0x6e, 0x74, 0x53, 0x65, 0x72, 0x76,
+	0x69, 0x63, 0x65, 0x12, 0x4e, 0x0a, 0x0e, 0x50, 0x6f, 0x73, 0x74, 0x70, 0x6f, 0x6e, 0x65, 0x55,
+	0x70, 0x64, 0x61, 0x74, 0x65, 0x12, 0x22, 0x2e, 0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72,
+	0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6f, 0x73, 0x74, 0x70, 0x6f, 0x6e, 0x65, 0x55, 0x70, 0x64, 0x61,
+	0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74,
+	0x79, 0x22, 0x00, 0x12, 0x4c, 0x0a, 0x0d, 0x44, 0x65, 0x63, 0x6c, 0x69, 0x6e, 0x65, 0x55, 0x70,
+	0x64, 0x61, 0x74, 0x65, 0x12, 0x21, 0x2e, 0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e,
+	0x76, 0x31, 0x2e, 0x44, 0x65, 0x63, 0x6c, 0x69, 0x6e, 0x65, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22,
+	0x00, 0x42, 0x33, 0x5a, 0x31, 0x61, 0x6f, 0x73, 0x5f, 0x63, 0x6f, 0x6d, 0x6d, 0x75, 0x6e, 0x69,
+	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x2f, 0x61, 0x70,
+	0x69, 0x2f, 0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2f, 0x76, 0x31, 0x3b, 0x63, 0x6d,
+	0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_cmserver_v1_consent_proto_rawDescOnce sync.Once
+	file_cmserver_v1_consent_proto_rawDescData = file_cmserver_v1_consent_proto_rawDesc
+)
+
+func file_cmserver_v1_consent_proto_rawDescGZIP() []byte {
+	file_cmserver_v1_consent_proto_rawDescOnce.Do(func() {
+		file_cmserver_v1_consent_proto_rawDescData = protoimpl.X.CompressGZIP(file_cmserver_v1_consent_proto_rawDescData)
+	})
+	return file_cmserver_v1_consent_proto_rawDescData
+}
+
+var file_cmserver_v1_consent_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_cmserver_v1_consent_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_cmserver_v1_consent_proto_goTypes = []interface{}{
+	(UpdateType)(0),               // 0: cmserver.v1.UpdateType
+	(*PostponeUpdateRequest)(nil), // 1: cmserver.v1.PostponeUpdateRequest
+	(*DeclineUpdateRequest)(nil),  // 2: cmserver.v1.DeclineUpdateRequest
+	(*durationpb.Duration)(nil),   // 3: google.protobuf.Duration
+	(*emptypb.Empty)(nil),         // 4: google.protobuf.Empty
+}
+var file_cmserver_v1_consent_proto_depIdxs = []int32{
+	0, // 0: cmserver.v1.PostponeUpdateRequest.type:type_name -> cmserver.v1.UpdateType
+	3, // 1: cmserver.v1.PostponeUpdateRequest.duration:type_name -> google.protobuf.Duration
+	0, // 2: cmserver.v1.DeclineUpdateRequest.type:type_name -> cmserver.v1.UpdateType
+	1, // 3: cmserver.v1.UpdateConsentService.PostponeUpdate:input_type -> cmserver.v1.PostponeUpdateRequest
+	2, // 4: cmserver.v1.UpdateConsentService.DeclineUpdate:input_type -> cmserver.v1.DeclineUpdateRequest
+	4, // 5: cmserver.v1.UpdateConsentService.PostponeUpdate:output_type -> google.protobuf.Empty
+	4, // 6: cmserver.v1.UpdateConsentService.DeclineUpdate:output_type -> google.protobuf.Empty
+	5, // [5:7] is the sub-list for method output_type
+	3, // [3:5] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_cmserver_v1_consent_proto_init() }
+func file_cmserver_v1_consent_proto_init() {
+	if File_cmserver_v1_consent_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_cmserver_v1_consent_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PostponeUpdateRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_cmserver_v1_consent_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeclineUpdateRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_cmserver_v1_consent_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_cmserver_v1_consent_proto_goTypes,
+		DependencyIndexes: file_cmserver_v1_consent_proto_depIdxs,
+		EnumInfos:         file_cmserver_v1_consent_proto_enumTypes,
+		MessageInfos:      file_cmserver_v1_consent_proto_msgTypes,
+	}.Build()
+	File_cmserver_v1_consent_proto = out.File
+	file_cmserver_v1_consent_proto_rawDesc = nil
+	file_cmserver_v1_consent_proto_goTypes = nil
+	file_cmserver_v1_consent_proto_depIdxs = nil
+}
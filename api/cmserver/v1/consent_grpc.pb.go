@@ -0,0 +1,138 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package cmserver
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// UpdateConsentServiceClient is the client API for UpdateConsentService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type UpdateConsentServiceClient interface {
+	PostponeUpdate(ctx context.Context, in *PostponeUpdateRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	DeclineUpdate(ctx context.Context, in *DeclineUpdateRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type updateConsentServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewUpdateConsentServiceClient(cc grpc.ClientConnInterface) UpdateConsentServiceClient {
+	return &updateConsentServiceClient{cc}
+}
+
+func (c *updateConsentServiceClient) PostponeUpdate(ctx context.Context, in *PostponeUpdateRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/cmserver.v1.UpdateConsentService/PostponeUpdate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *updateConsentServiceClient) DeclineUpdate(ctx context.Context, in *DeclineUpdateRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/cmserver.v1.UpdateConsentService/DeclineUpdate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpdateConsentServiceServer is the server API for UpdateConsentService service.
+// All implementations must embed UnimplementedUpdateConsentServiceServer
+// for forward compatibility
+type UpdateConsentServiceServer interface {
+	PostponeUpdate(context.Context, *PostponeUpdateRequest) (*emptypb.Empty, error)
+	DeclineUpdate(context.Context, *DeclineUpdateRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedUpdateConsentServiceServer()
+}
+
+// UnimplementedUpdateConsentServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedUpdateConsentServiceServer struct {
+}
+
+func (UnimplementedUpdateConsentServiceServer) PostponeUpdate(context.Context, *PostponeUpdateRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PostponeUpdate not implemented")
+}
+func (UnimplementedUpdateConsentServiceServer) DeclineUpdate(context.Context, *DeclineUpdateRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeclineUpdate not implemented")
+}
+func (UnimplementedUpdateConsentServiceServer) mustEmbedUnimplementedUpdateConsentServiceServer() {}
+
+// UnsafeUpdateConsentServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to UpdateConsentServiceServer will
+// result in compilation errors.
+type UnsafeUpdateConsentServiceServer interface {
+	mustEmbedUnimplementedUpdateConsentServiceServer()
+}
+
+func RegisterUpdateConsentServiceServer(s grpc.ServiceRegistrar, srv UpdateConsentServiceServer) {
+	s.RegisterService(&UpdateConsentService_ServiceDesc, srv)
+}
+
+func _UpdateConsentService_PostponeUpdate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PostponeUpdateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UpdateConsentServiceServer).PostponeUpdate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cmserver.v1.UpdateConsentService/PostponeUpdate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UpdateConsentServiceServer).PostponeUpdate(ctx, req.(*PostponeUpdateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UpdateConsentService_DeclineUpdate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeclineUpdateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UpdateConsentServiceServer).DeclineUpdate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cmserver.v1.UpdateConsentService/DeclineUpdate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UpdateConsentServiceServer).DeclineUpdate(ctx, req.(*DeclineUpdateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// UpdateConsentService_ServiceDesc is the grpc.ServiceDesc for UpdateConsentService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var UpdateConsentService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cmserver.v1.UpdateConsentService",
+	HandlerType: (*UpdateConsentServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PostponeUpdate",
+			Handler:    _UpdateConsentService_PostponeUpdate_Handler,
+		},
+		{
+			MethodName: "DeclineUpdate",
+			Handler:    _UpdateConsentService_DeclineUpdate_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "cmserver/v1/consent.proto",
+}
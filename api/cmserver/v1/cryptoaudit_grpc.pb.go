@@ -0,0 +1,137 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package cmserver
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// CryptoAuditServiceClient is the client API for CryptoAuditService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CryptoAuditServiceClient interface {
+	GetCryptoAuditRecords(ctx context.Context, in *CryptoAuditFilter, opts ...grpc.CallOption) (*CryptoAuditRecords, error)
+	ExportCryptoAuditLog(ctx context.Context, in *CryptoAuditFilter, opts ...grpc.CallOption) (*CryptoAuditExport, error)
+}
+
+type cryptoAuditServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCryptoAuditServiceClient(cc grpc.ClientConnInterface) CryptoAuditServiceClient {
+	return &cryptoAuditServiceClient{cc}
+}
+
+func (c *cryptoAuditServiceClient) GetCryptoAuditRecords(ctx context.Context, in *CryptoAuditFilter, opts ...grpc.CallOption) (*CryptoAuditRecords, error) {
+	out := new(CryptoAuditRecords)
+	err := c.cc.Invoke(ctx, "/cmserver.v1.CryptoAuditService/GetCryptoAuditRecords", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cryptoAuditServiceClient) ExportCryptoAuditLog(ctx context.Context, in *CryptoAuditFilter, opts ...grpc.CallOption) (*CryptoAuditExport, error) {
+	out := new(CryptoAuditExport)
+	err := c.cc.Invoke(ctx, "/cmserver.v1.CryptoAuditService/ExportCryptoAuditLog", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CryptoAuditServiceServer is the server API for CryptoAuditService service.
+// All implementations must embed UnimplementedCryptoAuditServiceServer
+// for forward compatibility
+type CryptoAuditServiceServer interface {
+	GetCryptoAuditRecords(context.Context, *CryptoAuditFilter) (*CryptoAuditRecords, error)
+	ExportCryptoAuditLog(context.Context, *CryptoAuditFilter) (*CryptoAuditExport, error)
+	mustEmbedUnimplementedCryptoAuditServiceServer()
+}
+
+// UnimplementedCryptoAuditServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedCryptoAuditServiceServer struct {
+}
+
+func (UnimplementedCryptoAuditServiceServer) GetCryptoAuditRecords(context.Context, *CryptoAuditFilter) (*CryptoAuditRecords, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCryptoAuditRecords not implemented")
+}
+func (UnimplementedCryptoAuditServiceServer) ExportCryptoAuditLog(context.Context, *CryptoAuditFilter) (*CryptoAuditExport, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportCryptoAuditLog not implemented")
+}
+func (UnimplementedCryptoAuditServiceServer) mustEmbedUnimplementedCryptoAuditServiceServer() {}
+
+// UnsafeCryptoAuditServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CryptoAuditServiceServer will
+// result in compilation errors.
+type UnsafeCryptoAuditServiceServer interface {
+	mustEmbedUnimplementedCryptoAuditServiceServer()
+}
+
+func RegisterCryptoAuditServiceServer(s grpc.ServiceRegistrar, srv CryptoAuditServiceServer) {
+	s.RegisterService(&CryptoAuditService_ServiceDesc, srv)
+}
+
+func _CryptoAuditService_GetCryptoAuditRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CryptoAuditFilter)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CryptoAuditServiceServer).GetCryptoAuditRecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cmserver.v1.CryptoAuditService/GetCryptoAuditRecords",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CryptoAuditServiceServer).GetCryptoAuditRecords(ctx, req.(*CryptoAuditFilter))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CryptoAuditService_ExportCryptoAuditLog_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CryptoAuditFilter)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CryptoAuditServiceServer).ExportCryptoAuditLog(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cmserver.v1.CryptoAuditService/ExportCryptoAuditLog",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CryptoAuditServiceServer).ExportCryptoAuditLog(ctx, req.(*CryptoAuditFilter))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CryptoAuditService_ServiceDesc is the grpc.ServiceDesc for CryptoAuditService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CryptoAuditService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cmserver.v1.CryptoAuditService",
+	HandlerType: (*CryptoAuditServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetCryptoAuditRecords",
+			Handler:    _CryptoAuditService_GetCryptoAuditRecords_Handler,
+		},
+		{
+			MethodName: "ExportCryptoAuditLog",
+			Handler:    _CryptoAuditService_ExportCryptoAuditLog_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "cmserver/v1/cryptoaudit.proto",
+}
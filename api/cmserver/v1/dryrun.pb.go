@@ -0,0 +1,990 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.27.1
+// 	protoc        v3.6.1
+// source: cmserver/v1/dryrun.proto
+
+package cmserver
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type DryRunRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// JSON encoded decoded desired status
+	DesiredStatus []byte `protobuf:"bytes,1,opt,name=desired_status,json=desiredStatus,proto3" json:"desired_status,omitempty"`
+}
+
+func (x *DryRunRequest) Reset() {
+	*x = DryRunRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_cmserver_v1_dryrun_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DryRunRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DryRunRequest) ProtoMessage() {}
+
+func (x *DryRunRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cmserver_v1_dryrun_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DryRunRequest.ProtoReflect.Descriptor instead.
+func (*DryRunRequest) Descriptor() ([]byte, []int) {
+	return file_cmserver_v1_dryrun_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *DryRunRequest) GetDesiredStatus() []byte {
+	if x != nil {
+		return x.DesiredStatus
+	}
+	return nil
+}
+
+type DownloadPlan struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type   string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Id     string `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	Size   uint64 `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	Cached bool   `protobuf:"varint,4,opt,name=cached,proto3" json:"cached,omitempty"`
+}
+
+func (x *DownloadPlan) Reset() {
+	*x = DownloadPlan{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_cmserver_v1_dryrun_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DownloadPlan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadPlan) ProtoMessage() {}
+
+func (x *DownloadPlan) ProtoReflect() protoreflect.Message {
+	mi := &file_cmserver_v1_dryrun_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadPlan.ProtoReflect.Descriptor instead.
+func (*DownloadPlan) Descriptor() ([]byte, []int) {
+	return file_cmserver_v1_dryrun_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DownloadPlan) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *DownloadPlan) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DownloadPlan) GetSize() uint64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *DownloadPlan) GetCached() bool {
+	if x != nil {
+		return x.Cached
+	}
+	return false
+}
+
+type SchedulePlan struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Ttl           uint64                 `protobuf:"varint,2,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	EarliestStart *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=earliest_start,json=earliestStart,proto3" json:"earliest_start,omitempty"`
+	Error         string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *SchedulePlan) Reset() {
+	*x = SchedulePlan{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_cmserver_v1_dryrun_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SchedulePlan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SchedulePlan) ProtoMessage() {}
+
+func (x *SchedulePlan) ProtoReflect() protoreflect.Message {
+	mi := &file_cmserver_v1_dryrun_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SchedulePlan.ProtoReflect.Descriptor instead.
+func (*SchedulePlan) Descriptor() ([]byte, []int) {
+	return file_cmserver_v1_dryrun_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SchedulePlan) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *SchedulePlan) GetTtl() uint64 {
+	if x != nil {
+		return x.Ttl
+	}
+	return 0
+}
+
+func (x *SchedulePlan) GetEarliestStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EarliestStart
+	}
+	return nil
+}
+
+func (x *SchedulePlan) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ComponentPlan struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id            string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	AosVersion    uint64 `protobuf:"varint,2,opt,name=aos_version,json=aosVersion,proto3" json:"aos_version,omitempty"`
+	VendorVersion string `protobuf:"bytes,3,opt,name=vendor_version,json=vendorVersion,proto3" json:"vendor_version,omitempty"`
+	UmId          string `protobuf:"bytes,4,opt,name=um_id,json=umId,proto3" json:"um_id,omitempty"`
+	Error         string `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *ComponentPlan) Reset() {
+	*x = ComponentPlan{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_cmserver_v1_dryrun_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ComponentPlan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ComponentPlan) ProtoMessage() {}
+
+func (x *ComponentPlan) ProtoReflect() protoreflect.Message {
+	mi := &file_cmserver_v1_dryrun_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ComponentPlan.ProtoReflect.Descriptor instead.
+func (*ComponentPlan) Descriptor() ([]byte, []int) {
+	return file_cmserver_v1_dryrun_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ComponentPlan) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ComponentPlan) GetAosVersion() uint64 {
+	if x != nil {
+		return x.AosVersion
+	}
+	return 0
+}
+
+func (x *ComponentPlan) GetVendorVersion() string {
+	if x != nil {
+		return x.VendorVersion
+	}
+	return ""
+}
+
+func (x *ComponentPlan) GetUmId() string {
+	if x != nil {
+		return x.UmId
+	}
+	return ""
+}
+
+func (x *ComponentPlan) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type BoardConfigPlan struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CurrentVersion string `protobuf:"bytes,1,opt,name=current_version,json=currentVersion,proto3" json:"current_version,omitempty"`
+	NewVersion     string `protobuf:"bytes,2,opt,name=new_version,json=newVersion,proto3" json:"new_version,omitempty"`
+	Error          string `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *BoardConfigPlan) Reset() {
+	*x = BoardConfigPlan{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_cmserver_v1_dryrun_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BoardConfigPlan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BoardConfigPlan) ProtoMessage() {}
+
+func (x *BoardConfigPlan) ProtoReflect() protoreflect.Message {
+	mi := &file_cmserver_v1_dryrun_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BoardConfigPlan.ProtoReflect.Descriptor instead.
+func (*BoardConfigPlan) Descriptor() ([]byte, []int) {
+	return file_cmserver_v1_dryrun_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BoardConfigPlan) GetCurrentVersion() string {
+	if x != nil {
+		return x.CurrentVersion
+	}
+	return ""
+}
+
+func (x *BoardConfigPlan) GetNewVersion() string {
+	if x != nil {
+		return x.NewVersion
+	}
+	return ""
+}
+
+func (x *BoardConfigPlan) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ServicePlan struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id         string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	AosVersion uint64 `protobuf:"varint,2,opt,name=aos_version,json=aosVersion,proto3" json:"aos_version,omitempty"`
+}
+
+func (x *ServicePlan) Reset() {
+	*x = ServicePlan{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_cmserver_v1_dryrun_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ServicePlan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServicePlan) ProtoMessage() {}
+
+func (x *ServicePlan) ProtoReflect() protoreflect.Message {
+	mi := &file_cmserver_v1_dryrun_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServicePlan.ProtoReflect.Descriptor instead.
+func (*ServicePlan) Descriptor() ([]byte, []int) {
+	return file_cmserver_v1_dryrun_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ServicePlan) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ServicePlan) GetAosVersion() uint64 {
+	if x != nil {
+		return x.AosVersion
+	}
+	return 0
+}
+
+type LayerPlan struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id         string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Digest     string `protobuf:"bytes,2,opt,name=digest,proto3" json:"digest,omitempty"`
+	AosVersion uint64 `protobuf:"varint,3,opt,name=aos_version,json=aosVersion,proto3" json:"aos_version,omitempty"`
+}
+
+func (x *LayerPlan) Reset() {
+	*x = LayerPlan{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_cmserver_v1_dryrun_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LayerPlan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LayerPlan) ProtoMessage() {}
+
+func (x *LayerPlan) ProtoReflect() protoreflect.Message {
+	mi := &file_cmserver_v1_dryrun_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LayerPlan.ProtoReflect.Descriptor instead.
+func (*LayerPlan) Descriptor() ([]byte, []int) {
+	return file_cmserver_v1_dryrun_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *LayerPlan) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *LayerPlan) GetDigest() string {
+	if x != nil {
+		return x.Digest
+	}
+	return ""
+}
+
+func (x *LayerPlan) GetAosVersion() uint64 {
+	if x != nil {
+		return x.AosVersion
+	}
+	return 0
+}
+
+type FOTAPlan struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Components  []*ComponentPlan `protobuf:"bytes,1,rep,name=components,proto3" json:"components,omitempty"`
+	BoardConfig *BoardConfigPlan `protobuf:"bytes,2,opt,name=board_config,json=boardConfig,proto3" json:"board_config,omitempty"`
+	Downloads   []*DownloadPlan  `protobuf:"bytes,3,rep,name=downloads,proto3" json:"downloads,omitempty"`
+	Schedule    *SchedulePlan    `protobuf:"bytes,4,opt,name=schedule,proto3" json:"schedule,omitempty"`
+}
+
+func (x *FOTAPlan) Reset() {
+	*x = FOTAPlan{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_cmserver_v1_dryrun_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FOTAPlan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FOTAPlan) ProtoMessage() {}
+
+func (x *FOTAPlan) ProtoReflect() protoreflect.Message {
+	mi := &file_cmserver_v1_dryrun_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FOTAPlan.ProtoReflect.Descriptor instead.
+func (*FOTAPlan) Descriptor() ([]byte, []int) {
+	return file_cmserver_v1_dryrun_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *FOTAPlan) GetComponents() []*ComponentPlan {
+	if x != nil {
+		return x.Components
+	}
+	return nil
+}
+
+func (x *FOTAPlan) GetBoardConfig() *BoardConfigPlan {
+	if x != nil {
+		return x.BoardConfig
+	}
+	return nil
+}
+
+func (x *FOTAPlan) GetDownloads() []*DownloadPlan {
+	if x != nil {
+		return x.Downloads
+	}
+	return nil
+}
+
+func (x *FOTAPlan) GetSchedule() *SchedulePlan {
+	if x != nil {
+		return x.Schedule
+	}
+	return nil
+}
+
+type SOTAPlan struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	InstallServices []*ServicePlan  `protobuf:"bytes,1,rep,name=install_services,json=installServices,proto3" json:"install_services,omitempty"`
+	RemoveServices  []*ServicePlan  `protobuf:"bytes,2,rep,name=remove_services,json=removeServices,proto3" json:"remove_services,omitempty"`
+	InstallLayers   []*LayerPlan    `protobuf:"bytes,3,rep,name=install_layers,json=installLayers,proto3" json:"install_layers,omitempty"`
+	RemoveLayers    []*LayerPlan    `protobuf:"bytes,4,rep,name=remove_layers,json=removeLayers,proto3" json:"remove_layers,omitempty"`
+	Downloads       []*DownloadPlan `protobuf:"bytes,5,rep,name=downloads,proto3" json:"downloads,omitempty"`
+	Schedule        *SchedulePlan   `protobuf:"bytes,6,opt,name=schedule,proto3" json:"schedule,omitempty"`
+}
+
+func (x *SOTAPlan) Reset() {
+	*x = SOTAPlan{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_cmserver_v1_dryrun_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SOTAPlan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SOTAPlan) ProtoMessage() {}
+
+func (x *SOTAPlan) ProtoReflect() protoreflect.Message {
+	mi := &file_cmserver_v1_dryrun_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SOTAPlan.ProtoReflect.Descriptor instead.
+func (*SOTAPlan) Descriptor() ([]byte, []int) {
+	return file_cmserver_v1_dryrun_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SOTAPlan) GetInstallServices() []*ServicePlan {
+	if x != nil {
+		return x.InstallServices
+	}
+	return nil
+}
+
+func (x *SOTAPlan) GetRemoveServices() []*ServicePlan {
+	if x != nil {
+		return x.RemoveServices
+	}
+	return nil
+}
+
+func (x *SOTAPlan) GetInstallLayers() []*LayerPlan {
+	if x != nil {
+		return x.InstallLayers
+	}
+	return nil
+}
+
+func (x *SOTAPlan) GetRemoveLayers() []*LayerPlan {
+	if x != nil {
+		return x.RemoveLayers
+	}
+	return nil
+}
+
+func (x *SOTAPlan) GetDownloads() []*DownloadPlan {
+	if x != nil {
+		return x.Downloads
+	}
+	return nil
+}
+
+func (x *SOTAPlan) GetSchedule() *SchedulePlan {
+	if x != nil {
+		return x.Schedule
+	}
+	return nil
+}
+
+type UpdatePlan struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Fota *FOTAPlan `protobuf:"bytes,1,opt,name=fota,proto3" json:"fota,omitempty"`
+	Sota *SOTAPlan `protobuf:"bytes,2,opt,name=sota,proto3" json:"sota,omitempty"`
+}
+
+func (x *UpdatePlan) Reset() {
+	*x = UpdatePlan{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_cmserver_v1_dryrun_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UpdatePlan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdatePlan) ProtoMessage() {}
+
+func (x *UpdatePlan) ProtoReflect() protoreflect.Message {
+	mi := &file_cmserver_v1_dryrun_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdatePlan.ProtoReflect.Descriptor instead.
+func (*UpdatePlan) Descriptor() ([]byte, []int) {
+	return file_cmserver_v1_dryrun_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *UpdatePlan) GetFota() *FOTAPlan {
+	if x != nil {
+		return x.Fota
+	}
+	return nil
+}
+
+func (x *UpdatePlan) GetSota() *SOTAPlan {
+	if x != nil {
+		return x.Sota
+	}
+	return nil
+}
+
+var File_cmserver_v1_dryrun_proto protoreflect.FileDescriptor
+
+var file_cmserver_v1_dryrun_proto_rawDesc = []byte{
+	0x0a, 0x18, 0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2f, 0x76, 0x31, 0x2f, 0x64, 0x72,
+	0x79, 0x72, 0x75, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0b, 0x63, 0x6d, 0x73, 0x65,
+	0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x36, 0x0a, 0x0d, 0x44, 0x72, 0x79, 0x52,
+	0x75, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x25, 0x0a, 0x0e, 0x64, 0x65, 0x73,
+	0x69, 0x72, 0x65, 0x64, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x0d, 0x64, 0x65, 0x73, 0x69, 0x72, 0x65, 0x64, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x22, 0x5e, 0x0a, 0x0c, 0x44, 0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x50, 0x6c, 0x61, 0x6e,
+	0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x74, 0x79, 0x70, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x61, 0x63, 0x68,
+	0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x63, 0x61, 0x63, 0x68, 0x65, 0x64,
+	0x22, 0x8d, 0x01, 0x0a, 0x0c, 0x53, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x50, 0x6c, 0x61,
+	0x6e, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x74, 0x74, 0x6c, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x03, 0x74, 0x74, 0x6c, 0x12, 0x41, 0x0a, 0x0e, 0x65, 0x61, 0x72, 0x6c, 0x69,
+	0x65, 0x73, 0x74, 0x5f, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0d, 0x65, 0x61, 0x72,
+	0x6c, 0x69, 0x65, 0x73, 0x74, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72,
+	0x72, 0x6f, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72,
+	0x22, 0x92, 0x01, 0x0a, 0x0d, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x50, 0x6c,
+	0x61, 0x6e, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02,
+	0x69, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x61, 0x6f, 0x73, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x61, 0x6f, 0x73, 0x56, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x12, 0x25, 0x0a, 0x0e, 0x76, 0x65, 0x6e, 0x64, 0x6f, 0x72, 0x5f, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x76, 0x65, 0x6e,
+	0x64, 0x6f, 0x72, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x13, 0x0a, 0x05, 0x75, 0x6d,
+	0x5f, 0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x75, 0x6d, 0x49, 0x64, 0x12,
+	0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x71, 0x0a, 0x0f, 0x42, 0x6f, 0x61, 0x72, 0x64, 0x43, 0x6f,
+	0x6e, 0x66, 0x69, 0x67, 0x50, 0x6c, 0x61, 0x6e, 0x12, 0x27, 0x0a, 0x0f, 0x63, 0x75, 0x72, 0x72,
+	0x65, 0x6e, 0x74, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0e, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x12, 0x1f, 0x0a, 0x0b, 0x6e, 0x65, 0x77, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x6e, 0x65, 0x77, 0x56, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x3e, 0x0a, 0x0b, 0x53, 0x65, 0x72, 0x76,
+	0x69, 0x63, 0x65, 0x50, 0x6c, 0x61, 0x6e, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x61, 0x6f, 0x73, 0x5f, 0x76,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x61, 0x6f,
+	0x73, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x54, 0x0a, 0x09, 0x4c, 0x61, 0x79, 0x65,
+	0x72, 0x50, 0x6c, 0x61, 0x6e, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x69, 0x67, 0x65, 0x73, 0x74, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x64, 0x69, 0x67, 0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a,
+	0x0b, 0x61, 0x6f, 0x73, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x0a, 0x61, 0x6f, 0x73, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0xf7,
+	0x01, 0x0a, 0x08, 0x46, 0x4f, 0x54, 0x41, 0x50, 0x6c, 0x61, 0x6e, 0x12, 0x3a, 0x0a, 0x0a, 0x63,
+	0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x1a, 0x2e, 0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f,
+	0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x50, 0x6c, 0x61, 0x6e, 0x52, 0x0a, 0x63, 0x6f, 0x6d,
+	0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x3f, 0x0a, 0x0c, 0x62, 0x6f, 0x61, 0x72, 0x64,
+	0x5f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e,
+	0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x6f, 0x61, 0x72,
+	0x64, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x50, 0x6c, 0x61, 0x6e, 0x52, 0x0b, 0x62, 0x6f, 0x61,
+	0x72, 0x64, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x37, 0x0a, 0x09, 0x64, 0x6f, 0x77, 0x6e,
+	0x6c, 0x6f, 0x61, 0x64, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x63, 0x6d,
+	0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x6f, 0x77, 0x6e, 0x6c, 0x6f,
+	0x61, 0x64, 0x50, 0x6c, 0x61, 0x6e, 0x52, 0x09, 0x64, 0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61, 0x64,
+	0x73, 0x12, 0x35, 0x0a, 0x08, 0x73, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76,
+	0x31, 0x2e, 0x53, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x50, 0x6c, 0x61, 0x6e, 0x52, 0x08,
+	0x73, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x22, 0xfe, 0x02, 0x0a, 0x08, 0x53, 0x4f, 0x54,
+	0x41, 0x50, 0x6c, 0x61, 0x6e, 0x12, 0x43, 0x0a, 0x10, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6c, 0x6c,
+	0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x18, 0x2e, 0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x50, 0x6c, 0x61, 0x6e, 0x52, 0x0f, 0x69, 0x6e, 0x73, 0x74, 0x61,
+	0x6c, 0x6c, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73, 0x12, 0x41, 0x0a, 0x0f, 0x72, 0x65,
+	0x6d, 0x6f, 0x76, 0x65, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73, 0x18, 0x02, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76,
+	0x31, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x50, 0x6c, 0x61, 0x6e, 0x52, 0x0e, 0x72,
+	0x65, 0x6d, 0x6f, 0x76, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73, 0x12, 0x3d, 0x0a,
+	0x0e, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6c, 0x6c, 0x5f, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x18,
+	0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72,
+	0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x61, 0x79, 0x65, 0x72, 0x50, 0x6c, 0x61, 0x6e, 0x52, 0x0d, 0x69,
+	0x6e, 0x73, 0x74, 0x61, 0x6c, 0x6c, 0x4c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x12, 0x3b, 0x0a, 0x0d,
+	0x72, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x5f, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x18, 0x04, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76,
+	0x31, 0x2e, 0x4c, 0x61, 0x79, 0x65, 0x72, 0x50, 0x6c, 0x61, 0x6e, 0x52, 0x0c, 0x72, 0x65, 0x6d,
+	0x6f, 0x76, 0x65, 0x4c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x12, 0x37, 0x0a, 0x09, 0x64, 0x6f, 0x77,
+	0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x63,
+	0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x6f, 0x77, 0x6e, 0x6c,
+	0x6f, 0x61, 0x64, 0x50, 0x6c, 0x61, 0x6e, 0x52, 0x09, 0x64, 0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61,
+	0x64, 0x73, 0x12, 0x35, 0x0a, 0x08, 0x73, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e,
+	0x76, 0x31, 0x2e, 0x53, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x50, 0x6c, 0x61, 0x6e, 0x52,
+	0x08, 0x73, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x22, 0x62, 0x0a, 0x0a, 0x55, 0x70, 0x64,
+	0x61, 0x74, 0x65, 0x50, 0x6c, 0x61, 0x6e, 0x12, 0x29, 0x0a, 0x04, 0x66, 0x6f, 0x74, 0x61, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72,
+	0x2e, 0x76, 0x31, 0x2e, 0x46, 0x4f, 0x54, 0x41, 0x50, 0x6c, 0x61, 0x6e, 0x52, 0x04, 0x66, 0x6f,
+	0x74, 0x61, 0x12, 0x29, 0x0a, 0x04, 0x73, 0x6f, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x15, 0x2e, 0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53,
+	0x4f, 0x54, 0x41, 0x50, 0x6c, 0x61, 0x6e, 0x52, 0x04, 0x73, 0x6f, 0x74, 0x61, 0x32, 0x50, 0x0a,
+	0x0d, 0x44, 0x72, 0x79, 0x52, 0x75, 0x6e, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x3f,
+	0x0a, 0x06, 0x44, 0x72, 0x79, 0x52, 0x75, 0x6e, 0x12, 0x1a, 0x2e, 0x63, 0x6d, 0x73, 0x65, 0x72,
+	0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x72, 0x79, 0x52, 0x75, 0x6e, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e,
+	0x76, 0x31, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x50, 0x6c, 0x61, 0x6e, 0x22, 0x00, 0x42,
+	0x33, 0x5a, 0x31, 0x61, 0x6f, 0x73, 0x5f, 0x63, 0x6f, 0x6d, 0x6d, 0x75, 0x6e, 0x69, 0x63, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x2f, 0x61, 0x70, 0x69, 0x2f,
+	0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2f, 0x76, 0x31, 0x3b, 0x63, 0x6d, 0x73, 0x65,
+	0x72, 0x76, 0x65, 0x72, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_cmserver_v1_dryrun_proto_rawDescOnce sync.Once
+	file_cmserver_v1_dryrun_proto_rawDescData = file_cmserver_v1_dryrun_proto_rawDesc
+)
+
+func file_cmserver_v1_dryrun_proto_rawDescGZIP() []byte {
+	file_cmserver_v1_dryrun_proto_rawDescOnce.Do(func() {
+		file_cmserver_v1_dryrun_proto_rawDescData = protoimpl.X.CompressGZIP(file_cmserver_v1_dryrun_proto_rawDescData)
+	})
+	return file_cmserver_v1_dryrun_proto_rawDescData
+}
+
+var file_cmserver_v1_dryrun_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_cmserver_v1_dryrun_proto_goTypes = []interface{}{
+	(*DryRunRequest)(nil),         // 0: cmserver.v1.DryRunRequest
+	(*DownloadPlan)(nil),          // 1: cmserver.v1.DownloadPlan
+	(*SchedulePlan)(nil),          // 2: cmserver.v1.SchedulePlan
+	(*ComponentPlan)(nil),         // 3: cmserver.v1.ComponentPlan
+	(*BoardConfigPlan)(nil),       // 4: cmserver.v1.BoardConfigPlan
+	(*ServicePlan)(nil),           // 5: cmserver.v1.ServicePlan
+	(*LayerPlan)(nil),             // 6: cmserver.v1.LayerPlan
+	(*FOTAPlan)(nil),              // 7: cmserver.v1.FOTAPlan
+	(*SOTAPlan)(nil),              // 8: cmserver.v1.SOTAPlan
+	(*UpdatePlan)(nil),            // 9: cmserver.v1.UpdatePlan
+	(*timestamppb.Timestamp)(nil), // 10: google.protobuf.Timestamp
+}
+var file_cmserver_v1_dryrun_proto_depIdxs = []int32{
+	10, // 0: cmserver.v1.SchedulePlan.earliest_start:type_name -> google.protobuf.Timestamp
+	3,  // 1: cmserver.v1.FOTAPlan.components:type_name -> cmserver.v1.ComponentPlan
+	4,  // 2: cmserver.v1.FOTAPlan.board_config:type_name -> cmserver.v1.BoardConfigPlan
+	1,  // 3: cmserver.v1.FOTAPlan.downloads:type_name -> cmserver.v1.DownloadPlan
+	2,  // 4: cmserver.v1.FOTAPlan.schedule:type_name -> cmserver.v1.SchedulePlan
+	5,  // 5: cmserver.v1.SOTAPlan.install_services:type_name -> cmserver.v1.ServicePlan
+	5,  // 6: cmserver.v1.SOTAPlan.remove_services:type_name -> cmserver.v1.ServicePlan
+	6,  // 7: cmserver.v1.SOTAPlan.install_layers:type_name -> cmserver.v1.LayerPlan
+	6,  // 8: cmserver.v1.SOTAPlan.remove_layers:type_name -> cmserver.v1.LayerPlan
+	1,  // 9: cmserver.v1.SOTAPlan.downloads:type_name -> cmserver.v1.DownloadPlan
+	2,  // 10: cmserver.v1.SOTAPlan.schedule:type_name -> cmserver.v1.SchedulePlan
+	7,  // 11: cmserver.v1.UpdatePlan.fota:type_name -> cmserver.v1.FOTAPlan
+	8,  // 12: cmserver.v1.UpdatePlan.sota:type_name -> cmserver.v1.SOTAPlan
+	0,  // 13: cmserver.v1.DryRunService.DryRun:input_type -> cmserver.v1.DryRunRequest
+	9,  // 14: cmserver.v1.DryRunService.DryRun:output_type -> cmserver.v1.UpdatePlan
+	14, // [14:15] is the sub-list for method output_type
+	13, // [13:14] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_cmserver_v1_dryrun_proto_init() }
+func file_cmserver_v1_dryrun_proto_init() {
+	if File_cmserver_v1_dryrun_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_cmserver_v1_dryrun_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DryRunRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_cmserver_v1_dryrun_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DownloadPlan); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_cmserver_v1_dryrun_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SchedulePlan); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_cmserver_v1_dryrun_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ComponentPlan); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_cmserver_v1_dryrun_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BoardConfigPlan); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_cmserver_v1_dryrun_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ServicePlan); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_cmserver_v1_dryrun_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LayerPlan); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_cmserver_v1_dryrun_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FOTAPlan); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_cmserver_v1_dryrun_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SOTAPlan); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_cmserver_v1_dryrun_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UpdatePlan); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_cmserver_v1_dryrun_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_cmserver_v1_dryrun_proto_goTypes,
+		DependencyIndexes: file_cmserver_v1_dryrun_proto_depIdxs,
+		MessageInfos:      file_cmserver_v1_dryrun_proto_msgTypes,
+	}.Build()
+	File_cmserver_v1_dryrun_proto = out.File
+	file_cmserver_v1_dryrun_proto_rawDesc = nil
+	file_cmserver_v1_dryrun_proto_goTypes = nil
+	file_cmserver_v1_dryrun_proto_depIdxs = nil
+}
@@ -0,0 +1,101 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package cmserver
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// DryRunServiceClient is the client API for DryRunService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DryRunServiceClient interface {
+	DryRun(ctx context.Context, in *DryRunRequest, opts ...grpc.CallOption) (*UpdatePlan, error)
+}
+
+type dryRunServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDryRunServiceClient(cc grpc.ClientConnInterface) DryRunServiceClient {
+	return &dryRunServiceClient{cc}
+}
+
+func (c *dryRunServiceClient) DryRun(ctx context.Context, in *DryRunRequest, opts ...grpc.CallOption) (*UpdatePlan, error) {
+	out := new(UpdatePlan)
+	err := c.cc.Invoke(ctx, "/cmserver.v1.DryRunService/DryRun", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DryRunServiceServer is the server API for DryRunService service.
+// All implementations must embed UnimplementedDryRunServiceServer
+// for forward compatibility
+type DryRunServiceServer interface {
+	DryRun(context.Context, *DryRunRequest) (*UpdatePlan, error)
+	mustEmbedUnimplementedDryRunServiceServer()
+}
+
+// UnimplementedDryRunServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedDryRunServiceServer struct {
+}
+
+func (UnimplementedDryRunServiceServer) DryRun(context.Context, *DryRunRequest) (*UpdatePlan, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DryRun not implemented")
+}
+func (UnimplementedDryRunServiceServer) mustEmbedUnimplementedDryRunServiceServer() {}
+
+// UnsafeDryRunServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DryRunServiceServer will
+// result in compilation errors.
+type UnsafeDryRunServiceServer interface {
+	mustEmbedUnimplementedDryRunServiceServer()
+}
+
+func RegisterDryRunServiceServer(s grpc.ServiceRegistrar, srv DryRunServiceServer) {
+	s.RegisterService(&DryRunService_ServiceDesc, srv)
+}
+
+func _DryRunService_DryRun_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DryRunRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DryRunServiceServer).DryRun(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cmserver.v1.DryRunService/DryRun",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DryRunServiceServer).DryRun(ctx, req.(*DryRunRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// DryRunService_ServiceDesc is the grpc.ServiceDesc for DryRunService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DryRunService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cmserver.v1.DryRunService",
+	HandlerType: (*DryRunServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "DryRun",
+			Handler:    _DryRunService_DryRun_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "cmserver/v1/dryrun.proto",
+}
@@ -0,0 +1,453 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.27.1
+// 	protoc        v3.6.1
+// source: cmserver/v1/preconditions.proto
+
+package cmserver
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Only signals present in the message are updated
+type VehicleSignal struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Signal:
+	//	*VehicleSignal_Parked
+	//	*VehicleSignal_BatteryLevel
+	//	*VehicleSignal_Charging
+	//	*VehicleSignal_IgnitionOn
+	Signal isVehicleSignal_Signal `protobuf_oneof:"signal"`
+}
+
+func (x *VehicleSignal) Reset() {
+	*x = VehicleSignal{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_cmserver_v1_preconditions_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VehicleSignal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VehicleSignal) ProtoMessage() {}
+
+func (x *VehicleSignal) ProtoReflect() protoreflect.Message {
+	mi := &file_cmserver_v1_preconditions_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VehicleSignal.ProtoReflect.Descriptor instead.
+func (*VehicleSignal) Descriptor() ([]byte, []int) {
+	return file_cmserver_v1_preconditions_proto_rawDescGZIP(), []int{0}
+}
+
+func (m *VehicleSignal) GetSignal() isVehicleSignal_Signal {
+	if m != nil {
+		return m.Signal
+	}
+	return nil
+}
+
+func (x *VehicleSignal) GetParked() bool {
+	if x, ok := x.GetSignal().(*VehicleSignal_Parked); ok {
+		return x.Parked
+	}
+	return false
+}
+
+func (x *VehicleSignal) GetBatteryLevel() uint32 {
+	if x, ok := x.GetSignal().(*VehicleSignal_BatteryLevel); ok {
+		return x.BatteryLevel
+	}
+	return 0
+}
+
+func (x *VehicleSignal) GetCharging() bool {
+	if x, ok := x.GetSignal().(*VehicleSignal_Charging); ok {
+		return x.Charging
+	}
+	return false
+}
+
+func (x *VehicleSignal) GetIgnitionOn() bool {
+	if x, ok := x.GetSignal().(*VehicleSignal_IgnitionOn); ok {
+		return x.IgnitionOn
+	}
+	return false
+}
+
+type isVehicleSignal_Signal interface {
+	isVehicleSignal_Signal()
+}
+
+type VehicleSignal_Parked struct {
+	Parked bool `protobuf:"varint,1,opt,name=parked,proto3,oneof"`
+}
+
+type VehicleSignal_BatteryLevel struct {
+	BatteryLevel uint32 `protobuf:"varint,2,opt,name=battery_level,json=batteryLevel,proto3,oneof"`
+}
+
+type VehicleSignal_Charging struct {
+	Charging bool `protobuf:"varint,3,opt,name=charging,proto3,oneof"`
+}
+
+type VehicleSignal_IgnitionOn struct {
+	IgnitionOn bool `protobuf:"varint,4,opt,name=ignition_on,json=ignitionOn,proto3,oneof"`
+}
+
+func (*VehicleSignal_Parked) isVehicleSignal_Signal() {}
+
+func (*VehicleSignal_BatteryLevel) isVehicleSignal_Signal() {}
+
+func (*VehicleSignal_Charging) isVehicleSignal_Signal() {}
+
+func (*VehicleSignal_IgnitionOn) isVehicleSignal_Signal() {}
+
+type VehicleState struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Signals []*VehicleSignal `protobuf:"bytes,1,rep,name=signals,proto3" json:"signals,omitempty"`
+}
+
+func (x *VehicleState) Reset() {
+	*x = VehicleState{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_cmserver_v1_preconditions_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VehicleState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VehicleState) ProtoMessage() {}
+
+func (x *VehicleState) ProtoReflect() protoreflect.Message {
+	mi := &file_cmserver_v1_preconditions_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VehicleState.ProtoReflect.Descriptor instead.
+func (*VehicleState) Descriptor() ([]byte, []int) {
+	return file_cmserver_v1_preconditions_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *VehicleState) GetSignals() []*VehicleSignal {
+	if x != nil {
+		return x.Signals
+	}
+	return nil
+}
+
+type Precondition struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name      string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Satisfied bool   `protobuf:"varint,2,opt,name=satisfied,proto3" json:"satisfied,omitempty"`
+	Reason    string `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+}
+
+func (x *Precondition) Reset() {
+	*x = Precondition{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_cmserver_v1_preconditions_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Precondition) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Precondition) ProtoMessage() {}
+
+func (x *Precondition) ProtoReflect() protoreflect.Message {
+	mi := &file_cmserver_v1_preconditions_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Precondition.ProtoReflect.Descriptor instead.
+func (*Precondition) Descriptor() ([]byte, []int) {
+	return file_cmserver_v1_preconditions_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Precondition) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Precondition) GetSatisfied() bool {
+	if x != nil {
+		return x.Satisfied
+	}
+	return false
+}
+
+func (x *Precondition) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type PreconditionsStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Preconditions []*Precondition `protobuf:"bytes,1,rep,name=preconditions,proto3" json:"preconditions,omitempty"`
+}
+
+func (x *PreconditionsStatus) Reset() {
+	*x = PreconditionsStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_cmserver_v1_preconditions_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PreconditionsStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PreconditionsStatus) ProtoMessage() {}
+
+func (x *PreconditionsStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_cmserver_v1_preconditions_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PreconditionsStatus.ProtoReflect.Descriptor instead.
+func (*PreconditionsStatus) Descriptor() ([]byte, []int) {
+	return file_cmserver_v1_preconditions_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PreconditionsStatus) GetPreconditions() []*Precondition {
+	if x != nil {
+		return x.Preconditions
+	}
+	return nil
+}
+
+var File_cmserver_v1_preconditions_proto protoreflect.FileDescriptor
+
+var file_cmserver_v1_preconditions_proto_rawDesc = []byte{
+	0x0a, 0x1f, 0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2f, 0x76, 0x31, 0x2f, 0x70, 0x72,
+	0x65, 0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x12, 0x0b, 0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x1a, 0x1b,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f,
+	0x65, 0x6d, 0x70, 0x74, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x9b, 0x01, 0x0a, 0x0d,
+	0x56, 0x65, 0x68, 0x69, 0x63, 0x6c, 0x65, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x6c, 0x12, 0x18, 0x0a,
+	0x06, 0x70, 0x61, 0x72, 0x6b, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52,
+	0x06, 0x70, 0x61, 0x72, 0x6b, 0x65, 0x64, 0x12, 0x25, 0x0a, 0x0d, 0x62, 0x61, 0x74, 0x74, 0x65,
+	0x72, 0x79, 0x5f, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x48, 0x00,
+	0x52, 0x0c, 0x62, 0x61, 0x74, 0x74, 0x65, 0x72, 0x79, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x1c,
+	0x0a, 0x08, 0x63, 0x68, 0x61, 0x72, 0x67, 0x69, 0x6e, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08,
+	0x48, 0x00, 0x52, 0x08, 0x63, 0x68, 0x61, 0x72, 0x67, 0x69, 0x6e, 0x67, 0x12, 0x21, 0x0a, 0x0b,
+	0x69, 0x67, 0x6e, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x08, 0x48, 0x00, 0x52, 0x0a, 0x69, 0x67, 0x6e, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x4f, 0x6e, 0x42,
+	0x08, 0x0a, 0x06, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x6c, 0x22, 0x44, 0x0a, 0x0c, 0x56, 0x65, 0x68,
+	0x69, 0x63, 0x6c, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x34, 0x0a, 0x07, 0x73, 0x69, 0x67,
+	0x6e, 0x61, 0x6c, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x63, 0x6d, 0x73,
+	0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x56, 0x65, 0x68, 0x69, 0x63, 0x6c, 0x65,
+	0x53, 0x69, 0x67, 0x6e, 0x61, 0x6c, 0x52, 0x07, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x6c, 0x73, 0x22,
+	0x58, 0x0a, 0x0c, 0x50, 0x72, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x12,
+	0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e,
+	0x61, 0x6d, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x61, 0x74, 0x69, 0x73, 0x66, 0x69, 0x65, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x73, 0x61, 0x74, 0x69, 0x73, 0x66, 0x69, 0x65,
+	0x64, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x22, 0x56, 0x0a, 0x13, 0x50, 0x72, 0x65,
+	0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x12, 0x3f, 0x0a, 0x0d, 0x70, 0x72, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x63, 0x6d, 0x73, 0x65, 0x72, 0x76,
+	0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x72, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69,
+	0x6f, 0x6e, 0x52, 0x0d, 0x70, 0x72, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x32, 0xae, 0x01, 0x0a, 0x14, 0x50, 0x72, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x46, 0x0a, 0x0f, 0x53, 0x65,
+	0x74, 0x56, 0x65, 0x68, 0x69, 0x63, 0x6c, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x19, 0x2e,
+	0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x56, 0x65, 0x68, 0x69,
+	0x63, 0x6c, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79,
+	0x22, 0x00, 0x12, 0x4e, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x50, 0x72, 0x65, 0x63, 0x6f, 0x6e, 0x64,
+	0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x20,
+	0x2e, 0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x72, 0x65,
+	0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x22, 0x00, 0x42, 0x33, 0x5a, 0x31, 0x61, 0x6f, 0x73, 0x5f, 0x63, 0x6f, 0x6d, 0x6d, 0x75, 0x6e,
+	0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x2f, 0x61,
+	0x70, 0x69, 0x2f, 0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2f, 0x76, 0x31, 0x3b, 0x63,
+	0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_cmserver_v1_preconditions_proto_rawDescOnce sync.Once
+	file_cmserver_v1_preconditions_proto_rawDescData = file_cmserver_v1_preconditions_proto_rawDesc
+)
+
+func file_cmserver_v1_preconditions_proto_rawDescGZIP() []byte {
+	file_cmserver_v1_preconditions_proto_rawDescOnce.Do(func() {
+		file_cmserver_v1_preconditions_proto_rawDescData = protoimpl.X.CompressGZIP(file_cmserver_v1_preconditions_proto_rawDescData)
+	})
+	return file_cmserver_v1_preconditions_proto_rawDescData
+}
+
+var file_cmserver_v1_preconditions_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_cmserver_v1_preconditions_proto_goTypes = []interface{}{
+	(*VehicleSignal)(nil),       // 0: cmserver.v1.VehicleSignal
+	(*VehicleState)(nil),        // 1: cmserver.v1.VehicleState
+	(*Precondition)(nil),        // 2: cmserver.v1.Precondition
+	(*PreconditionsStatus)(nil), // 3: cmserver.v1.PreconditionsStatus
+	(*emptypb.Empty)(nil),       // 4: google.protobuf.Empty
+}
+var file_cmserver_v1_preconditions_proto_depIdxs = []int32{
+	0, // 0: cmserver.v1.VehicleState.signals:type_name -> cmserver.v1.VehicleSignal
+	2, // 1: cmserver.v1.PreconditionsStatus.preconditions:type_name -> cmserver.v1.Precondition
+	1, // 2: cmserver.v1.PreconditionsService.SetVehicleState:input_type -> cmserver.v1.VehicleState
+	4, // 3: cmserver.v1.PreconditionsService.GetPreconditions:input_type -> google.protobuf.Empty
+	4, // 4: cmserver.v1.PreconditionsService.SetVehicleState:output_type -> google.protobuf.Empty
+	3, // 5: cmserver.v1.PreconditionsService.GetPreconditions:output_type -> cmserver.v1.PreconditionsStatus
+	4, // [4:6] is the sub-list for method output_type
+	2, // [2:4] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_cmserver_v1_preconditions_proto_init() }
+func file_cmserver_v1_preconditions_proto_init() {
+	if File_cmserver_v1_preconditions_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_cmserver_v1_preconditions_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VehicleSignal); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_cmserver_v1_preconditions_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VehicleState); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_cmserver_v1_preconditions_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Precondition); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_cmserver_v1_preconditions_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PreconditionsStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_cmserver_v1_preconditions_proto_msgTypes[0].OneofWrappers = []interface{}{
+		(*VehicleSignal_Parked)(nil),
+		(*VehicleSignal_BatteryLevel)(nil),
+		(*VehicleSignal_Charging)(nil),
+		(*VehicleSignal_IgnitionOn)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_cmserver_v1_preconditions_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_cmserver_v1_preconditions_proto_goTypes,
+		DependencyIndexes: file_cmserver_v1_preconditions_proto_depIdxs,
+		MessageInfos:      file_cmserver_v1_preconditions_proto_msgTypes,
+	}.Build()
+	File_cmserver_v1_preconditions_proto = out.File
+	file_cmserver_v1_preconditions_proto_rawDesc = nil
+	file_cmserver_v1_preconditions_proto_goTypes = nil
+	file_cmserver_v1_preconditions_proto_depIdxs = nil
+}
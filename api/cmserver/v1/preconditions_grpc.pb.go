@@ -0,0 +1,138 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package cmserver
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// PreconditionsServiceClient is the client API for PreconditionsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PreconditionsServiceClient interface {
+	SetVehicleState(ctx context.Context, in *VehicleState, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	GetPreconditions(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*PreconditionsStatus, error)
+}
+
+type preconditionsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPreconditionsServiceClient(cc grpc.ClientConnInterface) PreconditionsServiceClient {
+	return &preconditionsServiceClient{cc}
+}
+
+func (c *preconditionsServiceClient) SetVehicleState(ctx context.Context, in *VehicleState, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/cmserver.v1.PreconditionsService/SetVehicleState", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *preconditionsServiceClient) GetPreconditions(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*PreconditionsStatus, error) {
+	out := new(PreconditionsStatus)
+	err := c.cc.Invoke(ctx, "/cmserver.v1.PreconditionsService/GetPreconditions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PreconditionsServiceServer is the server API for PreconditionsService service.
+// All implementations must embed UnimplementedPreconditionsServiceServer
+// for forward compatibility
+type PreconditionsServiceServer interface {
+	SetVehicleState(context.Context, *VehicleState) (*emptypb.Empty, error)
+	GetPreconditions(context.Context, *emptypb.Empty) (*PreconditionsStatus, error)
+	mustEmbedUnimplementedPreconditionsServiceServer()
+}
+
+// UnimplementedPreconditionsServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedPreconditionsServiceServer struct {
+}
+
+func (UnimplementedPreconditionsServiceServer) SetVehicleState(context.Context, *VehicleState) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetVehicleState not implemented")
+}
+func (UnimplementedPreconditionsServiceServer) GetPreconditions(context.Context, *emptypb.Empty) (*PreconditionsStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPreconditions not implemented")
+}
+func (UnimplementedPreconditionsServiceServer) mustEmbedUnimplementedPreconditionsServiceServer() {}
+
+// UnsafePreconditionsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PreconditionsServiceServer will
+// result in compilation errors.
+type UnsafePreconditionsServiceServer interface {
+	mustEmbedUnimplementedPreconditionsServiceServer()
+}
+
+func RegisterPreconditionsServiceServer(s grpc.ServiceRegistrar, srv PreconditionsServiceServer) {
+	s.RegisterService(&PreconditionsService_ServiceDesc, srv)
+}
+
+func _PreconditionsService_SetVehicleState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VehicleState)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PreconditionsServiceServer).SetVehicleState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cmserver.v1.PreconditionsService/SetVehicleState",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PreconditionsServiceServer).SetVehicleState(ctx, req.(*VehicleState))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PreconditionsService_GetPreconditions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PreconditionsServiceServer).GetPreconditions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cmserver.v1.PreconditionsService/GetPreconditions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PreconditionsServiceServer).GetPreconditions(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PreconditionsService_ServiceDesc is the grpc.ServiceDesc for PreconditionsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PreconditionsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cmserver.v1.PreconditionsService",
+	HandlerType: (*PreconditionsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SetVehicleState",
+			Handler:    _PreconditionsService_SetVehicleState_Handler,
+		},
+		{
+			MethodName: "GetPreconditions",
+			Handler:    _PreconditionsService_GetPreconditions_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "cmserver/v1/preconditions.proto",
+}
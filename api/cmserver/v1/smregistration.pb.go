@@ -0,0 +1,276 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.27.1
+// 	protoc        v3.6.1
+// source: cmserver/v1/smregistration.proto
+
+package cmserver
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SMRegistration struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SmId         string   `protobuf:"bytes,1,opt,name=sm_id,json=smId,proto3" json:"sm_id,omitempty"`
+	ServerUrl    string   `protobuf:"bytes,2,opt,name=server_url,json=serverUrl,proto3" json:"server_url,omitempty"`
+	IsLocal      bool     `protobuf:"varint,3,opt,name=is_local,json=isLocal,proto3" json:"is_local,omitempty"`
+	Capabilities []string `protobuf:"bytes,4,rep,name=capabilities,proto3" json:"capabilities,omitempty"`
+	Ram          uint64   `protobuf:"varint,5,opt,name=ram,proto3" json:"ram,omitempty"`
+	Disk         uint64   `protobuf:"varint,6,opt,name=disk,proto3" json:"disk,omitempty"`
+}
+
+func (x *SMRegistration) Reset() {
+	*x = SMRegistration{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_cmserver_v1_smregistration_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SMRegistration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SMRegistration) ProtoMessage() {}
+
+func (x *SMRegistration) ProtoReflect() protoreflect.Message {
+	mi := &file_cmserver_v1_smregistration_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SMRegistration.ProtoReflect.Descriptor instead.
+func (*SMRegistration) Descriptor() ([]byte, []int) {
+	return file_cmserver_v1_smregistration_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SMRegistration) GetSmId() string {
+	if x != nil {
+		return x.SmId
+	}
+	return ""
+}
+
+func (x *SMRegistration) GetServerUrl() string {
+	if x != nil {
+		return x.ServerUrl
+	}
+	return ""
+}
+
+func (x *SMRegistration) GetIsLocal() bool {
+	if x != nil {
+		return x.IsLocal
+	}
+	return false
+}
+
+func (x *SMRegistration) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+func (x *SMRegistration) GetRam() uint64 {
+	if x != nil {
+		return x.Ram
+	}
+	return 0
+}
+
+func (x *SMRegistration) GetDisk() uint64 {
+	if x != nil {
+		return x.Disk
+	}
+	return 0
+}
+
+type SMRegistrationStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Registered bool   `protobuf:"varint,1,opt,name=registered,proto3" json:"registered,omitempty"`
+	Error      string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *SMRegistrationStatus) Reset() {
+	*x = SMRegistrationStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_cmserver_v1_smregistration_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SMRegistrationStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SMRegistrationStatus) ProtoMessage() {}
+
+func (x *SMRegistrationStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_cmserver_v1_smregistration_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SMRegistrationStatus.ProtoReflect.Descriptor instead.
+func (*SMRegistrationStatus) Descriptor() ([]byte, []int) {
+	return file_cmserver_v1_smregistration_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SMRegistrationStatus) GetRegistered() bool {
+	if x != nil {
+		return x.Registered
+	}
+	return false
+}
+
+func (x *SMRegistrationStatus) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_cmserver_v1_smregistration_proto protoreflect.FileDescriptor
+
+var file_cmserver_v1_smregistration_proto_rawDesc = []byte{
+	0x0a, 0x20, 0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2f, 0x76, 0x31, 0x2f, 0x73, 0x6d,
+	0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x12, 0x0b, 0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x22,
+	0xa9, 0x01, 0x0a, 0x0e, 0x53, 0x4d, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x12, 0x13, 0x0a, 0x05, 0x73, 0x6d, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x73, 0x6d, 0x49, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x65, 0x72, 0x76, 0x65,
+	0x72, 0x5f, 0x75, 0x72, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x65, 0x72,
+	0x76, 0x65, 0x72, 0x55, 0x72, 0x6c, 0x12, 0x19, 0x0a, 0x08, 0x69, 0x73, 0x5f, 0x6c, 0x6f, 0x63,
+	0x61, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x69, 0x73, 0x4c, 0x6f, 0x63, 0x61,
+	0x6c, 0x12, 0x22, 0x0a, 0x0c, 0x63, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65,
+	0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c, 0x63, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c,
+	0x69, 0x74, 0x69, 0x65, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x72, 0x61, 0x6d, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x03, 0x72, 0x61, 0x6d, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x69, 0x73, 0x6b, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x64, 0x69, 0x73, 0x6b, 0x22, 0x4c, 0x0a, 0x14, 0x53,
+	0x4d, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x65,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65,
+	0x72, 0x65, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x32, 0x6b, 0x0a, 0x15, 0x53, 0x4d, 0x52,
+	0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x65, 0x72, 0x76, 0x69,
+	0x63, 0x65, 0x12, 0x52, 0x0a, 0x0a, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x53, 0x4d,
+	0x12, 0x1b, 0x2e, 0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53,
+	0x4d, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x1a, 0x21, 0x2e,
+	0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x4d, 0x52, 0x65,
+	0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x22, 0x00, 0x28, 0x01, 0x30, 0x01, 0x42, 0x33, 0x5a, 0x31, 0x61, 0x6f, 0x73, 0x5f, 0x63, 0x6f,
+	0x6d, 0x6d, 0x75, 0x6e, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x6d, 0x61, 0x6e, 0x61, 0x67,
+	0x65, 0x72, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2f,
+	0x76, 0x31, 0x3b, 0x63, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
+}
+
+var (
+	file_cmserver_v1_smregistration_proto_rawDescOnce sync.Once
+	file_cmserver_v1_smregistration_proto_rawDescData = file_cmserver_v1_smregistration_proto_rawDesc
+)
+
+func file_cmserver_v1_smregistration_proto_rawDescGZIP() []byte {
+	file_cmserver_v1_smregistration_proto_rawDescOnce.Do(func() {
+		file_cmserver_v1_smregistration_proto_rawDescData = protoimpl.X.CompressGZIP(file_cmserver_v1_smregistration_proto_rawDescData)
+	})
+	return file_cmserver_v1_smregistration_proto_rawDescData
+}
+
+var file_cmserver_v1_smregistration_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_cmserver_v1_smregistration_proto_goTypes = []interface{}{
+	(*SMRegistration)(nil),       // 0: cmserver.v1.SMRegistration
+	(*SMRegistrationStatus)(nil), // 1: cmserver.v1.SMRegistrationStatus
+}
+var file_cmserver_v1_smregistration_proto_depIdxs = []int32{
+	0, // 0: cmserver.v1.SMRegistrationService.RegisterSM:input_type -> cmserver.v1.SMRegistration
+	1, // 1: cmserver.v1.SMRegistrationService.RegisterSM:output_type -> cmserver.v1.SMRegistrationStatus
+	1, // [1:2] is the sub-list for method output_type
+	0, // [0:1] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_cmserver_v1_smregistration_proto_init() }
+func file_cmserver_v1_smregistration_proto_init() {
+	if File_cmserver_v1_smregistration_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_cmserver_v1_smregistration_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SMRegistration); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_cmserver_v1_smregistration_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SMRegistrationStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_cmserver_v1_smregistration_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_cmserver_v1_smregistration_proto_goTypes,
+		DependencyIndexes: file_cmserver_v1_smregistration_proto_depIdxs,
+		MessageInfos:      file_cmserver_v1_smregistration_proto_msgTypes,
+	}.Build()
+	File_cmserver_v1_smregistration_proto = out.File
+	file_cmserver_v1_smregistration_proto_rawDesc = nil
+	file_cmserver_v1_smregistration_proto_goTypes = nil
+	file_cmserver_v1_smregistration_proto_depIdxs = nil
+}
@@ -0,0 +1,133 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package cmserver
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// SMRegistrationServiceClient is the client API for SMRegistrationService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SMRegistrationServiceClient interface {
+	RegisterSM(ctx context.Context, opts ...grpc.CallOption) (SMRegistrationService_RegisterSMClient, error)
+}
+
+type sMRegistrationServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSMRegistrationServiceClient(cc grpc.ClientConnInterface) SMRegistrationServiceClient {
+	return &sMRegistrationServiceClient{cc}
+}
+
+func (c *sMRegistrationServiceClient) RegisterSM(ctx context.Context, opts ...grpc.CallOption) (SMRegistrationService_RegisterSMClient, error) {
+	stream, err := c.cc.NewStream(ctx, &SMRegistrationService_ServiceDesc.Streams[0], "/cmserver.v1.SMRegistrationService/RegisterSM", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &sMRegistrationServiceRegisterSMClient{stream}
+	return x, nil
+}
+
+type SMRegistrationService_RegisterSMClient interface {
+	Send(*SMRegistration) error
+	Recv() (*SMRegistrationStatus, error)
+	grpc.ClientStream
+}
+
+type sMRegistrationServiceRegisterSMClient struct {
+	grpc.ClientStream
+}
+
+func (x *sMRegistrationServiceRegisterSMClient) Send(m *SMRegistration) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *sMRegistrationServiceRegisterSMClient) Recv() (*SMRegistrationStatus, error) {
+	m := new(SMRegistrationStatus)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// SMRegistrationServiceServer is the server API for SMRegistrationService service.
+// All implementations must embed UnimplementedSMRegistrationServiceServer
+// for forward compatibility
+type SMRegistrationServiceServer interface {
+	RegisterSM(SMRegistrationService_RegisterSMServer) error
+	mustEmbedUnimplementedSMRegistrationServiceServer()
+}
+
+// UnimplementedSMRegistrationServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedSMRegistrationServiceServer struct {
+}
+
+func (UnimplementedSMRegistrationServiceServer) RegisterSM(SMRegistrationService_RegisterSMServer) error {
+	return status.Errorf(codes.Unimplemented, "method RegisterSM not implemented")
+}
+func (UnimplementedSMRegistrationServiceServer) mustEmbedUnimplementedSMRegistrationServiceServer() {}
+
+// UnsafeSMRegistrationServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SMRegistrationServiceServer will
+// result in compilation errors.
+type UnsafeSMRegistrationServiceServer interface {
+	mustEmbedUnimplementedSMRegistrationServiceServer()
+}
+
+func RegisterSMRegistrationServiceServer(s grpc.ServiceRegistrar, srv SMRegistrationServiceServer) {
+	s.RegisterService(&SMRegistrationService_ServiceDesc, srv)
+}
+
+func _SMRegistrationService_RegisterSM_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(SMRegistrationServiceServer).RegisterSM(&sMRegistrationServiceRegisterSMServer{stream})
+}
+
+type SMRegistrationService_RegisterSMServer interface {
+	Send(*SMRegistrationStatus) error
+	Recv() (*SMRegistration, error)
+	grpc.ServerStream
+}
+
+type sMRegistrationServiceRegisterSMServer struct {
+	grpc.ServerStream
+}
+
+func (x *sMRegistrationServiceRegisterSMServer) Send(m *SMRegistrationStatus) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *sMRegistrationServiceRegisterSMServer) Recv() (*SMRegistration, error) {
+	m := new(SMRegistration)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// SMRegistrationService_ServiceDesc is the grpc.ServiceDesc for SMRegistrationService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SMRegistrationService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cmserver.v1.SMRegistrationService",
+	HandlerType: (*SMRegistrationServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "RegisterSM",
+			Handler:       _SMRegistrationService_RegisterSM_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "cmserver/v1/smregistration.proto",
+}
@@ -0,0 +1,101 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package cmserver
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// UpdateHistoryServiceClient is the client API for UpdateHistoryService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type UpdateHistoryServiceClient interface {
+	GetUpdateHistory(ctx context.Context, in *UpdateHistoryFilter, opts ...grpc.CallOption) (*UpdateHistoryRecords, error)
+}
+
+type updateHistoryServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewUpdateHistoryServiceClient(cc grpc.ClientConnInterface) UpdateHistoryServiceClient {
+	return &updateHistoryServiceClient{cc}
+}
+
+func (c *updateHistoryServiceClient) GetUpdateHistory(ctx context.Context, in *UpdateHistoryFilter, opts ...grpc.CallOption) (*UpdateHistoryRecords, error) {
+	out := new(UpdateHistoryRecords)
+	err := c.cc.Invoke(ctx, "/cmserver.v1.UpdateHistoryService/GetUpdateHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpdateHistoryServiceServer is the server API for UpdateHistoryService service.
+// All implementations must embed UnimplementedUpdateHistoryServiceServer
+// for forward compatibility
+type UpdateHistoryServiceServer interface {
+	GetUpdateHistory(context.Context, *UpdateHistoryFilter) (*UpdateHistoryRecords, error)
+	mustEmbedUnimplementedUpdateHistoryServiceServer()
+}
+
+// UnimplementedUpdateHistoryServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedUpdateHistoryServiceServer struct {
+}
+
+func (UnimplementedUpdateHistoryServiceServer) GetUpdateHistory(context.Context, *UpdateHistoryFilter) (*UpdateHistoryRecords, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUpdateHistory not implemented")
+}
+func (UnimplementedUpdateHistoryServiceServer) mustEmbedUnimplementedUpdateHistoryServiceServer() {}
+
+// UnsafeUpdateHistoryServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to UpdateHistoryServiceServer will
+// result in compilation errors.
+type UnsafeUpdateHistoryServiceServer interface {
+	mustEmbedUnimplementedUpdateHistoryServiceServer()
+}
+
+func RegisterUpdateHistoryServiceServer(s grpc.ServiceRegistrar, srv UpdateHistoryServiceServer) {
+	s.RegisterService(&UpdateHistoryService_ServiceDesc, srv)
+}
+
+func _UpdateHistoryService_GetUpdateHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateHistoryFilter)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UpdateHistoryServiceServer).GetUpdateHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cmserver.v1.UpdateHistoryService/GetUpdateHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UpdateHistoryServiceServer).GetUpdateHistory(ctx, req.(*UpdateHistoryFilter))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// UpdateHistoryService_ServiceDesc is the grpc.ServiceDesc for UpdateHistoryService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var UpdateHistoryService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cmserver.v1.UpdateHistoryService",
+	HandlerType: (*UpdateHistoryServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetUpdateHistory",
+			Handler:    _UpdateHistoryService_GetUpdateHistory_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "cmserver/v1/updatehistory.proto",
+}
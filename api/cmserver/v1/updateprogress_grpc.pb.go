@@ -0,0 +1,129 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package cmserver
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// UpdateProgressServiceClient is the client API for UpdateProgressService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type UpdateProgressServiceClient interface {
+	SubscribeUpdateProgress(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (UpdateProgressService_SubscribeUpdateProgressClient, error)
+}
+
+type updateProgressServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewUpdateProgressServiceClient(cc grpc.ClientConnInterface) UpdateProgressServiceClient {
+	return &updateProgressServiceClient{cc}
+}
+
+func (c *updateProgressServiceClient) SubscribeUpdateProgress(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (UpdateProgressService_SubscribeUpdateProgressClient, error) {
+	stream, err := c.cc.NewStream(ctx, &UpdateProgressService_ServiceDesc.Streams[0], "/cmserver.v1.UpdateProgressService/SubscribeUpdateProgress", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &updateProgressServiceSubscribeUpdateProgressClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type UpdateProgressService_SubscribeUpdateProgressClient interface {
+	Recv() (*UpdateProgress, error)
+	grpc.ClientStream
+}
+
+type updateProgressServiceSubscribeUpdateProgressClient struct {
+	grpc.ClientStream
+}
+
+func (x *updateProgressServiceSubscribeUpdateProgressClient) Recv() (*UpdateProgress, error) {
+	m := new(UpdateProgress)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// UpdateProgressServiceServer is the server API for UpdateProgressService service.
+// All implementations must embed UnimplementedUpdateProgressServiceServer
+// for forward compatibility
+type UpdateProgressServiceServer interface {
+	SubscribeUpdateProgress(*emptypb.Empty, UpdateProgressService_SubscribeUpdateProgressServer) error
+	mustEmbedUnimplementedUpdateProgressServiceServer()
+}
+
+// UnimplementedUpdateProgressServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedUpdateProgressServiceServer struct {
+}
+
+func (UnimplementedUpdateProgressServiceServer) SubscribeUpdateProgress(*emptypb.Empty, UpdateProgressService_SubscribeUpdateProgressServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeUpdateProgress not implemented")
+}
+func (UnimplementedUpdateProgressServiceServer) mustEmbedUnimplementedUpdateProgressServiceServer() {}
+
+// UnsafeUpdateProgressServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to UpdateProgressServiceServer will
+// result in compilation errors.
+type UnsafeUpdateProgressServiceServer interface {
+	mustEmbedUnimplementedUpdateProgressServiceServer()
+}
+
+func RegisterUpdateProgressServiceServer(s grpc.ServiceRegistrar, srv UpdateProgressServiceServer) {
+	s.RegisterService(&UpdateProgressService_ServiceDesc, srv)
+}
+
+func _UpdateProgressService_SubscribeUpdateProgress_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(emptypb.Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UpdateProgressServiceServer).SubscribeUpdateProgress(m, &updateProgressServiceSubscribeUpdateProgressServer{stream})
+}
+
+type UpdateProgressService_SubscribeUpdateProgressServer interface {
+	Send(*UpdateProgress) error
+	grpc.ServerStream
+}
+
+type updateProgressServiceSubscribeUpdateProgressServer struct {
+	grpc.ServerStream
+}
+
+func (x *updateProgressServiceSubscribeUpdateProgressServer) Send(m *UpdateProgress) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// UpdateProgressService_ServiceDesc is the grpc.ServiceDesc for UpdateProgressService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var UpdateProgressService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cmserver.v1.UpdateProgressService",
+	HandlerType: (*UpdateProgressServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeUpdateProgress",
+			Handler:       _UpdateProgressService_SubscribeUpdateProgress_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "cmserver/v1/updateprogress.proto",
+}
@@ -65,10 +65,17 @@ type BoardResource struct {
 
 // BoardConfig resources that are proviced by Cloud for using at AOS services
 type BoardConfig struct {
-	FormatVersion uint64           `json:"formatVersion"`
-	VendorVersion string           `json:"vendorVersion"`
-	Devices       []DeviceResource `json:"devices"`
-	Resources     []BoardResource  `json:"resources"`
+	FormatVersion uint64                     `json:"formatVersion"`
+	VendorVersion string                     `json:"vendorVersion"`
+	Devices       []DeviceResource           `json:"devices"`
+	Resources     []BoardResource            `json:"resources"`
+	Nodes         map[string]NodeBoardConfig `json:"nodes,omitempty"`
+}
+
+// NodeBoardConfig node specific devices and resources
+type NodeBoardConfig struct {
+	Devices   []DeviceResource `json:"devices,omitempty"`
+	Resources []BoardResource  `json:"resources,omitempty"`
 }
 
 // Instance board config instance
@@ -79,12 +86,13 @@ type Instance struct {
 	boardConfigFile  string
 	boardConfig      BoardConfig
 	boardConfigError error
+	nodesInfo        []cloudprotocol.NodeBoardConfigInfo
 }
 
 // Client client board config interface
 type Client interface {
-	CheckBoardConfig(boardConfig BoardConfig) (err error)
-	SetBoardConfig(boardConfig BoardConfig) (err error)
+	CheckBoardConfig(boardConfig BoardConfig) (nodesInfo []cloudprotocol.NodeBoardConfigInfo, err error)
+	SetBoardConfig(boardConfig BoardConfig) (nodesInfo []cloudprotocol.NodeBoardConfigInfo, err error)
 }
 
 /***********************************************************************************************************************
@@ -111,15 +119,24 @@ func (instance *Instance) GetStatus() (boardConfigInfo cloudprotocol.BoardConfig
 	boardConfigInfo.VendorVersion = instance.boardConfig.VendorVersion
 	boardConfigInfo.Status = cloudprotocol.InstalledStatus
 
-	if instance.boardConfigError == nil {
-		if err = instance.client.SetBoardConfig(instance.boardConfig); err != nil {
-			instance.boardConfigError = err
-		}
-	}
-
 	if instance.boardConfigError != nil {
+		// Nodes info belongs to previously set board config and is not valid anymore
+		instance.nodesInfo = nil
+
 		boardConfigInfo.Status = cloudprotocol.ErrorStatus
 		boardConfigInfo.Error = instance.boardConfigError.Error()
+
+		return boardConfigInfo, nil
+	}
+
+	// Set board config on each request to get actual status of all nodes
+	instance.nodesInfo, err = instance.client.SetBoardConfig(instance.boardConfig)
+
+	boardConfigInfo.Nodes = instance.nodesInfo
+
+	if err != nil {
+		boardConfigInfo.Status = cloudprotocol.ErrorStatus
+		boardConfigInfo.Error = err.Error()
 	}
 
 	return boardConfigInfo, nil
@@ -136,22 +153,23 @@ func (instance *Instance) GetBoardConfigVersion(configJSON json.RawMessage) (ven
 	return boardConfig.VendorVersion, nil
 }
 
-// CheckBoardConfig checks board config
-func (instance *Instance) CheckBoardConfig(configJSON json.RawMessage) (vendorVersion string, err error) {
+// CheckBoardConfig checks board config. Returns check result of each node.
+func (instance *Instance) CheckBoardConfig(configJSON json.RawMessage) (
+	vendorVersion string, nodesInfo []cloudprotocol.NodeBoardConfigInfo, err error) {
 	instance.Lock()
 	defer instance.Unlock()
 
 	boardConfig := BoardConfig{VendorVersion: "unknown"}
 
 	if err = json.Unmarshal(configJSON, &boardConfig); err != nil {
-		return boardConfig.VendorVersion, aoserrors.Wrap(err)
+		return boardConfig.VendorVersion, nil, aoserrors.Wrap(err)
 	}
 
-	if vendorVersion, err = instance.checkBoardConfig(boardConfig); err != nil {
-		return vendorVersion, aoserrors.Wrap(err)
+	if vendorVersion, nodesInfo, err = instance.checkBoardConfig(boardConfig); err != nil {
+		return vendorVersion, nodesInfo, aoserrors.Wrap(err)
 	}
 
-	return vendorVersion, nil
+	return vendorVersion, nodesInfo, nil
 }
 
 // UpdateBoardConfig updates board config
@@ -171,7 +189,7 @@ func (instance *Instance) UpdateBoardConfig(configJSON json.RawMessage) (err err
 
 	instance.boardConfig = boardConfig
 
-	if err = instance.client.SetBoardConfig(instance.boardConfig); err != nil {
+	if instance.nodesInfo, err = instance.client.SetBoardConfig(instance.boardConfig); err != nil {
 		return aoserrors.Wrap(err)
 	}
 
@@ -186,6 +204,44 @@ func (instance *Instance) UpdateBoardConfig(configJSON json.RawMessage) (err err
 	return nil
 }
 
+// GetNodeConfig returns board config of the node. Node specific devices and resources are merged with shared ones,
+// node item overrides shared item with the same name.
+func (boardConfig BoardConfig) GetNodeConfig(nodeID string) (nodeConfig BoardConfig) {
+	nodeConfig = BoardConfig{
+		FormatVersion: boardConfig.FormatVersion,
+		VendorVersion: boardConfig.VendorVersion,
+		Devices:       boardConfig.Devices,
+		Resources:     boardConfig.Resources,
+	}
+
+	nodeSection, ok := boardConfig.Nodes[nodeID]
+	if !ok {
+		return nodeConfig
+	}
+
+	nodeConfig.Devices = make([]DeviceResource, 0, len(boardConfig.Devices)+len(nodeSection.Devices))
+
+	for _, device := range boardConfig.Devices {
+		if !hasDevice(nodeSection.Devices, device.Name) {
+			nodeConfig.Devices = append(nodeConfig.Devices, device)
+		}
+	}
+
+	nodeConfig.Devices = append(nodeConfig.Devices, nodeSection.Devices...)
+
+	nodeConfig.Resources = make([]BoardResource, 0, len(boardConfig.Resources)+len(nodeSection.Resources))
+
+	for _, resource := range boardConfig.Resources {
+		if !hasResource(nodeSection.Resources, resource.Name) {
+			nodeConfig.Resources = append(nodeConfig.Resources, resource)
+		}
+	}
+
+	nodeConfig.Resources = append(nodeConfig.Resources, nodeSection.Resources...)
+
+	return nodeConfig
+}
+
 /***********************************************************************************************************************
  * Private
  **********************************************************************************************************************/
@@ -207,14 +263,35 @@ func (instance *Instance) load() (err error) {
 	return nil
 }
 
-func (instance *Instance) checkBoardConfig(boardConfig BoardConfig) (vendorVersion string, err error) {
+func (instance *Instance) checkBoardConfig(boardConfig BoardConfig) (
+	vendorVersion string, nodesInfo []cloudprotocol.NodeBoardConfigInfo, err error) {
 	if boardConfig.VendorVersion == instance.boardConfig.VendorVersion {
-		return boardConfig.VendorVersion, aoserrors.New("invalid vendor version")
+		return boardConfig.VendorVersion, nil, aoserrors.New("invalid vendor version")
 	}
 
-	if err = instance.client.CheckBoardConfig(boardConfig); err != nil {
-		return boardConfig.VendorVersion, aoserrors.Wrap(err)
+	if nodesInfo, err = instance.client.CheckBoardConfig(boardConfig); err != nil {
+		return boardConfig.VendorVersion, nodesInfo, aoserrors.Wrap(err)
 	}
 
-	return boardConfig.VendorVersion, nil
+	return boardConfig.VendorVersion, nodesInfo, nil
+}
+
+func hasDevice(devices []DeviceResource, name string) (result bool) {
+	for _, device := range devices {
+		if device.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasResource(resources []BoardResource, name string) (result bool) {
+	for _, resource := range resources {
+		if resource.Name == name {
+			return true
+		}
+	}
+
+	return false
 }
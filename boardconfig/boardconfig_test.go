@@ -22,9 +22,11 @@ import (
 	"aos_communicationmanager/cloudprotocol"
 	"aos_communicationmanager/config"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"os"
 	"path"
+	"reflect"
 	"testing"
 
 	log "github.com/sirupsen/logrus"
@@ -35,6 +37,7 @@ import (
  **********************************************************************************************************************/
 
 type testClient struct {
+	nodeErrors map[string]string
 }
 
 /***********************************************************************************************************************
@@ -159,7 +162,7 @@ func TestCheckBoardConfig(t *testing.T) {
 		"vendorVersion": "2.0.0"
 	}`
 
-	vendorVersion, err := boardConfig.CheckBoardConfig(json.RawMessage(validBoardConfig))
+	vendorVersion, _, err := boardConfig.CheckBoardConfig(json.RawMessage(validBoardConfig))
 	if err != nil {
 		t.Errorf("Check board config error: %s", err)
 	}
@@ -174,7 +177,7 @@ func TestCheckBoardConfig(t *testing.T) {
 		"vendorVersion": "1.0.0"
 	}`
 
-	if vendorVersion, err = boardConfig.CheckBoardConfig(json.RawMessage(invalidBoardConfig)); err == nil {
+	if vendorVersion, _, err = boardConfig.CheckBoardConfig(json.RawMessage(invalidBoardConfig)); err == nil {
 		t.Error("Error expected")
 	}
 
@@ -219,14 +222,150 @@ func TestUpdateBoardConfig(t *testing.T) {
 	}
 }
 
+func TestNodeBoardConfig(t *testing.T) {
+	testBoardConfig := `
+	{
+		"formatVersion": 1,
+		"vendorVersion": "1.0.0",
+		"devices": [
+			{"name": "camera", "hostDevices": ["/dev/video0"]},
+			{"name": "sound", "hostDevices": ["/dev/snd"]}
+		],
+		"resources": [
+			{"name": "system-dbus"}
+		],
+		"nodes": {
+			"sm1": {
+				"devices": [
+					{"name": "camera", "hostDevices": ["/dev/video1"]},
+					{"name": "gpu", "hostDevices": ["/dev/dri"]}
+				]
+			}
+		}
+	}`
+
+	if err := ioutil.WriteFile(path.Join(tmpDir, "aos_board.cfg"), []byte(testBoardConfig), 0644); err != nil {
+		t.Fatalf("Can't create board config file: %s", err)
+	}
+
+	var boardConfig boardconfig.BoardConfig
+
+	if err := json.Unmarshal([]byte(testBoardConfig), &boardConfig); err != nil {
+		t.Fatalf("Can't parse board config: %s", err)
+	}
+
+	nodeConfig := boardConfig.GetNodeConfig("sm1")
+
+	expectedDevices := []boardconfig.DeviceResource{
+		{Name: "sound", HostDevices: []string{"/dev/snd"}},
+		{Name: "camera", HostDevices: []string{"/dev/video1"}},
+		{Name: "gpu", HostDevices: []string{"/dev/dri"}},
+	}
+
+	if !reflect.DeepEqual(nodeConfig.Devices, expectedDevices) {
+		t.Errorf("Wrong node devices: %v", nodeConfig.Devices)
+	}
+
+	if !reflect.DeepEqual(nodeConfig.Resources, boardConfig.Resources) {
+		t.Errorf("Wrong node resources: %v", nodeConfig.Resources)
+	}
+
+	if nodeConfig = boardConfig.GetNodeConfig("sm2"); !reflect.DeepEqual(nodeConfig.Devices, boardConfig.Devices) {
+		t.Errorf("Wrong node devices: %v", nodeConfig.Devices)
+	}
+
+	client := &testClient{nodeErrors: map[string]string{"sm2": "invalid device"}}
+
+	instance, err := boardconfig.New(&config.Config{BoardConfigFile: path.Join(tmpDir, "aos_board.cfg")}, client)
+	if err != nil {
+		t.Fatalf("Can't create board config instance: %s", err)
+	}
+
+	info, err := instance.GetStatus()
+	if err != nil {
+		t.Fatalf("Can't get board config status: %s", err)
+	}
+
+	if info.Status != cloudprotocol.ErrorStatus {
+		t.Errorf("Wrong board config status: %s", info.Status)
+	}
+
+	expectedNodes := []cloudprotocol.NodeBoardConfigInfo{
+		{NodeID: "sm1", Status: cloudprotocol.InstalledStatus},
+		{NodeID: "sm2", Status: cloudprotocol.ErrorStatus, Error: "invalid device"},
+	}
+
+	if !reflect.DeepEqual(info.Nodes, expectedNodes) {
+		t.Errorf("Wrong nodes status: %v", info.Nodes)
+	}
+
+	// Nodes status should be updated when node error is fixed
+
+	client.nodeErrors = nil
+
+	if info, err = instance.GetStatus(); err != nil {
+		t.Fatalf("Can't get board config status: %s", err)
+	}
+
+	if info.Status != cloudprotocol.InstalledStatus {
+		t.Errorf("Wrong board config status: %s", info.Status)
+	}
+
+	expectedNodes = []cloudprotocol.NodeBoardConfigInfo{
+		{NodeID: "sm1", Status: cloudprotocol.InstalledStatus},
+		{NodeID: "sm2", Status: cloudprotocol.InstalledStatus},
+	}
+
+	if !reflect.DeepEqual(info.Nodes, expectedNodes) {
+		t.Errorf("Wrong nodes status: %v", info.Nodes)
+	}
+
+	// All nodes should be checked
+
+	client.nodeErrors = map[string]string{"sm1": "invalid device", "sm2": "invalid resource"}
+
+	_, nodesInfo, err := instance.CheckBoardConfig(json.RawMessage(`{"formatVersion": 1, "vendorVersion": "2.0.0"}`))
+	if err == nil {
+		t.Error("Error expected")
+	}
+
+	expectedNodes = []cloudprotocol.NodeBoardConfigInfo{
+		{NodeID: "sm1", Status: cloudprotocol.ErrorStatus, Error: "invalid device"},
+		{NodeID: "sm2", Status: cloudprotocol.ErrorStatus, Error: "invalid resource"},
+	}
+
+	if !reflect.DeepEqual(nodesInfo, expectedNodes) {
+		t.Errorf("Wrong nodes check result: %v", nodesInfo)
+	}
+}
+
 /***********************************************************************************************************************
  * testClient
  **********************************************************************************************************************/
 
-func (client *testClient) CheckBoardConfig(boardConfig boardconfig.BoardConfig) (err error) {
-	return nil
+func (client *testClient) CheckBoardConfig(
+	boardConfig boardconfig.BoardConfig) (nodesInfo []cloudprotocol.NodeBoardConfigInfo, err error) {
+	return client.getNodesInfo(cloudprotocol.PendingStatus)
+}
+
+func (client *testClient) SetBoardConfig(
+	boardConfig boardconfig.BoardConfig) (nodesInfo []cloudprotocol.NodeBoardConfigInfo, err error) {
+	return client.getNodesInfo(cloudprotocol.InstalledStatus)
 }
 
-func (client *testClient) SetBoardConfig(boardConfig boardconfig.BoardConfig) (err error) {
-	return nil
+func (client *testClient) getNodesInfo(
+	status string) (nodesInfo []cloudprotocol.NodeBoardConfigInfo, err error) {
+	for _, nodeID := range []string{"sm1", "sm2"} {
+		nodeInfo := cloudprotocol.NodeBoardConfigInfo{NodeID: nodeID, Status: status}
+
+		if nodeError, ok := client.nodeErrors[nodeID]; ok {
+			nodeInfo.Status = cloudprotocol.ErrorStatus
+			nodeInfo.Error = nodeError
+			err = errors.New(nodeError)
+		}
+
+		nodesInfo = append(nodesInfo, nodeInfo)
+	}
+
+	return nodesInfo, err
 }
@@ -0,0 +1,458 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package certmanager tracks unit certificates expiration and renews them in advance
+package certmanager
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aoscloud/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"aos_communicationmanager/cloudprotocol"
+	"aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const alertSource = "CM"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// CertificateProvider provides unit certificates and issues new ones
+type CertificateProvider interface {
+	GetCertTypes() (certTypes []string, err error)
+	GetCertificate(certType string, issuer []byte, serial string) (certURL, keyURL string, err error)
+	IssueUnitCertificates(certTypes []string, password string) (err error)
+}
+
+// CertificateInfoProvider provides certificate details
+type CertificateInfoProvider interface {
+	GetCertInfo(certURL string) (serial string, validTill time.Time, err error)
+}
+
+// KeyWrapper protects owner password with the unit key
+type KeyWrapper interface {
+	WrapKey(key []byte) (wrapped []byte, err error)
+	UnwrapKey(wrapped []byte) (key []byte, err error)
+}
+
+// Storage stores pending certificate renewals and wrapped owner password
+type Storage interface {
+	SetCertRenewal(renewal RenewalInfo) (err error)
+	GetCertRenewals() (renewals []RenewalInfo, err error)
+	RemoveCertRenewal(certType string) (err error)
+	SetOwnerPassword(wrappedPassword []byte) (err error)
+	GetOwnerPassword() (wrappedPassword []byte, err error)
+}
+
+// AlertSender sends alerts
+type AlertSender interface {
+	SendAlert(alert cloudprotocol.AlertItem) (err error)
+}
+
+// RenewalInfo pending certificate renewal info
+type RenewalInfo struct {
+	Type           string
+	Serial         string
+	ValidTill      time.Time
+	Attempt        int
+	NextAttempt    time.Time
+	FailureAlerted bool
+}
+
+// CertManager certificate manager instance
+type CertManager struct {
+	sync.Mutex
+
+	certProvider CertificateProvider
+	infoProvider CertificateInfoProvider
+	keyWrapper   KeyWrapper
+	storage      Storage
+	alertSender  AlertSender
+
+	config   config.CertManager
+	renewals map[string]RenewalInfo
+
+	checkChannel   chan struct{}
+	cancelFunction context.CancelFunc
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// New creates new certificate manager instance
+func New(cfg *config.Config, certProvider CertificateProvider, infoProvider CertificateInfoProvider,
+	keyWrapper KeyWrapper, storage Storage, alertSender AlertSender) (manager *CertManager, err error) {
+	log.Debug("Create certificate manager")
+
+	if certProvider == nil || infoProvider == nil || keyWrapper == nil || storage == nil {
+		return nil, aoserrors.New("certificate manager dependencies are not set")
+	}
+
+	manager = &CertManager{
+		certProvider: certProvider,
+		infoProvider: infoProvider,
+		keyWrapper:   keyWrapper,
+		storage:      storage,
+		alertSender:  alertSender,
+		config:       cfg.CertManager,
+		renewals:     make(map[string]RenewalInfo),
+		checkChannel: make(chan struct{}, 1),
+	}
+
+	renewals, err := manager.storage.GetCertRenewals()
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	for _, renewal := range renewals {
+		log.WithFields(log.Fields{
+			"type":        renewal.Type,
+			"serial":      renewal.Serial,
+			"attempt":     renewal.Attempt,
+			"nextAttempt": renewal.NextAttempt}).Debug("Restore pending certificate renewal")
+
+		manager.renewals[renewal.Type] = renewal
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	manager.cancelFunction = cancelFunc
+
+	go manager.run(ctx)
+
+	return manager, nil
+}
+
+// CheckCertificates triggers certificates check
+func (manager *CertManager) CheckCertificates() {
+	select {
+	case manager.checkChannel <- struct{}{}:
+
+	default:
+	}
+}
+
+// SetRenewalRequested registers renewal requested by the cloud.
+// It prevents requesting new keys for the same certificates until retry delay expires.
+func (manager *CertManager) SetRenewalRequested(certTypes []string) {
+	manager.Lock()
+	defer manager.Unlock()
+
+	now := time.Now()
+
+	for _, certType := range certTypes {
+		renewal, ok := manager.renewals[certType]
+		if !ok {
+			renewal = RenewalInfo{Type: certType}
+		}
+
+		renewal.Attempt++
+		renewal.NextAttempt = now.Add(manager.getRetryDelay(renewal.Attempt))
+
+		log.WithFields(log.Fields{
+			"type":        certType,
+			"attempt":     renewal.Attempt,
+			"nextAttempt": renewal.NextAttempt}).Debug("Certificate renewal requested by cloud")
+
+		manager.setRenewal(renewal)
+	}
+}
+
+// SetOwnerPassword stores owner password used to create keys for certificate renewal.
+// The password is wrapped with the unit key and kept in the storage, so renewal works after restart.
+func (manager *CertManager) SetOwnerPassword(password string) (err error) {
+	wrappedPassword, err := manager.keyWrapper.WrapKey([]byte(password))
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = manager.storage.SetOwnerPassword(wrappedPassword); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+// GetPendingRenewals returns pending certificate renewals
+func (manager *CertManager) GetPendingRenewals() (renewals []RenewalInfo) {
+	manager.Lock()
+	defer manager.Unlock()
+
+	renewals = make([]RenewalInfo, 0, len(manager.renewals))
+
+	for _, renewal := range manager.renewals {
+		renewals = append(renewals, renewal)
+	}
+
+	return renewals
+}
+
+// Close closes certificate manager
+func (manager *CertManager) Close() {
+	log.Debug("Close certificate manager")
+
+	if manager.cancelFunction != nil {
+		manager.cancelFunction()
+	}
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (manager *CertManager) run(ctx context.Context) {
+	for {
+		checkTimeout := manager.checkCertificates(time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-manager.checkChannel:
+
+		case <-time.After(checkTimeout):
+		}
+	}
+}
+
+func (manager *CertManager) checkCertificates(now time.Time) (checkTimeout time.Duration) {
+	log.Debug("Check certificates")
+
+	checkTimeout = manager.config.CheckPeriod.Duration
+
+	// IAM is requested without lock to not block pending renewals access
+	certTypes, err := manager.certProvider.GetCertTypes()
+	if err != nil {
+		log.Errorf("Can't get certificate types: %s", err)
+
+		return checkTimeout
+	}
+
+	var dueTypes []string
+
+	for _, certType := range certTypes {
+		serial, validTill, err := manager.getCertInfo(certType)
+		if err != nil {
+			log.WithField("type", certType).Errorf("Can't get certificate info: %s", err)
+
+			continue
+		}
+
+		if manager.checkCertificate(certType, serial, validTill, now) {
+			dueTypes = append(dueTypes, certType)
+		}
+	}
+
+	if len(dueTypes) > 0 {
+		manager.renewCertificates(dueTypes, now)
+	}
+
+	manager.Lock()
+	defer manager.Unlock()
+
+	for _, renewal := range manager.renewals {
+		if timeout := renewal.NextAttempt.Sub(now); timeout < checkTimeout {
+			checkTimeout = timeout
+		}
+	}
+
+	if checkTimeout <= 0 {
+		checkTimeout = manager.config.RetryDelay.Duration
+	}
+
+	return checkTimeout
+}
+
+func (manager *CertManager) getCertInfo(certType string) (serial string, validTill time.Time, err error) {
+	certURL, _, err := manager.certProvider.GetCertificate(certType, nil, "")
+	if err != nil {
+		return "", time.Time{}, aoserrors.Wrap(err)
+	}
+
+	if serial, validTill, err = manager.infoProvider.GetCertInfo(certURL); err != nil {
+		return "", time.Time{}, aoserrors.Wrap(err)
+	}
+
+	return serial, validTill, nil
+}
+
+// checkCertificate updates pending renewal of certificate and returns true if renewal attempt is due
+func (manager *CertManager) checkCertificate(certType, serial string, validTill, now time.Time) (due bool) {
+	manager.Lock()
+	defer manager.Unlock()
+
+	renewal, pending := manager.renewals[certType]
+
+	if validTill.Sub(now) > manager.config.RenewBefore.Duration {
+		if pending {
+			log.WithFields(log.Fields{"type": certType, "serial": serial}).Info("Certificate renewed")
+
+			manager.removeRenewal(certType)
+		}
+
+		return false
+	}
+
+	if pending {
+		if renewal.Serial != serial || !renewal.ValidTill.Equal(validTill) {
+			renewal.Serial = serial
+			renewal.ValidTill = validTill
+
+			manager.setRenewal(renewal)
+		}
+
+		return !now.Before(renewal.NextAttempt)
+	}
+
+	log.WithFields(log.Fields{
+		"type": certType, "serial": serial, "validTill": validTill}).Warn("Certificate expires soon, schedule renewal")
+
+	if now.Before(validTill) {
+		manager.sendAlert(fmt.Sprintf("Certificate %s (serial: %s) expires at %s, renewal scheduled",
+			certType, serial, validTill.UTC().Format(time.RFC3339)))
+	} else {
+		manager.sendAlert(fmt.Sprintf("Certificate %s (serial: %s) expired at %s, renewal scheduled",
+			certType, serial, validTill.UTC().Format(time.RFC3339)))
+	}
+
+	manager.setRenewal(RenewalInfo{Type: certType, Serial: serial, ValidTill: validTill, NextAttempt: now})
+
+	return true
+}
+
+func (manager *CertManager) renewCertificates(certTypes []string, now time.Time) {
+	log.WithField("types", certTypes).Debug("Renew certificates")
+
+	password, issueErr := manager.getOwnerPassword()
+	if issueErr == nil {
+		issueErr = manager.certProvider.IssueUnitCertificates(certTypes, password)
+	}
+
+	if issueErr != nil {
+		log.WithField("types", certTypes).Errorf("Can't issue certificates: %s", issueErr)
+	}
+
+	manager.Lock()
+	defer manager.Unlock()
+
+	for _, certType := range certTypes {
+		renewal, ok := manager.renewals[certType]
+		if !ok {
+			continue
+		}
+
+		renewal.Attempt++
+		renewal.NextAttempt = now.Add(manager.getRetryDelay(renewal.Attempt))
+
+		log.WithFields(log.Fields{
+			"type":        certType,
+			"attempt":     renewal.Attempt,
+			"nextAttempt": renewal.NextAttempt}).Debug("Certificate renewal requested")
+
+		// Failed renewal is alerted once, further attempts are only logged
+		if !renewal.FailureAlerted {
+			switch {
+			case issueErr != nil:
+				manager.sendAlert(fmt.Sprintf("Certificate %s renewal attempt %d failed: %s",
+					certType, renewal.Attempt, issueErr))
+
+				renewal.FailureAlerted = true
+
+			case renewal.Attempt > 1:
+				manager.sendAlert(fmt.Sprintf("Certificate %s is not renewed after %d attempts, renewal requested again",
+					certType, renewal.Attempt-1))
+
+				renewal.FailureAlerted = true
+			}
+		}
+
+		manager.setRenewal(renewal)
+	}
+}
+
+func (manager *CertManager) getOwnerPassword() (password string, err error) {
+	wrappedPassword, err := manager.storage.GetOwnerPassword()
+	if err != nil {
+		return "", aoserrors.Wrap(err)
+	}
+
+	if len(wrappedPassword) == 0 {
+		return "", aoserrors.New("owner password is not available")
+	}
+
+	passwordData, err := manager.keyWrapper.UnwrapKey(wrappedPassword)
+	if err != nil {
+		return "", aoserrors.Wrap(err)
+	}
+
+	return string(passwordData), nil
+}
+
+func (manager *CertManager) getRetryDelay(attempt int) (delay time.Duration) {
+	delay = manager.config.RetryDelay.Duration
+
+	for i := 1; i < attempt; i++ {
+		delay = delay * 2
+
+		if delay >= manager.config.MaxRetryDelay.Duration {
+			return manager.config.MaxRetryDelay.Duration
+		}
+	}
+
+	return delay
+}
+
+func (manager *CertManager) setRenewal(renewal RenewalInfo) {
+	manager.renewals[renewal.Type] = renewal
+
+	if err := manager.storage.SetCertRenewal(renewal); err != nil {
+		log.WithField("type", renewal.Type).Errorf("Can't store certificate renewal: %s", err)
+	}
+}
+
+func (manager *CertManager) removeRenewal(certType string) {
+	delete(manager.renewals, certType)
+
+	if err := manager.storage.RemoveCertRenewal(certType); err != nil {
+		log.WithField("type", certType).Errorf("Can't remove certificate renewal: %s", err)
+	}
+}
+
+func (manager *CertManager) sendAlert(message string) {
+	if manager.alertSender == nil {
+		return
+	}
+
+	if err := manager.alertSender.SendAlert(cloudprotocol.AlertItem{
+		Timestamp: time.Now(),
+		Tag:       cloudprotocol.AlertTagAosCore,
+		Source:    alertSource,
+		Payload:   cloudprotocol.SystemAlert{Message: message},
+	}); err != nil {
+		log.Errorf("Can't send alert: %s", err)
+	}
+}
@@ -0,0 +1,540 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certmanager_test
+
+import (
+	"bytes"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aoscloud/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"aos_communicationmanager/certmanager"
+	"aos_communicationmanager/cloudprotocol"
+	"aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const waitTimeout = 5 * time.Second
+
+const (
+	testPassword   = "password"
+	testWrapPrefix = "wrapped:"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type testCertInfo struct {
+	serial    string
+	validTill time.Time
+}
+
+type testCertProvider struct {
+	sync.Mutex
+
+	certs        map[string]testCertInfo
+	issueErr     error
+	issueChannel chan []string
+}
+
+type testStorage struct {
+	sync.Mutex
+
+	renewals        map[string]certmanager.RenewalInfo
+	wrappedPassword []byte
+}
+
+type testAlertSender struct {
+	alertChannel chan cloudprotocol.AlertItem
+}
+
+/***********************************************************************************************************************
+ * Init
+ **********************************************************************************************************************/
+
+func init() {
+	log.SetFormatter(&log.TextFormatter{
+		DisableTimestamp: false,
+		TimestampFormat:  "2006-01-02 15:04:05.000",
+		FullTimestamp:    true})
+	log.SetLevel(log.DebugLevel)
+	log.SetOutput(os.Stdout)
+}
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestNoRenewal(t *testing.T) {
+	certProvider := newTestCertProvider(map[string]testCertInfo{
+		"online":  {serial: "01", validTill: time.Now().Add(48 * time.Hour)},
+		"offline": {serial: "02", validTill: time.Now().Add(72 * time.Hour)},
+	})
+	storage := newTestStorage()
+	alertSender := newTestAlertSender()
+
+	manager, err := certmanager.New(createConfig(), certProvider, certProvider, certProvider, storage, alertSender)
+	if err != nil {
+		t.Fatalf("Can't create cert manager: %s", err)
+	}
+	defer manager.Close()
+
+	select {
+	case certTypes := <-certProvider.issueChannel:
+		t.Errorf("Unexpected certificates issue: %v", certTypes)
+
+	case alert := <-alertSender.alertChannel:
+		t.Errorf("Unexpected alert: %v", alert)
+
+	case <-time.After(time.Second):
+	}
+
+	if len(manager.GetPendingRenewals()) != 0 {
+		t.Errorf("Unexpected pending renewals: %v", manager.GetPendingRenewals())
+	}
+}
+
+func TestRenewal(t *testing.T) {
+	certProvider := newTestCertProvider(map[string]testCertInfo{
+		"online":  {serial: "01", validTill: time.Now().Add(time.Hour)},
+		"offline": {serial: "02", validTill: time.Now().Add(72 * time.Hour)},
+	})
+	storage := newTestStorage()
+	alertSender := newTestAlertSender()
+
+	manager, err := certmanager.New(createConfig(), certProvider, certProvider, certProvider, storage, alertSender)
+	if err != nil {
+		t.Fatalf("Can't create cert manager: %s", err)
+	}
+	defer manager.Close()
+
+	if err = waitAlert(alertSender); err != nil {
+		t.Fatalf("Wait alert error: %s", err)
+	}
+
+	certTypes, err := waitIssue(certProvider)
+	if err != nil {
+		t.Fatalf("Wait issue error: %s", err)
+	}
+
+	if len(certTypes) != 1 || certTypes[0] != "online" {
+		t.Errorf("Wrong issued cert types: %v", certTypes)
+	}
+
+	renewal, ok := storage.getRenewal("online")
+	if !ok {
+		t.Fatal("Renewal is not stored")
+	}
+
+	if renewal.Serial != "01" || renewal.Attempt != 1 {
+		t.Errorf("Wrong stored renewal: %v", renewal)
+	}
+
+	certProvider.setCert("online", testCertInfo{serial: "03", validTill: time.Now().Add(48 * time.Hour)})
+
+	manager.CheckCertificates()
+
+	if err = waitCondition(func() bool {
+		_, ok := storage.getRenewal("online")
+		return !ok
+	}); err != nil {
+		t.Errorf("Renewal is not removed: %s", err)
+	}
+
+	if len(manager.GetPendingRenewals()) != 0 {
+		t.Errorf("Unexpected pending renewals: %v", manager.GetPendingRenewals())
+	}
+}
+
+func TestRenewalRetry(t *testing.T) {
+	certProvider := newTestCertProvider(map[string]testCertInfo{
+		"online": {serial: "01", validTill: time.Now().Add(-time.Hour)},
+	})
+	certProvider.issueErr = aoserrors.New("issue error")
+	storage := newTestStorage()
+	alertSender := newTestAlertSender()
+
+	manager, err := certmanager.New(createConfig(), certProvider, certProvider, certProvider, storage, alertSender)
+	if err != nil {
+		t.Fatalf("Can't create cert manager: %s", err)
+	}
+	defer manager.Close()
+
+	if err = waitAlert(alertSender); err != nil {
+		t.Fatalf("Wait alert error: %s", err)
+	}
+
+	var issueTimes []time.Time
+
+	for i := 0; i < 4; i++ {
+		if _, err = waitIssue(certProvider); err != nil {
+			t.Fatalf("Wait issue error: %s", err)
+		}
+
+		issueTimes = append(issueTimes, time.Now())
+	}
+
+	// Retry delays: 100ms, 200ms, 300ms (max)
+	expectedDelays := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}
+
+	for i, expectedDelay := range expectedDelays {
+		if delay := issueTimes[i+1].Sub(issueTimes[i]); delay < expectedDelay-20*time.Millisecond {
+			t.Errorf("Wrong retry delay %d: %v, expected: %v", i, delay, expectedDelay)
+		}
+	}
+
+	// Failed renewal raises one alert for all attempts
+	if err = waitAlert(alertSender); err != nil {
+		t.Errorf("Wait retry alert error: %s", err)
+	}
+
+	select {
+	case alert := <-alertSender.alertChannel:
+		t.Errorf("Unexpected alert: %v", alert)
+
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	// Attempt is stored after issue request returns, wait until the last one is stored
+	if err = waitCondition(func() bool {
+		renewal, ok := storage.getRenewal("online")
+		return ok && renewal.Attempt >= len(issueTimes)
+	}); err != nil {
+		t.Errorf("Renewal attempt is not stored: %s", err)
+	}
+}
+
+func TestOwnerPassword(t *testing.T) {
+	certProvider := newTestCertProvider(map[string]testCertInfo{
+		"online": {serial: "01", validTill: time.Now().Add(time.Hour)},
+	})
+	storage := newTestStorage()
+	storage.wrappedPassword = nil
+	alertSender := newTestAlertSender()
+
+	manager, err := certmanager.New(createConfig(), certProvider, certProvider, certProvider, storage, alertSender)
+	if err != nil {
+		t.Fatalf("Can't create cert manager: %s", err)
+	}
+	defer manager.Close()
+
+	// Renewal is scheduled and fails as owner password is not available
+
+	for i := 0; i < 2; i++ {
+		if err = waitAlert(alertSender); err != nil {
+			t.Fatalf("Wait alert error: %s", err)
+		}
+	}
+
+	select {
+	case certTypes := <-certProvider.issueChannel:
+		t.Errorf("Unexpected certificates issue: %v", certTypes)
+
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	if err = manager.SetOwnerPassword(testPassword); err != nil {
+		t.Fatalf("Can't set owner password: %s", err)
+	}
+
+	if wrappedPassword, _ := storage.GetOwnerPassword(); string(wrappedPassword) != testWrapPrefix+testPassword {
+		t.Errorf("Wrong stored owner password: %s", wrappedPassword)
+	}
+
+	if _, err = waitIssue(certProvider); err != nil {
+		t.Errorf("Wait issue error: %s", err)
+	}
+}
+
+func TestRenewalRequestedByCloud(t *testing.T) {
+	certProvider := newTestCertProvider(map[string]testCertInfo{
+		"online": {serial: "01", validTill: time.Now().Add(time.Hour)},
+	})
+	storage := newTestStorage()
+	alertSender := newTestAlertSender()
+
+	cfg := createConfig()
+	cfg.CertManager.RetryDelay.Duration = time.Hour
+	cfg.CertManager.MaxRetryDelay.Duration = time.Hour
+
+	manager, err := certmanager.New(cfg, certProvider, certProvider, certProvider, storage, alertSender)
+	if err != nil {
+		t.Fatalf("Can't create cert manager: %s", err)
+	}
+	defer manager.Close()
+
+	if _, err = waitIssue(certProvider); err != nil {
+		t.Fatalf("Wait issue error: %s", err)
+	}
+
+	// Cloud requests the same certificate: it should not be requested again proactively
+
+	manager.SetRenewalRequested([]string{"online"})
+	manager.CheckCertificates()
+
+	select {
+	case certTypes := <-certProvider.issueChannel:
+		t.Errorf("Unexpected certificates issue: %v", certTypes)
+
+	case <-time.After(time.Second):
+	}
+
+	renewal, ok := storage.getRenewal("online")
+	if !ok || renewal.Attempt != 2 || !renewal.NextAttempt.After(time.Now().Add(30*time.Minute)) {
+		t.Errorf("Wrong stored renewal: %v", renewal)
+	}
+}
+
+func TestRestoreRenewal(t *testing.T) {
+	certProvider := newTestCertProvider(map[string]testCertInfo{
+		"online": {serial: "01", validTill: time.Now().Add(time.Hour)},
+	})
+	storage := newTestStorage()
+	alertSender := newTestAlertSender()
+
+	if err := storage.SetCertRenewal(certmanager.RenewalInfo{
+		Type: "online", Serial: "01", ValidTill: time.Now().Add(time.Hour),
+		Attempt: 5, NextAttempt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Can't set renewal: %s", err)
+	}
+
+	manager, err := certmanager.New(createConfig(), certProvider, certProvider, certProvider, storage, alertSender)
+	if err != nil {
+		t.Fatalf("Can't create cert manager: %s", err)
+	}
+	defer manager.Close()
+
+	select {
+	case certTypes := <-certProvider.issueChannel:
+		t.Errorf("Unexpected certificates issue: %v", certTypes)
+
+	case alert := <-alertSender.alertChannel:
+		t.Errorf("Unexpected alert: %v", alert)
+
+	case <-time.After(time.Second):
+	}
+
+	renewals := manager.GetPendingRenewals()
+
+	if len(renewals) != 1 || renewals[0].Attempt != 5 {
+		t.Errorf("Wrong pending renewals: %v", renewals)
+	}
+}
+
+/***********************************************************************************************************************
+ * Interfaces
+ **********************************************************************************************************************/
+
+func newTestCertProvider(certs map[string]testCertInfo) (provider *testCertProvider) {
+	return &testCertProvider{certs: certs, issueChannel: make(chan []string, 10)}
+}
+
+func (provider *testCertProvider) GetCertTypes() (certTypes []string, err error) {
+	provider.Lock()
+	defer provider.Unlock()
+
+	for certType := range provider.certs {
+		certTypes = append(certTypes, certType)
+	}
+
+	return certTypes, nil
+}
+
+func (provider *testCertProvider) GetCertificate(
+	certType string, issuer []byte, serial string) (certURL, keyURL string, err error) {
+	return certType, certType, nil
+}
+
+func (provider *testCertProvider) IssueUnitCertificates(certTypes []string, password string) (err error) {
+	if password != testPassword {
+		return aoserrors.Errorf("wrong password: %s", password)
+	}
+
+	provider.issueChannel <- certTypes
+
+	return provider.issueErr
+}
+
+func (provider *testCertProvider) WrapKey(key []byte) (wrapped []byte, err error) {
+	return append([]byte(testWrapPrefix), key...), nil
+}
+
+func (provider *testCertProvider) UnwrapKey(wrapped []byte) (key []byte, err error) {
+	if !bytes.HasPrefix(wrapped, []byte(testWrapPrefix)) {
+		return nil, aoserrors.New("wrong wrapped key")
+	}
+
+	return bytes.TrimPrefix(wrapped, []byte(testWrapPrefix)), nil
+}
+
+func (provider *testCertProvider) GetCertInfo(certURL string) (serial string, validTill time.Time, err error) {
+	provider.Lock()
+	defer provider.Unlock()
+
+	cert, ok := provider.certs[certURL]
+	if !ok {
+		return "", time.Time{}, aoserrors.New("certificate not found")
+	}
+
+	return cert.serial, cert.validTill, nil
+}
+
+func (provider *testCertProvider) setCert(certType string, cert testCertInfo) {
+	provider.Lock()
+	defer provider.Unlock()
+
+	provider.certs[certType] = cert
+}
+
+func newTestStorage() (storage *testStorage) {
+	return &testStorage{
+		renewals: make(map[string]certmanager.RenewalInfo), wrappedPassword: []byte(testWrapPrefix + testPassword),
+	}
+}
+
+func (storage *testStorage) SetOwnerPassword(wrappedPassword []byte) (err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	storage.wrappedPassword = wrappedPassword
+
+	return nil
+}
+
+func (storage *testStorage) GetOwnerPassword() (wrappedPassword []byte, err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	return storage.wrappedPassword, nil
+}
+
+func (storage *testStorage) SetCertRenewal(renewal certmanager.RenewalInfo) (err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	storage.renewals[renewal.Type] = renewal
+
+	return nil
+}
+
+func (storage *testStorage) GetCertRenewals() (renewals []certmanager.RenewalInfo, err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	for _, renewal := range storage.renewals {
+		renewals = append(renewals, renewal)
+	}
+
+	return renewals, nil
+}
+
+func (storage *testStorage) RemoveCertRenewal(certType string) (err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	if _, ok := storage.renewals[certType]; !ok {
+		return aoserrors.New("renewal not found")
+	}
+
+	delete(storage.renewals, certType)
+
+	return nil
+}
+
+func (storage *testStorage) getRenewal(certType string) (renewal certmanager.RenewalInfo, ok bool) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	renewal, ok = storage.renewals[certType]
+
+	return renewal, ok
+}
+
+func newTestAlertSender() (sender *testAlertSender) {
+	return &testAlertSender{alertChannel: make(chan cloudprotocol.AlertItem, 10)}
+}
+
+func (sender *testAlertSender) SendAlert(alert cloudprotocol.AlertItem) (err error) {
+	select {
+	case sender.alertChannel <- alert:
+
+	default:
+	}
+
+	return nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func createConfig() (cfg *config.Config) {
+	return &config.Config{CertManager: config.CertManager{
+		CheckPeriod:   config.Duration{Duration: time.Hour},
+		RenewBefore:   config.Duration{Duration: 24 * time.Hour},
+		RetryDelay:    config.Duration{Duration: 100 * time.Millisecond},
+		MaxRetryDelay: config.Duration{Duration: 300 * time.Millisecond},
+	}}
+}
+
+func waitAlert(sender *testAlertSender) (err error) {
+	select {
+	case alert := <-sender.alertChannel:
+		if alert.Tag != cloudprotocol.AlertTagAosCore {
+			return aoserrors.Errorf("wrong alert tag: %s", alert.Tag)
+		}
+
+		return nil
+
+	case <-time.After(waitTimeout):
+		return aoserrors.New("wait alert timeout")
+	}
+}
+
+func waitIssue(provider *testCertProvider) (certTypes []string, err error) {
+	select {
+	case certTypes = <-provider.issueChannel:
+		return certTypes, nil
+
+	case <-time.After(waitTimeout):
+		return nil, aoserrors.New("wait issue timeout")
+	}
+}
+
+func waitCondition(condition func() bool) (err error) {
+	timeout := time.After(waitTimeout)
+
+	for !condition() {
+		select {
+		case <-timeout:
+			return aoserrors.New("wait condition timeout")
+
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	return nil
+}
@@ -87,6 +87,19 @@ const (
 	ErrorStatus       = "error"
 )
 
+// Service state acceptance results
+const (
+	StateAccepted = "accepted"
+	StateRejected = "rejected"
+)
+
+// Node health statuses
+const (
+	NodeHealthy   = "healthy"
+	NodeUnhealthy = "unhealthy"
+	NodeDead      = "dead"
+)
+
 // SOTA/FOTA schedule type
 const (
 	ForceUpdate     = "force"
@@ -94,6 +107,19 @@ const (
 	TimetableUpdate = "timetable"
 )
 
+// SOTA/FOTA update type
+const (
+	FOTAUpdate = "fota"
+	SOTAUpdate = "sota"
+)
+
+// User update consent decisions
+const (
+	ConsentAccepted  = "accepted"
+	ConsentPostponed = "postponed"
+	ConsentDeclined  = "declined"
+)
+
 /***********************************************************************************************************************
  * Types
  **********************************************************************************************************************/
@@ -332,16 +358,27 @@ type ServiceMonitoringData struct {
 	OutTraffic uint64 `json:"outTraffic"`
 }
 
+// NodeMonitoringData monitoring data of the node
+type NodeMonitoringData struct {
+	NodeID       string                  `json:"nodeId"`
+	Timestamp    time.Time               `json:"timestamp"`
+	Global       GlobalMonitoringData    `json:"global"`
+	ServicesData []ServiceMonitoringData `json:"servicesData"`
+}
+
 // MonitoringData monitoring data structure
 type MonitoringData struct {
+	NodeID       string                  `json:"nodeId,omitempty"`
 	Timestamp    time.Time               `json:"timestamp"`
 	Global       GlobalMonitoringData    `json:"global"`
 	ServicesData []ServiceMonitoringData `json:"servicesData"`
+	Nodes        []NodeMonitoringData    `json:"nodes,omitempty"`
 }
 
 // PushLog push service log structure
 type PushLog struct {
 	LogID     string `json:"logID"`
+	NodeID    string `json:"nodeId,omitempty"`
 	PartCount uint64 `json:"partCount,omitempty"`
 	Part      uint64 `json:"part,omitempty"`
 	Data      []byte `json:"data,omitempty"`
@@ -360,22 +397,52 @@ type UnitStatus struct {
 	Services    []ServiceInfo     `json:"services"`
 	Layers      []LayerInfo       `json:"layers,omitempty"`
 	Components  []ComponentInfo   `json:"components"`
+	Nodes       []NodeHealth      `json:"nodes,omitempty"`
+	Consents    []UpdateConsent   `json:"updateConsents,omitempty"`
+}
+
+// UpdateConsent user consent decision on FOTA/SOTA update
+type UpdateConsent struct {
+	UpdateType    string    `json:"updateType"`
+	Decision      string    `json:"decision"`
+	Reason        string    `json:"reason,omitempty"`
+	Postponements uint64    `json:"postponements,omitempty"`
+	PostponedTill time.Time `json:"postponedTill,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// NodeHealth SM node health information
+type NodeHealth struct {
+	NodeID     string    `json:"nodeId"`
+	Status     string    `json:"status"`
+	LastSeen   time.Time `json:"lastSeen,omitempty"`
+	Latency    uint64    `json:"latency"`
+	ErrorCount uint64    `json:"errorCount"`
 }
 
 // BoardConfigInfo board config information
 type BoardConfigInfo struct {
-	VendorVersion string `json:"vendorVersion"`
-	Status        string `json:"status"`
-	Error         string `json:"error,omitempty"`
+	VendorVersion string                `json:"vendorVersion"`
+	Status        string                `json:"status"`
+	Error         string                `json:"error,omitempty"`
+	Nodes         []NodeBoardConfigInfo `json:"nodes,omitempty"`
+}
+
+// NodeBoardConfigInfo node board config information
+type NodeBoardConfigInfo struct {
+	NodeID string `json:"nodeId"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
 }
 
 // ServiceInfo struct with service information
 type ServiceInfo struct {
-	ID            string `json:"id"`
-	AosVersion    uint64 `json:"aosVersion"`
-	Status        string `json:"status"`
-	Error         string `json:"error,omitempty"`
-	StateChecksum string `json:"stateChecksum,omitempty"`
+	ID            string   `json:"id"`
+	AosVersion    uint64   `json:"aosVersion"`
+	Status        string   `json:"status"`
+	Error         string   `json:"error,omitempty"`
+	StateChecksum string   `json:"stateChecksum,omitempty"`
+	NodeIDs       []string `json:"nodeIds,omitempty"`
 }
 
 // LayerInfo struct with layer info and status
@@ -396,6 +463,14 @@ type ComponentInfo struct {
 	Error         string `json:"error,omitempty"`
 }
 
+// ComponentProgress system component update progress reported by UM
+type ComponentProgress struct {
+	ID            string `json:"id"`
+	VendorVersion string `json:"vendorVersion"`
+	Percentage    uint32 `json:"percentage"`
+	Phase         string `json:"phase,omitempty"`
+}
+
 // ServiceAlertRules define service monitoring alerts rules
 type ServiceAlertRules struct {
 	RAM        *config.AlertRule `json:"ram,omitempty"`
@@ -417,10 +492,22 @@ type ServiceInfoFromCloud struct {
 	ID         string `json:"id"`
 	ProviderID string `json:"providerId"`
 	VersionFromCloud
-	AlertRules *ServiceAlertRules `json:"alertRules,omitempty"`
+	AlertRules  *ServiceAlertRules           `json:"alertRules,omitempty"`
+	Permissions map[string]map[string]string `json:"permissions,omitempty"`
+	Placement   *PlacementHints              `json:"placement,omitempty"`
+	Layers      []string                     `json:"layers,omitempty"`
 	DecryptDataStruct
 }
 
+// PlacementHints service placement hints
+type PlacementHints struct {
+	Nodes        []string `json:"nodes,omitempty"`
+	Capabilities []string `json:"capabilities,omitempty"`
+	Replicas     int      `json:"replicas,omitempty"`
+	RAM          uint64   `json:"ram,omitempty"`
+	Disk         uint64   `json:"disk,omitempty"`
+}
+
 // LayerInfoFromCloud decrypted layer info
 type LayerInfoFromCloud struct {
 	ID     string `json:"id"`
@@ -456,9 +543,11 @@ type TimetableEntry struct {
 
 // ScheduleRule rule for performing schedule update
 type ScheduleRule struct {
-	TTL       uint64           `json:"ttl"`
-	Type      string           `json:"type"`
-	Timetable []TimetableEntry `json:"timetable"`
+	TTL              uint64           `json:"ttl"`
+	Type             string           `json:"type"`
+	Timetable        []TimetableEntry `json:"timetable"`
+	MaxPostponements uint64           `json:"maxPostponements,omitempty"`
+	Deadline         time.Time        `json:"deadline,omitempty"`
 }
 
 // DecodedDesiredStatus decoded desired status
@@ -21,6 +21,7 @@ import (
 	"context"
 	"net"
 	"sync"
+	"time"
 
 	"github.com/aoscloud/aos_common/aoserrors"
 	pb "github.com/aoscloud/aos_common/api/communicationmanager/v1"
@@ -31,8 +32,11 @@ import (
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/protobuf/types/known/emptypb"
 
+	pbcm "aos_communicationmanager/api/cmserver/v1"
 	"aos_communicationmanager/cloudprotocol"
 	"aos_communicationmanager/config"
+	"aos_communicationmanager/cryptoaudit"
+	"aos_communicationmanager/updatehistory"
 )
 
 /***********************************************************************************************************************
@@ -47,6 +51,13 @@ const (
 	Updating
 )
 
+// Download item types
+const (
+	DownloadComponent = "component"
+	DownloadLayer     = "layer"
+	DownloadService   = "service"
+)
+
 /***********************************************************************************************************************
  * Vars
  **********************************************************************************************************************/
@@ -62,12 +73,15 @@ type UpdateState int
 type UpdateStatus struct {
 	State UpdateState
 	Error string
+	// Unsatisfied preconditions which block update start
+	Preconditions []string
 }
 
 // UpdateFOTAStatus FOTA update status for update scheduler service
 type UpdateFOTAStatus struct {
 	Components  []cloudprotocol.ComponentInfo
 	BoardConfig *cloudprotocol.BoardConfigInfo
+	Progress    []cloudprotocol.ComponentProgress
 	UpdateStatus
 }
 
@@ -80,6 +94,61 @@ type UpdateSOTAStatus struct {
 	UpdateStatus
 }
 
+// DownloadPlan item which should be downloaded for update
+type DownloadPlan struct {
+	Type string
+	ID   string
+	Size uint64
+	// Item is already available on the unit and will not be downloaded
+	Cached bool
+}
+
+// SchedulePlan update schedule
+type SchedulePlan struct {
+	Type string
+	TTL  time.Duration
+	// Zero if update waits for trigger
+	EarliestStart time.Time
+	Error         string
+}
+
+// ComponentPlan component which should be updated
+type ComponentPlan struct {
+	cloudprotocol.ComponentInfo
+	UMID string
+}
+
+// BoardConfigPlan board config change
+type BoardConfigPlan struct {
+	CurrentVersion string
+	NewVersion     string
+	Error          string
+}
+
+// FOTAPlan firmware update plan
+type FOTAPlan struct {
+	Components  []ComponentPlan
+	BoardConfig *BoardConfigPlan
+	Downloads   []DownloadPlan
+	Schedule    SchedulePlan
+}
+
+// SOTAPlan software update plan
+type SOTAPlan struct {
+	InstallServices []cloudprotocol.ServiceInfo
+	RemoveServices  []cloudprotocol.ServiceInfo
+	InstallLayers   []cloudprotocol.LayerInfo
+	RemoveLayers    []cloudprotocol.LayerInfo
+	Downloads       []DownloadPlan
+	Schedule        SchedulePlan
+}
+
+// UpdatePlan update plan for desired status
+type UpdatePlan struct {
+	FOTA FOTAPlan
+	SOTA SOTAPlan
+}
+
 // UpdateHandler interface for SOTA/FOTA update
 type UpdateHandler interface {
 	GetFOTAStatusChannel() (channel <-chan UpdateFOTAStatus)
@@ -88,6 +157,23 @@ type UpdateHandler interface {
 	GetSOTAStatus() (status UpdateSOTAStatus)
 	StartFOTAUpdate() (err error)
 	StartSOTAUpdate() (err error)
+	DryRun(desiredStatus cloudprotocol.DecodedDesiredStatus) (plan UpdatePlan, err error)
+	PostponeFOTAUpdate(reason string, duration time.Duration) (err error)
+	PostponeSOTAUpdate(reason string, duration time.Duration) (err error)
+	DeclineFOTAUpdate(reason string) (err error)
+	DeclineSOTAUpdate(reason string) (err error)
+}
+
+// CryptoAuditProvider provides crypto operations audit log
+type CryptoAuditProvider interface {
+	GetRecords(filter cryptoaudit.Filter) (records []cryptoaudit.Record, err error)
+	Export(filter cryptoaudit.Filter) (data []byte, err error)
+	Verify() (err error)
+}
+
+// UpdateHistoryProvider provides history of performed updates
+type UpdateHistoryProvider interface {
+	GetRecords(filter updatehistory.Filter) (records []updatehistory.Record, err error)
 }
 
 // CMServer CM server instance
@@ -95,11 +181,19 @@ type CMServer struct {
 	grpcServer *grpc.Server
 	listener   net.Listener
 	pb.UnimplementedUpdateSchedulerServiceServer
+	pbcm.UnimplementedCryptoAuditServiceServer
+	pbcm.UnimplementedUpdateProgressServiceServer
+	pbcm.UnimplementedDryRunServiceServer
+	pbcm.UnimplementedUpdateConsentServiceServer
+	pbcm.UnimplementedUpdateHistoryServiceServer
 	clients           []pb.UpdateSchedulerService_SubscribeNotificationsServer
+	progressClients   []pbcm.UpdateProgressService_SubscribeUpdateProgressServer
 	currentFOTAStatus UpdateFOTAStatus
 	currentSOTAStatus UpdateSOTAStatus
 	stopChannel       chan bool
 	updatehandler     UpdateHandler
+	cryptoAudit       CryptoAuditProvider
+	updateHistory     UpdateHistoryProvider
 	sync.Mutex
 }
 
@@ -108,12 +202,16 @@ type CMServer struct {
  **********************************************************************************************************************/
 
 // New creates new IAM server instance
-func New(cfg *config.Config, handler UpdateHandler, insecure bool) (server *CMServer, err error) {
+func New(cfg *config.Config, handler UpdateHandler, cryptoAudit CryptoAuditProvider,
+	updateHistory UpdateHistoryProvider, permissionProvider PermissionProvider,
+	insecure bool) (server *CMServer, err error) {
 	server = &CMServer{
 		currentFOTAStatus: handler.GetFOTAStatus(),
 		currentSOTAStatus: handler.GetSOTAStatus(),
 		stopChannel:       make(chan bool, 1),
 		updatehandler:     handler,
+		cryptoAudit:       cryptoAudit,
+		updateHistory:     updateHistory,
 	}
 
 	if cfg.CMServerURL != "" {
@@ -130,9 +228,29 @@ func New(cfg *config.Config, handler UpdateHandler, insecure bool) (server *CMSe
 			log.Info("CM GRPC server starts in insecure mode")
 		}
 
+		if permissionProvider != nil {
+			checker := newPermissionChecker(permissionProvider, cfg.CMServerClients)
+
+			opts = append(opts, grpc.UnaryInterceptor(checker.unaryInterceptor),
+				grpc.StreamInterceptor(checker.streamInterceptor))
+		} else {
+			log.Warn("CM GRPC server starts without permission check")
+		}
+
 		server.grpcServer = grpc.NewServer(opts...)
 
 		pb.RegisterUpdateSchedulerServiceServer(server.grpcServer, server)
+		pbcm.RegisterUpdateProgressServiceServer(server.grpcServer, server)
+		pbcm.RegisterDryRunServiceServer(server.grpcServer, server)
+		pbcm.RegisterUpdateConsentServiceServer(server.grpcServer, server)
+
+		if server.cryptoAudit != nil {
+			pbcm.RegisterCryptoAuditServiceServer(server.grpcServer, server)
+		}
+
+		if server.updateHistory != nil {
+			pbcm.RegisterUpdateHistoryServiceServer(server.grpcServer, server)
+		}
 
 		log.Debug("Start update scheduler grpc server")
 
@@ -164,6 +282,7 @@ func (server *CMServer) Close() {
 	}
 
 	server.clients = nil
+	server.progressClients = nil
 
 	server.stopChannel <- true
 }
@@ -259,6 +378,7 @@ func (server *CMServer) handleChannels() {
 				FotaStatus: fotaStatus.convertToPBStatus()}
 
 			server.notifyAllClients(&notification)
+			server.notifyProgressClients(fotaStatus.Progress)
 
 			server.Unlock()
 
@@ -19,18 +19,31 @@ package cmserver_test
 
 import (
 	"context"
+	"encoding/json"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/aoscloud/aos_common/aoserrors"
 	pb "github.com/aoscloud/aos_common/api/communicationmanager/v1"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
+	pbcm "aos_communicationmanager/api/cmserver/v1"
 	"aos_communicationmanager/cloudprotocol"
 	"aos_communicationmanager/cmserver"
 	"aos_communicationmanager/config"
+	"aos_communicationmanager/cryptoaudit"
+	"aos_communicationmanager/fcrypt"
+	"aos_communicationmanager/updatehistory"
 )
 
 /*******************************************************************************
@@ -46,13 +59,43 @@ const (
  ******************************************************************************/
 
 type testClient struct {
-	connection *grpc.ClientConn
-	pbclient   pb.UpdateSchedulerServiceClient
+	connection    *grpc.ClientConn
+	pbclient      pb.UpdateSchedulerServiceClient
+	pbCryptoAudit pbcm.CryptoAuditServiceClient
+	pbProgress    pbcm.UpdateProgressServiceClient
+	pbDryRun      pbcm.DryRunServiceClient
+	pbConsent     pbcm.UpdateConsentServiceClient
+	pbHistory     pbcm.UpdateHistoryServiceClient
 }
 
 type testUpdateHandler struct {
-	fotaChannel chan cmserver.UpdateFOTAStatus
-	sotaChannel chan cmserver.UpdateSOTAStatus
+	fotaChannel   chan cmserver.UpdateFOTAStatus
+	sotaChannel   chan cmserver.UpdateSOTAStatus
+	plan          cmserver.UpdatePlan
+	desiredStatus cloudprotocol.DecodedDesiredStatus
+	consents      []testConsent
+	consentErr    error
+}
+
+type testConsent struct {
+	updateType string
+	decision   string
+	reason     string
+	duration   time.Duration
+}
+
+type testUpdateHistory struct {
+	records []updatehistory.Record
+	filter  updatehistory.Filter
+}
+
+type testPermissionProvider struct {
+	permissions map[string]map[string]string
+}
+
+type testCryptoAudit struct {
+	records []cryptoaudit.Record
+	filter  cryptoaudit.Filter
 }
 
 /*******************************************************************************
@@ -85,7 +128,7 @@ func TestConnection(t *testing.T) {
 		sotaChannel: make(chan cmserver.UpdateSOTAStatus, 10),
 		fotaChannel: make(chan cmserver.UpdateFOTAStatus, 10)}
 
-	cmServer, err := cmserver.New(&cmConfig, &unitStatusHandler, true)
+	cmServer, err := cmserver.New(&cmConfig, &unitStatusHandler, nil, nil, nil, true)
 	if err != nil {
 		t.Fatalf("Can't create CM server: %s", err)
 	}
@@ -226,6 +269,466 @@ func TestConnection(t *testing.T) {
 	client.close()
 }
 
+func TestCryptoAudit(t *testing.T) {
+	cmConfig := config.Config{
+		CMServerURL: serverURL,
+	}
+
+	unitStatusHandler := testUpdateHandler{
+		sotaChannel: make(chan cmserver.UpdateSOTAStatus, 10),
+		fotaChannel: make(chan cmserver.UpdateFOTAStatus, 10)}
+
+	timestamp := time.Now().UTC()
+
+	cryptoAudit := testCryptoAudit{records: []cryptoaudit.Record{
+		{
+			ID: 1, AuditRecord: fcrypt.AuditRecord{
+				Timestamp: timestamp, Operation: fcrypt.AuditVerifySign, PackageID: "service0", ChainName: "chain",
+				Fingerprints: []string{"01", "02"}, Algorithm: "RSA/SHA256/PKCS1v1_5", Result: fcrypt.AuditResultSuccess},
+			Hash: "hash1",
+		},
+		{
+			ID: 2, AuditRecord: fcrypt.AuditRecord{
+				Timestamp: timestamp, Operation: fcrypt.AuditImportSessionKey, PackageID: "service0",
+				Result: fcrypt.AuditResultFailed, Error: "error"},
+			PrevHash: "hash1", Hash: "hash2",
+		},
+	}}
+
+	cmServer, err := cmserver.New(&cmConfig, &unitStatusHandler, &cryptoAudit, nil, nil, true)
+	if err != nil {
+		t.Fatalf("Can't create CM server: %s", err)
+	}
+	defer cmServer.Close()
+
+	client, err := newTestClient(serverURL)
+	if err != nil {
+		t.Fatalf("Can't create test client: %s", err)
+	}
+	defer client.close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	response, err := client.pbCryptoAudit.GetCryptoAuditRecords(ctx, &pbcm.CryptoAuditFilter{
+		PackageId: "service0", From: timestamppb.New(timestamp), Limit: 10})
+	if err != nil {
+		t.Fatalf("Can't get crypto audit records: %s", err)
+	}
+
+	if cryptoAudit.filter.PackageID != "service0" || !cryptoAudit.filter.From.Equal(timestamp) ||
+		cryptoAudit.filter.Limit != 10 {
+		t.Errorf("Wrong crypto audit filter: %v", cryptoAudit.filter)
+	}
+
+	if len(response.Records) != len(cryptoAudit.records) {
+		t.Fatalf("Wrong crypto audit records count: %d", len(response.Records))
+	}
+
+	for i, record := range response.Records {
+		if record.Id != cryptoAudit.records[i].ID || record.Operation != cryptoAudit.records[i].Operation ||
+			record.Hash != cryptoAudit.records[i].Hash || !record.Timestamp.AsTime().Equal(timestamp) {
+			t.Errorf("Wrong crypto audit record: %v", record)
+		}
+	}
+
+	export, err := client.pbCryptoAudit.ExportCryptoAuditLog(ctx, &pbcm.CryptoAuditFilter{})
+	if err != nil {
+		t.Fatalf("Can't export crypto audit log: %s", err)
+	}
+
+	if !export.Verified || string(export.Data) != "exported" {
+		t.Errorf("Wrong crypto audit export: %v", export)
+	}
+}
+
+func TestUpdateHistory(t *testing.T) {
+	cmConfig := config.Config{
+		CMServerURL: serverURL,
+	}
+
+	unitStatusHandler := testUpdateHandler{
+		sotaChannel: make(chan cmserver.UpdateSOTAStatus, 10),
+		fotaChannel: make(chan cmserver.UpdateFOTAStatus, 10)}
+
+	timestamp := time.Now().UTC()
+
+	updateHistory := testUpdateHistory{records: []updatehistory.Record{
+		{
+			ID: 2, UpdateType: cloudprotocol.SOTAUpdate, DesiredStatusHash: "hash2", Started: timestamp,
+			Items: []updatehistory.Item{
+				{Type: updatehistory.LayerItem, ID: "layer1", Digest: "digest1", ToVersion: "1",
+					Status: cloudprotocol.ErrorStatus, Error: "download failed"},
+			},
+			Phases: []updatehistory.Phase{{State: "downloading", Timestamp: timestamp}},
+		},
+		{
+			ID: 1, UpdateType: cloudprotocol.FOTAUpdate, DesiredStatusHash: "hash1", Started: timestamp,
+			Finished: timestamp, Error: "update failed",
+			Items: []updatehistory.Item{
+				{Type: updatehistory.ComponentItem, ID: "comp1", FromVersion: "1.0", ToVersion: "2.0",
+					Status: cloudprotocol.ErrorStatus, Error: "update failed", Reverted: true},
+			},
+		},
+	}}
+
+	cmServer, err := cmserver.New(&cmConfig, &unitStatusHandler, nil, &updateHistory, nil, true)
+	if err != nil {
+		t.Fatalf("Can't create CM server: %s", err)
+	}
+	defer cmServer.Close()
+
+	client, err := newTestClient(serverURL)
+	if err != nil {
+		t.Fatalf("Can't create test client: %s", err)
+	}
+	defer client.close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	response, err := client.pbHistory.GetUpdateHistory(ctx, &pbcm.UpdateHistoryFilter{
+		UpdateType: cloudprotocol.FOTAUpdate, Till: timestamppb.New(timestamp), Offset: 5, Limit: 10})
+	if err != nil {
+		t.Fatalf("Can't get update history: %s", err)
+	}
+
+	if updateHistory.filter.UpdateType != cloudprotocol.FOTAUpdate || !updateHistory.filter.Till.Equal(timestamp) ||
+		!updateHistory.filter.From.IsZero() || updateHistory.filter.Offset != 5 || updateHistory.filter.Limit != 10 {
+		t.Errorf("Wrong update history filter: %v", updateHistory.filter)
+	}
+
+	if len(response.Records) != len(updateHistory.records) {
+		t.Fatalf("Wrong update history records count: %d", len(response.Records))
+	}
+
+	for i, record := range response.Records {
+		expectedRecord := updateHistory.records[i]
+
+		if record.Id != expectedRecord.ID || record.UpdateType != expectedRecord.UpdateType ||
+			record.DesiredStatusHash != expectedRecord.DesiredStatusHash || record.Error != expectedRecord.Error ||
+			!record.Started.AsTime().Equal(timestamp) || (record.Finished != nil) != !expectedRecord.Finished.IsZero() ||
+			len(record.Items) != len(expectedRecord.Items) || len(record.Phases) != len(expectedRecord.Phases) {
+			t.Errorf("Wrong update history record: %v", record)
+			continue
+		}
+
+		for j, item := range record.Items {
+			expectedItem := expectedRecord.Items[j]
+
+			if item.Type != expectedItem.Type || item.Id != expectedItem.ID || item.Digest != expectedItem.Digest ||
+				item.FromVersion != expectedItem.FromVersion || item.ToVersion != expectedItem.ToVersion ||
+				item.Status != expectedItem.Status || item.Error != expectedItem.Error ||
+				item.Reverted != expectedItem.Reverted {
+				t.Errorf("Wrong update history item: %v", item)
+			}
+		}
+	}
+}
+
+func TestUpdateProgress(t *testing.T) {
+	cmConfig := config.Config{
+		CMServerURL: serverURL,
+	}
+
+	unitStatusHandler := testUpdateHandler{
+		sotaChannel: make(chan cmserver.UpdateSOTAStatus, 10),
+		fotaChannel: make(chan cmserver.UpdateFOTAStatus, 10)}
+
+	cmServer, err := cmserver.New(&cmConfig, &unitStatusHandler, nil, nil, nil, true)
+	if err != nil {
+		t.Fatalf("Can't create CM server: %s", err)
+	}
+	defer cmServer.Close()
+
+	client, err := newTestClient(serverURL)
+	if err != nil {
+		t.Fatalf("Can't create test client: %s", err)
+	}
+	defer client.close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := client.pbProgress.SubscribeUpdateProgress(ctx, &emptypb.Empty{})
+	if err != nil {
+		t.Fatalf("Can't subscribe update progress: %s", err)
+	}
+
+	progress, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Can't receive update progress: %s", err)
+	}
+
+	if len(progress.Components) != 0 {
+		t.Errorf("Wrong initial update progress: %v", progress.Components)
+	}
+
+	unitStatusHandler.fotaChannel <- cmserver.UpdateFOTAStatus{
+		Progress: []cloudprotocol.ComponentProgress{
+			{ID: "comp1", VendorVersion: "1.0", Percentage: 30, Phase: "flashing"}},
+		UpdateStatus: cmserver.UpdateStatus{State: cmserver.Updating},
+	}
+
+	if progress, err = stream.Recv(); err != nil {
+		t.Fatalf("Can't receive update progress: %s", err)
+	}
+
+	if len(progress.Components) != 1 || progress.Components[0].Id != "comp1" ||
+		progress.Components[0].VendorVersion != "1.0" || progress.Components[0].Percentage != 30 ||
+		progress.Components[0].Phase != "flashing" {
+		t.Errorf("Wrong update progress: %v", progress.Components)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	cmConfig := config.Config{
+		CMServerURL: serverURL,
+	}
+
+	earliestStart := time.Now().Add(time.Hour).UTC()
+
+	unitStatusHandler := testUpdateHandler{
+		sotaChannel: make(chan cmserver.UpdateSOTAStatus, 10),
+		fotaChannel: make(chan cmserver.UpdateFOTAStatus, 10),
+		plan: cmserver.UpdatePlan{
+			FOTA: cmserver.FOTAPlan{
+				Components: []cmserver.ComponentPlan{{
+					ComponentInfo: cloudprotocol.ComponentInfo{ID: "comp1", VendorVersion: "2.0"}, UMID: "um1"}},
+				BoardConfig: &cmserver.BoardConfigPlan{CurrentVersion: "1.0", NewVersion: "2.0"},
+				Downloads: []cmserver.DownloadPlan{
+					{Type: cmserver.DownloadComponent, ID: "comp1", Size: 1024, Cached: true}},
+				Schedule: cmserver.SchedulePlan{
+					Type: cloudprotocol.TimetableUpdate, TTL: time.Hour, EarliestStart: earliestStart},
+			},
+			SOTA: cmserver.SOTAPlan{
+				InstallServices: []cloudprotocol.ServiceInfo{{ID: "service1", AosVersion: 1}},
+				RemoveLayers:    []cloudprotocol.LayerInfo{{ID: "layer1", Digest: "digest1", AosVersion: 1}},
+				Downloads: []cmserver.DownloadPlan{
+					{Type: cmserver.DownloadService, ID: "service1", Size: 2048}},
+				Schedule: cmserver.SchedulePlan{Type: cloudprotocol.TriggerUpdate},
+			},
+		},
+	}
+
+	cmServer, err := cmserver.New(&cmConfig, &unitStatusHandler, nil, nil, nil, true)
+	if err != nil {
+		t.Fatalf("Can't create CM server: %s", err)
+	}
+	defer cmServer.Close()
+
+	client, err := newTestClient(serverURL)
+	if err != nil {
+		t.Fatalf("Can't create test client: %s", err)
+	}
+	defer client.close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err = client.pbDryRun.DryRun(ctx, &pbcm.DryRunRequest{DesiredStatus: []byte("invalid")}); err == nil {
+		t.Error("Error expected for invalid desired status")
+	}
+
+	desiredStatus := cloudprotocol.DecodedDesiredStatus{
+		Components: []cloudprotocol.ComponentInfoFromCloud{
+			{ID: "comp1", VersionFromCloud: cloudprotocol.VersionFromCloud{VendorVersion: "2.0"}}},
+	}
+
+	desiredStatusJSON, err := json.Marshal(desiredStatus)
+	if err != nil {
+		t.Fatalf("Can't marshal desired status: %s", err)
+	}
+
+	plan, err := client.pbDryRun.DryRun(ctx, &pbcm.DryRunRequest{DesiredStatus: desiredStatusJSON})
+	if err != nil {
+		t.Fatalf("Can't dry run desired status: %s", err)
+	}
+
+	if len(unitStatusHandler.desiredStatus.Components) != 1 ||
+		unitStatusHandler.desiredStatus.Components[0].ID != "comp1" {
+		t.Errorf("Wrong desired status: %v", unitStatusHandler.desiredStatus)
+	}
+
+	fota := plan.GetFota()
+
+	if len(fota.GetComponents()) != 1 || fota.Components[0].Id != "comp1" ||
+		fota.Components[0].VendorVersion != "2.0" || fota.Components[0].UmId != "um1" {
+		t.Errorf("Wrong FOTA components: %v", fota.GetComponents())
+	}
+
+	if fota.GetBoardConfig().GetCurrentVersion() != "1.0" || fota.GetBoardConfig().GetNewVersion() != "2.0" {
+		t.Errorf("Wrong board config plan: %v", fota.GetBoardConfig())
+	}
+
+	if len(fota.GetDownloads()) != 1 || fota.Downloads[0].Id != "comp1" || fota.Downloads[0].Size != 1024 ||
+		!fota.Downloads[0].Cached {
+		t.Errorf("Wrong FOTA downloads: %v", fota.GetDownloads())
+	}
+
+	if fota.GetSchedule().GetTtl() != 3600 || !fota.GetSchedule().GetEarliestStart().AsTime().Equal(earliestStart) {
+		t.Errorf("Wrong FOTA schedule: %v", fota.GetSchedule())
+	}
+
+	sota := plan.GetSota()
+
+	if len(sota.GetInstallServices()) != 1 || sota.InstallServices[0].Id != "service1" ||
+		len(sota.GetRemoveLayers()) != 1 || sota.RemoveLayers[0].Digest != "digest1" {
+		t.Errorf("Wrong SOTA plan: %v", sota)
+	}
+
+	if len(sota.GetDownloads()) != 1 || sota.Downloads[0].Id != "service1" || sota.Downloads[0].Cached {
+		t.Errorf("Wrong SOTA downloads: %v", sota.GetDownloads())
+	}
+
+	if sota.GetSchedule().GetEarliestStart() != nil {
+		t.Errorf("Earliest start should not be set for trigger update: %v", sota.GetSchedule())
+	}
+}
+
+func TestUpdateConsent(t *testing.T) {
+	cmConfig := config.Config{
+		CMServerURL: serverURL,
+	}
+
+	unitStatusHandler := testUpdateHandler{
+		sotaChannel: make(chan cmserver.UpdateSOTAStatus, 10),
+		fotaChannel: make(chan cmserver.UpdateFOTAStatus, 10),
+	}
+
+	cmServer, err := cmserver.New(&cmConfig, &unitStatusHandler, nil, nil, nil, true)
+	if err != nil {
+		t.Fatalf("Can't create CM server: %s", err)
+	}
+	defer cmServer.Close()
+
+	client, err := newTestClient(serverURL)
+	if err != nil {
+		t.Fatalf("Can't create test client: %s", err)
+	}
+	defer client.close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err = client.pbConsent.PostponeUpdate(ctx, &pbcm.PostponeUpdateRequest{
+		Type: pbcm.UpdateType_FOTA, Reason: "driving", Duration: durationpb.New(time.Hour)}); err != nil {
+		t.Fatalf("Can't postpone update: %s", err)
+	}
+
+	if _, err = client.pbConsent.DeclineUpdate(ctx, &pbcm.DeclineUpdateRequest{
+		Type: pbcm.UpdateType_SOTA, Reason: "not interested"}); err != nil {
+		t.Fatalf("Can't decline update: %s", err)
+	}
+
+	if _, err = client.pbConsent.DeclineUpdate(ctx, &pbcm.DeclineUpdateRequest{Type: 10}); err == nil {
+		t.Error("Error expected for wrong update type")
+	}
+
+	expectedConsents := []testConsent{
+		{updateType: cloudprotocol.FOTAUpdate, decision: cloudprotocol.ConsentPostponed, reason: "driving",
+			duration: time.Hour},
+		{updateType: cloudprotocol.SOTAUpdate, decision: cloudprotocol.ConsentDeclined, reason: "not interested"},
+	}
+
+	if !reflect.DeepEqual(unitStatusHandler.consents, expectedConsents) {
+		t.Errorf("Wrong consents: %v", unitStatusHandler.consents)
+	}
+
+	unitStatusHandler.consentErr = aoserrors.New("max postponements reached")
+
+	if _, err = client.pbConsent.PostponeUpdate(ctx, &pbcm.PostponeUpdateRequest{
+		Type: pbcm.UpdateType_SOTA, Duration: durationpb.New(time.Hour)}); err == nil ||
+		!strings.Contains(err.Error(), "max postponements reached") {
+		t.Errorf("Wrong postpone error: %v", err)
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	cmConfig := config.Config{
+		CMServerURL: serverURL,
+	}
+
+	unitStatusHandler := testUpdateHandler{
+		sotaChannel: make(chan cmserver.UpdateSOTAStatus, 10),
+		fotaChannel: make(chan cmserver.UpdateFOTAStatus, 10)}
+
+	permissionProvider := testPermissionProvider{permissions: map[string]map[string]string{
+		"readerSecret":  {cmserver.PermissionUpdateRead: "true"},
+		"updaterSecret": {cmserver.PermissionUpdateRead: "true", cmserver.PermissionUpdateStart: "true"},
+	}}
+
+	cryptoAudit := testCryptoAudit{}
+
+	cmServer, err := cmserver.New(&cmConfig, &unitStatusHandler, &cryptoAudit, nil, &permissionProvider, true)
+	if err != nil {
+		t.Fatalf("Can't create CM server: %s", err)
+	}
+	defer cmServer.Close()
+
+	client, err := newTestClient(serverURL)
+	if err != nil {
+		t.Fatalf("Can't create test client: %s", err)
+	}
+	defer client.close()
+
+	type testData struct {
+		secret  string
+		call    func(ctx context.Context) (err error)
+		allowed bool
+	}
+
+	startFOTA := func(ctx context.Context) (err error) {
+		_, err = client.pbclient.StartFOTAUpdate(ctx, &emptypb.Empty{})
+		return err
+	}
+
+	subscribe := func(ctx context.Context) (err error) {
+		stream, err := client.pbclient.SubscribeNotifications(ctx, &emptypb.Empty{})
+		if err != nil {
+			return err
+		}
+
+		_, err = stream.Recv()
+
+		return err
+	}
+
+	getAuditRecords := func(ctx context.Context) (err error) {
+		_, err = client.pbCryptoAudit.GetCryptoAuditRecords(ctx, &pbcm.CryptoAuditFilter{})
+		return err
+	}
+
+	data := []testData{
+		{secret: "", call: startFOTA, allowed: false},
+		{secret: "unknownSecret", call: startFOTA, allowed: false},
+		{secret: "readerSecret", call: startFOTA, allowed: false},
+		{secret: "readerSecret", call: subscribe, allowed: true},
+		{secret: "updaterSecret", call: startFOTA, allowed: true},
+		{secret: "updaterSecret", call: getAuditRecords, allowed: false},
+	}
+
+	for i, item := range data {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+
+		if item.secret != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, cmserver.SecretMetadataKey, item.secret)
+		}
+
+		err := item.call(ctx)
+
+		cancel()
+
+		if item.allowed && err != nil {
+			t.Errorf("Call %d should be allowed: %s", i, err)
+		}
+
+		if !item.allowed && status.Code(err) != codes.PermissionDenied {
+			t.Errorf("Call %d should be denied: %v", i, err)
+		}
+	}
+}
+
 /*******************************************************************************
  * Private
  ******************************************************************************/
@@ -233,13 +736,19 @@ func TestConnection(t *testing.T) {
 func newTestClient(url string) (client *testClient, err error) {
 	client = &testClient{}
 
-	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
 	if client.connection, err = grpc.DialContext(ctx, url, grpc.WithInsecure(), grpc.WithBlock()); err != nil {
 		return nil, err
 	}
 
 	client.pbclient = pb.NewUpdateSchedulerServiceClient(client.connection)
+	client.pbCryptoAudit = pbcm.NewCryptoAuditServiceClient(client.connection)
+	client.pbProgress = pbcm.NewUpdateProgressServiceClient(client.connection)
+	client.pbDryRun = pbcm.NewDryRunServiceClient(client.connection)
+	client.pbConsent = pbcm.NewUpdateConsentServiceClient(client.connection)
+	client.pbHistory = pbcm.NewUpdateHistoryServiceClient(client.connection)
 
 	return client, nil
 }
@@ -278,3 +787,71 @@ func (handler *testUpdateHandler) StartFOTAUpdate() (err error) {
 func (handler *testUpdateHandler) StartSOTAUpdate() (err error) {
 	return nil
 }
+
+func (handler *testUpdateHandler) DryRun(
+	desiredStatus cloudprotocol.DecodedDesiredStatus) (plan cmserver.UpdatePlan, err error) {
+	handler.desiredStatus = desiredStatus
+
+	return handler.plan, nil
+}
+
+func (handler *testUpdateHandler) PostponeFOTAUpdate(reason string, duration time.Duration) (err error) {
+	return handler.addConsent(cloudprotocol.FOTAUpdate, cloudprotocol.ConsentPostponed, reason, duration)
+}
+
+func (handler *testUpdateHandler) PostponeSOTAUpdate(reason string, duration time.Duration) (err error) {
+	return handler.addConsent(cloudprotocol.SOTAUpdate, cloudprotocol.ConsentPostponed, reason, duration)
+}
+
+func (handler *testUpdateHandler) DeclineFOTAUpdate(reason string) (err error) {
+	return handler.addConsent(cloudprotocol.FOTAUpdate, cloudprotocol.ConsentDeclined, reason, 0)
+}
+
+func (handler *testUpdateHandler) DeclineSOTAUpdate(reason string) (err error) {
+	return handler.addConsent(cloudprotocol.SOTAUpdate, cloudprotocol.ConsentDeclined, reason, 0)
+}
+
+func (handler *testUpdateHandler) addConsent(updateType, decision, reason string, duration time.Duration) (err error) {
+	if handler.consentErr != nil {
+		return handler.consentErr
+	}
+
+	handler.consents = append(handler.consents, testConsent{
+		updateType: updateType, decision: decision, reason: reason, duration: duration})
+
+	return nil
+}
+
+func (audit *testCryptoAudit) GetRecords(filter cryptoaudit.Filter) (records []cryptoaudit.Record, err error) {
+	audit.filter = filter
+
+	return audit.records, nil
+}
+
+func (audit *testCryptoAudit) Export(filter cryptoaudit.Filter) (data []byte, err error) {
+	return []byte("exported"), nil
+}
+
+func (audit *testCryptoAudit) Verify() (err error) {
+	return nil
+}
+
+func (history *testUpdateHistory) GetRecords(
+	filter updatehistory.Filter) (records []updatehistory.Record, err error) {
+	history.filter = filter
+
+	return history.records, nil
+}
+
+func (provider *testPermissionProvider) GetPermissions(
+	secret, funcServerID string) (serviceID string, permissions map[string]string, err error) {
+	if funcServerID != cmserver.FunctionalServerID {
+		return "", nil, aoserrors.Errorf("wrong functional server ID: %s", funcServerID)
+	}
+
+	if permissions, ok := provider.permissions[secret]; ok {
+		return "service_" + secret, permissions, nil
+	}
+
+	return "", nil, aoserrors.New("secret not found")
+}
@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmserver
+
+import (
+	"context"
+
+	"github.com/aoscloud/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pbcm "aos_communicationmanager/api/cmserver/v1"
+)
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// PostponeUpdate postpones FOTA or SOTA update on user request
+func (server *CMServer) PostponeUpdate(
+	ctx context.Context, req *pbcm.PostponeUpdateRequest) (ret *emptypb.Empty, err error) {
+	duration := req.GetDuration().AsDuration()
+
+	log.WithFields(log.Fields{
+		"type": req.GetType(), "reason": req.GetReason(), "duration": duration}).Debug("Postpone update")
+
+	switch req.GetType() {
+	case pbcm.UpdateType_FOTA:
+		err = server.updatehandler.PostponeFOTAUpdate(req.GetReason(), duration)
+
+	case pbcm.UpdateType_SOTA:
+		err = server.updatehandler.PostponeSOTAUpdate(req.GetReason(), duration)
+
+	default:
+		err = aoserrors.Errorf("wrong update type: %d", req.GetType())
+	}
+
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// DeclineUpdate declines FOTA or SOTA update on user request
+func (server *CMServer) DeclineUpdate(
+	ctx context.Context, req *pbcm.DeclineUpdateRequest) (ret *emptypb.Empty, err error) {
+	log.WithFields(log.Fields{"type": req.GetType(), "reason": req.GetReason()}).Debug("Decline update")
+
+	switch req.GetType() {
+	case pbcm.UpdateType_FOTA:
+		err = server.updatehandler.DeclineFOTAUpdate(req.GetReason())
+
+	case pbcm.UpdateType_SOTA:
+		err = server.updatehandler.DeclineSOTAUpdate(req.GetReason())
+
+	default:
+		err = aoserrors.Errorf("wrong update type: %d", req.GetType())
+	}
+
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
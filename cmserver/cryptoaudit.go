@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmserver
+
+import (
+	"context"
+
+	"github.com/aoscloud/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pbcm "aos_communicationmanager/api/cmserver/v1"
+	"aos_communicationmanager/cryptoaudit"
+)
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// GetCryptoAuditRecords returns crypto audit records
+func (server *CMServer) GetCryptoAuditRecords(
+	ctx context.Context, req *pbcm.CryptoAuditFilter) (response *pbcm.CryptoAuditRecords, err error) {
+	log.WithFields(log.Fields{
+		"operation": req.Operation, "packageID": req.PackageId}).Debug("Get crypto audit records")
+
+	records, err := server.cryptoAudit.GetRecords(convertCryptoAuditFilter(req))
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	response = &pbcm.CryptoAuditRecords{Records: make([]*pbcm.CryptoAuditRecord, 0, len(records))}
+
+	for _, record := range records {
+		response.Records = append(response.Records, &pbcm.CryptoAuditRecord{
+			Id:           record.ID,
+			Timestamp:    timestamppb.New(record.Timestamp),
+			Operation:    record.Operation,
+			PackageId:    record.PackageID,
+			Subject:      record.Subject,
+			ChainName:    record.ChainName,
+			Fingerprints: record.Fingerprints,
+			Algorithm:    record.Algorithm,
+			Result:       record.Result,
+			Error:        record.Error,
+			PrevHash:     record.PrevHash,
+			Hash:         record.Hash,
+		})
+	}
+
+	return response, nil
+}
+
+// ExportCryptoAuditLog exports crypto audit log with integrity verification result
+func (server *CMServer) ExportCryptoAuditLog(
+	ctx context.Context, req *pbcm.CryptoAuditFilter) (response *pbcm.CryptoAuditExport, err error) {
+	log.WithFields(log.Fields{
+		"operation": req.Operation, "packageID": req.PackageId}).Debug("Export crypto audit log")
+
+	response = &pbcm.CryptoAuditExport{Verified: true}
+
+	if err = server.cryptoAudit.Verify(); err != nil {
+		log.Errorf("Crypto audit log verification failed: %s", err)
+
+		response.Verified = false
+		response.VerifyError = err.Error()
+	}
+
+	if response.Data, err = server.cryptoAudit.Export(convertCryptoAuditFilter(req)); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return response, nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func convertCryptoAuditFilter(pbFilter *pbcm.CryptoAuditFilter) (filter cryptoaudit.Filter) {
+	filter = cryptoaudit.Filter{
+		Operation: pbFilter.Operation,
+		PackageID: pbFilter.PackageId,
+		Offset:    pbFilter.Offset,
+		Limit:     pbFilter.Limit,
+	}
+
+	if pbFilter.From != nil {
+		filter.From = pbFilter.From.AsTime()
+	}
+
+	if pbFilter.Till != nil {
+		filter.Till = pbFilter.Till.AsTime()
+	}
+
+	return filter
+}
@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmserver
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/aoscloud/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pbcm "aos_communicationmanager/api/cmserver/v1"
+	"aos_communicationmanager/cloudprotocol"
+)
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// DryRun returns update plan for desired status without performing update
+func (server *CMServer) DryRun(ctx context.Context, req *pbcm.DryRunRequest) (response *pbcm.UpdatePlan, err error) {
+	log.Debug("Dry run desired status")
+
+	var desiredStatus cloudprotocol.DecodedDesiredStatus
+
+	if err = json.Unmarshal(req.DesiredStatus, &desiredStatus); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	plan, err := server.updatehandler.DryRun(desiredStatus)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return convertUpdatePlan(plan), nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func convertUpdatePlan(plan UpdatePlan) (response *pbcm.UpdatePlan) {
+	response = &pbcm.UpdatePlan{
+		Fota: &pbcm.FOTAPlan{
+			Downloads: convertDownloadPlan(plan.FOTA.Downloads),
+			Schedule:  convertSchedulePlan(plan.FOTA.Schedule),
+		},
+		Sota: &pbcm.SOTAPlan{
+			InstallServices: convertServicePlan(plan.SOTA.InstallServices),
+			RemoveServices:  convertServicePlan(plan.SOTA.RemoveServices),
+			InstallLayers:   convertLayerPlan(plan.SOTA.InstallLayers),
+			RemoveLayers:    convertLayerPlan(plan.SOTA.RemoveLayers),
+			Downloads:       convertDownloadPlan(plan.SOTA.Downloads),
+			Schedule:        convertSchedulePlan(plan.SOTA.Schedule),
+		},
+	}
+
+	for _, component := range plan.FOTA.Components {
+		response.Fota.Components = append(response.Fota.Components, &pbcm.ComponentPlan{
+			Id:            component.ID,
+			AosVersion:    component.AosVersion,
+			VendorVersion: component.VendorVersion,
+			UmId:          component.UMID,
+			Error:         component.Error,
+		})
+	}
+
+	if plan.FOTA.BoardConfig != nil {
+		response.Fota.BoardConfig = &pbcm.BoardConfigPlan{
+			CurrentVersion: plan.FOTA.BoardConfig.CurrentVersion,
+			NewVersion:     plan.FOTA.BoardConfig.NewVersion,
+			Error:          plan.FOTA.BoardConfig.Error,
+		}
+	}
+
+	return response
+}
+
+func convertDownloadPlan(downloads []DownloadPlan) (response []*pbcm.DownloadPlan) {
+	for _, item := range downloads {
+		response = append(response, &pbcm.DownloadPlan{
+			Type: item.Type, Id: item.ID, Size: item.Size, Cached: item.Cached})
+	}
+
+	return response
+}
+
+func convertSchedulePlan(schedule SchedulePlan) (response *pbcm.SchedulePlan) {
+	response = &pbcm.SchedulePlan{
+		Type:  schedule.Type,
+		Ttl:   uint64(schedule.TTL / time.Second),
+		Error: schedule.Error,
+	}
+
+	if !schedule.EarliestStart.IsZero() {
+		response.EarliestStart = timestamppb.New(schedule.EarliestStart)
+	}
+
+	return response
+}
+
+func convertServicePlan(services []cloudprotocol.ServiceInfo) (response []*pbcm.ServicePlan) {
+	for _, service := range services {
+		response = append(response, &pbcm.ServicePlan{Id: service.ID, AosVersion: service.AosVersion})
+	}
+
+	return response
+}
+
+func convertLayerPlan(layers []cloudprotocol.LayerInfo) (response []*pbcm.LayerPlan) {
+	for _, layer := range layers {
+		response = append(response, &pbcm.LayerPlan{
+			Id: layer.ID, Digest: layer.Digest, AosVersion: layer.AosVersion})
+	}
+
+	return response
+}
@@ -0,0 +1,247 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmserver
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"aos_communicationmanager/config"
+	"aos_communicationmanager/grpcauth"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// CM functional server ID used to request service permissions from IAM
+const FunctionalServerID = "cm"
+
+// SecretMetadataKey gRPC metadata key to pass service secret
+const SecretMetadataKey = "aos-secret"
+
+// Permissions
+const (
+	PermissionUpdateRead  = "update.read"
+	PermissionUpdateStart = "update.start"
+	PermissionAuditRead   = "audit.read"
+)
+
+const permissionGranted = "true"
+
+// Denied calls are logged once per period for each client, other denied calls are counted
+const (
+	deniedLogPeriod     = 1 * time.Minute
+	maxDeniedLogClients = 256
+	otherDeniedClients  = "*"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// PermissionProvider provides service permissions by service secret
+type PermissionProvider interface {
+	GetPermissions(secret, funcServerID string) (serviceID string, permissions map[string]string, err error)
+}
+
+type permissionChecker struct {
+	sync.Mutex
+
+	provider    PermissionProvider
+	certClients map[string][]string
+	deniedLog   map[string]deniedLogInfo
+}
+
+type deniedLogInfo struct {
+	logTime    time.Time
+	suppressed uint64
+}
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+var methodPermissions = map[string]string{
+	"/communicationmanager.v1.UpdateSchedulerService/SubscribeNotifications": PermissionUpdateRead,
+	"/communicationmanager.v1.UpdateSchedulerService/StartFOTAUpdate":        PermissionUpdateStart,
+	"/communicationmanager.v1.UpdateSchedulerService/StartSOTAUpdate":        PermissionUpdateStart,
+	"/cmserver.v1.CryptoAuditService/GetCryptoAuditRecords":                  PermissionAuditRead,
+	"/cmserver.v1.CryptoAuditService/ExportCryptoAuditLog":                   PermissionAuditRead,
+	"/cmserver.v1.UpdateProgressService/SubscribeUpdateProgress":             PermissionUpdateRead,
+	"/cmserver.v1.DryRunService/DryRun":                                      PermissionUpdateRead,
+	"/cmserver.v1.UpdateConsentService/PostponeUpdate":                       PermissionUpdateStart,
+	"/cmserver.v1.UpdateConsentService/DeclineUpdate":                        PermissionUpdateStart,
+	"/cmserver.v1.UpdateHistoryService/GetUpdateHistory":                     PermissionUpdateRead,
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func newPermissionChecker(
+	provider PermissionProvider, clients []config.CMServerClient) (checker *permissionChecker) {
+	checker = &permissionChecker{
+		provider: provider, certClients: make(map[string][]string), deniedLog: make(map[string]deniedLogInfo)}
+
+	for _, client := range clients {
+		checker.certClients[client.CommonName] = client.Permissions
+	}
+
+	if len(clients) == 0 {
+		log.Warn("No CM server clients configured, only clients with service secret are allowed")
+	}
+
+	return checker
+}
+
+func (checker *permissionChecker) unaryInterceptor(ctx context.Context, req interface{},
+	info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	if err = checker.checkPermission(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+func (checker *permissionChecker) streamInterceptor(srv interface{}, stream grpc.ServerStream,
+	info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	if err = checker.checkPermission(stream.Context(), info.FullMethod); err != nil {
+		return err
+	}
+
+	return handler(srv, stream)
+}
+
+func (checker *permissionChecker) checkPermission(ctx context.Context, method string) (err error) {
+	permission, ok := methodPermissions[method]
+	if !ok {
+		return checker.deny(ctx, method, "", "unknown method")
+	}
+
+	if commonName := grpcauth.GetPeerCommonName(ctx); commonName != "" {
+		for _, clientPermission := range checker.certClients[commonName] {
+			if clientPermission == permission {
+				return nil
+			}
+		}
+	}
+
+	secret := getClientSecret(ctx)
+	if secret == "" {
+		return checker.deny(ctx, method, permission, "client is not identified")
+	}
+
+	serviceID, permissions, err := checker.provider.GetPermissions(secret, FunctionalServerID)
+	if err != nil {
+		return checker.deny(ctx, method, permission, err.Error())
+	}
+
+	if permissions[permission] != permissionGranted {
+		return checker.deny(ctx, method, permission, "permission is not granted to service "+serviceID)
+	}
+
+	return nil
+}
+
+func (checker *permissionChecker) deny(ctx context.Context, method, permission, reason string) (err error) {
+	fields := log.Fields{"method": method, "permission": permission, "reason": reason}
+	client := ""
+
+	if commonName := grpcauth.GetPeerCommonName(ctx); commonName != "" {
+		fields["client"] = commonName
+		client = commonName
+	}
+
+	if clientPeer, ok := peer.FromContext(ctx); ok {
+		fields["address"] = clientPeer.Addr.String()
+
+		// Port is changed on each connection, use host only to identify the client
+		if client == "" {
+			client = clientPeer.Addr.String()
+
+			if host, _, err := net.SplitHostPort(client); err == nil {
+				client = host
+			}
+		}
+	}
+
+	if suppressed, ok := checker.shouldLogDenied(client); ok {
+		if suppressed != 0 {
+			fields["suppressed"] = suppressed
+		}
+
+		log.WithFields(fields).Warn("CM server call denied")
+	}
+
+	return status.Error(codes.PermissionDenied, "permission denied")
+}
+
+// shouldLogDenied limits denied calls logging to protect the log from flooding by misbehaving clients
+func (checker *permissionChecker) shouldLogDenied(client string) (suppressed uint64, ok bool) {
+	checker.Lock()
+	defer checker.Unlock()
+
+	now := time.Now()
+
+	if _, exists := checker.deniedLog[client]; !exists && len(checker.deniedLog) >= maxDeniedLogClients {
+		for key, info := range checker.deniedLog {
+			if now.Sub(info.logTime) >= deniedLogPeriod {
+				delete(checker.deniedLog, key)
+			}
+		}
+
+		if len(checker.deniedLog) >= maxDeniedLogClients {
+			client = otherDeniedClients
+		}
+	}
+
+	info, exists := checker.deniedLog[client]
+	if exists && now.Sub(info.logTime) < deniedLogPeriod {
+		info.suppressed++
+		checker.deniedLog[client] = info
+
+		return 0, false
+	}
+
+	checker.deniedLog[client] = deniedLogInfo{logTime: now}
+
+	return info.suppressed, true
+}
+
+func getClientSecret(ctx context.Context) (secret string) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	if values := md.Get(SecretMetadataKey); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmserver
+
+import (
+	"context"
+
+	"github.com/aoscloud/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pbcm "aos_communicationmanager/api/cmserver/v1"
+	"aos_communicationmanager/updatehistory"
+)
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// GetUpdateHistory returns update history records
+func (server *CMServer) GetUpdateHistory(
+	ctx context.Context, req *pbcm.UpdateHistoryFilter) (response *pbcm.UpdateHistoryRecords, err error) {
+	log.WithFields(log.Fields{
+		"updateType": req.UpdateType, "offset": req.Offset, "limit": req.Limit}).Debug("Get update history")
+
+	records, err := server.updateHistory.GetRecords(convertUpdateHistoryFilter(req))
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	response = &pbcm.UpdateHistoryRecords{Records: make([]*pbcm.UpdateHistoryRecord, 0, len(records))}
+
+	for _, record := range records {
+		response.Records = append(response.Records, convertUpdateHistoryRecord(record))
+	}
+
+	return response, nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func convertUpdateHistoryFilter(pbFilter *pbcm.UpdateHistoryFilter) (filter updatehistory.Filter) {
+	filter = updatehistory.Filter{
+		UpdateType: pbFilter.UpdateType,
+		Offset:     pbFilter.Offset,
+		Limit:      pbFilter.Limit,
+	}
+
+	if pbFilter.From != nil {
+		filter.From = pbFilter.From.AsTime()
+	}
+
+	if pbFilter.Till != nil {
+		filter.Till = pbFilter.Till.AsTime()
+	}
+
+	return filter
+}
+
+func convertUpdateHistoryRecord(record updatehistory.Record) (pbRecord *pbcm.UpdateHistoryRecord) {
+	pbRecord = &pbcm.UpdateHistoryRecord{
+		Id:                record.ID,
+		UpdateType:        record.UpdateType,
+		DesiredStatusHash: record.DesiredStatusHash,
+		Started:           timestamppb.New(record.Started),
+		Error:             record.Error,
+	}
+
+	if !record.Finished.IsZero() {
+		pbRecord.Finished = timestamppb.New(record.Finished)
+	}
+
+	for _, item := range record.Items {
+		pbRecord.Items = append(pbRecord.Items, &pbcm.UpdateHistoryItem{
+			Type:        item.Type,
+			Id:          item.ID,
+			Digest:      item.Digest,
+			FromVersion: item.FromVersion,
+			ToVersion:   item.ToVersion,
+			Status:      item.Status,
+			Error:       item.Error,
+			Reverted:    item.Reverted,
+		})
+	}
+
+	for _, phase := range record.Phases {
+		pbRecord.Phases = append(pbRecord.Phases, &pbcm.UpdateHistoryPhase{
+			State:     phase.State,
+			Timestamp: timestamppb.New(phase.Timestamp),
+		})
+	}
+
+	return pbRecord
+}
@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmserver
+
+import (
+	"github.com/aoscloud/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pbcm "aos_communicationmanager/api/cmserver/v1"
+	"aos_communicationmanager/cloudprotocol"
+)
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// SubscribeUpdateProgress subscribes on progress of components being updated
+func (server *CMServer) SubscribeUpdateProgress(
+	req *emptypb.Empty, stream pbcm.UpdateProgressService_SubscribeUpdateProgressServer) (err error) {
+	log.Debug("New CM client subscribed to update progress")
+
+	server.Lock()
+
+	if err = stream.Send(convertUpdateProgress(server.currentFOTAStatus.Progress)); err != nil {
+		server.Unlock()
+
+		log.Errorf("Can't send update progress: %s", err)
+
+		return aoserrors.Wrap(err)
+	}
+
+	server.progressClients = append(server.progressClients, stream)
+
+	server.Unlock()
+
+	<-stream.Context().Done()
+
+	server.Lock()
+
+	for i, item := range server.progressClients {
+		if stream == item {
+			server.progressClients[i] = server.progressClients[len(server.progressClients)-1]
+			server.progressClients = server.progressClients[:len(server.progressClients)-1]
+
+			break
+		}
+	}
+
+	server.Unlock()
+
+	return nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (server *CMServer) notifyProgressClients(progress []cloudprotocol.ComponentProgress) {
+	updateProgress := convertUpdateProgress(progress)
+
+	for _, client := range server.progressClients {
+		if err := client.Send(updateProgress); err != nil {
+			log.Errorf("Can't send update progress: %s", err)
+		}
+	}
+}
+
+func convertUpdateProgress(progress []cloudprotocol.ComponentProgress) (updateProgress *pbcm.UpdateProgress) {
+	updateProgress = &pbcm.UpdateProgress{Components: make([]*pbcm.ComponentProgress, 0, len(progress))}
+
+	for _, item := range progress {
+		updateProgress.Components = append(updateProgress.Components, &pbcm.ComponentProgress{
+			Id:            item.ID,
+			VendorVersion: item.VendorVersion,
+			Percentage:    item.Percentage,
+			Phase:         item.Phase,
+		})
+	}
+
+	return updateProgress
+}
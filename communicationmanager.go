@@ -18,14 +18,18 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/url"
 	"os"
 	"os/signal"
 	"reflect"
+	"strings"
 	"syscall"
 	"time"
 
@@ -38,18 +42,23 @@ import (
 	"aos_communicationmanager/alerts"
 	amqp "aos_communicationmanager/amqphandler"
 	"aos_communicationmanager/boardconfig"
+	"aos_communicationmanager/certmanager"
 	"aos_communicationmanager/cloudprotocol"
 	"aos_communicationmanager/cmserver"
 	"aos_communicationmanager/config"
+	"aos_communicationmanager/cryptoaudit"
 	"aos_communicationmanager/database"
 	"aos_communicationmanager/downloader"
 	"aos_communicationmanager/fcrypt"
 	"aos_communicationmanager/fileserver"
 	"aos_communicationmanager/iamclient"
 	"aos_communicationmanager/monitoring"
+	"aos_communicationmanager/preconditions"
+	"aos_communicationmanager/provisioning"
 	"aos_communicationmanager/smcontroller"
 	"aos_communicationmanager/umcontroller"
 	"aos_communicationmanager/unitstatushandler"
+	"aos_communicationmanager/updatehistory"
 )
 
 /***********************************************************************************************************************
@@ -70,15 +79,20 @@ type communicationManager struct {
 	amqp          *amqp.AmqpHandler
 	iam           *iamclient.Client
 	crypt         *fcrypt.CryptoContext
+	cryptoAudit   *cryptoaudit.Audit
 	alerts        *alerts.Alerts
+	certManager   *certmanager.CertManager
 	monitor       *monitoring.Monitor
 	downloader    *downloader.Downloader
 	fileServer    *fileserver.FileServer
 	smController  *smcontroller.Controller
 	umController  *umcontroller.Controller
 	boardConfig   *boardconfig.Instance
+	preconditions *preconditions.Preconditions
+	updateHistory *updatehistory.History
 	statusHandler *unitstatushandler.Instance
 	cmServer      *cmserver.CMServer
+	provisioner   *provisioning.Provisioner
 }
 
 type journalHook struct {
@@ -138,7 +152,7 @@ func newCommunicationManager(cfg *config.Config) (cm *communicationManager, err
 	}
 
 	// Create IAM client
-	if cm.iam, err = iamclient.New(cfg, cm.amqp, false); err != nil {
+	if cm.iam, err = iamclient.New(cfg, cm.amqp, cm.db, false); err != nil {
 		return cm, aoserrors.Wrap(err)
 	}
 
@@ -147,11 +161,23 @@ func newCommunicationManager(cfg *config.Config) (cm *communicationManager, err
 		return cm, aoserrors.Wrap(err)
 	}
 
+	// Create crypto audit
+	if cm.cryptoAudit, err = cryptoaudit.New(cfg.CryptoAuditKeyFile, cm.db, cm.crypt); err != nil {
+		return cm, aoserrors.Wrap(err)
+	}
+
+	cm.crypt.SetAuditLogger(cm.cryptoAudit)
+
 	// Create alerts
 	if cm.alerts, err = alerts.New(cfg, cm.amqp, cm.db); err != nil {
 		return cm, aoserrors.Wrap(err)
 	}
 
+	// Create certificate manager
+	if cm.certManager, err = certmanager.New(cfg, cm.iam, cm.crypt, cm.crypt, cm.db, cm.alerts); err != nil {
+		return cm, aoserrors.Wrap(err)
+	}
+
 	// Create monitor
 	if cm.monitor, err = monitoring.New(cfg, cm.alerts, nil, cm.amqp); err != nil {
 		return cm, aoserrors.Wrap(err)
@@ -168,12 +194,13 @@ func newCommunicationManager(cfg *config.Config) (cm *communicationManager, err
 	}
 
 	// Create SM controller
-	if cm.smController, err = smcontroller.New(cfg, cm.amqp, cm.alerts, cm.monitor, cm.fileServer, false); err != nil {
+	if cm.smController, err = smcontroller.New(
+		cfg, cm.db, cm.amqp, cm.alerts, cm.monitor, cm.fileServer, cm.crypt, false); err != nil {
 		return cm, aoserrors.Wrap(err)
 	}
 
 	// Create UM controller
-	if cm.umController, err = umcontroller.New(cfg, cm.db, cm.fileServer, false); err != nil {
+	if cm.umController, err = umcontroller.New(cfg, cm.db, cm.fileServer, cm.alerts, false); err != nil {
 		return cm, aoserrors.Wrap(err)
 	}
 
@@ -182,14 +209,62 @@ func newCommunicationManager(cfg *config.Config) (cm *communicationManager, err
 		return cm, aoserrors.Wrap(err)
 	}
 
+	// Create update preconditions
+	if cm.preconditions, err = preconditions.New(cfg); err != nil {
+		return cm, aoserrors.Wrap(err)
+	}
+
+	// Create update history
+	if cm.updateHistory, err = updatehistory.New(cfg, cm.db); err != nil {
+		return cm, aoserrors.Wrap(err)
+	}
+
 	// Create unit status handler
 	if cm.statusHandler, err = unitstatushandler.New(cfg, cm.boardConfig, cm.umController, cm.smController,
-		cm.downloader, cm.db, cm.amqp); err != nil {
+		cm.iam, cm.downloader, cm.db, cm.amqp, cm.preconditions, cm.updateHistory); err != nil {
 		return cm, aoserrors.Wrap(err)
 	}
 
 	// Create CM server
-	if cm.cmServer, err = cmserver.New(cfg, cm.statusHandler, false); err != nil {
+	if cm.cmServer, err = cmserver.New(
+		cfg, cm.statusHandler, cm.cryptoAudit, cm.updateHistory, cm.iam, false); err != nil {
+		return cm, aoserrors.Wrap(err)
+	}
+
+	return cm, nil
+}
+
+func newProvisioningManager(cfg *config.Config) (cm *communicationManager, err error) {
+	defer func() {
+		if err != nil {
+			cm.close()
+			cm = nil
+		}
+	}()
+
+	cm = &communicationManager{}
+
+	if cm.db, err = database.New(cfg); err != nil {
+		return cm, aoserrors.Wrap(err)
+	}
+
+	// Create AMQP handler
+	if cm.amqp, err = amqp.New(); err != nil {
+		return cm, aoserrors.Wrap(err)
+	}
+
+	// Create IAM client
+	if cm.iam, err = iamclient.New(cfg, cm.amqp, cm.db, false); err != nil {
+		return cm, aoserrors.Wrap(err)
+	}
+
+	// Create crypto context
+	if cm.crypt, err = fcrypt.New(cfg.Crypt, cm.iam); err != nil {
+		return cm, aoserrors.Wrap(err)
+	}
+
+	// Create provisioner
+	if cm.provisioner, err = provisioning.New(cm.iam, cm.amqp, cm.crypt, cm.crypt, cm.db); err != nil {
 		return cm, aoserrors.Wrap(err)
 	}
 
@@ -207,6 +282,11 @@ func (cm *communicationManager) close() {
 		cm.statusHandler.Close()
 	}
 
+	// Close update preconditions
+	if cm.preconditions != nil {
+		cm.preconditions.Close()
+	}
+
 	// Close UM controller
 	if cm.umController != nil {
 		cm.umController.Close()
@@ -227,6 +307,11 @@ func (cm *communicationManager) close() {
 		cm.monitor.Close()
 	}
 
+	// Close certificate manager
+	if cm.certManager != nil {
+		cm.certManager.Close()
+	}
+
 	// Close alerts
 	if cm.alerts != nil {
 		cm.alerts.Close()
@@ -340,17 +425,39 @@ func (cm *communicationManager) processMessage(message amqp.Message) (err error)
 	case *cloudprotocol.RenewCertsNotificationWithPwd:
 		log.Info("Receive renew certificates notification message")
 
+		if data.Password != "" {
+			if err = cm.certManager.SetOwnerPassword(data.Password); err != nil {
+				log.Errorf("Can't store owner password: %s", err)
+			}
+		}
+
 		if err = cm.iam.RenewCertificatesNotification(data.Password, data.Certificates); err != nil {
 			return aoserrors.Wrap(err)
 		}
 
+		certTypes := make([]string, 0, len(data.Certificates))
+
+		for _, cert := range data.Certificates {
+			certTypes = append(certTypes, cert.Type)
+		}
+
+		// Keys are already requested by the notification, don't request them again proactively
+		cm.certManager.SetRenewalRequested(certTypes)
+
 	case *cloudprotocol.IssuedUnitCerts:
 		log.Info("Receive issued unit certificates message")
 
-		if err = cm.iam.InstallCertificates(data.Certificates, cm.crypt); err != nil {
+		err = cm.iam.InstallCertificates(data.Certificates, cm.crypt)
+
+		// Renewed certificates have new fingerprints, they should be logged again
+		cm.crypt.InvalidateAuditCache()
+
+		if err != nil {
 			return aoserrors.Wrap(err)
 		}
 
+		cm.certManager.CheckCertificates()
+
 	default:
 		log.Warnf("Receive unsupported amqp message: %s", reflect.TypeOf(data))
 	}
@@ -377,22 +484,32 @@ func (cm *communicationManager) handleMessages(ctx context.Context) {
 	}
 }
 
-func (cm *communicationManager) handleConnection(ctx context.Context, serviceDiscoveryURL string) {
+func (cm *communicationManager) connect(ctx context.Context, cfg *config.Config) {
+	retryhelper.Retry(ctx,
+		func() (err error) {
+			systemID := cm.iam.GetSystemID()
+			if systemID == "" {
+				return aoserrors.New("system ID is not available")
+			}
+
+			// Discovery URL depends on online certificate which may be unavailable while IAM is not accessible
+			if err = cm.amqp.Connect(cm.crypt, cm.getServiceDiscoveryURL(cfg), systemID,
+				cm.iam.GetUsers()); err != nil {
+				return aoserrors.Wrap(err)
+			}
+
+			return nil
+		},
+		func(retryCount int, delay time.Duration, err error) {
+			log.Errorf("Can't establish connection: %s", err)
+			log.Debugf("Retry connection in %v", delay)
+		},
+		0, initReconnectTimeout, maxReconnectTimeout)
+}
+
+func (cm *communicationManager) handleConnection(ctx context.Context, cfg *config.Config) {
 	for {
-		retryhelper.Retry(ctx,
-			func() (err error) {
-				if err = cm.amqp.Connect(cm.crypt, serviceDiscoveryURL,
-					cm.iam.GetSystemID(), cm.iam.GetUsers()); err != nil {
-					return aoserrors.Wrap(err)
-				}
-
-				return nil
-			},
-			func(retryCount int, delay time.Duration, err error) {
-				log.Errorf("Can't establish connection: %s", err)
-				log.Debugf("Retry connection in %v", delay)
-			},
-			0, initReconnectTimeout, maxReconnectTimeout)
+		cm.connect(ctx, cfg)
 
 		if err := cm.statusHandler.SetUsers(cm.iam.GetUsers()); err != nil {
 			log.Errorf("Can't set users: %s", err)
@@ -414,6 +531,48 @@ func (cm *communicationManager) handleConnection(ctx context.Context, serviceDis
 	}
 }
 
+func (cm *communicationManager) handleProvisioningConnection(ctx context.Context, cfg *config.Config) {
+	for {
+		cm.connect(ctx, cfg)
+
+		cm.handleProvisioningMessages(ctx)
+
+		if err := cm.amqp.Disconnect(); err != nil {
+			log.Errorf("Disconnect error: %s", err)
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (cm *communicationManager) handleProvisioningMessages(ctx context.Context) {
+	for {
+		select {
+		case message := <-cm.amqp.MessageChannel:
+			if err, ok := message.Data.(error); ok {
+				log.Errorf("AMQP error: %s", err)
+				return
+			}
+
+			if data, ok := message.Data.(*cloudprotocol.IssuedUnitCerts); ok {
+				log.Info("Receive issued unit certificates message")
+
+				cm.provisioner.InstallCertificates(data.Certificates)
+				cm.crypt.InvalidateAuditCache()
+
+				continue
+			}
+
+			log.Warnf("Skip amqp message during provisioning: %s", reflect.TypeOf(message.Data))
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 func (cm *communicationManager) handleUsers(ctx context.Context) {
 	for {
 		select {
@@ -428,6 +587,66 @@ func (cm *communicationManager) handleUsers(ctx context.Context) {
 	}
 }
 
+func (cm *communicationManager) handleNodesHealth(ctx context.Context) {
+	for {
+		select {
+		case nodesHealth := <-cm.smController.GetNodesHealthChannel():
+			cm.statusHandler.UpdateNodesHealth(nodesHealth)
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (cm *communicationManager) handleComponentsProgress(ctx context.Context, sendAlerts bool) {
+	phases := make(map[string]string)
+
+	for {
+		select {
+		case progress := <-cm.umController.GetProgressChannel():
+			cm.statusHandler.UpdateComponentsProgress(progress)
+
+			if sendAlerts {
+				cm.sendProgressAlerts(progress, phases)
+			}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sendProgressAlerts sends alert on each component update phase change
+func (cm *communicationManager) sendProgressAlerts(
+	progress []cloudprotocol.ComponentProgress, phases map[string]string) {
+	if len(progress) == 0 {
+		for id := range phases {
+			delete(phases, id)
+		}
+
+		return
+	}
+
+	for _, item := range progress {
+		if item.Phase == "" || phases[item.ID] == item.Phase {
+			continue
+		}
+
+		phases[item.ID] = item.Phase
+
+		if err := cm.alerts.SendAlert(cloudprotocol.AlertItem{
+			Timestamp: time.Now(),
+			Tag:       cloudprotocol.AlertTagAosCore,
+			Source:    "communicationmanager",
+			Payload: cloudprotocol.SystemAlert{Message: fmt.Sprintf("Component %s %s: %s %d%%",
+				item.ID, item.VendorVersion, item.Phase, item.Percentage)},
+		}); err != nil {
+			log.Errorf("Can't send progress alert: %s", err)
+		}
+	}
+}
+
 /***********************************************************************************************************************
  * Systemd journal hook
  **********************************************************************************************************************/
@@ -476,6 +695,98 @@ func (hook *journalHook) Levels() []log.Level {
  * Private
  **********************************************************************************************************************/
 
+// readOwnerPassword reads owner password from the first line of file or stdin. The password is not passed as
+// command line argument to not expose it in the process list and shell history.
+func readOwnerPassword(fileName string) (password string, err error) {
+	var input io.Reader = os.Stdin
+
+	if fileName != "-" {
+		file, err := os.Open(fileName)
+		if err != nil {
+			return "", aoserrors.Wrap(err)
+		}
+		defer file.Close()
+
+		input = file
+	} else {
+		fmt.Fprint(os.Stderr, "Owner password: ")
+	}
+
+	if password, err = bufio.NewReader(input).ReadString('\n'); err != nil && err != io.EOF {
+		return "", aoserrors.Wrap(err)
+	}
+
+	if password = strings.TrimRight(password, "\r\n"); password == "" {
+		return "", aoserrors.New("owner password is empty")
+	}
+
+	return password, nil
+}
+
+func provision(cfg *config.Config, password string) (err error) {
+	cm, err := newProvisioningManager(cfg)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+	defer cm.close()
+
+	if cm.provisioner.IsProvisioned() {
+		log.Info("Unit is already provisioned")
+
+		return nil
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	go cm.handleProvisioningConnection(ctx, cfg)
+
+	terminateChannel := make(chan os.Signal, 1)
+
+	signal.Notify(terminateChannel, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-terminateChannel:
+			cancelFunc()
+
+		case <-ctx.Done():
+		}
+	}()
+
+	if err = cm.provisioner.Provision(ctx, password); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func showUpdateHistory(cfg *config.Config, filter updatehistory.Filter) (err error) {
+	db, err := database.NewReadOnly(cfg)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+	defer db.Close()
+
+	records, err := db.GetUpdateHistoryRecords(filter)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if records == nil {
+		records = make([]updatehistory.Record, 0)
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	fmt.Println(string(data))
+
+	return nil
+}
+
 func reset(cfg *config.Config) (err error) {
 	log.Info("Cleanup working directory")
 
@@ -497,6 +808,13 @@ func main() {
 	doReset := flag.Bool("reset", false, `cleanup working directory`)
 	showVersion := flag.Bool("version", false, `show communication manager version`)
 	useJournal := flag.Bool("j", false, "output logs to systemd journal")
+	doProvision := flag.Bool("provision", false, `provision unit and exit`)
+	passwordFile := flag.String("password-file", "-",
+		`file with owner password used for provisioning, "-" reads password from stdin`)
+	showHistory := flag.Bool("history", false, `show update history starting from the latest update and exit`)
+	historyType := flag.String("history-type", "", `update history type filter: "fota", "sota"`)
+	historyOffset := flag.Uint64("history-offset", 0, `number of update history records to skip`)
+	historyLimit := flag.Uint64("history-limit", 20, `max number of update history records to show, 0 - no limit`)
 
 	flag.Parse()
 
@@ -550,6 +868,40 @@ func main() {
 		os.Exit(0)
 	}
 
+	// Show update history
+
+	if *showHistory {
+		if err = showUpdateHistory(cfg, updatehistory.Filter{
+			UpdateType: *historyType, Offset: *historyOffset, Limit: *historyLimit}); err != nil {
+			log.Errorf("Can't show update history: %s", err)
+
+			os.Exit(1)
+		}
+
+		os.Exit(0)
+	}
+
+	// Do provisioning
+
+	if *doProvision {
+		ownerPassword, err := readOwnerPassword(*passwordFile)
+		if err != nil {
+			log.Errorf("Can't read owner password: %s", err)
+
+			os.Exit(1)
+		}
+
+		if err = provision(cfg, ownerPassword); err != nil {
+			log.Errorf("Can't provision unit: %s", err)
+
+			os.Exit(1)
+		}
+
+		log.Info("Unit provisioned successfully")
+
+		os.Exit(0)
+	}
+
 	log.WithFields(log.Fields{"configFile": *configFile, "version": GitSummary}).Info("Start communication manager")
 
 	cm, err := newCommunicationManager(cfg)
@@ -565,8 +917,10 @@ func main() {
 
 	ctx, cancelFunc := context.WithCancel(context.Background())
 
-	go cm.handleConnection(ctx, cm.getServiceDiscoveryURL(cfg))
+	go cm.handleConnection(ctx, cfg)
 	go cm.handleUsers(ctx)
+	go cm.handleNodesHealth(ctx)
+	go cm.handleComponentsProgress(ctx, cfg.UMController.ProgressAlerts)
 
 	// Handle SIGTERM
 
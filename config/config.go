@@ -27,6 +27,14 @@ import (
 	"github.com/aoscloud/aos_common/aoserrors"
 )
 
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// KeptImagePrefix prefix of images kept in decrypt dir after update to reschedule services when SM fails or to
+// install components which update is deferred
+const KeptImagePrefix = "kept_"
+
 /***********************************************************************************************************************
  * Types
  **********************************************************************************************************************/
@@ -40,9 +48,12 @@ type Crypt struct {
 
 // UMController configuration for update controller
 type UMController struct {
-	ServerURL string           `json:"serverUrl"`
-	UMClients []UMClientConfig `json:"umClients"`
-	UpdateTTL Duration         `json:"updateTTL"`
+	ServerURL          string           `json:"serverUrl"`
+	UMClients          []UMClientConfig `json:"umClients"`
+	UpdateTTL          Duration         `json:"updateTTL"`
+	ConnectionTimeout  Duration         `json:"connectionTimeout"`
+	ProgressAlerts     bool             `json:"progressAlerts"`
+	HealthCheckTimeout Duration         `json:"healthCheckTimeout"`
 }
 
 // UMClientConfig update manager config
@@ -50,6 +61,10 @@ type UMClientConfig struct {
 	UMID     string `json:"umId"`
 	Priority uint32 `json:"priority"`
 	IsLocal  bool   `json:"isLocal,omitempty"`
+	// HealthCheck should be set only for UMs which report health in UpdateStatus.health message, otherwise update
+	// of UM components is reverted on health check timeout
+	HealthCheck bool   `json:"healthCheck,omitempty"`
+	CommonName  string `json:"commonName,omitempty"`
 }
 
 // Duration represents duration in format "00:00:00"
@@ -66,15 +81,26 @@ type AlertRule struct {
 
 // Monitoring configuration for system monitoring
 type Monitoring struct {
-	EnableSystemMonitoring bool       `json:"enableSystemMonitoring"`
-	MaxOfflineMessages     int        `json:"maxOfflineMessages"`
-	SendPeriod             Duration   `json:"sendPeriod"`
-	PollPeriod             Duration   `json:"pollPeriod"`
-	RAM                    *AlertRule `json:"ram"`
-	CPU                    *AlertRule `json:"cpu"`
-	UsedDisk               *AlertRule `json:"usedDisk"`
-	InTraffic              *AlertRule `json:"inTraffic"`
-	OutTraffic             *AlertRule `json:"outTraffic"`
+	EnableSystemMonitoring bool                      `json:"enableSystemMonitoring"`
+	MaxOfflineMessages     int                       `json:"maxOfflineMessages"`
+	SendPeriod             Duration                  `json:"sendPeriod"`
+	PollPeriod             Duration                  `json:"pollPeriod"`
+	RAM                    *AlertRule                `json:"ram"`
+	CPU                    *AlertRule                `json:"cpu"`
+	UsedDisk               *AlertRule                `json:"usedDisk"`
+	InTraffic              *AlertRule                `json:"inTraffic"`
+	OutTraffic             *AlertRule                `json:"outTraffic"`
+	NodeDataTTL            Duration                  `json:"nodeDataTtl"`
+	Nodes                  map[string]NodeAlertRules `json:"nodes"`
+}
+
+// NodeAlertRules alert rules applied to monitoring data of the specific node
+type NodeAlertRules struct {
+	RAM        *AlertRule `json:"ram"`
+	CPU        *AlertRule `json:"cpu"`
+	UsedDisk   *AlertRule `json:"usedDisk"`
+	InTraffic  *AlertRule `json:"inTraffic"`
+	OutTraffic *AlertRule `json:"outTraffic"`
 }
 
 // Alerts configuration for alerts
@@ -104,34 +130,80 @@ type Downloader struct {
 
 // SMConfig SM configuration
 type SMConfig struct {
-	SMID      string `json:"smId"`
-	ServerURL string `json:"serverUrl"`
-	IsLocal   bool   `json:"isLocal,omitempty"`
+	SMID         string   `json:"smId"`
+	ServerURL    string   `json:"serverUrl"`
+	IsLocal      bool     `json:"isLocal,omitempty"`
+	Capabilities []string `json:"capabilities,omitempty"`
+	RAM          uint64   `json:"ram,omitempty"`
+	Disk         uint64   `json:"disk,omitempty"`
 }
 
 // SMController SM controller configuration
 type SMController struct {
-	SMList    []SMConfig `json:"smList"`
-	UpdateTTL Duration   `json:"updateTTL"`
+	ServerURL           string     `json:"serverUrl,omitempty"`
+	SMList              []SMConfig `json:"smList"`
+	UpdateTTL           Duration   `json:"updateTTL"`
+	HealthCheckPeriod   Duration   `json:"healthCheckPeriod"`
+	FailoverGracePeriod Duration   `json:"failoverGracePeriod"`
+	StateBackupKeyFile  string     `json:"stateBackupKeyFile,omitempty"`
+	MaxStateBackupSize  uint64     `json:"maxStateBackupSize"`
+}
+
+// CMServerClient CM server client identified by certificate and its permissions
+type CMServerClient struct {
+	CommonName  string   `json:"commonName"`
+	Permissions []string `json:"permissions"`
+}
+
+// Preconditions update preconditions configuration
+type Preconditions struct {
+	ServerURL       string   `json:"serverUrl,omitempty"`
+	CheckPeriod     Duration `json:"checkPeriod"`
+	Parked          bool     `json:"parked,omitempty"`
+	MinBatteryLevel uint32   `json:"minBatteryLevel,omitempty"`
+	NotCharging     bool     `json:"notCharging,omitempty"`
+	IgnitionOff     bool     `json:"ignitionOff,omitempty"`
+	MinFreeRAM      uint64   `json:"minFreeRam,omitempty"`
+	MinFreeDisk     uint64   `json:"minFreeDisk,omitempty"`
+	DiskPath        string   `json:"diskPath,omitempty"`
+}
+
+// CertManager certificate manager configuration
+type CertManager struct {
+	CheckPeriod   Duration `json:"checkPeriod"`
+	RenewBefore   Duration `json:"renewBefore"`
+	RetryDelay    Duration `json:"retryDelay"`
+	MaxRetryDelay Duration `json:"maxRetryDelay"`
+}
+
+// UpdateHistory update history configuration. Zero values mean no limit.
+type UpdateHistory struct {
+	MaxRecords uint64   `json:"maxRecords"`
+	MaxAge     Duration `json:"maxAge"`
 }
 
 // Config instance
 type Config struct {
-	Crypt                 Crypt        `json:"fcrypt"`
-	CertStorage           string       `json:"certStorage"`
-	ServiceDiscoveryURL   string       `json:"serviceDiscoveryUrl"`
-	IAMServerURL          string       `json:"iamServerUrl"`
-	FileServerURL         string       `json:"fileServerUrl"`
-	CMServerURL           string       `json:"cmServerUrl"`
-	Downloader            Downloader   `json:"downloader"`
-	WorkingDir            string       `json:"workingDir"`
-	BoardConfigFile       string       `json:"boardConfigFile"`
-	UnitStatusSendTimeout Duration     `json:"unitStatusSendTimeout"`
-	Monitoring            Monitoring   `json:"monitoring"`
-	Alerts                Alerts       `json:"alerts"`
-	Migration             Migration    `json:"migration"`
-	SMController          SMController `json:"smController"`
-	UMController          UMController `json:"umController"`
+	Crypt                 Crypt            `json:"fcrypt"`
+	CertStorage           string           `json:"certStorage"`
+	ServiceDiscoveryURL   string           `json:"serviceDiscoveryUrl"`
+	IAMServerURL          string           `json:"iamServerUrl"`
+	FileServerURL         string           `json:"fileServerUrl"`
+	CMServerURL           string           `json:"cmServerUrl"`
+	CMServerClients       []CMServerClient `json:"cmServerClients"`
+	Downloader            Downloader       `json:"downloader"`
+	WorkingDir            string           `json:"workingDir"`
+	BoardConfigFile       string           `json:"boardConfigFile"`
+	CryptoAuditKeyFile    string           `json:"cryptoAuditKeyFile"`
+	UnitStatusSendTimeout Duration         `json:"unitStatusSendTimeout"`
+	Monitoring            Monitoring       `json:"monitoring"`
+	Alerts                Alerts           `json:"alerts"`
+	Migration             Migration        `json:"migration"`
+	SMController          SMController     `json:"smController"`
+	UMController          UMController     `json:"umController"`
+	CertManager           CertManager      `json:"certManager"`
+	Preconditions         Preconditions    `json:"preconditions"`
+	UpdateHistory         UpdateHistory    `json:"updateHistory"`
 }
 
 /***********************************************************************************************************************
@@ -150,6 +222,7 @@ func New(fileName string) (config *Config, err error) {
 		Monitoring: Monitoring{
 			SendPeriod:         Duration{1 * time.Minute},
 			PollPeriod:         Duration{10 * time.Second},
+			NodeDataTTL:        Duration{2 * time.Minute},
 			MaxOfflineMessages: 25},
 		Alerts: Alerts{
 			SendPeriod:         Duration{10 * time.Second},
@@ -161,8 +234,28 @@ func New(fileName string) (config *Config, err error) {
 			MaxRetryDelay:          Duration{30 * time.Minute},
 			DownloadPartLimit:      100,
 		},
-		SMController: SMController{UpdateTTL: Duration{30 * 24 * time.Hour}},
-		UMController: UMController{UpdateTTL: Duration{30 * 24 * time.Hour}},
+		SMController: SMController{
+			UpdateTTL:           Duration{30 * 24 * time.Hour},
+			HealthCheckPeriod:   Duration{10 * time.Second},
+			FailoverGracePeriod: Duration{5 * time.Minute},
+			MaxStateBackupSize:  1024 * 1024,
+		},
+		UMController: UMController{
+			UpdateTTL:         Duration{30 * 24 * time.Hour},
+			ConnectionTimeout: Duration{300 * time.Second},
+		},
+		UpdateHistory: UpdateHistory{
+			MaxRecords: 100,
+		},
+		CertManager: CertManager{
+			CheckPeriod:   Duration{1 * time.Hour},
+			RenewBefore:   Duration{30 * 24 * time.Hour},
+			RetryDelay:    Duration{10 * time.Minute},
+			MaxRetryDelay: Duration{24 * time.Hour},
+		},
+		Preconditions: Preconditions{
+			CheckPeriod: Duration{10 * time.Second},
+		},
 	}
 
 	if err = json.Unmarshal(raw, &config); err != nil {
@@ -185,6 +278,18 @@ func New(fileName string) (config *Config, err error) {
 		config.BoardConfigFile = path.Join(config.WorkingDir, "aos_board.cfg")
 	}
 
+	if config.CryptoAuditKeyFile == "" {
+		config.CryptoAuditKeyFile = path.Join(config.WorkingDir, "cryptoaudit.key")
+	}
+
+	if config.SMController.StateBackupKeyFile == "" {
+		config.SMController.StateBackupKeyFile = path.Join(config.WorkingDir, "statebackup.key")
+	}
+
+	if config.Preconditions.DiskPath == "" {
+		config.Preconditions.DiskPath = config.WorkingDir
+	}
+
 	if config.Migration.MigrationPath == "" {
 		config.Migration.MigrationPath = "/usr/share/aos/communicationmanager/migration"
 	}
@@ -45,8 +45,15 @@ const testConfigContent = `{
 	"iamServerUrl" : "localhost:8090",
 	"fileServerUrl":"localhost:8092",
 	"cmServerUrl":"localhost:8094",
+	"cmServerClients": [
+		{
+			"commonName": "hmi",
+			"permissions": ["update.read", "update.start"]
+		}
+	],
 	"workingDir" : "workingDir",
 	"boardConfigFile" : "/var/aos/aos_board.cfg",
+	"cryptoAuditKeyFile" : "/var/aos/cryptoaudit.key",
 	"downloader": {
 		"downloadDir": "/path/to/download",
 		"decryptDir": "/path/to/decrypt",
@@ -68,6 +75,16 @@ const testConfigContent = `{
 			"minTimeout": "20s",
 			"minThreshold": 10,
 			"maxThreshold": 150
+		},
+		"nodeDataTtl": "3m",
+		"nodes": {
+			"sm1": {
+				"cpu": {
+					"minTimeout": "30s",
+					"minThreshold": 50,
+					"maxThreshold": 90
+				}
+			}
 		}
 	},
 	"alerts": {
@@ -82,6 +99,7 @@ const testConfigContent = `{
 		"mergedMigrationPath" : "/var/aos/communicationmanager/migration"
 	},
 	"smController": {
+		"serverUrl": ":8094",
 		"smList": [
 			{
 				"smId": "sm0",
@@ -90,19 +108,50 @@ const testConfigContent = `{
 			},
 			{
 				"smId": "sm1",
-				"serverUrl": "remotehost:8888"
+				"serverUrl": "remotehost:8888",
+				"capabilities": ["gpu"],
+				"ram": 1073741824,
+				"disk": 8589934592
 			}
 		],
-		"updateTTL": "30h"
+		"updateTTL": "30h",
+		"healthCheckPeriod": "5s",
+		"failoverGracePeriod": "1m",
+		"maxStateBackupSize": 65536
 	},
 	"umController": {
 		"serverUrl": "localhost:8091",
 		"umClients": [{
 			"umId": "um",
 			"priority": 0,
-			"isLocal": true
+			"isLocal": true,
+			"healthCheck": true,
+			"commonName": "um.aos"
 		}],
-		"updateTTL": "100h"
+		"updateTTL": "100h",
+		"connectionTimeout": "2m",
+		"progressAlerts": true,
+		"healthCheckTimeout": "10m"
+	},
+	"certManager": {
+		"checkPeriod": "30m",
+		"renewBefore": "240h",
+		"retryDelay": "5m",
+		"maxRetryDelay": "12h"
+	},
+	"preconditions": {
+		"serverUrl": "unix:///tmp/preconditions.sock",
+		"checkPeriod": "30s",
+		"parked": true,
+		"minBatteryLevel": 40,
+		"notCharging": true,
+		"ignitionOff": true,
+		"minFreeRam": 1048576,
+		"minFreeDisk": 2097152
+	},
+	"updateHistory": {
+		"maxRecords": 50,
+		"maxAge": "2160h"
 	}
 }`
 
@@ -167,6 +216,12 @@ func TestGetBoardConfigFile(t *testing.T) {
 	}
 }
 
+func TestGetCryptoAuditKeyFile(t *testing.T) {
+	if testCfg.CryptoAuditKeyFile != "/var/aos/cryptoaudit.key" {
+		t.Errorf("Wrong crypto audit key file value: %s", testCfg.CryptoAuditKeyFile)
+	}
+}
+
 func TestGetIAMServerURL(t *testing.T) {
 	if testCfg.IAMServerURL != "localhost:8090" {
 		t.Errorf("Wrong IAM server value: %s", testCfg.IAMServerURL)
@@ -202,6 +257,14 @@ func TestGetMonitoringConfig(t *testing.T) {
 	if testCfg.Monitoring.OutTraffic.MinTimeout.Duration != 20*time.Second {
 		t.Errorf("Wrong value: %s", testCfg.Monitoring.RAM.MinTimeout)
 	}
+
+	if testCfg.Monitoring.NodeDataTTL.Duration != 3*time.Minute {
+		t.Errorf("Wrong node data TTL value: %s", testCfg.Monitoring.NodeDataTTL)
+	}
+
+	if rules, ok := testCfg.Monitoring.Nodes["sm1"]; !ok || rules.CPU == nil || rules.CPU.MaxThreshold != 90 {
+		t.Errorf("Wrong node alert rules: %v", testCfg.Monitoring.Nodes)
+	}
 }
 
 func TestGetAlertsConfig(t *testing.T) {
@@ -229,12 +292,15 @@ func TestGetAlertsConfig(t *testing.T) {
 }
 
 func TestUMControllerConfig(t *testing.T) {
-	umClient := config.UMClientConfig{UMID: "um", Priority: 0, IsLocal: true}
+	umClient := config.UMClientConfig{UMID: "um", Priority: 0, IsLocal: true, HealthCheck: true, CommonName: "um.aos"}
 
 	originalConfig := config.UMController{
-		ServerURL: "localhost:8091",
-		UMClients: []config.UMClientConfig{umClient},
-		UpdateTTL: config.Duration{100 * time.Hour},
+		ServerURL:          "localhost:8091",
+		UMClients:          []config.UMClientConfig{umClient},
+		UpdateTTL:          config.Duration{100 * time.Hour},
+		ConnectionTimeout:  config.Duration{2 * time.Minute},
+		ProgressAlerts:     true,
+		HealthCheckTimeout: config.Duration{10 * time.Minute},
 	}
 
 	if !reflect.DeepEqual(originalConfig, testCfg.UMController) {
@@ -259,11 +325,17 @@ func TestDownloaderConfig(t *testing.T) {
 
 func TestSMControllerConfig(t *testing.T) {
 	originalConfig := config.SMController{
+		ServerURL: ":8094",
 		SMList: []config.SMConfig{
 			{SMID: "sm0", ServerURL: "localhost:8888", IsLocal: true},
-			{SMID: "sm1", ServerURL: "remotehost:8888"},
+			{SMID: "sm1", ServerURL: "remotehost:8888", Capabilities: []string{"gpu"}, RAM: 1073741824,
+				Disk: 8589934592},
 		},
-		UpdateTTL: config.Duration{30 * time.Hour},
+		UpdateTTL:           config.Duration{30 * time.Hour},
+		HealthCheckPeriod:   config.Duration{5 * time.Second},
+		FailoverGracePeriod: config.Duration{1 * time.Minute},
+		StateBackupKeyFile:  "workingDir/statebackup.key",
+		MaxStateBackupSize:  65536,
 	}
 
 	if !reflect.DeepEqual(originalConfig, testCfg.SMController) {
@@ -336,3 +408,52 @@ func cleanup() (err error) {
 
 	return nil
 }
+
+func TestCertManagerConfig(t *testing.T) {
+	originalConfig := config.CertManager{
+		CheckPeriod:   config.Duration{30 * time.Minute},
+		RenewBefore:   config.Duration{240 * time.Hour},
+		RetryDelay:    config.Duration{5 * time.Minute},
+		MaxRetryDelay: config.Duration{12 * time.Hour},
+	}
+
+	if !reflect.DeepEqual(originalConfig, testCfg.CertManager) {
+		t.Errorf("Wrong cert manager config value: %v", testCfg.CertManager)
+	}
+}
+
+func TestPreconditionsConfig(t *testing.T) {
+	originalConfig := config.Preconditions{
+		ServerURL:       "unix:///tmp/preconditions.sock",
+		CheckPeriod:     config.Duration{30 * time.Second},
+		Parked:          true,
+		MinBatteryLevel: 40,
+		NotCharging:     true,
+		IgnitionOff:     true,
+		MinFreeRAM:      1048576,
+		MinFreeDisk:     2097152,
+		DiskPath:        testCfg.WorkingDir,
+	}
+
+	if !reflect.DeepEqual(originalConfig, testCfg.Preconditions) {
+		t.Errorf("Wrong preconditions config value: %v", testCfg.Preconditions)
+	}
+}
+
+func TestUpdateHistoryConfig(t *testing.T) {
+	originalConfig := config.UpdateHistory{MaxRecords: 50, MaxAge: config.Duration{90 * 24 * time.Hour}}
+
+	if !reflect.DeepEqual(originalConfig, testCfg.UpdateHistory) {
+		t.Errorf("Wrong update history config value: %v", testCfg.UpdateHistory)
+	}
+}
+
+func TestCMServerClients(t *testing.T) {
+	originalClients := []config.CMServerClient{
+		{CommonName: "hmi", Permissions: []string{"update.read", "update.start"}},
+	}
+
+	if !reflect.DeepEqual(originalClients, testCfg.CMServerClients) {
+		t.Errorf("Wrong CM server clients value: %v", testCfg.CMServerClients)
+	}
+}
@@ -0,0 +1,371 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cryptoaudit provides tamper-evident log of crypto operations decisions
+package cryptoaudit
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aoscloud/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"aos_communicationmanager/fcrypt"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// verifyPageSize number of records loaded at once during verification
+const verifyPageSize = 1000
+
+const anchorKeySize = 32
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// Record audit log record
+type Record struct {
+	ID uint64 `json:"id"`
+	fcrypt.AuditRecord
+	PrevHash string `json:"prevHash"`
+	Hash     string `json:"hash"`
+}
+
+// Filter audit log records filter
+type Filter struct {
+	Operation string
+	PackageID string
+	From      time.Time
+	Till      time.Time
+	Offset    uint64
+	Limit     uint64
+}
+
+// Storage audit log storage. Anchor is HMAC of the last record, it is stored together with the record.
+type Storage interface {
+	AddCryptoAuditRecord(record Record, anchor string) (err error)
+	GetLastCryptoAuditRecord() (record Record, err error)
+	GetCryptoAuditRecords(filter Filter) (records []Record, err error)
+	SetCryptoAuditAnchor(anchor string) (err error)
+	GetCryptoAuditAnchor() (anchor string, err error)
+}
+
+// KeyWrapper protects anchor key with the unit key
+type KeyWrapper interface {
+	WrapKey(key []byte) (wrapped []byte, err error)
+	UnwrapKey(wrapped []byte) (key []byte, err error)
+}
+
+// Audit audit log instance
+type Audit struct {
+	sync.Mutex
+
+	storage  Storage
+	lastID   uint64
+	lastHash string
+	// anchorKey authenticates the last record, the key is kept outside the storage, so the chain can't be
+	// rewritten with storage write access only
+	anchorKey []byte
+	anchorErr error
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// New creates new audit log instance. Anchor key is stored in the key file wrapped with the unit key.
+func New(keyFile string, storage Storage, keyWrapper KeyWrapper) (audit *Audit, err error) {
+	log.Debug("Create crypto audit log")
+
+	if storage == nil || keyWrapper == nil {
+		return nil, aoserrors.New("crypto audit dependencies are not set")
+	}
+
+	audit = &Audit{storage: storage}
+
+	lastRecord, err := audit.storage.GetLastCryptoAuditRecord()
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	audit.lastID = lastRecord.ID
+	audit.lastHash = lastRecord.Hash
+
+	if audit.anchorErr = audit.initAnchor(keyFile, keyWrapper); audit.anchorErr != nil {
+		log.Errorf("Crypto audit log is not anchored: %s", audit.anchorErr)
+	}
+
+	return audit, nil
+}
+
+// LogCryptoOperation adds crypto operation record to the audit log
+func (audit *Audit) LogCryptoOperation(auditRecord fcrypt.AuditRecord) {
+	audit.Lock()
+	defer audit.Unlock()
+
+	record := Record{ID: audit.lastID + 1, AuditRecord: auditRecord, PrevHash: audit.lastHash}
+
+	hash, err := calculateHash(record)
+	if err != nil {
+		log.Errorf("Can't calculate audit record hash: %s", err)
+		return
+	}
+
+	record.Hash = hash
+
+	log.WithFields(log.Fields{
+		"id":        record.ID,
+		"operation": record.Operation,
+		"packageID": record.PackageID,
+		"subject":   record.Subject,
+		"result":    record.Result}).Debug("Add crypto audit record")
+
+	// Anchor is not updated if the log is not anchored, so verification keeps failing
+	var anchor string
+
+	if audit.anchorErr == nil {
+		anchor = calculateAnchor(audit.anchorKey, record.ID, record.Hash)
+	}
+
+	if err = audit.storage.AddCryptoAuditRecord(record, anchor); err != nil {
+		log.Errorf("Can't store audit record: %s", err)
+		return
+	}
+
+	audit.lastID = record.ID
+	audit.lastHash = record.Hash
+}
+
+// GetRecords returns audit log records
+func (audit *Audit) GetRecords(filter Filter) (records []Record, err error) {
+	if records, err = audit.storage.GetCryptoAuditRecords(filter); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return records, nil
+}
+
+// Verify checks integrity of whole audit log. Records added during verification are not verified.
+func (audit *Audit) Verify() (err error) {
+	audit.Lock()
+
+	lastID, lastHash, anchorKey, anchorErr := audit.lastID, audit.lastHash, audit.anchorKey, audit.anchorErr
+
+	var anchor string
+
+	if anchorErr == nil {
+		anchor, anchorErr = audit.storage.GetCryptoAuditAnchor()
+	}
+
+	audit.Unlock()
+
+	if anchorErr != nil {
+		return aoserrors.Errorf("audit log is not anchored: %s", anchorErr)
+	}
+
+	if lastID != 0 && !hmac.Equal([]byte(anchor), []byte(calculateAnchor(anchorKey, lastID, lastHash))) {
+		return aoserrors.New("audit log anchor mismatch")
+	}
+
+	var (
+		prevHash string
+		nextID   uint64 = 1
+	)
+
+	// Records are verified page by page to not load whole log into memory and lock audit log
+	for nextID <= lastID {
+		records, err := audit.storage.GetCryptoAuditRecords(Filter{Offset: nextID - 1, Limit: verifyPageSize})
+		if err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		for _, record := range records {
+			if record.ID > lastID {
+				break
+			}
+
+			if record.ID != nextID {
+				return aoserrors.Errorf("audit record %d is missing", nextID)
+			}
+
+			if record.PrevHash != prevHash {
+				return aoserrors.Errorf("audit record %d chain is broken", record.ID)
+			}
+
+			hash, err := calculateHash(record)
+			if err != nil {
+				return aoserrors.Wrap(err)
+			}
+
+			if hash != record.Hash {
+				return aoserrors.Errorf("audit record %d hash mismatch", record.ID)
+			}
+
+			prevHash = record.Hash
+			nextID++
+		}
+
+		if len(records) < verifyPageSize {
+			break
+		}
+	}
+
+	if prevHash != lastHash {
+		return aoserrors.New("audit log is truncated")
+	}
+
+	return nil
+}
+
+// Export exports audit log records in JSON format
+func (audit *Audit) Export(filter Filter) (data []byte, err error) {
+	records, err := audit.GetRecords(filter)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	if records == nil {
+		records = make([]Record, 0)
+	}
+
+	if data, err = json.Marshal(records); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return data, nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// initAnchor loads anchor key and checks that the last record is anchored. Log without anchor is anchored only if
+// it is empty or the anchor key is just created.
+func (audit *Audit) initAnchor(keyFile string, keyWrapper KeyWrapper) (err error) {
+	key, created, err := getAnchorKey(keyFile, keyWrapper)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	anchor, err := audit.storage.GetCryptoAuditAnchor()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	switch {
+	case audit.lastID == 0:
+
+	case anchor == "" && created:
+		log.Warn("Anchor existing crypto audit log with new key")
+
+		if err = audit.storage.SetCryptoAuditAnchor(calculateAnchor(key, audit.lastID, audit.lastHash)); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+	case anchor == "":
+		return aoserrors.New("audit log anchor is missing")
+
+	case !hmac.Equal([]byte(anchor), []byte(calculateAnchor(key, audit.lastID, audit.lastHash))):
+		return aoserrors.New("audit log anchor mismatch")
+	}
+
+	audit.anchorKey = key
+
+	return nil
+}
+
+// getAnchorKey reads and unwraps anchor key. New key is generated only if the key file doesn't exist.
+func getAnchorKey(keyFile string, keyWrapper KeyWrapper) (key []byte, created bool, err error) {
+	wrappedKey, err := ioutil.ReadFile(keyFile)
+	if err == nil {
+		if key, err = keyWrapper.UnwrapKey(wrappedKey); err != nil {
+			return nil, false, aoserrors.Wrap(err)
+		}
+
+		if len(key) != anchorKeySize {
+			return nil, false, aoserrors.Errorf("invalid anchor key size: %d", len(key))
+		}
+
+		return key, false, nil
+	}
+
+	if !os.IsNotExist(err) {
+		return nil, false, aoserrors.Wrap(err)
+	}
+
+	log.WithField("file", keyFile).Info("Generate crypto audit anchor key")
+
+	key = make([]byte, anchorKeySize)
+
+	if _, err = io.ReadFull(rand.Reader, key); err != nil {
+		return nil, false, aoserrors.Wrap(err)
+	}
+
+	if wrappedKey, err = keyWrapper.WrapKey(key); err != nil {
+		return nil, false, aoserrors.Wrap(err)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(keyFile), 0755); err != nil {
+		return nil, false, aoserrors.Wrap(err)
+	}
+
+	if err = ioutil.WriteFile(keyFile, wrappedKey, 0600); err != nil {
+		return nil, false, aoserrors.Wrap(err)
+	}
+
+	return key, true, nil
+}
+
+func calculateAnchor(key []byte, id uint64, hash string) (anchor string) {
+	mac := hmac.New(sha256.New, key)
+
+	fmt.Fprintf(mac, "%d:%s", id, hash)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func calculateHash(record Record) (hash string, err error) {
+	record.Hash = ""
+	record.Timestamp = record.Timestamp.UTC()
+
+	if len(record.Fingerprints) == 0 {
+		record.Fingerprints = nil
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return "", aoserrors.Wrap(err)
+	}
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
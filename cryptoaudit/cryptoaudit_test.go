@@ -0,0 +1,333 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptoaudit_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"aos_communicationmanager/cryptoaudit"
+	"aos_communicationmanager/fcrypt"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type testStorage struct {
+	records []cryptoaudit.Record
+	anchor  string
+}
+
+type testKeyWrapper struct {
+	unwrapError error
+}
+
+/***********************************************************************************************************************
+ * Variables
+ **********************************************************************************************************************/
+
+var tmpDir string
+
+/***********************************************************************************************************************
+ * Init
+ **********************************************************************************************************************/
+
+func init() {
+	log.SetFormatter(&log.TextFormatter{
+		DisableTimestamp: false,
+		TimestampFormat:  "2006-01-02 15:04:05.000",
+		FullTimestamp:    true})
+	log.SetLevel(log.DebugLevel)
+	log.SetOutput(os.Stdout)
+}
+
+/***********************************************************************************************************************
+ * Main
+ **********************************************************************************************************************/
+
+func TestMain(m *testing.M) {
+	var err error
+
+	if tmpDir, err = ioutil.TempDir("", "cm_"); err != nil {
+		log.Fatalf("Error creating tmp dir: %s", err)
+	}
+
+	ret := m.Run()
+
+	if err = os.RemoveAll(tmpDir); err != nil {
+		log.Fatalf("Error removing tmp dir: %s", err)
+	}
+
+	os.Exit(ret)
+}
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestHashChain(t *testing.T) {
+	storage := &testStorage{}
+	keyFile := filepath.Join(tmpDir, "chain.key")
+
+	audit, err := cryptoaudit.New(keyFile, storage, &testKeyWrapper{})
+	if err != nil {
+		t.Fatalf("Can't create audit: %s", err)
+	}
+
+	audit.LogCryptoOperation(fcrypt.AuditRecord{
+		Timestamp: time.Now(), Operation: fcrypt.AuditDecryptMetadata, Result: fcrypt.AuditResultSuccess})
+	audit.LogCryptoOperation(fcrypt.AuditRecord{
+		Timestamp: time.Now(), Operation: fcrypt.AuditImportSessionKey, PackageID: "service0",
+		Result: fcrypt.AuditResultFailed, Error: "unknown algorithm"})
+
+	// Recreate audit to check it continues existing chain
+
+	if audit, err = cryptoaudit.New(keyFile, storage, &testKeyWrapper{}); err != nil {
+		t.Fatalf("Can't create audit: %s", err)
+	}
+
+	audit.LogCryptoOperation(fcrypt.AuditRecord{
+		Timestamp: time.Now(), Operation: fcrypt.AuditVerifySign, PackageID: "service0", ChainName: "chain",
+		Fingerprints: []string{"01", "02"}, Result: fcrypt.AuditResultSuccess})
+
+	if len(storage.records) != 3 {
+		t.Fatalf("Wrong records count: %d", len(storage.records))
+	}
+
+	for i, record := range storage.records {
+		if record.ID != uint64(i+1) {
+			t.Errorf("Wrong record ID: %d", record.ID)
+		}
+
+		if i > 0 && record.PrevHash != storage.records[i-1].Hash {
+			t.Errorf("Record %d is not chained", record.ID)
+		}
+	}
+
+	if err = audit.Verify(); err != nil {
+		t.Errorf("Verification failed: %s", err)
+	}
+
+	data, err := audit.Export(cryptoaudit.Filter{})
+	if err != nil {
+		t.Fatalf("Can't export audit log: %s", err)
+	}
+
+	var exportedRecords []cryptoaudit.Record
+
+	if err = json.Unmarshal(data, &exportedRecords); err != nil {
+		t.Fatalf("Can't unmarshal exported records: %s", err)
+	}
+
+	if len(exportedRecords) != len(storage.records) {
+		t.Errorf("Wrong exported records count: %d", len(exportedRecords))
+	}
+
+	storage.records = storage.records[:2]
+
+	if err = audit.Verify(); err == nil {
+		t.Error("Verification should fail on truncated log")
+	}
+}
+
+func TestVerifyLargeLog(t *testing.T) {
+	storage := &testStorage{}
+
+	audit, err := cryptoaudit.New(filepath.Join(tmpDir, "large.key"), storage, &testKeyWrapper{})
+	if err != nil {
+		t.Fatalf("Can't create audit: %s", err)
+	}
+
+	for i := 0; i < 2500; i++ {
+		audit.LogCryptoOperation(fcrypt.AuditRecord{
+			Timestamp: time.Now(), Operation: fcrypt.AuditDecryptMetadata, Result: fcrypt.AuditResultSuccess})
+	}
+
+	if err = audit.Verify(); err != nil {
+		t.Errorf("Verification failed: %s", err)
+	}
+
+	storage.records = append(storage.records[:1500], storage.records[1501:]...)
+
+	if err = audit.Verify(); err == nil {
+		t.Error("Verification should fail on missing record")
+	}
+}
+
+func TestAnchor(t *testing.T) {
+	storage := &testStorage{}
+	keyFile := filepath.Join(tmpDir, "anchor.key")
+
+	audit, err := cryptoaudit.New(keyFile, storage, &testKeyWrapper{})
+	if err != nil {
+		t.Fatalf("Can't create audit: %s", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		audit.LogCryptoOperation(fcrypt.AuditRecord{
+			Timestamp: time.Now(), Operation: fcrypt.AuditImportSessionKey, PackageID: "service0",
+			Subject: "desiredStatus/services", Result: fcrypt.AuditResultSuccess})
+	}
+
+	if storage.anchor == "" {
+		t.Fatal("Audit log is not anchored")
+	}
+
+	// Rewrite whole chain without anchor key
+
+	forgedStorage := &testStorage{}
+
+	forgedAudit, err := cryptoaudit.New(filepath.Join(tmpDir, "forged.key"), forgedStorage, &testKeyWrapper{})
+	if err != nil {
+		t.Fatalf("Can't create audit: %s", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		forgedAudit.LogCryptoOperation(fcrypt.AuditRecord{
+			Timestamp: time.Now(), Operation: fcrypt.AuditImportSessionKey, PackageID: "service0",
+			Subject: "desiredStatus/services", Result: fcrypt.AuditResultFailed})
+	}
+
+	storage.records = forgedStorage.records
+
+	if err = audit.Verify(); err == nil {
+		t.Error("Verification should fail on rewritten log")
+	}
+
+	if audit, err = cryptoaudit.New(keyFile, storage, &testKeyWrapper{}); err != nil {
+		t.Fatalf("Can't create audit: %s", err)
+	}
+
+	if err = audit.Verify(); err == nil {
+		t.Error("Verification should fail on rewritten log")
+	}
+
+	// Anchor can't be removed to reanchor the log
+
+	storage.anchor = ""
+
+	if audit, err = cryptoaudit.New(keyFile, storage, &testKeyWrapper{}); err != nil {
+		t.Fatalf("Can't create audit: %s", err)
+	}
+
+	if err = audit.Verify(); err == nil {
+		t.Error("Verification should fail on missing anchor")
+	}
+}
+
+func TestAnchorKeyUnwrapError(t *testing.T) {
+	storage := &testStorage{}
+	keyFile := filepath.Join(tmpDir, "unwrap.key")
+
+	if _, err := cryptoaudit.New(keyFile, storage, &testKeyWrapper{}); err != nil {
+		t.Fatalf("Can't create audit: %s", err)
+	}
+
+	wrappedKey, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		t.Fatalf("Can't read key file: %s", err)
+	}
+
+	audit, err := cryptoaudit.New(keyFile, storage, &testKeyWrapper{unwrapError: errors.New("unwrap error")})
+	if err != nil {
+		t.Fatalf("Can't create audit: %s", err)
+	}
+
+	audit.LogCryptoOperation(fcrypt.AuditRecord{
+		Timestamp: time.Now(), Operation: fcrypt.AuditDecryptMetadata, Result: fcrypt.AuditResultSuccess})
+
+	if err = audit.Verify(); err == nil {
+		t.Error("Verification should fail without anchor key")
+	}
+
+	data, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		t.Fatalf("Can't read key file: %s", err)
+	}
+
+	if !bytes.Equal(data, wrappedKey) {
+		t.Error("Anchor key should not be regenerated")
+	}
+}
+
+/***********************************************************************************************************************
+ * Interfaces
+ **********************************************************************************************************************/
+
+func (storage *testStorage) AddCryptoAuditRecord(record cryptoaudit.Record, anchor string) (err error) {
+	storage.records = append(storage.records, record)
+
+	if anchor != "" {
+		storage.anchor = anchor
+	}
+
+	return nil
+}
+
+func (storage *testStorage) SetCryptoAuditAnchor(anchor string) (err error) {
+	storage.anchor = anchor
+
+	return nil
+}
+
+func (storage *testStorage) GetCryptoAuditAnchor() (anchor string, err error) {
+	return storage.anchor, nil
+}
+
+func (storage *testStorage) GetLastCryptoAuditRecord() (record cryptoaudit.Record, err error) {
+	if len(storage.records) == 0 {
+		return record, nil
+	}
+
+	return storage.records[len(storage.records)-1], nil
+}
+
+func (storage *testStorage) GetCryptoAuditRecords(filter cryptoaudit.Filter) (records []cryptoaudit.Record, err error) {
+	if filter.Offset >= uint64(len(storage.records)) {
+		return nil, nil
+	}
+
+	records = storage.records[filter.Offset:]
+
+	if filter.Limit != 0 && filter.Limit < uint64(len(records)) {
+		records = records[:filter.Limit]
+	}
+
+	return records, nil
+}
+
+func (wrapper *testKeyWrapper) WrapKey(key []byte) (wrapped []byte, err error) {
+	return append([]byte("wrapped:"), key...), nil
+}
+
+func (wrapper *testKeyWrapper) UnwrapKey(wrapped []byte) (key []byte, err error) {
+	if wrapper.unwrapError != nil {
+		return nil, wrapper.unwrapError
+	}
+
+	return bytes.TrimPrefix(wrapped, []byte("wrapped:")), nil
+}
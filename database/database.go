@@ -25,14 +25,22 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/aoscloud/aos_common/aoserrors"
 	"github.com/aoscloud/aos_common/migration"
 	_ "github.com/mattn/go-sqlite3" //ignore lint
 	log "github.com/sirupsen/logrus"
 
+	"aos_communicationmanager/certmanager"
+	"aos_communicationmanager/cloudprotocol"
 	"aos_communicationmanager/config"
+	"aos_communicationmanager/cryptoaudit"
+	"aos_communicationmanager/provisioning"
+	"aos_communicationmanager/smcontroller"
 	"aos_communicationmanager/umcontroller"
+	"aos_communicationmanager/updatehistory"
 )
 
 /***********************************************************************************************************************
@@ -117,9 +125,68 @@ func New(config *config.Config) (db *Database, err error) {
 		}
 	}
 
+	if err = db.createIAMInfoTable(); err != nil {
+		return db, aoserrors.Wrap(err)
+	}
+
+	if err = db.createCertRenewalsTable(); err != nil {
+		return db, aoserrors.Wrap(err)
+	}
+
+	if err = db.createOwnerPasswordTable(); err != nil {
+		return db, aoserrors.Wrap(err)
+	}
+
+	if err = db.createCryptoAuditTable(); err != nil {
+		return db, aoserrors.Wrap(err)
+	}
+
+	if err = db.createProvisioningTable(); err != nil {
+		return db, aoserrors.Wrap(err)
+	}
+
+	if err = db.createServicePlacementsTable(); err != nil {
+		return db, aoserrors.Wrap(err)
+	}
+
+	if err = db.createServiceStatesTable(); err != nil {
+		return db, aoserrors.Wrap(err)
+	}
+
+	if err = db.createOverrideEnvVarsTable(); err != nil {
+		return db, aoserrors.Wrap(err)
+	}
+
+	if err = db.createUpdateHistoryTable(); err != nil {
+		return db, aoserrors.Wrap(err)
+	}
+
+	if err = db.createUpdateManagersTable(); err != nil {
+		return db, aoserrors.Wrap(err)
+	}
+
 	return db, nil
 }
 
+// NewReadOnly opens existing database in read only mode. Migration is not performed and tables are not created, so
+// the database can be inspected while it is used by running CM.
+func NewReadOnly(config *config.Config) (db *Database, err error) {
+	fileName := path.Join(config.WorkingDir, dbFileName)
+
+	log.WithField("fileName", fileName).Debug("Open database read only")
+
+	if _, err = os.Stat(fileName); err != nil {
+		return db, aoserrors.Wrap(err)
+	}
+
+	sqlite, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro&_busy_timeout=%d", fileName, busyTimeout))
+	if err != nil {
+		return db, aoserrors.Wrap(err)
+	}
+
+	return &Database{sqlite}, nil
+}
+
 // SetJournalCursor stores system logger cursor
 func (db *Database) SetJournalCursor(cursor string) (err error) {
 	result, err := db.sql.Exec("UPDATE config SET cursor = ?", cursor)
@@ -216,6 +283,58 @@ func (db *Database) GetComponentsUpdateInfo() (updateInfo []umcontroller.SystemC
 	return updateInfo, nil
 }
 
+// SetUMInfo stores update manager components and deferred components
+func (db *Database) SetUMInfo(info umcontroller.UMInfo) (err error) {
+	components, err := json.Marshal(info.Components)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	deferredComponents, err := json.Marshal(info.DeferredComponents)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if _, err = db.sql.Exec("REPLACE INTO updateManagers values(?, ?, ?)",
+		info.UMID, components, deferredComponents); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+// GetUMInfos returns stored info of all update managers
+func (db *Database) GetUMInfos() (infos []umcontroller.UMInfo, err error) {
+	rows, err := db.sql.Query("SELECT * FROM updateManagers")
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			info                           umcontroller.UMInfo
+			components, deferredComponents []byte
+		)
+
+		if err = rows.Scan(&info.UMID, &components, &deferredComponents); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		if err = json.Unmarshal(components, &info.Components); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		if err = json.Unmarshal(deferredComponents, &info.DeferredComponents); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos, aoserrors.Wrap(rows.Err())
+}
+
 // SetFirmwareUpdateState sets FOTA update state
 func (db *Database) SetFirmwareUpdateState(state json.RawMessage) (err error) {
 	result, err := db.sql.Exec("UPDATE config SET fotaUpdateState = ?", state)
@@ -292,45 +411,1017 @@ func (db *Database) GetSoftwareUpdateState() (state json.RawMessage, err error)
 	return state, nil
 }
 
-// Close closes database
-func (db *Database) Close() {
-	db.sql.Close()
+// SetSystemID stores system ID received from IAM
+func (db *Database) SetSystemID(systemID string) (err error) {
+	result, err := db.sql.Exec("UPDATE iamInfo SET systemID = ?", systemID)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if count == 0 {
+		return errNotExist
+	}
+
+	return nil
 }
 
-/***********************************************************************************************************************
- * Private
- **********************************************************************************************************************/
+// GetSystemID returns stored system ID
+func (db *Database) GetSystemID() (systemID string, err error) {
+	stmt, err := db.sql.Prepare("SELECT systemID FROM iamInfo")
+	if err != nil {
+		return systemID, aoserrors.Wrap(err)
+	}
+	defer stmt.Close()
 
-func (db *Database) isTableExist(name string) (result bool, err error) {
-	rows, err := db.sql.Query("SELECT * FROM sqlite_master WHERE name = ? and type='table'", name)
+	if err = stmt.QueryRow().Scan(&systemID); err != nil {
+		if err == sql.ErrNoRows {
+			return systemID, errNotExist
+		}
+
+		return systemID, aoserrors.Wrap(err)
+	}
+
+	return systemID, nil
+}
+
+// SetUsers stores users received from IAM
+func (db *Database) SetUsers(users []string) (err error) {
+	usersJSON, err := json.Marshal(users)
 	if err != nil {
-		return false, aoserrors.Wrap(err)
+		return aoserrors.Wrap(err)
+	}
+
+	result, err := db.sql.Exec("UPDATE iamInfo SET users = ?", usersJSON)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if count == 0 {
+		return errNotExist
+	}
+
+	return nil
+}
+
+// GetUsers returns stored users
+func (db *Database) GetUsers() (users []string, err error) {
+	stmt, err := db.sql.Prepare("SELECT users FROM iamInfo")
+	if err != nil {
+		return users, aoserrors.Wrap(err)
+	}
+	defer stmt.Close()
+
+	var usersJSON []byte
+
+	if err = stmt.QueryRow().Scan(&usersJSON); err != nil {
+		if err == sql.ErrNoRows {
+			return users, errNotExist
+		}
+
+		return users, aoserrors.Wrap(err)
+	}
+
+	if len(usersJSON) == 0 {
+		return users, nil
+	}
+
+	if err = json.Unmarshal(usersJSON, &users); err != nil {
+		return users, aoserrors.Wrap(err)
+	}
+
+	return users, nil
+}
+
+// SetCertRenewal stores pending certificate renewal
+func (db *Database) SetCertRenewal(renewal certmanager.RenewalInfo) (err error) {
+	if _, err = db.sql.Exec("REPLACE INTO certRenewals values(?, ?, ?, ?, ?, ?)", renewal.Type, renewal.Serial,
+		renewal.ValidTill, renewal.Attempt, renewal.NextAttempt, renewal.FailureAlerted); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+// GetCertRenewals returns all pending certificate renewals
+func (db *Database) GetCertRenewals() (renewals []certmanager.RenewalInfo, err error) {
+	rows, err := db.sql.Query("SELECT * FROM certRenewals")
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
 	}
 	defer rows.Close()
 
-	result = rows.Next()
+	for rows.Next() {
+		var renewal certmanager.RenewalInfo
 
-	return result, aoserrors.Wrap(rows.Err())
+		if err = rows.Scan(&renewal.Type, &renewal.Serial, &renewal.ValidTill,
+			&renewal.Attempt, &renewal.NextAttempt, &renewal.FailureAlerted); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		renewals = append(renewals, renewal)
+	}
+
+	return renewals, aoserrors.Wrap(rows.Err())
 }
 
-func (db *Database) createConfigTable() (err error) {
-	log.Info("Create config table")
+// RemoveCertRenewal removes pending certificate renewal
+func (db *Database) RemoveCertRenewal(certType string) (err error) {
+	result, err := db.sql.Exec("DELETE FROM certRenewals WHERE type = ?", certType)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
 
-	if _, err = db.sql.Exec(
-		`CREATE TABLE config (
-			cursor TEXT,
-			componentsUpdateInfo BLOB,
-			fotaUpdateState BLOB,
-			sotaUpdateState BLOB)`); err != nil {
+	count, err := result.RowsAffected()
+	if err != nil {
 		return aoserrors.Wrap(err)
 	}
 
-	if _, err = db.sql.Exec(
-		`INSERT INTO config (
-			cursor,
-			componentsUpdateInfo,
-			fotaUpdateState,
-			sotaUpdateState) values(?, ?, ?, ?)`, "", "", json.RawMessage{}, json.RawMessage{}); err != nil {
+	if count == 0 {
+		return errNotExist
+	}
+
+	return nil
+}
+
+// SetOwnerPassword stores wrapped owner password
+func (db *Database) SetOwnerPassword(wrappedPassword []byte) (err error) {
+	if _, err = db.sql.Exec("REPLACE INTO ownerPassword values(?, ?)", 0, wrappedPassword); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+// GetOwnerPassword returns wrapped owner password
+func (db *Database) GetOwnerPassword() (wrappedPassword []byte, err error) {
+	if err = db.sql.QueryRow("SELECT password FROM ownerPassword WHERE id = 0").Scan(
+		&wrappedPassword); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return wrappedPassword, nil
+}
+
+// AddCryptoAuditRecord adds crypto audit record and updates audit log anchor
+func (db *Database) AddCryptoAuditRecord(record cryptoaudit.Record, anchor string) (err error) {
+	fingerprints, err := json.Marshal(record.Fingerprints)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	tx, err := db.sql.Begin()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec("INSERT INTO cryptoAudit values(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		record.ID, record.Timestamp, record.Operation, record.PackageID, record.Subject, record.ChainName,
+		fingerprints, record.Algorithm, record.Result, record.Error, record.PrevHash, record.Hash); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if anchor != "" {
+		if _, err = tx.Exec("REPLACE INTO cryptoAuditAnchor values(?, ?)", 0, anchor); err != nil {
+			return aoserrors.Wrap(err)
+		}
+	}
+
+	return aoserrors.Wrap(tx.Commit())
+}
+
+// SetCryptoAuditAnchor sets crypto audit log anchor
+func (db *Database) SetCryptoAuditAnchor(anchor string) (err error) {
+	if _, err = db.sql.Exec("REPLACE INTO cryptoAuditAnchor values(?, ?)", 0, anchor); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+// GetCryptoAuditAnchor returns crypto audit log anchor or empty string if log is not anchored
+func (db *Database) GetCryptoAuditAnchor() (anchor string, err error) {
+	if err = db.sql.QueryRow("SELECT anchor FROM cryptoAuditAnchor WHERE id = 0").Scan(&anchor); err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+
+		return "", aoserrors.Wrap(err)
+	}
+
+	return anchor, nil
+}
+
+// GetLastCryptoAuditRecord returns last crypto audit record or empty record if audit log is empty
+func (db *Database) GetLastCryptoAuditRecord() (record cryptoaudit.Record, err error) {
+	records, err := db.getCryptoAuditRecords("SELECT * FROM cryptoAudit ORDER BY id DESC LIMIT 1")
+	if err != nil {
+		return record, aoserrors.Wrap(err)
+	}
+
+	if len(records) == 0 {
+		return record, nil
+	}
+
+	return records[0], nil
+}
+
+// GetCryptoAuditRecords returns crypto audit records
+func (db *Database) GetCryptoAuditRecords(filter cryptoaudit.Filter) (records []cryptoaudit.Record, err error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+
+	if filter.Operation != "" {
+		conditions = append(conditions, "operation = ?")
+		args = append(args, filter.Operation)
+	}
+
+	if filter.PackageID != "" {
+		conditions = append(conditions, "packageID = ?")
+		args = append(args, filter.PackageID)
+	}
+
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, filter.From.UTC())
+	}
+
+	if !filter.Till.IsZero() {
+		conditions = append(conditions, "timestamp < ?")
+		args = append(args, filter.Till.UTC())
+	}
+
+	query := "SELECT * FROM cryptoAudit"
+
+	if len(conditions) != 0 {
+		query = query + " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query = query + " ORDER BY id"
+
+	if filter.Limit != 0 || filter.Offset != 0 {
+		limit := int64(-1)
+
+		if filter.Limit != 0 {
+			limit = int64(filter.Limit)
+		}
+
+		query = query + " LIMIT ? OFFSET ?"
+		args = append(args, limit, filter.Offset)
+	}
+
+	if records, err = db.getCryptoAuditRecords(query, args...); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return records, nil
+}
+
+// SetUpdateHistoryRecord adds or updates update history record
+func (db *Database) SetUpdateHistoryRecord(record updatehistory.Record) (err error) {
+	items, err := json.Marshal(record.Items)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	phases, err := json.Marshal(record.Phases)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if _, err = db.sql.Exec("INSERT OR REPLACE INTO updateHistory values(?, ?, ?, ?, ?, ?, ?, ?)",
+		record.ID, record.UpdateType, record.DesiredStatusHash, items, phases, record.Started.UTC(),
+		record.Finished.UTC(), record.Error); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+// RemoveUpdateHistoryRecords removes update history records with ID less than beforeID or started before
+// startedBefore. Zero arguments are ignored.
+func (db *Database) RemoveUpdateHistoryRecords(beforeID uint64, startedBefore time.Time) (err error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+
+	if beforeID != 0 {
+		conditions = append(conditions, "id < ?")
+		args = append(args, beforeID)
+	}
+
+	if !startedBefore.IsZero() {
+		conditions = append(conditions, "started < ?")
+		args = append(args, startedBefore.UTC())
+	}
+
+	if len(conditions) == 0 {
+		return nil
+	}
+
+	if _, err = db.sql.Exec("DELETE FROM updateHistory WHERE "+strings.Join(conditions, " OR "), args...); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+// GetUpdateHistoryRecords returns update history records starting from the latest one
+func (db *Database) GetUpdateHistoryRecords(filter updatehistory.Filter) (records []updatehistory.Record, err error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+
+	if filter.UpdateType != "" {
+		conditions = append(conditions, "updateType = ?")
+		args = append(args, filter.UpdateType)
+	}
+
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "started >= ?")
+		args = append(args, filter.From.UTC())
+	}
+
+	if !filter.Till.IsZero() {
+		conditions = append(conditions, "started < ?")
+		args = append(args, filter.Till.UTC())
+	}
+
+	query := "SELECT * FROM updateHistory"
+
+	if len(conditions) != 0 {
+		query = query + " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query = query + " ORDER BY id DESC"
+
+	if filter.Limit != 0 || filter.Offset != 0 {
+		limit := int64(-1)
+
+		if filter.Limit != 0 {
+			limit = int64(filter.Limit)
+		}
+
+		query = query + " LIMIT ? OFFSET ?"
+		args = append(args, limit, filter.Offset)
+	}
+
+	rows, err := db.sql.Query(query, args...)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			record        updatehistory.Record
+			items, phases []byte
+		)
+
+		if err = rows.Scan(&record.ID, &record.UpdateType, &record.DesiredStatusHash, &items, &phases,
+			&record.Started, &record.Finished, &record.Error); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		if err = json.Unmarshal(items, &record.Items); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		if err = json.Unmarshal(phases, &record.Phases); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		records = append(records, record)
+	}
+
+	return records, aoserrors.Wrap(rows.Err())
+}
+
+// Close closes database
+func (db *Database) Close() {
+	db.sql.Close()
+}
+
+// SetProvisioningState stores provisioning state
+func (db *Database) SetProvisioningState(state provisioning.State) (err error) {
+	certTypes, err := json.Marshal(state.CertTypes)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	pendingCerts, err := json.Marshal(state.PendingCerts)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	result, err := db.sql.Exec("UPDATE provisioning SET step = ?, certTypes = ?, pendingCerts = ?",
+		state.Step, certTypes, pendingCerts)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if count == 0 {
+		return errNotExist
+	}
+
+	return nil
+}
+
+// GetProvisioningState returns stored provisioning state
+func (db *Database) GetProvisioningState() (state provisioning.State, err error) {
+	stmt, err := db.sql.Prepare("SELECT step, certTypes, pendingCerts FROM provisioning")
+	if err != nil {
+		return state, aoserrors.Wrap(err)
+	}
+	defer stmt.Close()
+
+	var certTypes, pendingCerts []byte
+
+	if err = stmt.QueryRow().Scan(&state.Step, &certTypes, &pendingCerts); err != nil {
+		if err == sql.ErrNoRows {
+			return state, errNotExist
+		}
+
+		return state, aoserrors.Wrap(err)
+	}
+
+	if len(certTypes) != 0 {
+		if err = json.Unmarshal(certTypes, &state.CertTypes); err != nil {
+			return state, aoserrors.Wrap(err)
+		}
+	}
+
+	if len(pendingCerts) != 0 {
+		if err = json.Unmarshal(pendingCerts, &state.PendingCerts); err != nil {
+			return state, aoserrors.Wrap(err)
+		}
+	}
+
+	return state, nil
+}
+
+// SetServicePlacement stores service placement
+func (db *Database) SetServicePlacement(placement smcontroller.ServicePlacement) (err error) {
+	smIDs, err := json.Marshal(placement.SMIDs)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	layers, err := json.Marshal(placement.Layers)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	users, err := json.Marshal(placement.Users)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	serviceInfo, err := json.Marshal(placement.ServiceInfo)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if _, err = db.sql.Exec("REPLACE INTO servicePlacements values(?, ?, ?, ?, ?)",
+		placement.ServiceID, smIDs, layers, users, serviceInfo); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+// GetServicePlacements returns all service placements
+func (db *Database) GetServicePlacements() (placements []smcontroller.ServicePlacement, err error) {
+	rows, err := db.sql.Query("SELECT * FROM servicePlacements")
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			placement                         smcontroller.ServicePlacement
+			smIDs, layers, users, serviceInfo []byte
+		)
+
+		if err = rows.Scan(&placement.ServiceID, &smIDs, &layers, &users, &serviceInfo); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		if err = json.Unmarshal(users, &placement.Users); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		if err = json.Unmarshal(serviceInfo, &placement.ServiceInfo); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		if err = json.Unmarshal(smIDs, &placement.SMIDs); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		if err = json.Unmarshal(layers, &placement.Layers); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		placements = append(placements, placement)
+	}
+
+	return placements, aoserrors.Wrap(rows.Err())
+}
+
+// SetLayerPlacement stores layer placement
+func (db *Database) SetLayerPlacement(placement smcontroller.LayerPlacement) (err error) {
+	smIDs, err := json.Marshal(placement.SMIDs)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	layerInfo, err := json.Marshal(placement.LayerInfo)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if _, err = db.sql.Exec("REPLACE INTO layerPlacements values(?, ?, ?)",
+		placement.Digest, smIDs, layerInfo); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+// GetLayerPlacements returns all layer placements
+func (db *Database) GetLayerPlacements() (placements []smcontroller.LayerPlacement, err error) {
+	rows, err := db.sql.Query("SELECT * FROM layerPlacements")
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			placement        smcontroller.LayerPlacement
+			smIDs, layerInfo []byte
+		)
+
+		if err = rows.Scan(&placement.Digest, &smIDs, &layerInfo); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		if err = json.Unmarshal(smIDs, &placement.SMIDs); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		if err = json.Unmarshal(layerInfo, &placement.LayerInfo); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		placements = append(placements, placement)
+	}
+
+	return placements, aoserrors.Wrap(rows.Err())
+}
+
+// RemoveServicePlacement removes service placement
+func (db *Database) RemoveServicePlacement(serviceID string) (err error) {
+	result, err := db.sql.Exec("DELETE FROM servicePlacements WHERE serviceID = ?", serviceID)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if count == 0 {
+		return errNotExist
+	}
+
+	return nil
+}
+
+// SetServiceStateBackup stores service state backup
+func (db *Database) SetServiceStateBackup(backup smcontroller.ServiceStateBackup) (err error) {
+	users, err := json.Marshal(backup.Users)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if _, err = db.sql.Exec("REPLACE INTO serviceStates values(?, ?, ?, ?)",
+		backup.ServiceID, users, backup.Checksum, backup.State); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+// GetServiceStateBackup returns service state backup
+func (db *Database) GetServiceStateBackup(
+	serviceID string, users []string) (backup smcontroller.ServiceStateBackup, err error) {
+	usersJSON, err := json.Marshal(users)
+	if err != nil {
+		return backup, aoserrors.Wrap(err)
+	}
+
+	stmt, err := db.sql.Prepare("SELECT checksum, state FROM serviceStates WHERE serviceID = ? AND users = ?")
+	if err != nil {
+		return backup, aoserrors.Wrap(err)
+	}
+	defer stmt.Close()
+
+	if err = stmt.QueryRow(serviceID, usersJSON).Scan(&backup.Checksum, &backup.State); err != nil {
+		if err == sql.ErrNoRows {
+			return backup, errNotExist
+		}
+
+		return backup, aoserrors.Wrap(err)
+	}
+
+	backup.ServiceID = serviceID
+	backup.Users = users
+
+	return backup, nil
+}
+
+// RemoveServiceStateBackup removes service state backup
+func (db *Database) RemoveServiceStateBackup(serviceID string, users []string) (err error) {
+	usersJSON, err := json.Marshal(users)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	result, err := db.sql.Exec("DELETE FROM serviceStates WHERE serviceID = ? AND users = ?", serviceID, usersJSON)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if count == 0 {
+		return errNotExist
+	}
+
+	return nil
+}
+
+// SetOverrideEnvVars replaces active override env vars
+func (db *Database) SetOverrideEnvVars(envVars []cloudprotocol.OverrideEnvsFromCloud) (err error) {
+	tx, err := db.sql.Begin()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec("DELETE FROM overrideEnvVars"); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	for _, item := range envVars {
+		itemVars, err := json.Marshal(item.EnvVars)
+		if err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		if _, err = tx.Exec("REPLACE INTO overrideEnvVars values(?, ?, ?)",
+			item.ServiceID, item.SubjectID, itemVars); err != nil {
+			return aoserrors.Wrap(err)
+		}
+	}
+
+	return aoserrors.Wrap(tx.Commit())
+}
+
+// GetOverrideEnvVars returns active override env vars
+func (db *Database) GetOverrideEnvVars() (envVars []cloudprotocol.OverrideEnvsFromCloud, err error) {
+	rows, err := db.sql.Query("SELECT * FROM overrideEnvVars")
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			item     cloudprotocol.OverrideEnvsFromCloud
+			itemVars []byte
+		)
+
+		if err = rows.Scan(&item.ServiceID, &item.SubjectID, &itemVars); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		if err = json.Unmarshal(itemVars, &item.EnvVars); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		envVars = append(envVars, item)
+	}
+
+	return envVars, aoserrors.Wrap(rows.Err())
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (db *Database) isTableExist(name string) (result bool, err error) {
+	rows, err := db.sql.Query("SELECT * FROM sqlite_master WHERE name = ? and type='table'", name)
+	if err != nil {
+		return false, aoserrors.Wrap(err)
+	}
+	defer rows.Close()
+
+	result = rows.Next()
+
+	return result, aoserrors.Wrap(rows.Err())
+}
+
+func (db *Database) createConfigTable() (err error) {
+	log.Info("Create config table")
+
+	if _, err = db.sql.Exec(
+		`CREATE TABLE config (
+			cursor TEXT,
+			componentsUpdateInfo BLOB,
+			fotaUpdateState BLOB,
+			sotaUpdateState BLOB)`); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if _, err = db.sql.Exec(
+		`INSERT INTO config (
+			cursor,
+			componentsUpdateInfo,
+			fotaUpdateState,
+			sotaUpdateState) values(?, ?, ?, ?)`, "", "", json.RawMessage{}, json.RawMessage{}); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (db *Database) getCryptoAuditRecords(
+	query string, args ...interface{}) (records []cryptoaudit.Record, err error) {
+	rows, err := db.sql.Query(query, args...)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			record       cryptoaudit.Record
+			fingerprints []byte
+		)
+
+		if err = rows.Scan(&record.ID, &record.Timestamp, &record.Operation, &record.PackageID, &record.Subject,
+			&record.ChainName, &fingerprints, &record.Algorithm, &record.Result, &record.Error, &record.PrevHash,
+			&record.Hash); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		if err = json.Unmarshal(fingerprints, &record.Fingerprints); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		records = append(records, record)
+	}
+
+	return records, aoserrors.Wrap(rows.Err())
+}
+
+func (db *Database) createIAMInfoTable() (err error) {
+	exists, err := db.isTableExist("iamInfo")
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if exists {
+		return nil
+	}
+
+	log.Info("Create IAM info table")
+
+	if _, err = db.sql.Exec(
+		`CREATE TABLE iamInfo (
+			systemID TEXT,
+			users BLOB)`); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if _, err = db.sql.Exec("INSERT INTO iamInfo (systemID, users) values(?, ?)", "", []byte{}); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (db *Database) createCertRenewalsTable() (err error) {
+	log.Debug("Create cert renewals table")
+
+	if _, err = db.sql.Exec(
+		`CREATE TABLE IF NOT EXISTS certRenewals (
+			type TEXT NOT NULL PRIMARY KEY,
+			serial TEXT,
+			validTill TIMESTAMP,
+			attempt INTEGER,
+			nextAttempt TIMESTAMP,
+			failureAlerted INTEGER)`); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (db *Database) createOwnerPasswordTable() (err error) {
+	log.Debug("Create owner password table")
+
+	if _, err = db.sql.Exec(
+		`CREATE TABLE IF NOT EXISTS ownerPassword (
+			id INTEGER NOT NULL PRIMARY KEY,
+			password BLOB)`); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (db *Database) createCryptoAuditTable() (err error) {
+	log.Debug("Create crypto audit table")
+
+	if _, err = db.sql.Exec(
+		`CREATE TABLE IF NOT EXISTS cryptoAudit (
+			id INTEGER NOT NULL PRIMARY KEY,
+			timestamp TIMESTAMP,
+			operation TEXT,
+			packageID TEXT,
+			subject TEXT,
+			chainName TEXT,
+			fingerprints BLOB,
+			algorithm TEXT,
+			result TEXT,
+			error TEXT,
+			prevHash TEXT,
+			hash TEXT)`); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if _, err = db.sql.Exec(
+		`CREATE TABLE IF NOT EXISTS cryptoAuditAnchor (
+			id INTEGER NOT NULL PRIMARY KEY,
+			anchor TEXT)`); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (db *Database) createProvisioningTable() (err error) {
+	exists, err := db.isTableExist("provisioning")
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if exists {
+		return nil
+	}
+
+	log.Info("Create provisioning table")
+
+	if _, err = db.sql.Exec(
+		`CREATE TABLE provisioning (
+			step TEXT,
+			certTypes BLOB,
+			pendingCerts BLOB)`); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if _, err = db.sql.Exec("INSERT INTO provisioning (step, certTypes, pendingCerts) values(?, ?, ?)",
+		"", []byte{}, []byte{}); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (db *Database) createServicePlacementsTable() (err error) {
+	log.Debug("Create service placements table")
+
+	if _, err = db.sql.Exec(
+		`CREATE TABLE IF NOT EXISTS servicePlacements (
+			serviceID TEXT NOT NULL PRIMARY KEY,
+			smIDs BLOB,
+			layers BLOB,
+			users BLOB,
+			serviceInfo BLOB)`); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if _, err = db.sql.Exec(
+		`CREATE TABLE IF NOT EXISTS layerPlacements (
+			digest TEXT NOT NULL PRIMARY KEY,
+			smIDs BLOB,
+			layerInfo BLOB)`); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (db *Database) createServiceStatesTable() (err error) {
+	log.Debug("Create service states table")
+
+	if _, err = db.sql.Exec(
+		`CREATE TABLE IF NOT EXISTS serviceStates (
+			serviceID TEXT NOT NULL,
+			users BLOB NOT NULL,
+			checksum TEXT,
+			state BLOB,
+			PRIMARY KEY(serviceID, users))`); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (db *Database) createOverrideEnvVarsTable() (err error) {
+	log.Debug("Create override env vars table")
+
+	if _, err = db.sql.Exec(
+		`CREATE TABLE IF NOT EXISTS overrideEnvVars (
+			serviceID TEXT NOT NULL,
+			subjectID TEXT NOT NULL,
+			envVars BLOB,
+			PRIMARY KEY(serviceID, subjectID))`); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (db *Database) createUpdateHistoryTable() (err error) {
+	log.Debug("Create update history table")
+
+	if _, err = db.sql.Exec(
+		`CREATE TABLE IF NOT EXISTS updateHistory (
+			id INTEGER NOT NULL PRIMARY KEY,
+			updateType TEXT,
+			desiredStatusHash TEXT,
+			items BLOB,
+			phases BLOB,
+			started TIMESTAMP,
+			finished TIMESTAMP,
+			error TEXT)`); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (db *Database) createUpdateManagersTable() (err error) {
+	log.Debug("Create update managers table")
+
+	if _, err = db.sql.Exec(
+		`CREATE TABLE IF NOT EXISTS updateManagers (
+			umID TEXT NOT NULL PRIMARY KEY,
+			components BLOB,
+			deferredComponents BLOB)`); err != nil {
 		return aoserrors.Wrap(err)
 	}
 
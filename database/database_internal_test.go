@@ -21,17 +21,33 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
+	"aos_communicationmanager/certmanager"
+	"aos_communicationmanager/cloudprotocol"
 	"aos_communicationmanager/config"
+	"aos_communicationmanager/cryptoaudit"
+	"aos_communicationmanager/fcrypt"
+	"aos_communicationmanager/provisioning"
+	"aos_communicationmanager/smcontroller"
 	"aos_communicationmanager/umcontroller"
+	"aos_communicationmanager/updatehistory"
 )
 
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type testKeyWrapper struct{}
+
 /***********************************************************************************************************************
  * Variables
  **********************************************************************************************************************/
@@ -141,6 +157,43 @@ func TestComponentsUpdateInfo(t *testing.T) {
 	}
 }
 
+func TestUMInfos(t *testing.T) {
+	infos := []umcontroller.UMInfo{
+		{UMID: "um1", Components: []string{"component1", "component2"}},
+		{UMID: "um2", Components: []string{"component3"}, DeferredComponents: []umcontroller.SystemComponent{
+			{ID: "component3", VendorVersion: "v2", URL: "url3", Sha256: []byte{1, 2, 3}}}},
+	}
+
+	for _, info := range infos {
+		if err := db.SetUMInfo(info); err != nil {
+			t.Fatalf("Can't set UM info: %s", err)
+		}
+	}
+
+	getInfos, err := db.GetUMInfos()
+	if err != nil {
+		t.Fatalf("Can't get UM infos: %s", err)
+	}
+
+	if !reflect.DeepEqual(getInfos, infos) {
+		t.Errorf("Wrong UM infos: %v", getInfos)
+	}
+
+	infos[1].DeferredComponents = nil
+
+	if err = db.SetUMInfo(infos[1]); err != nil {
+		t.Fatalf("Can't set UM info: %s", err)
+	}
+
+	if getInfos, err = db.GetUMInfos(); err != nil {
+		t.Fatalf("Can't get UM infos: %s", err)
+	}
+
+	if !reflect.DeepEqual(getInfos, infos) {
+		t.Errorf("Wrong UM infos: %v", getInfos)
+	}
+}
+
 func TestSotaFotaState(t *testing.T) {
 	fotaState := json.RawMessage("fotaState")
 	sotaState := json.RawMessage("sotaState")
@@ -172,6 +225,567 @@ func TestSotaFotaState(t *testing.T) {
 	}
 }
 
+func TestIAMInfo(t *testing.T) {
+	systemID, err := db.GetSystemID()
+	if err != nil {
+		t.Fatalf("Can't get system ID: %s", err)
+	}
+
+	if systemID != "" {
+		t.Errorf("Wrong initial system ID: %s", systemID)
+	}
+
+	if err = db.SetSystemID("systemID"); err != nil {
+		t.Fatalf("Can't set system ID: %s", err)
+	}
+
+	if systemID, err = db.GetSystemID(); err != nil {
+		t.Fatalf("Can't get system ID: %s", err)
+	}
+
+	if systemID != "systemID" {
+		t.Errorf("Wrong system ID: %s", systemID)
+	}
+
+	setUsers := []string{"user1", "user2"}
+
+	if err = db.SetUsers(setUsers); err != nil {
+		t.Fatalf("Can't set users: %s", err)
+	}
+
+	getUsers, err := db.GetUsers()
+	if err != nil {
+		t.Fatalf("Can't get users: %s", err)
+	}
+
+	if !reflect.DeepEqual(setUsers, getUsers) {
+		t.Errorf("Wrong users: %v", getUsers)
+	}
+}
+
+func TestProvisioningState(t *testing.T) {
+	state, err := db.GetProvisioningState()
+	if err != nil {
+		t.Fatalf("Can't get provisioning state: %s", err)
+	}
+
+	if state.Step != provisioning.StepNotStarted {
+		t.Errorf("Wrong initial provisioning step: %s", state.Step)
+	}
+
+	setState := provisioning.State{
+		Step:         provisioning.StepCertsRequested,
+		CertTypes:    []string{"online", "offline"},
+		PendingCerts: []string{"offline"},
+	}
+
+	if err = db.SetProvisioningState(setState); err != nil {
+		t.Fatalf("Can't set provisioning state: %s", err)
+	}
+
+	getState, err := db.GetProvisioningState()
+	if err != nil {
+		t.Fatalf("Can't get provisioning state: %s", err)
+	}
+
+	if !reflect.DeepEqual(setState, getState) {
+		t.Errorf("Wrong provisioning state: %v", getState)
+	}
+}
+
+func TestServicePlacements(t *testing.T) {
+	placements := []smcontroller.ServicePlacement{
+		{ServiceID: "service1", SMIDs: []string{"sm1"}, Layers: []string{"digest1"}},
+		{ServiceID: "service2", SMIDs: []string{"sm1", "sm2"}, Layers: []string{}},
+	}
+
+	for _, placement := range placements {
+		if err := db.SetServicePlacement(placement); err != nil {
+			t.Fatalf("Can't set service placement: %s", err)
+		}
+	}
+
+	placements[0].SMIDs = []string{"sm2"}
+
+	if err := db.SetServicePlacement(placements[0]); err != nil {
+		t.Fatalf("Can't set service placement: %s", err)
+	}
+
+	getPlacements, err := db.GetServicePlacements()
+	if err != nil {
+		t.Fatalf("Can't get service placements: %s", err)
+	}
+
+	sort.Slice(getPlacements, func(i, j int) bool { return getPlacements[i].ServiceID < getPlacements[j].ServiceID })
+
+	if !reflect.DeepEqual(placements, getPlacements) {
+		t.Errorf("Wrong service placements: %v", getPlacements)
+	}
+
+	if err = db.RemoveServicePlacement("service1"); err != nil {
+		t.Fatalf("Can't remove service placement: %s", err)
+	}
+
+	if err = db.RemoveServicePlacement("service1"); err == nil {
+		t.Error("Error expected on removing non existing service placement")
+	}
+
+	if getPlacements, err = db.GetServicePlacements(); err != nil {
+		t.Fatalf("Can't get service placements: %s", err)
+	}
+
+	if !reflect.DeepEqual(getPlacements, placements[1:]) {
+		t.Errorf("Wrong service placements: %v", getPlacements)
+	}
+}
+
+func TestLayerPlacements(t *testing.T) {
+	placements := []smcontroller.LayerPlacement{
+		{Digest: "digest1", SMIDs: []string{"sm1"}, LayerInfo: cloudprotocol.LayerInfoFromCloud{ID: "layer1"}},
+		{Digest: "digest2", SMIDs: []string{"sm1", "sm2"}, LayerInfo: cloudprotocol.LayerInfoFromCloud{ID: "layer2"}},
+	}
+
+	for _, placement := range placements {
+		if err := db.SetLayerPlacement(placement); err != nil {
+			t.Fatalf("Can't set layer placement: %s", err)
+		}
+	}
+
+	placements[0].SMIDs = []string{"sm1", "sm2"}
+
+	if err := db.SetLayerPlacement(placements[0]); err != nil {
+		t.Fatalf("Can't set layer placement: %s", err)
+	}
+
+	getPlacements, err := db.GetLayerPlacements()
+	if err != nil {
+		t.Fatalf("Can't get layer placements: %s", err)
+	}
+
+	sort.Slice(getPlacements, func(i, j int) bool { return getPlacements[i].Digest < getPlacements[j].Digest })
+
+	if !reflect.DeepEqual(placements, getPlacements) {
+		t.Errorf("Wrong layer placements: %v", getPlacements)
+	}
+}
+
+func TestServiceStateBackups(t *testing.T) {
+	backups := []smcontroller.ServiceStateBackup{
+		{ServiceID: "service1", Users: []string{"user1"}, Checksum: "checksum1", State: []byte("state1")},
+		{ServiceID: "service1", Users: []string{"user2"}, Checksum: "checksum2", State: []byte("state2")},
+	}
+
+	for _, backup := range backups {
+		if err := db.SetServiceStateBackup(backup); err != nil {
+			t.Fatalf("Can't set service state backup: %s", err)
+		}
+	}
+
+	backups[0].Checksum = "checksum3"
+	backups[0].State = []byte("state3")
+
+	if err := db.SetServiceStateBackup(backups[0]); err != nil {
+		t.Fatalf("Can't set service state backup: %s", err)
+	}
+
+	for _, backup := range backups {
+		getBackup, err := db.GetServiceStateBackup(backup.ServiceID, backup.Users)
+		if err != nil {
+			t.Fatalf("Can't get service state backup: %s", err)
+		}
+
+		if !reflect.DeepEqual(getBackup, backup) {
+			t.Errorf("Wrong service state backup: %v", getBackup)
+		}
+	}
+
+	if err := db.RemoveServiceStateBackup("service1", []string{"user1"}); err != nil {
+		t.Fatalf("Can't remove service state backup: %s", err)
+	}
+
+	if _, err := db.GetServiceStateBackup("service1", []string{"user1"}); err == nil {
+		t.Error("Error expected on getting removed service state backup")
+	}
+
+	if err := db.RemoveServiceStateBackup("service1", []string{"user1"}); err == nil {
+		t.Error("Error expected on removing non existing service state backup")
+	}
+}
+
+func TestOverrideEnvVars(t *testing.T) {
+	ttl := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+
+	envVars := []cloudprotocol.OverrideEnvsFromCloud{
+		{ServiceID: "service1", SubjectID: "subject1", EnvVars: []cloudprotocol.EnvVarInfo{
+			{ID: "var1", Variable: "NAME1=VALUE1"}, {ID: "var2", Variable: "NAME2=VALUE2", TTL: &ttl}}},
+		{ServiceID: "service2", SubjectID: "subject1", EnvVars: []cloudprotocol.EnvVarInfo{
+			{ID: "var3", Variable: "NAME3=VALUE3"}}},
+	}
+
+	if err := db.SetOverrideEnvVars(envVars); err != nil {
+		t.Fatalf("Can't set override env vars: %s", err)
+	}
+
+	getEnvVars, err := db.GetOverrideEnvVars()
+	if err != nil {
+		t.Fatalf("Can't get override env vars: %s", err)
+	}
+
+	if !reflect.DeepEqual(getEnvVars, envVars) {
+		t.Errorf("Wrong override env vars: %v", getEnvVars)
+	}
+
+	// Override env vars should be replaced
+
+	if err := db.SetOverrideEnvVars(envVars[1:]); err != nil {
+		t.Fatalf("Can't set override env vars: %s", err)
+	}
+
+	if getEnvVars, err = db.GetOverrideEnvVars(); err != nil {
+		t.Fatalf("Can't get override env vars: %s", err)
+	}
+
+	if !reflect.DeepEqual(getEnvVars, envVars[1:]) {
+		t.Errorf("Wrong override env vars: %v", getEnvVars)
+	}
+
+	if err := db.SetOverrideEnvVars(nil); err != nil {
+		t.Fatalf("Can't set override env vars: %s", err)
+	}
+
+	if getEnvVars, err = db.GetOverrideEnvVars(); err != nil {
+		t.Fatalf("Can't get override env vars: %s", err)
+	}
+
+	if len(getEnvVars) != 0 {
+		t.Errorf("Wrong override env vars count: %d", len(getEnvVars))
+	}
+}
+
+func TestCertRenewals(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	renewals := []certmanager.RenewalInfo{
+		{Type: "online", Serial: "01", ValidTill: now.Add(time.Hour), Attempt: 0, NextAttempt: now},
+		{
+			Type: "offline", Serial: "02", ValidTill: now.Add(2 * time.Hour), Attempt: 3,
+			NextAttempt: now.Add(time.Minute), FailureAlerted: true,
+		},
+	}
+
+	for _, renewal := range renewals {
+		if err := db.SetCertRenewal(renewal); err != nil {
+			t.Fatalf("Can't set cert renewal: %s", err)
+		}
+	}
+
+	renewals[0].Attempt = 1
+	renewals[0].NextAttempt = now.Add(10 * time.Minute)
+
+	if err := db.SetCertRenewal(renewals[0]); err != nil {
+		t.Fatalf("Can't set cert renewal: %s", err)
+	}
+
+	getRenewals, err := db.GetCertRenewals()
+	if err != nil {
+		t.Fatalf("Can't get cert renewals: %s", err)
+	}
+
+	if len(getRenewals) != len(renewals) {
+		t.Fatalf("Wrong cert renewals count: %d", len(getRenewals))
+	}
+
+	for _, renewal := range renewals {
+		found := false
+
+		for _, getRenewal := range getRenewals {
+			if getRenewal.Type == renewal.Type {
+				found = true
+
+				if getRenewal.Serial != renewal.Serial || getRenewal.Attempt != renewal.Attempt ||
+					!getRenewal.ValidTill.Equal(renewal.ValidTill) || !getRenewal.NextAttempt.Equal(renewal.NextAttempt) ||
+					getRenewal.FailureAlerted != renewal.FailureAlerted {
+					t.Errorf("Wrong cert renewal: %v", getRenewal)
+				}
+			}
+		}
+
+		if !found {
+			t.Errorf("Cert renewal %s not found", renewal.Type)
+		}
+	}
+
+	if err = db.RemoveCertRenewal("online"); err != nil {
+		t.Fatalf("Can't remove cert renewal: %s", err)
+	}
+
+	if err = db.RemoveCertRenewal("online"); err == nil {
+		t.Error("Error expected on removing non existing cert renewal")
+	}
+
+	if getRenewals, err = db.GetCertRenewals(); err != nil {
+		t.Fatalf("Can't get cert renewals: %s", err)
+	}
+
+	if len(getRenewals) != 1 || getRenewals[0].Type != "offline" {
+		t.Errorf("Wrong cert renewals: %v", getRenewals)
+	}
+}
+
+func TestOwnerPassword(t *testing.T) {
+	wrappedPassword, err := db.GetOwnerPassword()
+	if err != nil {
+		t.Fatalf("Can't get owner password: %s", err)
+	}
+
+	if len(wrappedPassword) != 0 {
+		t.Errorf("Unexpected owner password: %v", wrappedPassword)
+	}
+
+	for _, password := range []string{"password1", "password2"} {
+		if err = db.SetOwnerPassword([]byte(password)); err != nil {
+			t.Fatalf("Can't set owner password: %s", err)
+		}
+
+		if wrappedPassword, err = db.GetOwnerPassword(); err != nil {
+			t.Fatalf("Can't get owner password: %s", err)
+		}
+
+		if string(wrappedPassword) != password {
+			t.Errorf("Wrong owner password: %s", wrappedPassword)
+		}
+	}
+}
+
+func TestCryptoAudit(t *testing.T) {
+	audit, err := cryptoaudit.New(filepath.Join(tmpDir, "cryptoaudit.key"), db, &testKeyWrapper{})
+	if err != nil {
+		t.Fatalf("Can't create crypto audit: %s", err)
+	}
+
+	startTime := time.Now()
+
+	for i := 0; i < 10; i++ {
+		record := fcrypt.AuditRecord{
+			Timestamp: time.Now().UTC(), Operation: fcrypt.AuditVerifySign, PackageID: "service" + strconv.Itoa(i%2),
+			Subject: "subject", ChainName: "chain", Fingerprints: []string{"01", "02"}, Algorithm: "RSA/SHA256/PKCS1v1_5",
+			Result: fcrypt.AuditResultSuccess}
+
+		if i%3 == 0 {
+			record.Operation = fcrypt.AuditImportSessionKey
+			record.Fingerprints = nil
+		}
+
+		audit.LogCryptoOperation(record)
+	}
+
+	if err = audit.Verify(); err != nil {
+		t.Errorf("Audit log verification failed: %s", err)
+	}
+
+	records, err := db.GetCryptoAuditRecords(cryptoaudit.Filter{PackageID: "service1"})
+	if err != nil {
+		t.Fatalf("Can't get crypto audit records: %s", err)
+	}
+
+	if len(records) != 5 {
+		t.Errorf("Wrong crypto audit records count: %d", len(records))
+	}
+
+	if records, err = db.GetCryptoAuditRecords(cryptoaudit.Filter{
+		Operation: fcrypt.AuditImportSessionKey, From: startTime}); err != nil {
+		t.Fatalf("Can't get crypto audit records: %s", err)
+	}
+
+	if len(records) != 4 {
+		t.Errorf("Wrong crypto audit records count: %d", len(records))
+	}
+
+	if records, err = db.GetCryptoAuditRecords(cryptoaudit.Filter{Offset: 8, Limit: 5}); err != nil {
+		t.Fatalf("Can't get crypto audit records: %s", err)
+	}
+
+	if len(records) != 2 || records[0].ID != 9 {
+		t.Errorf("Wrong crypto audit records: %v", records)
+	}
+
+	if records, err = db.GetCryptoAuditRecords(cryptoaudit.Filter{Till: startTime}); err != nil {
+		t.Fatalf("Can't get crypto audit records: %s", err)
+	}
+
+	if len(records) != 0 {
+		t.Errorf("Wrong crypto audit records count: %d", len(records))
+	}
+
+	lastRecord, err := db.GetLastCryptoAuditRecord()
+	if err != nil {
+		t.Fatalf("Can't get last crypto audit record: %s", err)
+	}
+
+	if lastRecord.ID != 10 || lastRecord.Subject != "subject" {
+		t.Errorf("Wrong last crypto audit record: %v", lastRecord)
+	}
+
+	anchor, err := db.GetCryptoAuditAnchor()
+	if err != nil {
+		t.Fatalf("Can't get crypto audit anchor: %s", err)
+	}
+
+	if anchor == "" {
+		t.Error("Crypto audit log is not anchored")
+	}
+
+	if _, err = db.sql.Exec("UPDATE cryptoAudit SET result = ? WHERE id = 5", fcrypt.AuditResultFailed); err != nil {
+		t.Fatalf("Can't modify crypto audit record: %s", err)
+	}
+
+	if err = audit.Verify(); err == nil {
+		t.Error("Audit log verification should fail on modified record")
+	}
+}
+
+func TestUpdateHistory(t *testing.T) {
+	history, err := updatehistory.New(&config.Config{}, db)
+	if err != nil {
+		t.Fatalf("Can't create update history: %s", err)
+	}
+
+	startTime := time.Now()
+
+	for i := 0; i < 6; i++ {
+		record := updatehistory.Record{
+			UpdateType: "fota", DesiredStatusHash: "hash" + strconv.Itoa(i), Started: time.Now(),
+			Items: []updatehistory.Item{
+				{Type: updatehistory.ComponentItem, ID: "comp1", FromVersion: "1.0", ToVersion: "2.0"},
+			},
+			Phases: []updatehistory.Phase{{State: "downloading", Timestamp: time.Now().UTC()}},
+		}
+
+		if i%2 == 1 {
+			record.UpdateType = "sota"
+		}
+
+		if err = history.AddRecord(&record); err != nil {
+			t.Fatalf("Can't add update history record: %s", err)
+		}
+
+		if record.ID != uint64(i+1) {
+			t.Errorf("Wrong record ID: %d", record.ID)
+		}
+	}
+
+	records, err := history.GetRecords(updatehistory.Filter{UpdateType: "fota"})
+	if err != nil {
+		t.Fatalf("Can't get update history records: %s", err)
+	}
+
+	if len(records) != 3 || records[0].ID != 5 {
+		t.Errorf("Wrong update history records: %v", records)
+	}
+
+	record := records[0]
+	record.Items[0].Status = "error"
+	record.Items[0].Reverted = true
+	record.Phases = append(record.Phases, updatehistory.Phase{State: "noUpdate", Timestamp: time.Now().UTC()})
+	record.Finished = time.Now()
+	record.Error = "update failed"
+
+	if err = history.UpdateRecord(record); err != nil {
+		t.Fatalf("Can't update history record: %s", err)
+	}
+
+	if err = history.UpdateRecord(updatehistory.Record{ID: 10}); err == nil {
+		t.Error("Error expected for unknown record")
+	}
+
+	if records, err = history.GetRecords(updatehistory.Filter{Offset: 1, Limit: 2}); err != nil {
+		t.Fatalf("Can't get update history records: %s", err)
+	}
+
+	if len(records) != 2 || records[0].ID != 5 || records[1].ID != 4 {
+		t.Fatalf("Wrong update history records: %v", records)
+	}
+
+	if !reflect.DeepEqual(records[0].Items, record.Items) || !reflect.DeepEqual(records[0].Phases, record.Phases) ||
+		records[0].Error != record.Error || !records[0].Finished.Equal(record.Finished) {
+		t.Errorf("Wrong update history record: %v", records[0])
+	}
+
+	if records, err = history.GetRecords(updatehistory.Filter{Till: startTime}); err != nil {
+		t.Fatalf("Can't get update history records: %s", err)
+	}
+
+	if len(records) != 0 {
+		t.Errorf("Wrong update history records count: %d", len(records))
+	}
+
+	// Last ID is restored on restart
+
+	if history, err = updatehistory.New(&config.Config{}, db); err != nil {
+		t.Fatalf("Can't create update history: %s", err)
+	}
+
+	record = updatehistory.Record{UpdateType: "fota", Started: time.Now()}
+
+	if err = history.AddRecord(&record); err != nil {
+		t.Fatalf("Can't add update history record: %s", err)
+	}
+
+	if record.ID != 7 {
+		t.Errorf("Wrong record ID: %d", record.ID)
+	}
+
+	// Database opened read only returns records and can't be modified
+
+	readOnlyDB, err := NewReadOnly(&config.Config{WorkingDir: tmpDir})
+	if err != nil {
+		t.Fatalf("Can't open database read only: %s", err)
+	}
+	defer readOnlyDB.Close()
+
+	if records, err = readOnlyDB.GetUpdateHistoryRecords(updatehistory.Filter{}); err != nil {
+		t.Fatalf("Can't get update history records: %s", err)
+	}
+
+	if len(records) != 7 {
+		t.Errorf("Wrong update history records count: %d", len(records))
+	}
+
+	if err = readOnlyDB.SetUpdateHistoryRecord(record); err == nil {
+		t.Error("Error expected for read only database")
+	}
+
+	if _, err = NewReadOnly(&config.Config{WorkingDir: filepath.Join(tmpDir, "notExist")}); err == nil {
+		t.Error("Error expected for not existing database")
+	}
+
+	// Outdated records are removed
+
+	if err = db.RemoveUpdateHistoryRecords(3, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("Can't remove update history records: %s", err)
+	}
+
+	if records, err = history.GetRecords(updatehistory.Filter{}); err != nil {
+		t.Fatalf("Can't get update history records: %s", err)
+	}
+
+	if len(records) != 5 || records[len(records)-1].ID != 3 {
+		t.Errorf("Wrong update history records: %v", records)
+	}
+
+	if err = db.RemoveUpdateHistoryRecords(0, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Can't remove update history records: %s", err)
+	}
+
+	if records, err = history.GetRecords(updatehistory.Filter{}); err != nil {
+		t.Fatalf("Can't get update history records: %s", err)
+	}
+
+	if len(records) != 0 {
+		t.Errorf("Wrong update history records count: %d", len(records))
+	}
+}
+
 func TestMultiThread(t *testing.T) {
 	const numIterations = 1000
 
@@ -221,3 +835,15 @@ func TestMultiThread(t *testing.T) {
 
 	wg.Wait()
 }
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (wrapper *testKeyWrapper) WrapKey(key []byte) (wrapped []byte, err error) {
+	return key, nil
+}
+
+func (wrapper *testKeyWrapper) UnwrapKey(wrapped []byte) (key []byte, err error) {
+	return wrapped, nil
+}
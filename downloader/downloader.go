@@ -630,6 +630,7 @@ func (downloader *Downloader) decryptPackage(result *downloadResult) (err error)
 		ReceiverInfo: fcrypt.ReceiverInfo{
 			Issuer: result.packageInfo.DecryptionInfo.ReceiverInfo.Issuer,
 			Serial: result.packageInfo.DecryptionInfo.ReceiverInfo.Serial},
+		PackageID: result.id,
 	})
 	if err != nil {
 		return aoserrors.Wrap(err)
@@ -682,7 +683,7 @@ func (downloader *Downloader) validateSigns(result *downloadResult) (err error)
 
 	log.WithField("file", file.Name()).Debug("Check signature")
 
-	if err = signCtx.VerifySign(result.ctx, file,
+	if err = signCtx.VerifySign(fcrypt.ContextWithPackageID(result.ctx, result.id), file,
 		result.packageInfo.Signs.ChainName, result.packageInfo.Signs.Alg, result.packageInfo.Signs.Value); err != nil {
 		return aoserrors.Wrap(err)
 	}
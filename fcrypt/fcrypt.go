@@ -24,15 +24,19 @@ import (
 	"crypto/cipher"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/asn1"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/ThalesIgnite/crypto11"
 	"github.com/aoscloud/aos_common/aoserrors"
@@ -55,6 +59,19 @@ const (
 	offlineCertificate = "offline"
 )
 
+// Audit operations
+const (
+	AuditVerifySign       = "verifySign"
+	AuditImportSessionKey = "importSessionKey"
+	AuditDecryptMetadata  = "decryptMetadata"
+)
+
+// Audit results
+const (
+	AuditResultSuccess = "success"
+	AuditResultFailed  = "failed"
+)
+
 /***********************************************************************************************************************
  * Types
  **********************************************************************************************************************/
@@ -72,15 +89,22 @@ type CryptoSessionKeyInfo struct {
 	SymmetricAlgName  string       `json:"symmetricAlgName"`
 	AsymmetricAlgName string       `json:"asymmetricAlgName"`
 	ReceiverInfo      ReceiverInfo `json:"recipientInfo"`
+	PackageID         string       `json:"packageId,omitempty"`
 }
 
 // CryptoContext crypto context
 type CryptoContext struct {
 	rootCertPool  *x509.CertPool
 	tpmDevice     io.ReadWriteCloser
+	tpmMutex      sync.Mutex
 	pkcs11Ctx     map[pkcs11Descriptor]*crypto11.Context
 	pkcs11Library string
 	certProvider  CertificateProvider
+	auditLogger   AuditLogger
+
+	// audit fingerprints cache by certificate URL to not load certificate on each audit record
+	auditMutex        sync.Mutex
+	auditFingerprints map[string][]string
 }
 
 // SymmetricContextInterface interface for SymmetricCipherContext
@@ -118,6 +142,33 @@ type CertificateProvider interface {
 	GetCertificate(certType string, issuer []byte, serial string) (certURL, ketURL string, err error)
 }
 
+// AuditRecord crypto operation audit record. Package ID identifies installed artifact, subject identifies other
+// data processed by the operation, e.g. desired status part.
+type AuditRecord struct {
+	Timestamp    time.Time
+	Operation    string
+	PackageID    string
+	Subject      string
+	ChainName    string
+	Fingerprints []string
+	Algorithm    string
+	Result       string
+	Error        string
+}
+
+// AuditLogger logs crypto operations decisions
+type AuditLogger interface {
+	LogCryptoOperation(record AuditRecord)
+}
+
+type packageIDKey struct{}
+
+// wrappedKey key encrypted with public key of the offline certificate
+type wrappedKey struct {
+	ReceiverInfo ReceiverInfo `json:"recipientInfo"`
+	Key          []byte       `json:"key"`
+}
+
 type certificateInfo struct {
 	fingerprint string
 	certificate *x509.Certificate
@@ -133,6 +184,11 @@ type pkcs11Descriptor struct {
 	token   string
 }
 
+type tpmPrivateKey struct {
+	key   crypto.PrivateKey
+	mutex *sync.Mutex
+}
+
 /***********************************************************************************************************************
  * Public
  **********************************************************************************************************************/
@@ -141,9 +197,10 @@ type pkcs11Descriptor struct {
 func New(conf config.Crypt, provider CertificateProvider) (cryptoContext *CryptoContext, err error) {
 	// Create context
 	cryptoContext = &CryptoContext{
-		certProvider:  provider,
-		pkcs11Ctx:     make(map[pkcs11Descriptor]*crypto11.Context),
-		pkcs11Library: conf.Pkcs11Library}
+		certProvider:      provider,
+		pkcs11Ctx:         make(map[pkcs11Descriptor]*crypto11.Context),
+		pkcs11Library:     conf.Pkcs11Library,
+		auditFingerprints: make(map[string][]string)}
 
 	if conf.CACert != "" {
 		if cryptoContext.rootCertPool, err = cryptutils.GetCaCertPool(conf.CACert); err != nil {
@@ -185,6 +242,25 @@ func (cryptoContext *CryptoContext) Close() (err error) {
 	return aoserrors.Wrap(err)
 }
 
+// SetAuditLogger sets logger for crypto operations decisions
+func (cryptoContext *CryptoContext) SetAuditLogger(auditLogger AuditLogger) {
+	cryptoContext.auditLogger = auditLogger
+}
+
+// InvalidateAuditCache drops cached certificate fingerprints. It should be called when unit certificates are
+// renewed as renewed certificate may be available by the same URL.
+func (cryptoContext *CryptoContext) InvalidateAuditCache() {
+	cryptoContext.auditMutex.Lock()
+	defer cryptoContext.auditMutex.Unlock()
+
+	cryptoContext.auditFingerprints = make(map[string][]string)
+}
+
+// ContextWithPackageID returns context which holds package ID for audit records
+func ContextWithPackageID(ctx context.Context, packageID string) (packageCtx context.Context) {
+	return context.WithValue(ctx, packageIDKey{}, packageID)
+}
+
 // GetOrganization returns online certificate origanizarion names
 func (cryptoContext *CryptoContext) GetOrganization() (names []string, err error) {
 	certURLStr, _, err := cryptoContext.certProvider.GetCertificate(onlineCertificate, nil, "")
@@ -214,6 +290,16 @@ func (cryptoContext *CryptoContext) GetCertSerial(certURL string) (serial string
 	return fmt.Sprintf("%X", certs[0].SerialNumber), nil
 }
 
+// GetCertInfo returns certificate serial number and expiration time
+func (cryptoContext *CryptoContext) GetCertInfo(certURL string) (serial string, validTill time.Time, err error) {
+	certs, err := cryptoContext.loadCertificateByURL(certURL)
+	if err != nil {
+		return "", time.Time{}, aoserrors.Wrap(err)
+	}
+
+	return fmt.Sprintf("%X", certs[0].SerialNumber), certs[0].NotAfter, nil
+}
+
 // CreateSignContext creates sign context
 func (cryptoContext *CryptoContext) CreateSignContext() (signContext SignContextInterface, err error) {
 	if cryptoContext == nil || cryptoContext.rootCertPool == nil {
@@ -253,15 +339,28 @@ func (cryptoContext *CryptoContext) GetTLSConfig() (cfg *tls.Config, err error)
 	return cfg, nil
 }
 
-// DecryptMetadata decrypt envelope
-func (cryptoContext *CryptoContext) DecryptMetadata(input []byte) (output []byte, err error) {
+// DecryptMetadata decrypt envelope. Subject identifies decrypted metadata in audit records.
+func (cryptoContext *CryptoContext) DecryptMetadata(input []byte, subject string) (output []byte, err error) {
+	var (
+		certURL string
+		algName string
+	)
+
+	defer func() {
+		cryptoContext.logAudit(AuditRecord{
+			Operation: AuditDecryptMetadata, Subject: subject, Algorithm: algName,
+		}, certURL, err)
+	}()
+
 	ci, err := unmarshallCMS(input)
 	if err != nil {
 		return nil, aoserrors.Wrap(err)
 	}
 
+	algName = ci.EnvelopedData.EncryptedContentInfo.ContentEncryptionAlgorithm.Algorithm.String()
+
 	for _, recipient := range ci.EnvelopedData.RecipientInfos {
-		dkey, err := cryptoContext.getKeyForEnvelope(recipient.(keyTransRecipientInfo))
+		dkey, recipientCertURL, err := cryptoContext.getKeyForEnvelope(recipient.(keyTransRecipientInfo))
 		if err != nil {
 			log.Warnf("Can't get key for envelope: %s", err)
 
@@ -275,6 +374,8 @@ func (cryptoContext *CryptoContext) DecryptMetadata(input []byte) (output []byte
 			continue
 		}
 
+		certURL = recipientCertURL
+
 		return output, nil
 	}
 
@@ -284,7 +385,16 @@ func (cryptoContext *CryptoContext) DecryptMetadata(input []byte) (output []byte
 // ImportSessionKey function retrieves a symmetric key from crypto context
 func (cryptoContext *CryptoContext) ImportSessionKey(
 	keyInfo CryptoSessionKeyInfo) (symContext SymmetricContextInterface, err error) {
-	_, keyURLStr, err := cryptoContext.certProvider.GetCertificate(
+	var certURLStr string
+
+	defer func() {
+		cryptoContext.logAudit(AuditRecord{
+			Operation: AuditImportSessionKey, PackageID: keyInfo.PackageID,
+			Algorithm: keyInfo.AsymmetricAlgName + "/" + keyInfo.SymmetricAlgName,
+		}, certURLStr, err)
+	}()
+
+	certURLStr, keyURLStr, err := cryptoContext.certProvider.GetCertificate(
 		offlineCertificate, keyInfo.ReceiverInfo.Issuer, keyInfo.ReceiverInfo.Serial)
 	if err != nil {
 		return nil, aoserrors.Wrap(err)
@@ -349,6 +459,73 @@ func (cryptoContext *CryptoContext) ImportSessionKey(
 	return ctxSym, nil
 }
 
+// WrapKey encrypts key with the public key of the current offline certificate. Wrapped key contains the certificate
+// info which is used to find the private key on unwrap.
+func (cryptoContext *CryptoContext) WrapKey(key []byte) (wrapped []byte, err error) {
+	certURLStr, _, err := cryptoContext.certProvider.GetCertificate(offlineCertificate, nil, "")
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	certs, err := cryptoContext.loadCertificateByURL(certURLStr)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	publicKey, ok := certs[0].PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, aoserrors.New("offline certificate doesn't have RSA public key")
+	}
+
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, key, nil)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	if wrapped, err = json.Marshal(wrappedKey{
+		ReceiverInfo: ReceiverInfo{
+			Serial: fmt.Sprintf("%X", certs[0].SerialNumber),
+			Issuer: certs[0].RawIssuer,
+		},
+		Key: encryptedKey,
+	}); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return wrapped, nil
+}
+
+// UnwrapKey decrypts key wrapped by WrapKey with the private key of the offline certificate
+func (cryptoContext *CryptoContext) UnwrapKey(wrapped []byte) (key []byte, err error) {
+	var keyInfo wrappedKey
+
+	if err = json.Unmarshal(wrapped, &keyInfo); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	_, keyURLStr, err := cryptoContext.certProvider.GetCertificate(
+		offlineCertificate, keyInfo.ReceiverInfo.Issuer, keyInfo.ReceiverInfo.Serial)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	privKey, _, err := cryptoContext.loadPrivateKeyByURL(keyURLStr)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	decrypter, ok := privKey.(crypto.Decrypter)
+	if !ok {
+		return nil, aoserrors.New("private key doesn't implement decrypter interface")
+	}
+
+	if key, err = decrypter.Decrypt(rand.Reader, keyInfo.Key, &rsa.OAEPOptions{Hash: crypto.SHA256}); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return key, nil
+}
+
 // AddCertificate adds certificate to context
 func (signContext *SignContext) AddCertificate(fingerprint string, asn1Bytes []byte) error {
 	fingerUpper := strings.ToUpper(fingerprint)
@@ -395,11 +572,21 @@ func (signContext *SignContext) AddCertificateChain(name string, fingerprints []
 // VerifySign verifies signature
 func (signContext *SignContext) VerifySign(
 	ctx context.Context, f *os.File, chainName string, algName string, signValue []byte) (err error) {
+	var chain certificateChainInfo
+
+	defer func() {
+		packageID, _ := ctx.Value(packageIDKey{}).(string)
+
+		signContext.cryptoContext.logAudit(AuditRecord{
+			Operation: AuditVerifySign, PackageID: packageID, ChainName: chainName,
+			Fingerprints: signContext.getChainFingerprints(chain), Algorithm: algName,
+		}, "", err)
+	}()
+
 	if len(signContext.signCertificateChains) == 0 || len(signContext.signCertificates) == 0 {
 		return aoserrors.New("sign context not initialized (no certificates)")
 	}
 
-	var chain certificateChainInfo
 	var signCertFingerprint string
 
 	// Find chain
@@ -567,6 +754,47 @@ func (symmetricContext *SymmetricCipherContext) DecryptFile(
 	return nil
 }
 
+// Public returns public key of TPM private key
+func (key *tpmPrivateKey) Public() (publicKey crypto.PublicKey) {
+	signer, ok := key.key.(crypto.Signer)
+	if !ok {
+		return nil
+	}
+
+	return signer.Public()
+}
+
+// Sign signs digest with TPM private key
+func (key *tpmPrivateKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) (signature []byte, err error) {
+	signer, ok := key.key.(crypto.Signer)
+	if !ok {
+		return nil, aoserrors.New("TPM key doesn't implement signer interface")
+	}
+
+	key.mutex.Lock()
+	defer key.mutex.Unlock()
+
+	signature, err = signer.Sign(rand, digest, opts)
+
+	return signature, aoserrors.Wrap(err)
+}
+
+// Decrypt decrypts message with TPM private key
+func (key *tpmPrivateKey) Decrypt(
+	rand io.Reader, msg []byte, opts crypto.DecrypterOpts) (plaintext []byte, err error) {
+	decrypter, ok := key.key.(crypto.Decrypter)
+	if !ok {
+		return nil, aoserrors.New("TPM key doesn't implement decrypter interface")
+	}
+
+	key.mutex.Lock()
+	defer key.mutex.Unlock()
+
+	plaintext, err = decrypter.Decrypt(rand, msg, opts)
+
+	return plaintext, aoserrors.Wrap(err)
+}
+
 /***********************************************************************************************************************
  * Private
  **********************************************************************************************************************/
@@ -595,6 +823,28 @@ func (cryptoContext *CryptoContext) loadPkcs11PrivateKey(keyURL *url.URL) (key c
 	return key, nil
 }
 
+func (cryptoContext *CryptoContext) loadTPMPrivateKey(keyURL *url.URL) (key crypto.PrivateKey, err error) {
+	if cryptoContext.tpmDevice == nil {
+		return nil, aoserrors.New("TPM device is not configured")
+	}
+
+	handle, err := parseTPMURL(keyURL)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	log.WithField("handle", fmt.Sprintf("0x%X", handle)).Debug("Load TPM private key")
+
+	cryptoContext.tpmMutex.Lock()
+	defer cryptoContext.tpmMutex.Unlock()
+
+	if key, err = tpmkey.CreateFromPersistent(cryptoContext.tpmDevice, handle); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return &tpmPrivateKey{key: key, mutex: &cryptoContext.tpmMutex}, nil
+}
+
 func (cryptoContext *CryptoContext) loadPrivateKeyByURL(keyURLStr string) (privKey crypto.PrivateKey,
 	supportPKCS1v15SessionKey bool, err error) {
 	keyURL, err := url.Parse(keyURLStr)
@@ -611,17 +861,7 @@ func (cryptoContext *CryptoContext) loadPrivateKeyByURL(keyURLStr string) (privK
 		supportPKCS1v15SessionKey = true
 
 	case cryptutils.SchemeTPM:
-		if cryptoContext.tpmDevice == nil {
-			return nil, false, aoserrors.Errorf("TPM device is not configured")
-		}
-
-		var handle uint64
-
-		if handle, err = strconv.ParseUint(keyURL.Hostname(), 0, 32); err != nil {
-			return nil, false, aoserrors.Wrap(err)
-		}
-
-		if privKey, err = tpmkey.CreateFromPersistent(cryptoContext.tpmDevice, tpmutil.Handle(handle)); err != nil {
+		if privKey, err = cryptoContext.loadTPMPrivateKey(keyURL); err != nil {
 			return nil, false, aoserrors.Wrap(err)
 		}
 
@@ -637,6 +877,25 @@ func (cryptoContext *CryptoContext) loadPrivateKeyByURL(keyURLStr string) (privK
 	return privKey, supportPKCS1v15SessionKey, nil
 }
 
+func parseTPMURL(tpmURL *url.URL) (handle tpmutil.Handle, err error) {
+	// Both tpm:0x81000001 and tpm://0x81000001 forms are accepted
+	handleStr := tpmURL.Hostname()
+	if handleStr == "" {
+		handleStr = tpmURL.Opaque
+	}
+
+	if handleStr == "" {
+		return 0, aoserrors.New("TPM handle is not specified")
+	}
+
+	value, err := strconv.ParseUint(handleStr, 0, 32)
+	if err != nil {
+		return 0, aoserrors.Wrap(err)
+	}
+
+	return tpmutil.Handle(value), nil
+}
+
 func getRawCertificate(certs []*x509.Certificate) (rawCerts [][]byte) {
 	rawCerts = make([][]byte, 0, len(certs))
 
@@ -768,32 +1027,101 @@ func (cryptoContext *CryptoContext) loadCertificateByURL(certURLStr string) (cer
 	}
 }
 
-func (cryptoContext *CryptoContext) getKeyForEnvelope(keyInfo keyTransRecipientInfo) (key []byte, err error) {
+func (cryptoContext *CryptoContext) getKeyForEnvelope(
+	keyInfo keyTransRecipientInfo) (key []byte, certURLStr string, err error) {
 	issuer, err := asn1.Marshal(keyInfo.Rid.Issuer)
 	if err != nil {
-		return key, aoserrors.Wrap(err)
+		return key, "", aoserrors.Wrap(err)
 	}
 
-	_, keyURLStr, err := cryptoContext.certProvider.GetCertificate(offlineCertificate, issuer, fmt.Sprintf("%X", keyInfo.Rid.SerialNumber))
+	certURLStr, keyURLStr, err := cryptoContext.certProvider.GetCertificate(
+		offlineCertificate, issuer, fmt.Sprintf("%X", keyInfo.Rid.SerialNumber))
 	if err != nil {
-		return key, aoserrors.Wrap(err)
+		return key, "", aoserrors.Wrap(err)
 	}
 
 	privKey, _, err := cryptoContext.loadPrivateKeyByURL(keyURLStr)
 	if err != nil {
-		return key, aoserrors.Wrap(err)
+		return key, "", aoserrors.Wrap(err)
 	}
 
 	decrypter, ok := privKey.(crypto.Decrypter)
 	if !ok {
-		return nil, aoserrors.New("private key doesn't have a decryption suite")
+		return nil, "", aoserrors.New("private key doesn't have a decryption suite")
 	}
 
 	if key, err = decryptCMSKey(&keyInfo, decrypter); err != nil {
+		return nil, "", aoserrors.Wrap(err)
+	}
+
+	return key, certURLStr, nil
+}
+
+func (cryptoContext *CryptoContext) logAudit(record AuditRecord, certURLStr string, err error) {
+	if cryptoContext.auditLogger == nil {
+		return
+	}
+
+	record.Timestamp = time.Now().UTC()
+	record.Result = AuditResultSuccess
+
+	if err != nil {
+		record.Result = AuditResultFailed
+		record.Error = err.Error()
+	}
+
+	if certURLStr != "" {
+		fingerprints, err := cryptoContext.getAuditFingerprints(certURLStr)
+		if err != nil {
+			log.Warnf("Can't load certificate for audit record: %s", err)
+		}
+
+		record.Fingerprints = fingerprints
+	}
+
+	cryptoContext.auditLogger.LogCryptoOperation(record)
+}
+
+func (cryptoContext *CryptoContext) getAuditFingerprints(certURLStr string) (fingerprints []string, err error) {
+	cryptoContext.auditMutex.Lock()
+	defer cryptoContext.auditMutex.Unlock()
+
+	if fingerprints, ok := cryptoContext.auditFingerprints[certURLStr]; ok {
+		return fingerprints, nil
+	}
+
+	certs, err := cryptoContext.loadCertificateByURL(certURLStr)
+	if err != nil {
 		return nil, aoserrors.Wrap(err)
 	}
 
-	return key, nil
+	fingerprints = getCertFingerprints(certs)
+
+	cryptoContext.auditFingerprints[certURLStr] = fingerprints
+
+	return fingerprints, nil
+}
+
+// getChainFingerprints returns fingerprints of chain certificates in the same format as for unit certificates
+func (signContext *SignContext) getChainFingerprints(chain certificateChainInfo) (fingerprints []string) {
+	certs := make([]*x509.Certificate, 0, len(chain.fingerprints))
+
+	for _, chainFingerprint := range chain.fingerprints {
+		if cert := signContext.getCertificateByFingerprint(chainFingerprint); cert != nil {
+			certs = append(certs, cert)
+		}
+	}
+
+	return getCertFingerprints(certs)
+}
+
+// getCertFingerprints returns SHA256 fingerprints of certificates
+func getCertFingerprints(certs []*x509.Certificate) (fingerprints []string) {
+	for _, cert := range certs {
+		fingerprints = append(fingerprints, fmt.Sprintf("%X", sha256.Sum256(cert.Raw)))
+	}
+
+	return fingerprints
 }
 
 func (symmetricContext *SymmetricCipherContext) generateKeyAndIV(algString string) (err error) {
@@ -20,22 +20,34 @@ package fcrypt
 import (
 	"bytes"
 	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
 	"crypto/ecdsa"
+	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math/big"
 	"net/url"
 	"os"
 	"os/exec"
 	"path"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/aoscloud/aos_common/utils/cryptutils"
+	"github.com/aoscloud/aos_common/utils/tpmkey"
+	"github.com/google/go-tpm/tpm2"
+	"github.com/google/go-tpm/tpmutil"
 	log "github.com/sirupsen/logrus"
 
 	"aos_communicationmanager/config"
@@ -58,6 +70,13 @@ const (
 	pkcs11Token   = "aos"
 )
 
+const (
+	tpmSimulatorSocket = tmpDir + "/swtpm.sock"
+	tpmSimulatorCtrl   = tmpDir + "/swtpm.ctrl"
+	tpmSimulatorState  = tmpDir + "/swtpm"
+	tpmKeyHandle       = 0x81000001
+)
+
 /***********************************************************************************************************************
  * Types
  **********************************************************************************************************************/
@@ -117,10 +136,19 @@ type testCertificateProvider struct {
 	keyURL  string
 }
 
+type testAuditLogger struct {
+	records []AuditRecord
+}
+
 /***********************************************************************************************************************
  * Vars
  **********************************************************************************************************************/
 
+var (
+	tpmSimulator    *exec.Cmd
+	tpmSimulatorErr error
+)
+
 var (
 	// Symmetric encryption done with
 	// openssl aes-128-cbc -a -e -p -nosalt -in plaintext.sh -out encrypted.txt
@@ -582,8 +610,17 @@ func TestMain(m *testing.M) {
 		log.Fatalf("Can't setup PKCS11 storage: %s", err)
 	}
 
+	// TPM tests are skipped if TPM simulator is not available on the host
+	if tpmSimulatorErr = startTPMSimulator(); tpmSimulatorErr != nil {
+		log.Warnf("Can't start TPM simulator: %s", tpmSimulatorErr)
+	}
+
 	ret := m.Run()
 
+	if err = stopTPMSimulator(); err != nil {
+		log.Fatalf("Can't stop TPM simulator: %s", err)
+	}
+
 	if err = clearFileStorage(); err != nil {
 		log.Fatalf("Can't clear file storage: %s", err)
 	}
@@ -896,6 +933,42 @@ func TestDecryptSessionKeyOAEP(t *testing.T) {
 	}
 }
 
+func TestWrapKey(t *testing.T) {
+	cryptoContext, err := New(config.Crypt{}, &testCertificateProvider{
+		certURL: certNameToFileURL("offline1"), keyURL: keyNameToFileURL("offline1")})
+	if err != nil {
+		t.Fatalf("Can't create crypto context: %s", err)
+	}
+	defer cryptoContext.Close()
+
+	key, err := hex.DecodeString(ClearAesKey)
+	if err != nil {
+		t.Fatalf("Error decode key: %s", err)
+	}
+
+	wrapped, err := cryptoContext.WrapKey(key)
+	if err != nil {
+		t.Fatalf("Can't wrap key: %s", err)
+	}
+
+	if bytes.Contains(wrapped, key) {
+		t.Error("Wrapped key contains clear key")
+	}
+
+	unwrapped, err := cryptoContext.UnwrapKey(wrapped)
+	if err != nil {
+		t.Fatalf("Can't unwrap key: %s", err)
+	}
+
+	if !bytes.Equal(unwrapped, key) {
+		t.Error("Wrong unwrapped key")
+	}
+
+	if _, err = cryptoContext.UnwrapKey([]byte("invalid")); err == nil {
+		t.Error("Error expected for invalid wrapped key")
+	}
+}
+
 func TestInvalidSessionKeyPkcs1v15(t *testing.T) {
 	// For testing only
 	iv, err := hex.DecodeString(UsedIV)
@@ -1116,6 +1189,240 @@ func TestGetCertificateOrganization(t *testing.T) {
 	}
 }
 
+func TestTPMPrivateKey(t *testing.T) {
+	if tpmSimulatorErr != nil {
+		t.Skipf("TPM simulator is not available: %s", tpmSimulatorErr)
+	}
+
+	publicKey, err := createTPMKey(tpmKeyHandle)
+	if err != nil {
+		t.Fatalf("Can't create TPM key: %s", err)
+	}
+
+	cryptoContext, err := New(config.Crypt{TpmDevice: tpmSimulatorSocket}, &testCertificateProvider{
+		certURL: certNameToFileURL("online"), keyURL: handleToTPMURL(tpmKeyHandle)})
+	if err != nil {
+		t.Fatalf("Can't create crypto context: %s", err)
+	}
+
+	// TPM emulator connection is closed after each command, so close error is expected here
+	defer cryptoContext.Close()
+
+	clearAesKey, err := hex.DecodeString(ClearAesKey)
+	if err != nil {
+		t.Fatalf("Error decode ClearKey: %s", err)
+	}
+
+	iv, err := hex.DecodeString(UsedIV)
+	if err != nil {
+		t.Fatalf("Error decode IV: %s", err)
+	}
+
+	// Import session key
+
+	for _, asymmetricAlgName := range []string{"RSA/PKCS1v1_5", "RSA/OAEP", "RSA/OAEP-256"} {
+		var encryptedKey []byte
+
+		switch asymmetricAlgName {
+		case "RSA/PKCS1v1_5":
+			encryptedKey, err = rsa.EncryptPKCS1v15(rand.Reader, publicKey, clearAesKey)
+
+		case "RSA/OAEP":
+			encryptedKey, err = rsa.EncryptOAEP(crypto.SHA1.New(), rand.Reader, publicKey, clearAesKey, nil)
+
+		case "RSA/OAEP-256":
+			encryptedKey, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, clearAesKey, nil)
+		}
+
+		if err != nil {
+			t.Fatalf("Can't encrypt session key: %s", err)
+		}
+
+		symContext, err := cryptoContext.ImportSessionKey(CryptoSessionKeyInfo{
+			SessionKey:        encryptedKey,
+			SessionIV:         iv,
+			SymmetricAlgName:  "AES128/CBC/PKCS7PADDING",
+			AsymmetricAlgName: asymmetricAlgName,
+		})
+		if err != nil {
+			t.Fatalf("Can't import %s session key: %s", asymmetricAlgName, err)
+		}
+
+		cipherContext, ok := symContext.(*SymmetricCipherContext)
+		if !ok {
+			t.Fatal("Can't cast to SymmetricCipherContext")
+		}
+
+		if !bytes.Equal(cipherContext.key, clearAesKey) {
+			t.Errorf("Wrong %s session key", asymmetricAlgName)
+		}
+	}
+
+	// Decrypt metadata
+
+	metadata := []byte(`{"metadata": "test metadata"}`)
+
+	envelope, err := createTestEnvelope(publicKey, metadata)
+	if err != nil {
+		t.Fatalf("Can't create envelope: %s", err)
+	}
+
+	decryptedMetadata, err := cryptoContext.DecryptMetadata(envelope, "desiredStatus")
+	if err != nil {
+		t.Fatalf("Can't decrypt metadata: %s", err)
+	}
+
+	if !bytes.Equal(decryptedMetadata, metadata) {
+		t.Errorf("Wrong decrypted metadata: %s", string(decryptedMetadata))
+	}
+
+	// TLS client key
+
+	tlsConfig, err := cryptoContext.GetTLSConfig()
+	if err != nil {
+		t.Fatalf("Can't get TLS config: %s", err)
+	}
+
+	signer, ok := tlsConfig.Certificates[0].PrivateKey.(crypto.Signer)
+	if !ok {
+		t.Fatal("TLS private key doesn't implement signer interface")
+	}
+
+	digest := sha256.Sum256([]byte("test data"))
+
+	signature, err := signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatalf("Can't sign: %s", err)
+	}
+
+	if err = rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature); err != nil {
+		t.Errorf("Wrong signature: %s", err)
+	}
+}
+
+func TestParseTPMURL(t *testing.T) {
+	for _, urlStr := range []string{"tpm:0x81000001", "tpm://0x81000001", "tpm:2164260865"} {
+		tpmURL, err := url.Parse(urlStr)
+		if err != nil {
+			t.Fatalf("Can't parse URL: %s", err)
+		}
+
+		handle, err := parseTPMURL(tpmURL)
+		if err != nil {
+			t.Errorf("Can't parse TPM URL %s: %s", urlStr, err)
+		}
+
+		if handle != tpmKeyHandle {
+			t.Errorf("Wrong TPM handle: 0x%X", handle)
+		}
+	}
+
+	for _, urlStr := range []string{"tpm:", "tpm://", "tpm:handle"} {
+		tpmURL, err := url.Parse(urlStr)
+		if err != nil {
+			t.Fatalf("Can't parse URL: %s", err)
+		}
+
+		if _, err = parseTPMURL(tpmURL); err == nil {
+			t.Errorf("Error expected for TPM URL %s", urlStr)
+		}
+	}
+}
+
+func TestCryptoAudit(t *testing.T) {
+	iv, err := hex.DecodeString(UsedIV)
+	if err != nil {
+		t.Fatalf("Error decode IV: '%v'", err)
+	}
+
+	encryptedKey, err := base64.StdEncoding.DecodeString(EncryptedKeyOaep)
+	if err != nil {
+		t.Fatalf("Error decode key: '%v'", err)
+	}
+
+	rootCertURL, err := url.Parse(certNameToFileURL("root"))
+	if err != nil {
+		t.Fatalf("Can't parse cert URL: '%v'", err)
+	}
+
+	certProvider := testCertificateProvider{
+		certURL: certNameToFileURL("offline1"), keyURL: keyNameToFileURL("offline1")}
+
+	cryptoContext, err := New(config.Crypt{CACert: rootCertURL.Path}, &certProvider)
+	if err != nil {
+		t.Fatalf("Error creating context: '%v'", err)
+	}
+
+	auditLogger := &testAuditLogger{}
+
+	cryptoContext.SetAuditLogger(auditLogger)
+
+	keyInfo := CryptoSessionKeyInfo{
+		SessionKey:        encryptedKey,
+		SessionIV:         iv,
+		SymmetricAlgName:  "AES128/CBC/PKCS7PADDING",
+		AsymmetricAlgName: "RSA/OAEP",
+		PackageID:         "service0",
+	}
+
+	if _, err = cryptoContext.ImportSessionKey(keyInfo); err != nil {
+		t.Fatalf("Error decode key: '%v'", err)
+	}
+
+	keyInfo.AsymmetricAlgName = "RSA/UNKNOWN"
+
+	if _, err = cryptoContext.ImportSessionKey(keyInfo); err == nil {
+		t.Error("Error expected for unknown asymmetric algorithm")
+	}
+
+	signCtx, err := cryptoContext.CreateSignContext()
+	if err != nil {
+		t.Fatalf("Error creating sign context: '%v'", err)
+	}
+
+	ctx := ContextWithPackageID(context.Background(), "service1")
+
+	if err = signCtx.VerifySign(ctx, nil, "chain", "RSA/SHA256/PKCS1v1_5", nil); err == nil {
+		t.Error("Error expected for not initialized sign context")
+	}
+
+	if len(auditLogger.records) != 3 {
+		t.Fatalf("Wrong audit records count: %d", len(auditLogger.records))
+	}
+
+	record := auditLogger.records[0]
+
+	if record.Operation != AuditImportSessionKey || record.PackageID != "service0" ||
+		record.Result != AuditResultSuccess || record.Algorithm != "RSA/OAEP/AES128/CBC/PKCS7PADDING" {
+		t.Errorf("Wrong audit record: %v", record)
+	}
+
+	if len(record.Fingerprints) == 0 || len(record.Fingerprints[0]) != 2*sha256.Size {
+		t.Errorf("Wrong audit record fingerprints: %v", record.Fingerprints)
+	}
+
+	if record = auditLogger.records[1]; record.Result != AuditResultFailed || record.Error == "" {
+		t.Errorf("Wrong audit record: %v", record)
+	}
+
+	if record = auditLogger.records[2]; record.Operation != AuditVerifySign ||
+		record.PackageID != "service1" || record.ChainName != "chain" || record.Result != AuditResultFailed {
+		t.Errorf("Wrong audit record: %v", record)
+	}
+
+	// Renewed certificate fingerprints should be loaded again
+
+	if len(cryptoContext.auditFingerprints) == 0 {
+		t.Error("Audit fingerprints are not cached")
+	}
+
+	cryptoContext.InvalidateAuditCache()
+
+	if len(cryptoContext.auditFingerprints) != 0 {
+		t.Error("Audit fingerprints cache is not invalidated")
+	}
+}
+
 /*******************************************************************************
  * Private
  ******************************************************************************/
@@ -1124,6 +1431,10 @@ func (provider *testCertificateProvider) GetCertificate(certType string, issuer
 	return provider.certURL, provider.keyURL, nil
 }
 
+func (logger *testAuditLogger) LogCryptoOperation(record AuditRecord) {
+	logger.records = append(logger.records, record)
+}
+
 func certNameToFileURL(name string) (file string) {
 	return cryptutils.SchemeFile + "://" + path.Join(tmpDir, "cert_"+name+".pem")
 }
@@ -1137,6 +1448,161 @@ func nameToPkcs11URL(name string) (file string) {
 		pkcs11Token, name, pkcs11LibPath, pkcs11Pin)
 }
 
+func handleToTPMURL(handle tpmutil.Handle) (tpmURL string) {
+	return fmt.Sprintf("%s:0x%X", cryptutils.SchemeTPM, handle)
+}
+
+func startTPMSimulator() (err error) {
+	if err = os.MkdirAll(tpmSimulatorState, 0755); err != nil {
+		return err
+	}
+
+	tpmSimulator = exec.Command("swtpm", "socket", "--tpm2",
+		"--server", "type=unixio,path="+tpmSimulatorSocket,
+		"--ctrl", "type=unixio,path="+tpmSimulatorCtrl,
+		"--tpmstate", "dir="+tpmSimulatorState,
+		"--flags", "not-need-init,startup-clear")
+
+	if err = tpmSimulator.Start(); err != nil {
+		return err
+	}
+
+	for i := 0; i < 50; i++ {
+		if _, err = os.Stat(tpmSimulatorSocket); err == nil {
+			return nil
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return err
+}
+
+func stopTPMSimulator() (err error) {
+	if tpmSimulator == nil || tpmSimulator.Process == nil {
+		return nil
+	}
+
+	if err = tpmSimulator.Process.Kill(); err != nil {
+		return err
+	}
+
+	_ = tpmSimulator.Wait()
+
+	return nil
+}
+
+func createTPMKey(persistentHandle tpmutil.Handle) (publicKey *rsa.PublicKey, err error) {
+	device, err := tpm2.OpenTPM(tpmSimulatorSocket)
+	if err != nil {
+		return nil, err
+	}
+	defer device.Close()
+
+	primaryHandle, _, err := tpm2.CreatePrimary(device, tpm2.HandleOwner, tpm2.PCRSelection{}, "", "", tpm2.Public{
+		Type:    tpm2.AlgRSA,
+		NameAlg: tpm2.AlgSHA256,
+		Attributes: tpm2.FlagFixedTPM | tpm2.FlagFixedParent | tpm2.FlagSensitiveDataOrigin |
+			tpm2.FlagUserWithAuth | tpm2.FlagRestricted | tpm2.FlagDecrypt,
+		RSAParameters: &tpm2.RSAParams{
+			Symmetric: &tpm2.SymScheme{Alg: tpm2.AlgAES, KeyBits: 128, Mode: tpm2.AlgCFB},
+			KeyBits:   2048,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer tpm2.FlushContext(device, primaryHandle)
+
+	privateBlob, publicBlob, _, _, _, err := tpm2.CreateKey(device, primaryHandle, tpm2.PCRSelection{}, "", "",
+		tpm2.Public{
+			Type:    tpm2.AlgRSA,
+			NameAlg: tpm2.AlgSHA256,
+			Attributes: tpm2.FlagFixedTPM | tpm2.FlagFixedParent | tpm2.FlagSensitiveDataOrigin |
+				tpm2.FlagUserWithAuth | tpm2.FlagDecrypt | tpm2.FlagSign,
+			RSAParameters: &tpm2.RSAParams{KeyBits: 2048},
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := tpmkey.CreateFromBlobs(device, primaryHandle, "", privateBlob, publicBlob)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = key.MakePersistent(persistentHandle); err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("TPM key doesn't implement signer interface")
+	}
+
+	if publicKey, ok = signer.Public().(*rsa.PublicKey); !ok {
+		return nil, fmt.Errorf("TPM key is not RSA key")
+	}
+
+	return publicKey, nil
+}
+
+func createTestEnvelope(publicKey *rsa.PublicKey, data []byte) (envelope []byte, err error) {
+	key := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+
+	if _, err = rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	if _, err = rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	padSize := aes.BlockSize - len(data)%aes.BlockSize
+	encryptedContent := append(data, bytes.Repeat([]byte{byte(padSize)}, padSize)...)
+
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encryptedContent, encryptedContent)
+
+	encryptedKey, err := rsa.EncryptPKCS1v15(rand.Reader, publicKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	issuer, err := asn1.Marshal(pkix.Name{CommonName: "Test issuer"}.ToRDNSequence())
+	if err != nil {
+		return nil, err
+	}
+
+	recipientInfo, err := asn1.Marshal(keyTransRecipientInfo{
+		Rid: issuerAndSerialNumber{Issuer: asn1.RawValue{FullBytes: issuer}, SerialNumber: big.NewInt(1)},
+		KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{
+			Algorithm: rsaEncryptionOid, Parameters: asn1.NullRawValue},
+		EncryptedKey: encryptedKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(asnContentInfo{
+		OID: envelopedDataOid,
+		EnvelopedData: asnEnvelopedData{
+			RecipientInfos: []asn1.RawValue{{FullBytes: recipientInfo}},
+			EncryptedContentInfo: EncryptedContentInfo{
+				ContentType: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1},
+				ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{
+					Algorithm: aes256CbcOid, Parameters: asn1.RawValue{Tag: asn1.TagOctetString, Bytes: iv}},
+				EncryptedContent: encryptedContent,
+			},
+		},
+	})
+}
+
 func setupFileStorage() (err error) {
 	for name, certData := range testCerts {
 		if certData.cert != nil {
@@ -26,6 +26,7 @@ import (
 	"github.com/aoscloud/aos_common/aoserrors"
 	pb "github.com/aoscloud/aos_common/api/iamanager/v1"
 	"github.com/aoscloud/aos_common/utils/cryptutils"
+	"github.com/aoscloud/aos_common/utils/retryhelper"
 	"github.com/golang/protobuf/ptypes/empty"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
@@ -40,8 +41,9 @@ import (
  **********************************************************************************************************************/
 
 const (
-	iamRequestTimeout   = 30 * time.Second
-	iamReconnectTimeout = 10 * time.Second
+	iamRequestTimeout      = 30 * time.Second
+	iamMinReconnectTimeout = 1 * time.Second
+	iamMaxReconnectTimeout = 1 * time.Minute
 )
 
 const usersChangedChannelSize = 1
@@ -54,7 +56,8 @@ const usersChangedChannelSize = 1
 type Client struct {
 	sync.Mutex
 
-	sender Sender
+	sender  Sender
+	storage Storage
 
 	systemID string
 	users    []string
@@ -63,7 +66,7 @@ type Client struct {
 	pbProtected pb.IAMProtectedServiceClient
 	pbPublic    pb.IAMPublicServiceClient
 
-	closeChannel        chan struct{}
+	cancelFunction      context.CancelFunc
 	usersChangedChannel chan []string
 }
 
@@ -73,6 +76,14 @@ type Sender interface {
 	SendInstallCertsConfirmation(confirmations []cloudprotocol.InstallCertData) (err error)
 }
 
+// Storage caches IAM info to be available when IAM is not accessible
+type Storage interface {
+	SetSystemID(systemID string) (err error)
+	GetSystemID() (systemID string, err error)
+	SetUsers(users []string) (err error)
+	GetUsers() (users []string, err error)
+}
+
 // CertificateProvider provides certificate info
 type CertificateProvider interface {
 	GetCertSerial(certURL string) (serial string, err error)
@@ -83,25 +94,34 @@ type CertificateProvider interface {
  **********************************************************************************************************************/
 
 // New creates new IAM client
-func New(config *config.Config, sender Sender, insecure bool) (client *Client, err error) {
+func New(config *config.Config, sender Sender, storage Storage, insecure bool) (client *Client, err error) {
 	log.Debug("Connecting to IAM...")
 
 	if sender == nil {
 		return nil, aoserrors.New("sender is nil")
 	}
 
+	if storage == nil {
+		return nil, aoserrors.New("storage is nil")
+	}
+
 	client = &Client{
 		sender:              sender,
-		usersChangedChannel: make(chan []string, usersChangedChannelSize),
-		closeChannel:        make(chan struct{}, 1)}
+		storage:             storage,
+		usersChangedChannel: make(chan []string, usersChangedChannelSize)}
 	defer func() {
 		if err != nil {
 			client.Close()
 		}
 	}()
 
-	ctx, cancel := context.WithTimeout(context.Background(), iamRequestTimeout)
-	defer cancel()
+	if client.systemID, err = client.storage.GetSystemID(); err != nil {
+		log.Warnf("Can't get cached system ID: %s", err)
+	}
+
+	if client.users, err = client.storage.GetUsers(); err != nil {
+		log.Warnf("Can't get cached users: %s", err)
+	}
 
 	var secureOpt grpc.DialOption
 
@@ -116,30 +136,33 @@ func New(config *config.Config, sender Sender, insecure bool) (client *Client, e
 		secureOpt = grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
 	}
 
-	if client.connection, err = grpc.DialContext(ctx, config.IAMServerURL, secureOpt, grpc.WithBlock()); err != nil {
+	// Connection is established and restored by gRPC in background
+	if client.connection, err = grpc.Dial(config.IAMServerURL, secureOpt); err != nil {
 		return client, aoserrors.Wrap(err)
 	}
 
 	client.pbProtected = pb.NewIAMProtectedServiceClient(client.connection)
 	client.pbPublic = pb.NewIAMPublicServiceClient(client.connection)
 
-	log.Debug("Connected to IAM")
-
-	if client.systemID, err = client.getSystemID(); err != nil {
-		return client, aoserrors.Wrap(err)
+	if err = client.syncIAMInfo(false); err != nil {
+		log.Warnf("IAM is not available, start with cached info: %s", err)
+	} else {
+		log.Debug("Connected to IAM")
 	}
 
-	if client.users, err = client.getUsers(); err != nil {
-		return client, aoserrors.Wrap(err)
-	}
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	client.cancelFunction = cancelFunc
 
-	go client.handleUsersChanged()
+	go client.handleConnection(ctx)
 
 	return client, nil
 }
 
 // GetSystemID returns system ID
 func (client *Client) GetSystemID() (systemID string) {
+	client.Lock()
+	defer client.Unlock()
+
 	return client.systemID
 }
 
@@ -158,29 +181,24 @@ func (client *Client) UsersChangedChannel() (channel <-chan []string) {
 
 // RenewCertificatesNotification renew certificates notification
 func (client *Client) RenewCertificatesNotification(pwd string, certInfo []cloudprotocol.RenewCertData) (err error) {
-	var newCerts []cloudprotocol.IssueCertData
+	certTypes := make([]string, 0, len(certInfo))
 
 	for _, cert := range certInfo {
 		log.WithFields(log.Fields{"type": cert.Type, "serial": cert.Serial, "validTill": cert.ValidTill}).Debug("Renew certificate")
 
-		ctx, cancel := context.WithTimeout(context.Background(), iamRequestTimeout)
-		defer cancel()
-
-		request := &pb.CreateKeyRequest{Type: cert.Type, Password: pwd}
-
-		response, err := client.pbProtected.CreateKey(ctx, request)
-		if err != nil {
-			return aoserrors.Wrap(err)
-		}
-
-		newCerts = append(newCerts, cloudprotocol.IssueCertData{Type: response.Type, Csr: response.Csr})
+		certTypes = append(certTypes, cert.Type)
 	}
 
-	if len(newCerts) == 0 {
-		return nil
+	if err = client.issueUnitCertificates(certTypes, pwd); err != nil {
+		return aoserrors.Wrap(err)
 	}
 
-	if err := client.sender.SendIssueUnitCerts(newCerts); err != nil {
+	return nil
+}
+
+// IssueUnitCertificates creates new keys with owner password and sends issue certificates request to the cloud
+func (client *Client) IssueUnitCertificates(certTypes []string, password string) (err error) {
+	if err = client.issueUnitCertificates(certTypes, password); err != nil {
 		return aoserrors.Wrap(err)
 	}
 
@@ -251,10 +269,167 @@ func (client *Client) GetCertificate(certType string, issuer []byte, serial stri
 	return response.CertUrl, response.KeyUrl, nil
 }
 
+// GetCertTypes returns all IAM cert types
+func (client *Client) GetCertTypes() (certTypes []string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), iamRequestTimeout)
+	defer cancel()
+
+	response, err := client.pbProtected.GetCertTypes(ctx, &empty.Empty{})
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	log.WithFields(log.Fields{"types": response.Types}).Debug("Get certificate types")
+
+	return response.Types, nil
+}
+
+// GetPermissions returns service ID and permissions of functional server for service secret
+func (client *Client) GetPermissions(
+	secret, funcServerID string) (serviceID string, permissions map[string]string, err error) {
+	log.WithFields(log.Fields{"funcServerID": funcServerID}).Debug("Get permissions")
+
+	ctx, cancel := context.WithTimeout(context.Background(), iamRequestTimeout)
+	defer cancel()
+
+	response, err := client.pbPublic.GetPermissions(
+		ctx, &pb.PermissionsRequest{Secret: secret, FunctionalServerId: funcServerID})
+	if err != nil {
+		return "", nil, aoserrors.Wrap(err)
+	}
+
+	return response.ServiceId, response.Permissions.GetPermissions(), nil
+}
+
+// RegisterService registers service with permissions for functional servers and returns service secret
+func (client *Client) RegisterService(
+	serviceID string, permissions map[string]map[string]string) (secret string, err error) {
+	log.WithFields(log.Fields{"serviceID": serviceID}).Debug("Register service")
+
+	ctx, cancel := context.WithTimeout(context.Background(), iamRequestTimeout)
+	defer cancel()
+
+	request := &pb.RegisterServiceRequest{ServiceId: serviceID, Permissions: make(map[string]*pb.Permissions)}
+
+	for funcServerID, funcServerPermissions := range permissions {
+		request.Permissions[funcServerID] = &pb.Permissions{Permissions: funcServerPermissions}
+	}
+
+	response, err := client.pbProtected.RegisterService(ctx, request)
+	if err != nil {
+		return "", aoserrors.Wrap(err)
+	}
+
+	return response.Secret, nil
+}
+
+// UnregisterService unregisters service
+func (client *Client) UnregisterService(serviceID string) (err error) {
+	log.WithFields(log.Fields{"serviceID": serviceID}).Debug("Unregister service")
+
+	ctx, cancel := context.WithTimeout(context.Background(), iamRequestTimeout)
+	defer cancel()
+
+	if _, err = client.pbProtected.UnregisterService(
+		ctx, &pb.UnregisterServiceRequest{ServiceId: serviceID}); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+// Clear removes certificates and keys of specified type
+func (client *Client) Clear(certType string) (err error) {
+	log.WithFields(log.Fields{"type": certType}).Debug("Clear certificates")
+
+	ctx, cancel := context.WithTimeout(context.Background(), iamRequestTimeout)
+	defer cancel()
+
+	if _, err = client.pbProtected.Clear(ctx, &pb.ClearRequest{Type: certType}); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+// SetOwner sets owner password for certificate storage of specified type
+func (client *Client) SetOwner(certType, password string) (err error) {
+	log.WithFields(log.Fields{"type": certType}).Debug("Set owner")
+
+	ctx, cancel := context.WithTimeout(context.Background(), iamRequestTimeout)
+	defer cancel()
+
+	if _, err = client.pbProtected.SetOwner(ctx, &pb.SetOwnerRequest{Type: certType, Password: password}); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+// CreateKey creates new key of specified type and returns its CSR
+func (client *Client) CreateKey(certType, password string) (csr string, err error) {
+	log.WithFields(log.Fields{"type": certType}).Debug("Create key")
+
+	ctx, cancel := context.WithTimeout(context.Background(), iamRequestTimeout)
+	defer cancel()
+
+	response, err := client.pbProtected.CreateKey(ctx, &pb.CreateKeyRequest{Type: certType, Password: password})
+	if err != nil {
+		return "", aoserrors.Wrap(err)
+	}
+
+	return response.Csr, nil
+}
+
+// ApplyCert applies issued certificate of specified type
+func (client *Client) ApplyCert(certType, cert string) (certURL string, err error) {
+	log.WithFields(log.Fields{"type": certType}).Debug("Apply certificate")
+
+	ctx, cancel := context.WithTimeout(context.Background(), iamRequestTimeout)
+	defer cancel()
+
+	response, err := client.pbProtected.ApplyCert(ctx, &pb.ApplyCertRequest{Type: certType, Cert: cert})
+	if err != nil {
+		return "", aoserrors.Wrap(err)
+	}
+
+	return response.CertUrl, nil
+}
+
+// EncryptDisk encrypts unit disk with owner password
+func (client *Client) EncryptDisk(password string) (err error) {
+	log.Debug("Encrypt disk")
+
+	// Disk encryption may take long time, don't limit it with request timeout
+	if _, err = client.pbProtected.EncryptDisk(
+		context.Background(), &pb.EncryptDiskRequest{Password: password}); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+// FinishProvisioning notifies IAM that provisioning is finished
+func (client *Client) FinishProvisioning() (err error) {
+	log.Debug("Finish provisioning")
+
+	ctx, cancel := context.WithTimeout(context.Background(), iamRequestTimeout)
+	defer cancel()
+
+	if _, err = client.pbProtected.FinishProvisioning(ctx, &empty.Empty{}); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
 // Close closes IAM client
 func (client *Client) Close() (err error) {
+	if client.cancelFunction != nil {
+		client.cancelFunction()
+	}
+
 	if client.connection != nil {
-		client.closeChannel <- struct{}{}
 		client.connection.Close()
 	}
 
@@ -299,31 +474,31 @@ func (client *Client) getUsers() (users []string, err error) {
 	return response.Users, nil
 }
 
-func (client *Client) handleUsersChanged() {
-	err := client.subscribeUsersChanged()
+func (client *Client) issueUnitCertificates(certTypes []string, pwd string) (err error) {
+	newCerts := make([]cloudprotocol.IssueCertData, 0, len(certTypes))
 
-	for {
-		if err != nil && len(client.closeChannel) == 0 {
-			log.Errorf("Error subscribe users changed: %s", err)
-			log.Debugf("Reconnect to IAM in %v...", iamReconnectTimeout)
+	for _, certType := range certTypes {
+		csr, err := client.CreateKey(certType, pwd)
+		if err != nil {
+			return aoserrors.Wrap(err)
 		}
 
-		select {
-		case <-client.closeChannel:
-			return
+		newCerts = append(newCerts, cloudprotocol.IssueCertData{Type: certType, Csr: csr})
+	}
 
-		case <-time.After(iamReconnectTimeout):
-			err = client.subscribeUsersChanged()
-		}
+	if len(newCerts) == 0 {
+		return nil
 	}
-}
 
-func (client *Client) subscribeUsersChanged() (err error) {
-	log.Debug("Subscribe to users changed notification")
+	if err := client.sender.SendIssueUnitCerts(newCerts); err != nil {
+		return aoserrors.Wrap(err)
+	}
 
-	request := &empty.Empty{}
+	return nil
+}
 
-	stream, err := client.pbPublic.SubscribeUsersChanged(context.Background(), request)
+func (client *Client) syncIAMInfo(notify bool) (err error) {
+	systemID, err := client.getSystemID()
 	if err != nil {
 		return aoserrors.Wrap(err)
 	}
@@ -333,14 +508,110 @@ func (client *Client) subscribeUsersChanged() (err error) {
 		return aoserrors.Wrap(err)
 	}
 
-	if !isUsersEqual(users, client.users) {
-		client.Lock()
-		client.users = users
-		client.Unlock()
+	client.Lock()
+	defer client.Unlock()
+
+	if systemID != client.systemID {
+		client.systemID = systemID
+
+		if err = client.storage.SetSystemID(systemID); err != nil {
+			log.Errorf("Can't cache system ID: %s", err)
+		}
+	}
+
+	client.setUsers(users, notify)
+
+	return nil
+}
+
+func (client *Client) setUsers(users []string, notify bool) {
+	if isUsersEqual(users, client.users) {
+		return
+	}
+
+	client.users = users
+
+	if err := client.storage.SetUsers(users); err != nil {
+		log.Errorf("Can't cache users: %s", err)
+	}
+
+	if !notify {
+		return
+	}
+
+	select {
+	case client.usersChangedChannel <- users:
+
+	default:
+		// Drop outdated users notification
+		select {
+		case <-client.usersChangedChannel:
+		default:
+		}
+
+		client.usersChangedChannel <- users
+	}
+}
+
+func (client *Client) handleConnection(ctx context.Context) {
+	for {
+		var (
+			stream       pb.IAMPublicService_SubscribeUsersChangedClient
+			cancelStream context.CancelFunc
+		)
+
+		if err := retryhelper.Retry(ctx,
+			func() (err error) {
+				stream, cancelStream, err = client.subscribeUsersChanged(ctx)
+
+				return aoserrors.Wrap(err)
+			},
+			func(retryCount int, delay time.Duration, err error) {
+				log.Errorf("Can't subscribe to IAM: %s", err)
+				log.Debugf("Reconnect to IAM in %v...", delay)
+			},
+			0, iamMinReconnectTimeout, iamMaxReconnectTimeout); err != nil {
+			return
+		}
+
+		if err := client.handleUsersChanged(stream); err != nil && ctx.Err() == nil {
+			log.Errorf("IAM users changed subscription error: %s", err)
+		}
 
-		client.usersChangedChannel <- client.users
+		cancelStream()
+
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-time.After(iamMinReconnectTimeout):
+		}
 	}
+}
+
+func (client *Client) subscribeUsersChanged(ctx context.Context) (
+	stream pb.IAMPublicService_SubscribeUsersChangedClient, cancelStream context.CancelFunc, err error) {
+	log.Debug("Subscribe to users changed notification")
+
+	streamCtx, cancelStream := context.WithCancel(ctx)
 
+	if stream, err = client.pbPublic.SubscribeUsersChanged(streamCtx, &empty.Empty{}); err != nil {
+		cancelStream()
+
+		return nil, nil, aoserrors.Wrap(err)
+	}
+
+	// Sync after subscription to not miss any change
+	if err = client.syncIAMInfo(true); err != nil {
+		cancelStream()
+
+		return nil, nil, aoserrors.Wrap(err)
+	}
+
+	return stream, cancelStream, nil
+}
+
+func (client *Client) handleUsersChanged(stream pb.IAMPublicService_SubscribeUsersChangedClient) (err error) {
 	for {
 		notification, err := stream.Recv()
 		if err != nil {
@@ -349,13 +620,9 @@ func (client *Client) subscribeUsersChanged() (err error) {
 
 		log.WithFields(log.Fields{"users": notification.Users}).Debug("Users changed notification")
 
-		if !isUsersEqual(notification.Users, client.users) {
-			client.Lock()
-			client.users = notification.Users
-			client.Unlock()
-
-			client.usersChangedChannel <- client.users
-		}
+		client.Lock()
+		client.setUsers(notification.Users, true)
+		client.Unlock()
 	}
 }
 
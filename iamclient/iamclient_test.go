@@ -30,6 +30,7 @@ import (
 	"path"
 	"reflect"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -70,6 +71,10 @@ type testServer struct {
 	certURL             map[string]string
 	keyURL              map[string]string
 	permissionsCache    map[string]servicePermissions
+	clearedTypes        []string
+	owners              map[string]string
+	diskPassword        string
+	provisioned         bool
 }
 
 type testSender struct {
@@ -80,6 +85,13 @@ type testSender struct {
 type testCertProvider struct {
 }
 
+type testStorage struct {
+	sync.Mutex
+
+	systemID string
+	users    []string
+}
+
 type servicePermissions struct {
 	serviceID   string
 	permissions map[string]map[string]string
@@ -138,7 +150,7 @@ func TestGetSystemID(t *testing.T) {
 
 	server.systemID = "testID"
 
-	client, err := iamclient.New(&config.Config{IAMServerURL: serverURL}, &testSender{}, true)
+	client, err := iamclient.New(&config.Config{IAMServerURL: serverURL}, &testSender{}, &testStorage{}, true)
 	if err != nil {
 		t.Fatalf("Can't create IAM client: %s", err)
 	}
@@ -158,7 +170,7 @@ func TestGetUsers(t *testing.T) {
 
 	server.users = []string{"user1", "user2", "user3"}
 
-	client, err := iamclient.New(&config.Config{IAMServerURL: serverURL}, &testSender{}, true)
+	client, err := iamclient.New(&config.Config{IAMServerURL: serverURL}, &testSender{}, &testStorage{}, true)
 	if err != nil {
 		t.Fatalf("Can't create IAM client: %s", err)
 	}
@@ -183,6 +195,221 @@ func TestGetUsers(t *testing.T) {
 	}
 }
 
+func TestDegradedStart(t *testing.T) {
+	storage := &testStorage{systemID: "cachedID", users: []string{"cachedUser"}}
+
+	client, err := iamclient.New(&config.Config{IAMServerURL: serverURL}, &testSender{}, storage, true)
+	if err != nil {
+		t.Fatalf("Can't create IAM client: %s", err)
+	}
+	defer client.Close()
+
+	if client.GetSystemID() != "cachedID" {
+		t.Errorf("Invalid system ID: %s", client.GetSystemID())
+	}
+
+	if !reflect.DeepEqual(client.GetUsers(), []string{"cachedUser"}) {
+		t.Errorf("Invalid users: %s", client.GetUsers())
+	}
+
+	server, err := newTestServer(serverURL)
+	if err != nil {
+		t.Fatalf("Can't create test server: %s", err)
+	}
+	defer server.close()
+
+	server.systemID = "testID"
+	server.users = []string{"user1", "user2"}
+
+	select {
+	case users := <-client.UsersChangedChannel():
+		if !reflect.DeepEqual(users, server.users) {
+			t.Errorf("Invalid users: %s", users)
+		}
+
+	case <-time.After(10 * time.Second):
+		t.Fatal("Wait users changed timeout")
+	}
+
+	if client.GetSystemID() != server.systemID {
+		t.Errorf("Invalid system ID: %s", client.GetSystemID())
+	}
+
+	systemID, _ := storage.GetSystemID()
+	users, _ := storage.GetUsers()
+
+	if systemID != server.systemID || !reflect.DeepEqual(users, server.users) {
+		t.Errorf("Wrong cached IAM info: %s, %v", systemID, users)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	server, err := newTestServer(serverURL)
+	if err != nil {
+		t.Fatalf("Can't create test server: %s", err)
+	}
+
+	server.systemID = "testID"
+	server.users = []string{"user1"}
+
+	client, err := iamclient.New(&config.Config{IAMServerURL: serverURL}, &testSender{}, &testStorage{}, true)
+	if err != nil {
+		t.Fatalf("Can't create IAM client: %s", err)
+	}
+	defer client.Close()
+
+	server.close()
+
+	if server, err = newTestServer(serverURL); err != nil {
+		t.Fatalf("Can't create test server: %s", err)
+	}
+	defer server.close()
+
+	server.systemID = "testID"
+	server.users = []string{"user1"}
+
+	newUsers := []string{"user2", "user3"}
+
+	server.usersChangedChannel <- newUsers
+
+	select {
+	case users := <-client.UsersChangedChannel():
+		if !reflect.DeepEqual(users, newUsers) {
+			t.Errorf("Invalid users: %s", users)
+		}
+
+	case <-time.After(10 * time.Second):
+		t.Error("Wait users changed timeout")
+	}
+}
+
+func TestGetPermissions(t *testing.T) {
+	server, err := newTestServer(serverURL)
+	if err != nil {
+		t.Fatalf("Can't create test server: %s", err)
+	}
+	defer server.close()
+
+	server.permissionsCache["secret"] = servicePermissions{
+		serviceID: "service1", permissions: map[string]map[string]string{"cm": {"update.start": "true"}}}
+
+	client, err := iamclient.New(&config.Config{IAMServerURL: serverURL}, &testSender{}, &testStorage{}, true)
+	if err != nil {
+		t.Fatalf("Can't create IAM client: %s", err)
+	}
+	defer client.Close()
+
+	serviceID, permissions, err := client.GetPermissions("secret", "cm")
+	if err != nil {
+		t.Fatalf("Can't get permissions: %s", err)
+	}
+
+	if serviceID != "service1" || !reflect.DeepEqual(permissions, map[string]string{"update.start": "true"}) {
+		t.Errorf("Wrong permissions: %s, %v", serviceID, permissions)
+	}
+
+	if _, _, err = client.GetPermissions("unknown", "cm"); err == nil {
+		t.Error("Error expected for unknown secret")
+	}
+}
+
+func TestRegisterService(t *testing.T) {
+	server, err := newTestServer(serverURL)
+	if err != nil {
+		t.Fatalf("Can't create test server: %s", err)
+	}
+	defer server.close()
+
+	client, err := iamclient.New(&config.Config{IAMServerURL: serverURL}, &testSender{}, &testStorage{}, true)
+	if err != nil {
+		t.Fatalf("Can't create IAM client: %s", err)
+	}
+	defer client.Close()
+
+	permissions := map[string]map[string]string{"cm": {"update.read": "true"}}
+
+	secret, err := client.RegisterService("service1", permissions)
+	if err != nil {
+		t.Fatalf("Can't register service: %s", err)
+	}
+
+	serviceID, servicePermissions, err := client.GetPermissions(secret, "cm")
+	if err != nil {
+		t.Fatalf("Can't get permissions: %s", err)
+	}
+
+	if serviceID != "service1" || !reflect.DeepEqual(servicePermissions, permissions["cm"]) {
+		t.Errorf("Wrong permissions: %s, %v", serviceID, servicePermissions)
+	}
+
+	if err = client.UnregisterService("service1"); err != nil {
+		t.Fatalf("Can't unregister service: %s", err)
+	}
+
+	if _, _, err = client.GetPermissions(secret, "cm"); err == nil {
+		t.Error("Error expected for unregistered service")
+	}
+}
+
+func TestProvisioning(t *testing.T) {
+	server, err := newTestServer(serverURL)
+	if err != nil {
+		t.Fatalf("Can't create test server: %s", err)
+	}
+	defer server.close()
+
+	server.csr = map[string]string{"online": "onlineCSR"}
+	server.certURL = map[string]string{"online": "onlineCertURL"}
+
+	client, err := iamclient.New(&config.Config{IAMServerURL: serverURL}, &testSender{}, &testStorage{}, true)
+	if err != nil {
+		t.Fatalf("Can't create IAM client: %s", err)
+	}
+	defer client.Close()
+
+	if err = client.Clear("online"); err != nil {
+		t.Fatalf("Can't clear: %s", err)
+	}
+
+	if err = client.SetOwner("online", "pwd"); err != nil {
+		t.Fatalf("Can't set owner: %s", err)
+	}
+
+	csr, err := client.CreateKey("online", "pwd")
+	if err != nil {
+		t.Fatalf("Can't create key: %s", err)
+	}
+
+	certURL, err := client.ApplyCert("online", "onlineCert")
+	if err != nil {
+		t.Fatalf("Can't apply cert: %s", err)
+	}
+
+	if err = client.EncryptDisk("pwd"); err != nil {
+		t.Fatalf("Can't encrypt disk: %s", err)
+	}
+
+	if err = client.FinishProvisioning(); err != nil {
+		t.Fatalf("Can't finish provisioning: %s", err)
+	}
+
+	if !reflect.DeepEqual(server.clearedTypes, []string{"online"}) {
+		t.Errorf("Wrong cleared types: %v", server.clearedTypes)
+	}
+
+	if server.owners["online"] != "pwd" {
+		t.Errorf("Wrong owner password: %s", server.owners["online"])
+	}
+
+	if csr != "onlineCSR" || certURL != "onlineCertURL" {
+		t.Errorf("Wrong CSR or cert URL: %s, %s", csr, certURL)
+	}
+
+	if server.diskPassword != "pwd" || !server.provisioned {
+		t.Error("Disk is not encrypted or provisioning is not finished")
+	}
+}
+
 func TestRenewCertificatesNotification(t *testing.T) {
 	sender := &testSender{}
 
@@ -194,7 +421,7 @@ func TestRenewCertificatesNotification(t *testing.T) {
 
 	server.csr = map[string]string{"online": "onlineCSR", "offline": "offlineCSR"}
 
-	client, err := iamclient.New(&config.Config{IAMServerURL: serverURL}, sender, true)
+	client, err := iamclient.New(&config.Config{IAMServerURL: serverURL}, sender, &testStorage{}, true)
 	if err != nil {
 		t.Fatalf("Can't create IAM client: %s", err)
 	}
@@ -212,6 +439,10 @@ func TestRenewCertificatesNotification(t *testing.T) {
 	if !reflect.DeepEqual(server.csr, sender.csr) {
 		t.Errorf("Wrong sender CSR: %v", sender.csr)
 	}
+
+	if err = client.IssueUnitCertificates([]string{"online"}, "pwd"); err != nil {
+		t.Errorf("Can't issue unit certificates: %s", err)
+	}
 }
 
 func TestInstallCertificates(t *testing.T) {
@@ -286,7 +517,7 @@ KzpDMr/kcScwzmmNcN8aLp31TSRVee64QrK7yF3YJxL+rA==
 		"online":  onlineURL.String(),
 		"offline": offlineURL.String()}
 
-	client, err := iamclient.New(&config.Config{IAMServerURL: serverURL}, sender, true)
+	client, err := iamclient.New(&config.Config{IAMServerURL: serverURL}, sender, &testStorage{}, true)
 	if err != nil {
 		t.Fatalf("Can't create IAM client: %s", err)
 	}
@@ -320,7 +551,7 @@ func TestGetCertificates(t *testing.T) {
 	server.certURL = map[string]string{"online": "onlineCertURL", "offline": "offlineCertURL"}
 	server.keyURL = map[string]string{"online": "onlineKeyURL", "offline": "offlineKeyURL"}
 
-	client, err := iamclient.New(&config.Config{IAMServerURL: serverURL}, sender, true)
+	client, err := iamclient.New(&config.Config{IAMServerURL: serverURL}, sender, &testStorage{}, true)
 	if err != nil {
 		t.Fatalf("Can't create IAM client: %s", err)
 	}
@@ -427,15 +658,25 @@ func (server *testServer) GetCertTypes(context context.Context, req *empty.Empty
 }
 
 func (server *testServer) FinishProvisioning(context context.Context, req *empty.Empty) (rsp *empty.Empty, err error) {
-	return rsp, nil
+	server.provisioned = true
+
+	return &empty.Empty{}, nil
 }
 
 func (server *testServer) Clear(context context.Context, req *pb.ClearRequest) (rsp *empty.Empty, err error) {
-	return rsp, nil
+	server.clearedTypes = append(server.clearedTypes, req.Type)
+
+	return &empty.Empty{}, nil
 }
 
 func (server *testServer) SetOwner(context context.Context, req *pb.SetOwnerRequest) (rsp *empty.Empty, err error) {
-	return rsp, nil
+	if server.owners == nil {
+		server.owners = make(map[string]string)
+	}
+
+	server.owners[req.Type] = req.Password
+
+	return &empty.Empty{}, nil
 }
 
 func (server *testServer) GetSystemInfo(
@@ -524,8 +765,10 @@ func (server *testServer) GetPermissions(
 	return rsp, nil
 }
 
-func (server *testServer) EncryptDisk(context.Context, *pb.EncryptDiskRequest) (*empty.Empty, error) {
-	return nil, nil
+func (server *testServer) EncryptDisk(context context.Context, req *pb.EncryptDiskRequest) (*empty.Empty, error) {
+	server.diskPassword = req.Password
+
+	return &empty.Empty{}, nil
 }
 
 func (server *testServer) findServiceID(serviceID string) (secret string) {
@@ -591,3 +834,35 @@ func (provider *testCertProvider) GetCertSerial(certURLStr string) (serial strin
 
 	return fmt.Sprintf("%X", certs[0].SerialNumber), nil
 }
+
+func (storage *testStorage) SetSystemID(systemID string) (err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	storage.systemID = systemID
+
+	return nil
+}
+
+func (storage *testStorage) GetSystemID() (systemID string, err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	return storage.systemID, nil
+}
+
+func (storage *testStorage) SetUsers(users []string) (err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	storage.users = users
+
+	return nil
+}
+
+func (storage *testStorage) GetUsers() (users []string, err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	return storage.users, nil
+}
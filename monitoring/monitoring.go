@@ -22,6 +22,7 @@ import (
 	"container/list"
 	"context"
 	"math"
+	"sort"
 	"sync"
 	"time"
 
@@ -71,12 +72,19 @@ type Monitor struct {
 	pollTimer *time.Ticker
 
 	dataToSend cloudprotocol.MonitoringData
+	nodesData  map[string]*nodeMonitoring
 
 	alertProcessors *list.List
 
 	cancelFunction context.CancelFunc
 }
 
+type nodeMonitoring struct {
+	data            cloudprotocol.NodeMonitoringData
+	updateTime      time.Time
+	alertProcessors *list.List
+}
+
 /***********************************************************************************************************************
  * Public
  **********************************************************************************************************************/
@@ -96,6 +104,7 @@ func New(config *config.Config, resourceAlerts ResourceAlertSender,
 	monitor.alertProcessors = list.New()
 
 	monitor.dataToSend.ServicesData = make([]cloudprotocol.ServiceMonitoringData, 0)
+	monitor.nodesData = make(map[string]*nodeMonitoring)
 
 	if monitor.resourceAlerts != nil {
 		if config.Monitoring.CPU != nil {
@@ -176,12 +185,39 @@ func New(config *config.Config, resourceAlerts ResourceAlertSender,
 	return monitor, nil
 }
 
-// SendMonitoringData sends monitoring data
+// SendMonitoringData sends monitoring data. Monitoring data of the node is aggregated with the latest data of other
+// nodes. If system monitoring is enabled, aggregated data is sent periodically, otherwise it is sent on each update.
 func (monitor *Monitor) SendMonitoringData(monitoringData cloudprotocol.MonitoringData) (err error) {
 	monitor.Lock()
 	defer monitor.Unlock()
 
-	monitor.sendMonitoringData(monitoringData)
+	if monitoringData.NodeID == "" {
+		monitor.sendMonitoringData(monitoringData)
+
+		return nil
+	}
+
+	node, ok := monitor.nodesData[monitoringData.NodeID]
+	if !ok {
+		node = monitor.newNodeMonitoring(monitoringData.NodeID)
+		monitor.nodesData[monitoringData.NodeID] = node
+	}
+
+	node.data = cloudprotocol.NodeMonitoringData{
+		NodeID:       monitoringData.NodeID,
+		Timestamp:    monitoringData.Timestamp,
+		Global:       monitoringData.Global,
+		ServicesData: monitoringData.ServicesData,
+	}
+	node.updateTime = time.Now()
+
+	for e := node.alertProcessors.Front(); e != nil; e = e.Next() {
+		e.Value.(*alertProcessor).checkAlertDetection(node.updateTime)
+	}
+
+	if monitor.sendTimer == nil {
+		monitor.sendMonitoringData(monitor.getAggregatedData(monitoringData.Timestamp))
+	}
 
 	return nil
 }
@@ -219,7 +255,7 @@ func (monitor *Monitor) run(ctx context.Context) {
 		case <-monitor.sendTimer.C:
 			monitor.Lock()
 			monitor.dataToSend.Timestamp = time.Now()
-			monitor.sendMonitoringData(monitor.dataToSend)
+			monitor.sendMonitoringData(monitor.getAggregatedData(monitor.dataToSend.Timestamp))
 			monitor.Unlock()
 
 		case <-monitor.pollTimer.C:
@@ -239,6 +275,108 @@ func (monitor *Monitor) sendMonitoringData(monitoringData cloudprotocol.Monitori
 	}
 }
 
+// newNodeMonitoring creates node monitoring entry with alert processors of the node alert rules
+func (monitor *Monitor) newNodeMonitoring(nodeID string) (node *nodeMonitoring) {
+	node = &nodeMonitoring{alertProcessors: list.New()}
+
+	rules, ok := monitor.config.Nodes[nodeID]
+	if !ok || monitor.resourceAlerts == nil {
+		return node
+	}
+
+	for _, item := range []struct {
+		resource string
+		source   *uint64
+		rule     *config.AlertRule
+	}{
+		{"cpu", &node.data.Global.CPU, rules.CPU},
+		{"ram", &node.data.Global.RAM, rules.RAM},
+		{"disk", &node.data.Global.UsedDisk, rules.UsedDisk},
+		{"inTraffic", &node.data.Global.InTraffic, rules.InTraffic},
+		{"outTraffic", &node.data.Global.OutTraffic, rules.OutTraffic},
+	} {
+		if item.rule == nil {
+			continue
+		}
+
+		resource := item.resource
+
+		node.alertProcessors.PushBack(createAlertProcessor(
+			"Node "+nodeID+" "+resource,
+			item.source,
+			func(time time.Time, value uint64) {
+				monitor.resourceAlerts.SendResourceAlert(nodeID, resource, time, value)
+			},
+			*item.rule))
+	}
+
+	return node
+}
+
+// removeExpiredNodes removes data of nodes which haven't sent monitoring data during node data TTL
+func (monitor *Monitor) removeExpiredNodes(currentTime time.Time) {
+	if monitor.config.NodeDataTTL.Duration == 0 {
+		return
+	}
+
+	for nodeID, node := range monitor.nodesData {
+		if currentTime.Sub(node.updateTime) > monitor.config.NodeDataTTL.Duration {
+			log.WithField("nodeID", nodeID).Warn("Node monitoring data expired")
+
+			delete(monitor.nodesData, nodeID)
+		}
+	}
+}
+
+// getAggregatedData returns unit-wide totals of nodes monitoring data. CM system data is used only if there is no
+// nodes data as CM node is monitored by its local SM. Data of nodes which stopped reporting is not aggregated.
+func (monitor *Monitor) getAggregatedData(timestamp time.Time) (monitoringData cloudprotocol.MonitoringData) {
+	monitor.removeExpiredNodes(time.Now())
+
+	if len(monitor.nodesData) == 0 {
+		monitoringData = monitor.dataToSend
+		monitoringData.Timestamp = timestamp
+
+		return monitoringData
+	}
+
+	monitoringData = cloudprotocol.MonitoringData{
+		Timestamp:    timestamp,
+		ServicesData: make([]cloudprotocol.ServiceMonitoringData, 0),
+	}
+
+	nodeIDs := make([]string, 0, len(monitor.nodesData))
+
+	for nodeID := range monitor.nodesData {
+		nodeIDs = append(nodeIDs, nodeID)
+	}
+
+	sort.Strings(nodeIDs)
+
+	var cpu uint64
+
+	for _, nodeID := range nodeIDs {
+		nodeData := monitor.nodesData[nodeID].data
+
+		cpu += nodeData.Global.CPU
+		monitoringData.Global.RAM += nodeData.Global.RAM
+		monitoringData.Global.UsedDisk += nodeData.Global.UsedDisk
+		monitoringData.Global.InTraffic += nodeData.Global.InTraffic
+		monitoringData.Global.OutTraffic += nodeData.Global.OutTraffic
+
+		for _, serviceData := range nodeData.ServicesData {
+			monitoringData.ServicesData = addServiceData(monitoringData.ServicesData, serviceData)
+		}
+
+		monitoringData.Nodes = append(monitoringData.Nodes, nodeData)
+	}
+
+	// CPU is measured in percent, so average value is used
+	monitoringData.Global.CPU = cpu / uint64(len(nodeIDs))
+
+	return monitoringData
+}
+
 func (monitor *Monitor) getCurrentSystemData() {
 	cpu, err := getSystemCPUUsage()
 	if err != nil {
@@ -280,6 +418,26 @@ func (monitor *Monitor) processAlerts() {
 	}
 }
 
+// addServiceData sums monitoring data of service replicas running on different nodes
+func addServiceData(servicesData []cloudprotocol.ServiceMonitoringData,
+	serviceData cloudprotocol.ServiceMonitoringData) (result []cloudprotocol.ServiceMonitoringData) {
+	for i, data := range servicesData {
+		if data.ServiceID != serviceData.ServiceID {
+			continue
+		}
+
+		servicesData[i].RAM += serviceData.RAM
+		servicesData[i].CPU += serviceData.CPU
+		servicesData[i].UsedDisk += serviceData.UsedDisk
+		servicesData[i].InTraffic += serviceData.InTraffic
+		servicesData[i].OutTraffic += serviceData.OutTraffic
+
+		return servicesData
+	}
+
+	return append(servicesData, serviceData)
+}
+
 // getSystemCPUUsage returns CPU usage in parcent
 func getSystemCPUUsage() (cpuUse float64, err error) {
 	v, err := cpu.Percent(0, false)
@@ -19,6 +19,7 @@ package monitoring
 
 import (
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
@@ -188,6 +189,121 @@ func TestSystemAlerts(t *testing.T) {
 	}
 }
 
+func TestNodesAggregation(t *testing.T) {
+	testSender := newTestSender()
+
+	monitor, err := New(&config.Config{Monitoring: config.Monitoring{MaxOfflineMessages: 10}}, nil, nil, testSender)
+	if err != nil {
+		t.Fatalf("Can't create monitoring instance: %s", err)
+	}
+	defer monitor.Close()
+
+	nodesData := []cloudprotocol.MonitoringData{
+		{
+			NodeID: "node2", Timestamp: time.Now(),
+			Global: cloudprotocol.GlobalMonitoringData{RAM: 100, CPU: 20, UsedDisk: 300, InTraffic: 1, OutTraffic: 2},
+			ServicesData: []cloudprotocol.ServiceMonitoringData{
+				{ServiceID: "service1", RAM: 10, CPU: 5, UsedDisk: 30},
+				{ServiceID: "service2", RAM: 20, CPU: 10, UsedDisk: 40},
+			},
+		},
+		{
+			NodeID: "node1", Timestamp: time.Now(),
+			Global: cloudprotocol.GlobalMonitoringData{RAM: 200, CPU: 40, UsedDisk: 100, InTraffic: 3, OutTraffic: 4},
+			ServicesData: []cloudprotocol.ServiceMonitoringData{
+				{ServiceID: "service1", RAM: 15, CPU: 5, UsedDisk: 30},
+			},
+		},
+	}
+
+	var monitoringData cloudprotocol.MonitoringData
+
+	for _, nodeData := range nodesData {
+		if err = monitor.SendMonitoringData(nodeData); err != nil {
+			t.Fatalf("Can't send monitoring data: %s", err)
+		}
+
+		if monitoringData, err = testSender.waitResult(5 * time.Second); err != nil {
+			t.Fatalf("Can't wait monitoring result: %s", err)
+		}
+	}
+
+	if monitoringData.Global != (cloudprotocol.GlobalMonitoringData{
+		RAM: 300, CPU: 30, UsedDisk: 400, InTraffic: 4, OutTraffic: 6}) {
+		t.Errorf("Wrong unit monitoring data: %v", monitoringData.Global)
+	}
+
+	if !reflect.DeepEqual(monitoringData.ServicesData, []cloudprotocol.ServiceMonitoringData{
+		{ServiceID: "service1", RAM: 25, CPU: 10, UsedDisk: 60},
+		{ServiceID: "service2", RAM: 20, CPU: 10, UsedDisk: 40},
+	}) {
+		t.Errorf("Wrong services monitoring data: %v", monitoringData.ServicesData)
+	}
+
+	if len(monitoringData.Nodes) != 2 || monitoringData.Nodes[0].NodeID != "node1" ||
+		monitoringData.Nodes[1].NodeID != "node2" ||
+		!reflect.DeepEqual(monitoringData.Nodes[1].ServicesData, nodesData[0].ServicesData) {
+		t.Errorf("Wrong nodes monitoring data: %v", monitoringData.Nodes)
+	}
+}
+
+func TestNodeAlertsAndExpiration(t *testing.T) {
+	testSender := newTestSender()
+
+	type alert struct {
+		source   string
+		resource string
+	}
+
+	var alerts []alert
+
+	monitor, err := New(&config.Config{Monitoring: config.Monitoring{
+		MaxOfflineMessages: 10,
+		NodeDataTTL:        config.Duration{Duration: 1 * time.Second},
+		Nodes: map[string]config.NodeAlertRules{
+			"node1": {CPU: &config.AlertRule{MinTimeout: config.Duration{}, MinThreshold: 0, MaxThreshold: 0}},
+		},
+	}}, &testAlerts{callback: func(source, resource string, time time.Time, value uint64) {
+		alerts = append(alerts, alert{source: source, resource: resource})
+	}}, nil, testSender)
+	if err != nil {
+		t.Fatalf("Can't create monitoring instance: %s", err)
+	}
+	defer monitor.Close()
+
+	for _, nodeID := range []string{"node1", "node2"} {
+		if err = monitor.SendMonitoringData(cloudprotocol.MonitoringData{
+			NodeID: nodeID, Timestamp: time.Now(),
+			Global: cloudprotocol.GlobalMonitoringData{CPU: 50}}); err != nil {
+			t.Fatalf("Can't send monitoring data: %s", err)
+		}
+
+		if _, err = testSender.waitResult(5 * time.Second); err != nil {
+			t.Fatalf("Can't wait monitoring result: %s", err)
+		}
+	}
+
+	if !reflect.DeepEqual(alerts, []alert{{source: "node1", resource: "cpu"}}) {
+		t.Errorf("Wrong node alerts: %v", alerts)
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+
+	if err = monitor.SendMonitoringData(cloudprotocol.MonitoringData{
+		NodeID: "node2", Timestamp: time.Now()}); err != nil {
+		t.Fatalf("Can't send monitoring data: %s", err)
+	}
+
+	monitoringData, err := testSender.waitResult(5 * time.Second)
+	if err != nil {
+		t.Fatalf("Can't wait monitoring result: %s", err)
+	}
+
+	if len(monitoringData.Nodes) != 1 || monitoringData.Nodes[0].NodeID != "node2" {
+		t.Errorf("Wrong nodes monitoring data: %v", monitoringData.Nodes)
+	}
+}
+
 /*******************************************************************************
  * Interfaces
  ******************************************************************************/
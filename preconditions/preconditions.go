@@ -0,0 +1,278 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package preconditions checks conditions which should be satisfied before update is started
+package preconditions
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/aoscloud/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Built-in precondition names
+const (
+	ParkedCondition      = "parked"
+	BatteryCondition     = "battery"
+	NotChargingCondition = "notCharging"
+	IgnitionOffCondition = "ignitionOff"
+	FreeRAMCondition     = "freeRam"
+	FreeDiskCondition    = "freeDisk"
+)
+
+const memInfoFile = "/proc/meminfo"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// Provider update precondition provider
+type Provider interface {
+	GetName() string
+	// Check returns error which describes why precondition is not satisfied
+	Check() (err error)
+}
+
+// Status precondition status
+type Status struct {
+	Name string
+	// Empty if precondition is satisfied
+	Reason string
+}
+
+// Preconditions preconditions engine instance
+type Preconditions struct {
+	sync.Mutex
+
+	providers []Provider
+	vehicle   *vehicleState
+	server    *apiServer
+}
+
+type checkProvider struct {
+	name  string
+	check func() (err error)
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// New creates preconditions engine with providers enabled in config
+func New(cfg *config.Config) (preconditions *Preconditions, err error) {
+	log.Debug("Create preconditions engine")
+
+	preconditions = &Preconditions{vehicle: &vehicleState{}}
+
+	if cfg.Preconditions.Parked {
+		preconditions.AddProvider(NewProvider(ParkedCondition, preconditions.vehicle.checkParked))
+	}
+
+	if cfg.Preconditions.MinBatteryLevel != 0 {
+		minLevel := cfg.Preconditions.MinBatteryLevel
+
+		preconditions.AddProvider(NewProvider(BatteryCondition, func() (err error) {
+			return preconditions.vehicle.checkBatteryLevel(minLevel)
+		}))
+	}
+
+	if cfg.Preconditions.NotCharging {
+		preconditions.AddProvider(NewProvider(NotChargingCondition, preconditions.vehicle.checkNotCharging))
+	}
+
+	if cfg.Preconditions.IgnitionOff {
+		preconditions.AddProvider(NewProvider(IgnitionOffCondition, preconditions.vehicle.checkIgnitionOff))
+	}
+
+	if cfg.Preconditions.MinFreeRAM != 0 {
+		minRAM := cfg.Preconditions.MinFreeRAM
+
+		preconditions.AddProvider(NewProvider(FreeRAMCondition, func() (err error) {
+			return checkFreeRAM(minRAM)
+		}))
+	}
+
+	if cfg.Preconditions.MinFreeDisk != 0 {
+		minDisk, diskPath := cfg.Preconditions.MinFreeDisk, cfg.Preconditions.DiskPath
+
+		preconditions.AddProvider(NewProvider(FreeDiskCondition, func() (err error) {
+			return checkFreeDisk(diskPath, minDisk)
+		}))
+	}
+
+	if cfg.Preconditions.ServerURL != "" {
+		if preconditions.server, err = newServer(cfg.Preconditions.ServerURL, preconditions); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+	}
+
+	return preconditions, nil
+}
+
+// Close closes preconditions engine
+func (preconditions *Preconditions) Close() {
+	log.Debug("Close preconditions engine")
+
+	if preconditions.server != nil {
+		preconditions.server.close()
+	}
+}
+
+// NewProvider creates precondition provider from check function
+func NewProvider(name string, check func() (err error)) (provider Provider) {
+	return &checkProvider{name: name, check: check}
+}
+
+// AddProvider adds precondition provider
+func (preconditions *Preconditions) AddProvider(provider Provider) {
+	preconditions.Lock()
+	defer preconditions.Unlock()
+
+	log.WithField("name", provider.GetName()).Debug("Add update precondition")
+
+	preconditions.providers = append(preconditions.providers, provider)
+}
+
+// GetStatus returns status of all preconditions
+func (preconditions *Preconditions) GetStatus() (status []Status) {
+	preconditions.Lock()
+	defer preconditions.Unlock()
+
+	for _, provider := range preconditions.providers {
+		item := Status{Name: provider.GetName()}
+
+		if err := provider.Check(); err != nil {
+			item.Reason = err.Error()
+		}
+
+		status = append(status, item)
+	}
+
+	return status
+}
+
+// CheckPreconditions returns unsatisfied preconditions
+func (preconditions *Preconditions) CheckPreconditions() (unsatisfied []string) {
+	for _, item := range preconditions.GetStatus() {
+		if item.Reason != "" {
+			unsatisfied = append(unsatisfied, item.Name+": "+item.Reason)
+		}
+	}
+
+	return unsatisfied
+}
+
+// SetParked sets vehicle parked state
+func (preconditions *Preconditions) SetParked(parked bool) {
+	preconditions.vehicle.setParked(parked)
+}
+
+// SetBatteryLevel sets vehicle battery level in percents
+func (preconditions *Preconditions) SetBatteryLevel(level uint32) {
+	preconditions.vehicle.setBatteryLevel(level)
+}
+
+// SetCharging sets vehicle charging state
+func (preconditions *Preconditions) SetCharging(charging bool) {
+	preconditions.vehicle.setCharging(charging)
+}
+
+// SetIgnitionOn sets vehicle ignition state
+func (preconditions *Preconditions) SetIgnitionOn(ignitionOn bool) {
+	preconditions.vehicle.setIgnitionOn(ignitionOn)
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (provider *checkProvider) GetName() (name string) {
+	return provider.name
+}
+
+func (provider *checkProvider) Check() (err error) {
+	return provider.check()
+}
+
+func checkFreeRAM(minRAM uint64) (err error) {
+	freeRAM, err := getAvailableRAM()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if freeRAM < minRAM {
+		return aoserrors.Errorf("free RAM %d is below %d", freeRAM, minRAM)
+	}
+
+	return nil
+}
+
+func checkFreeDisk(diskPath string, minDisk uint64) (err error) {
+	var stat syscall.Statfs_t
+
+	if err = syscall.Statfs(diskPath, &stat); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if freeDisk := stat.Bavail * uint64(stat.Bsize); freeDisk < minDisk {
+		return aoserrors.Errorf("free disk %d is below %d", freeDisk, minDisk)
+	}
+
+	return nil
+}
+
+func getAvailableRAM() (availableRAM uint64, err error) {
+	file, err := os.Open(memInfoFile)
+	if err != nil {
+		return 0, aoserrors.Wrap(err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		// Format: "MemAvailable:   12345678 kB"
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "MemAvailable:" {
+			continue
+		}
+
+		if availableRAM, err = strconv.ParseUint(fields[1], 10, 64); err != nil {
+			return 0, aoserrors.Wrap(err)
+		}
+
+		return availableRAM * 1024, nil
+	}
+
+	if err = scanner.Err(); err != nil {
+		return 0, aoserrors.Wrap(err)
+	}
+
+	return 0, aoserrors.Errorf("available memory not found in %s", memInfoFile)
+}
@@ -0,0 +1,260 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preconditions_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aoscloud/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pbcm "aos_communicationmanager/api/cmserver/v1"
+	"aos_communicationmanager/config"
+	"aos_communicationmanager/preconditions"
+)
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+var tmpDir string
+
+/***********************************************************************************************************************
+ * Init
+ **********************************************************************************************************************/
+
+func init() {
+	log.SetFormatter(&log.TextFormatter{
+		DisableTimestamp: false,
+		TimestampFormat:  "2006-01-02 15:04:05.000",
+		FullTimestamp:    true})
+	log.SetLevel(log.DebugLevel)
+	log.SetOutput(os.Stdout)
+}
+
+/***********************************************************************************************************************
+ * Main
+ **********************************************************************************************************************/
+
+func TestMain(m *testing.M) {
+	var err error
+
+	if tmpDir, err = ioutil.TempDir("", "cm_"); err != nil {
+		log.Fatalf("Error creating tmp dir: %s", err)
+	}
+
+	ret := m.Run()
+
+	if err = os.RemoveAll(tmpDir); err != nil {
+		log.Errorf("Error removing tmp dir: %s", err)
+	}
+
+	os.Exit(ret)
+}
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestVehiclePreconditions(t *testing.T) {
+	socketPath := path.Join(tmpDir, "preconditions.sock")
+
+	engine, err := preconditions.New(&config.Config{Preconditions: config.Preconditions{
+		ServerURL:       "unix://" + socketPath,
+		Parked:          true,
+		MinBatteryLevel: 40,
+		NotCharging:     true,
+		IgnitionOff:     true,
+	}})
+	if err != nil {
+		t.Fatalf("Can't create preconditions: %s", err)
+	}
+	defer engine.Close()
+
+	info, err := os.Stat(socketPath)
+	if err != nil {
+		t.Fatalf("Can't get socket info: %s", err)
+	}
+
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Wrong socket permissions: %v", info.Mode().Perm())
+	}
+
+	// Vehicle state is unknown until it is reported
+
+	if err = checkUnsatisfied(engine.CheckPreconditions(), []string{
+		preconditions.ParkedCondition, preconditions.BatteryCondition,
+		preconditions.NotChargingCondition, preconditions.IgnitionOffCondition,
+	}); err != nil {
+		t.Errorf("Wrong unsatisfied preconditions: %s", err)
+	}
+
+	connection, err := grpc.Dial("unix://"+socketPath, grpc.WithInsecure(), grpc.WithBlock(),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+		}))
+	if err != nil {
+		t.Fatalf("Can't connect to preconditions server: %s", err)
+	}
+	defer connection.Close()
+
+	client := pbcm.NewPreconditionsServiceClient(connection)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err = client.SetVehicleState(ctx, &pbcm.VehicleState{Signals: []*pbcm.VehicleSignal{
+		{Signal: &pbcm.VehicleSignal_Parked{Parked: true}},
+		{Signal: &pbcm.VehicleSignal_BatteryLevel{BatteryLevel: 30}},
+		{Signal: &pbcm.VehicleSignal_Charging{Charging: false}},
+		{Signal: &pbcm.VehicleSignal_IgnitionOn{IgnitionOn: true}},
+	}}); err != nil {
+		t.Fatalf("Can't set vehicle state: %s", err)
+	}
+
+	if err = checkUnsatisfied(engine.CheckPreconditions(), []string{
+		preconditions.BatteryCondition, preconditions.IgnitionOffCondition,
+	}); err != nil {
+		t.Errorf("Wrong unsatisfied preconditions: %s", err)
+	}
+
+	status, err := client.GetPreconditions(ctx, &emptypb.Empty{})
+	if err != nil {
+		t.Fatalf("Can't get preconditions: %s", err)
+	}
+
+	if len(status.GetPreconditions()) != 4 {
+		t.Fatalf("Wrong preconditions count: %d", len(status.GetPreconditions()))
+	}
+
+	for _, item := range status.GetPreconditions() {
+		satisfied := item.Name == preconditions.ParkedCondition || item.Name == preconditions.NotChargingCondition
+
+		if item.Satisfied != satisfied || (item.Reason == "") != satisfied {
+			t.Errorf("Wrong precondition status: %v", item)
+		}
+	}
+
+	// Only reported signals are updated
+
+	if _, err = client.SetVehicleState(ctx, &pbcm.VehicleState{Signals: []*pbcm.VehicleSignal{
+		{Signal: &pbcm.VehicleSignal_BatteryLevel{BatteryLevel: 80}},
+		{Signal: &pbcm.VehicleSignal_IgnitionOn{IgnitionOn: false}},
+	}}); err != nil {
+		t.Fatalf("Can't set vehicle state: %s", err)
+	}
+
+	if unsatisfied := engine.CheckPreconditions(); len(unsatisfied) != 0 {
+		t.Errorf("Unexpected unsatisfied preconditions: %v", unsatisfied)
+	}
+}
+
+func TestServerURL(t *testing.T) {
+	if _, err := preconditions.New(&config.Config{Preconditions: config.Preconditions{
+		ServerURL: "localhost:8095",
+	}}); err == nil {
+		t.Error("Error expected for not unix socket server URL")
+	}
+}
+
+func TestSystemPreconditions(t *testing.T) {
+	engine, err := preconditions.New(&config.Config{Preconditions: config.Preconditions{
+		MinFreeRAM:  1,
+		MinFreeDisk: 1,
+		DiskPath:    tmpDir,
+	}})
+	if err != nil {
+		t.Fatalf("Can't create preconditions: %s", err)
+	}
+	defer engine.Close()
+
+	if unsatisfied := engine.CheckPreconditions(); len(unsatisfied) != 0 {
+		t.Errorf("Unexpected unsatisfied preconditions: %v", unsatisfied)
+	}
+
+	engine, err = preconditions.New(&config.Config{Preconditions: config.Preconditions{
+		MinFreeRAM:  1 << 62,
+		MinFreeDisk: 1 << 62,
+		DiskPath:    tmpDir,
+	}})
+	if err != nil {
+		t.Fatalf("Can't create preconditions: %s", err)
+	}
+	defer engine.Close()
+
+	if err = checkUnsatisfied(engine.CheckPreconditions(), []string{
+		preconditions.FreeRAMCondition, preconditions.FreeDiskCondition,
+	}); err != nil {
+		t.Errorf("Wrong unsatisfied preconditions: %s", err)
+	}
+}
+
+func TestCustomProvider(t *testing.T) {
+	engine, err := preconditions.New(&config.Config{})
+	if err != nil {
+		t.Fatalf("Can't create preconditions: %s", err)
+	}
+	defer engine.Close()
+
+	var doorsOpen bool
+
+	engine.AddProvider(preconditions.NewProvider("doorsClosed", func() (err error) {
+		if doorsOpen {
+			return aoserrors.New("doors are open")
+		}
+
+		return nil
+	}))
+
+	if unsatisfied := engine.CheckPreconditions(); len(unsatisfied) != 0 {
+		t.Errorf("Unexpected unsatisfied preconditions: %v", unsatisfied)
+	}
+
+	doorsOpen = true
+
+	if err = checkUnsatisfied(engine.CheckPreconditions(), []string{"doorsClosed"}); err != nil {
+		t.Errorf("Wrong unsatisfied preconditions: %s", err)
+	}
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func checkUnsatisfied(unsatisfied []string, expectedNames []string) (err error) {
+	if len(unsatisfied) != len(expectedNames) {
+		return aoserrors.Errorf("wrong count: %v", unsatisfied)
+	}
+
+	for i, name := range expectedNames {
+		if !strings.HasPrefix(unsatisfied[i], name+": ") {
+			return aoserrors.Errorf("precondition %s not found: %v", name, unsatisfied)
+		}
+	}
+
+	return nil
+}
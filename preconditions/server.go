@@ -0,0 +1,144 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preconditions
+
+import (
+	"context"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/aoscloud/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pbcm "aos_communicationmanager/api/cmserver/v1"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const (
+	unixSocketPrefix  = "unix://"
+	socketPermissions = 0600
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// apiServer local API which receives vehicle state from vehicle services
+type apiServer struct {
+	pbcm.UnimplementedPreconditionsServiceServer
+
+	preconditions *Preconditions
+	grpcServer    *grpc.Server
+	listener      net.Listener
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// SetVehicleState updates reported vehicle signals
+func (server *apiServer) SetVehicleState(ctx context.Context, state *pbcm.VehicleState) (*emptypb.Empty, error) {
+	for _, signal := range state.GetSignals() {
+		switch value := signal.GetSignal().(type) {
+		case *pbcm.VehicleSignal_Parked:
+			server.preconditions.SetParked(value.Parked)
+
+		case *pbcm.VehicleSignal_BatteryLevel:
+			server.preconditions.SetBatteryLevel(value.BatteryLevel)
+
+		case *pbcm.VehicleSignal_Charging:
+			server.preconditions.SetCharging(value.Charging)
+
+		case *pbcm.VehicleSignal_IgnitionOn:
+			server.preconditions.SetIgnitionOn(value.IgnitionOn)
+
+		default:
+			return nil, aoserrors.New("unknown vehicle signal")
+		}
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// GetPreconditions returns status of update preconditions
+func (server *apiServer) GetPreconditions(ctx context.Context, req *emptypb.Empty) (*pbcm.PreconditionsStatus, error) {
+	response := &pbcm.PreconditionsStatus{}
+
+	for _, item := range server.preconditions.GetStatus() {
+		response.Preconditions = append(response.Preconditions, &pbcm.Precondition{
+			Name:      item.Name,
+			Satisfied: item.Reason == "",
+			Reason:    item.Reason,
+		})
+	}
+
+	return response, nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func newServer(url string, preconditions *Preconditions) (server *apiServer, err error) {
+	log.WithField("url", url).Debug("Start preconditions server")
+
+	// Server has no TLS and authentication, access is restricted by unix socket file permissions
+	if !strings.HasPrefix(url, unixSocketPrefix) {
+		return nil, aoserrors.Errorf("preconditions server URL should be unix socket: %s", url)
+	}
+
+	address := strings.TrimPrefix(url, unixSocketPrefix)
+
+	// Remove socket left after previous run
+	if err = os.Remove(address); err != nil && !os.IsNotExist(err) {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	server = &apiServer{preconditions: preconditions, grpcServer: grpc.NewServer()}
+
+	pbcm.RegisterPreconditionsServiceServer(server.grpcServer, server)
+
+	if server.listener, err = net.Listen("unix", address); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	// Socket is created with umask permissions, allow access for the owner only
+	if err = os.Chmod(address, socketPermissions); err != nil {
+		server.listener.Close()
+
+		return nil, aoserrors.Wrap(err)
+	}
+
+	go func() {
+		if err := server.grpcServer.Serve(server.listener); err != nil {
+			log.Errorf("Can't serve preconditions server: %s", err)
+		}
+	}()
+
+	return server, nil
+}
+
+func (server *apiServer) close() {
+	server.grpcServer.Stop()
+}
@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preconditions
+
+import (
+	"sync"
+
+	"github.com/aoscloud/aos_common/aoserrors"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// vehicleState vehicle signals reported by local API. Nil value means signal is not reported yet.
+type vehicleState struct {
+	sync.RWMutex
+
+	parked       *bool
+	batteryLevel *uint32
+	charging     *bool
+	ignitionOn   *bool
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (vehicle *vehicleState) setParked(parked bool) {
+	vehicle.Lock()
+	defer vehicle.Unlock()
+
+	vehicle.parked = &parked
+}
+
+func (vehicle *vehicleState) setBatteryLevel(level uint32) {
+	vehicle.Lock()
+	defer vehicle.Unlock()
+
+	vehicle.batteryLevel = &level
+}
+
+func (vehicle *vehicleState) setCharging(charging bool) {
+	vehicle.Lock()
+	defer vehicle.Unlock()
+
+	vehicle.charging = &charging
+}
+
+func (vehicle *vehicleState) setIgnitionOn(ignitionOn bool) {
+	vehicle.Lock()
+	defer vehicle.Unlock()
+
+	vehicle.ignitionOn = &ignitionOn
+}
+
+func (vehicle *vehicleState) checkParked() (err error) {
+	vehicle.RLock()
+	defer vehicle.RUnlock()
+
+	if vehicle.parked == nil {
+		return aoserrors.New("parked state is unknown")
+	}
+
+	if !*vehicle.parked {
+		return aoserrors.New("vehicle is not parked")
+	}
+
+	return nil
+}
+
+func (vehicle *vehicleState) checkBatteryLevel(minLevel uint32) (err error) {
+	vehicle.RLock()
+	defer vehicle.RUnlock()
+
+	if vehicle.batteryLevel == nil {
+		return aoserrors.New("battery level is unknown")
+	}
+
+	if *vehicle.batteryLevel < minLevel {
+		return aoserrors.Errorf("battery level %d%% is below %d%%", *vehicle.batteryLevel, minLevel)
+	}
+
+	return nil
+}
+
+func (vehicle *vehicleState) checkNotCharging() (err error) {
+	vehicle.RLock()
+	defer vehicle.RUnlock()
+
+	if vehicle.charging == nil {
+		return aoserrors.New("charging state is unknown")
+	}
+
+	if *vehicle.charging {
+		return aoserrors.New("vehicle is charging")
+	}
+
+	return nil
+}
+
+func (vehicle *vehicleState) checkIgnitionOff() (err error) {
+	vehicle.RLock()
+	defer vehicle.RUnlock()
+
+	if vehicle.ignitionOn == nil {
+		return aoserrors.New("ignition state is unknown")
+	}
+
+	if *vehicle.ignitionOn {
+		return aoserrors.New("ignition is on")
+	}
+
+	return nil
+}
@@ -0,0 +1,424 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package provisioning performs unit provisioning through IAM and the cloud
+package provisioning
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aoscloud/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"aos_communicationmanager/cloudprotocol"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Provisioning steps
+const (
+	StepNotStarted     = ""
+	StepCleared        = "cleared"
+	StepOwnerSet       = "ownerSet"
+	StepCertsRequested = "certsRequested"
+	StepCertsApplied   = "certsApplied"
+	StepDiskEncrypted  = "diskEncrypted"
+	StepFinished       = "finished"
+)
+
+const issuedCertsChannelSize = 1
+
+// connectionCertType certificate used for the cloud connection. It is not cleared as it is required to send
+// certificate requests to the cloud, the certificate is replaced when the issued one is applied.
+const connectionCertType = "online"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// IAMProvisioner IAM provisioning API
+type IAMProvisioner interface {
+	GetCertTypes() (certTypes []string, err error)
+	Clear(certType string) (err error)
+	SetOwner(certType, password string) (err error)
+	CreateKey(certType, password string) (csr string, err error)
+	ApplyCert(certType, cert string) (certURL string, err error)
+	EncryptDisk(password string) (err error)
+	FinishProvisioning() (err error)
+}
+
+// Sender sends certificate requests to the cloud
+type Sender interface {
+	SendIssueUnitCerts(requests []cloudprotocol.IssueCertData) (err error)
+	SendInstallCertsConfirmation(confirmations []cloudprotocol.InstallCertData) (err error)
+}
+
+// CertificateProvider provides certificate info
+type CertificateProvider interface {
+	GetCertSerial(certURL string) (serial string, err error)
+}
+
+// KeyWrapper protects owner password with the unit key
+type KeyWrapper interface {
+	WrapKey(key []byte) (wrapped []byte, err error)
+}
+
+// Storage stores provisioning state and wrapped owner password
+type Storage interface {
+	SetProvisioningState(state State) (err error)
+	GetProvisioningState() (state State, err error)
+	SetOwnerPassword(wrappedPassword []byte) (err error)
+}
+
+// State provisioning state
+type State struct {
+	Step         string
+	CertTypes    []string
+	PendingCerts []string
+}
+
+// Provisioner provisioning instance
+type Provisioner struct {
+	sync.Mutex
+
+	iam          IAMProvisioner
+	sender       Sender
+	certProvider CertificateProvider
+	keyWrapper   KeyWrapper
+	storage      Storage
+
+	state              State
+	issuedCertsChannel chan []cloudprotocol.IssuedCertData
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// New creates new provisioner instance
+func New(iam IAMProvisioner, sender Sender, certProvider CertificateProvider, keyWrapper KeyWrapper,
+	storage Storage) (provisioner *Provisioner, err error) {
+	log.Debug("Create provisioner")
+
+	if iam == nil || sender == nil || certProvider == nil || keyWrapper == nil || storage == nil {
+		return nil, aoserrors.New("provisioner dependencies are not set")
+	}
+
+	provisioner = &Provisioner{
+		iam:                iam,
+		sender:             sender,
+		certProvider:       certProvider,
+		keyWrapper:         keyWrapper,
+		storage:            storage,
+		issuedCertsChannel: make(chan []cloudprotocol.IssuedCertData, issuedCertsChannelSize),
+	}
+
+	if provisioner.state, err = storage.GetProvisioningState(); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	log.WithField("step", provisioner.state.Step).Debug("Provisioning state")
+
+	return provisioner, nil
+}
+
+// GetState returns current provisioning state
+func (provisioner *Provisioner) GetState() (state State) {
+	provisioner.Lock()
+	defer provisioner.Unlock()
+
+	return provisioner.state
+}
+
+// IsProvisioned indicates whether unit is provisioned
+func (provisioner *Provisioner) IsProvisioned() (provisioned bool) {
+	return provisioner.GetState().Step == StepFinished
+}
+
+// InstallCertificates passes certificates issued by the cloud to the provisioning process
+func (provisioner *Provisioner) InstallCertificates(certs []cloudprotocol.IssuedCertData) {
+	select {
+	case provisioner.issuedCertsChannel <- certs:
+
+	default:
+		log.Warn("Provisioning is not waiting for certificates, skip issued certificates")
+	}
+}
+
+// Provision performs provisioning. It continues from the last successfully completed step
+// and does nothing if the unit is already provisioned.
+func (provisioner *Provisioner) Provision(ctx context.Context, password string) (err error) {
+	certsRequested := false
+
+	for {
+		state := provisioner.GetState()
+
+		log.WithField("step", state.Step).Debug("Provisioning step")
+
+		switch state.Step {
+		case StepNotStarted:
+			err = provisioner.clear()
+
+		case StepCleared:
+			err = provisioner.setOwner(password)
+
+		case StepOwnerSet:
+			err = provisioner.requestCerts(state.CertTypes, password)
+			certsRequested = err == nil
+
+		case StepCertsRequested:
+			// Keys created by previous run may be lost, request pending certificates again
+			if !certsRequested {
+				if err = provisioner.requestCerts(state.PendingCerts, password); err != nil {
+					break
+				}
+
+				certsRequested = true
+			}
+
+			err = provisioner.applyCerts(ctx)
+
+		case StepCertsApplied:
+			// Owner password is wrapped with the unit key issued above and used later for certificate renewal
+			if err = provisioner.storeOwnerPassword(password); err != nil {
+				break
+			}
+
+			if err = provisioner.iam.EncryptDisk(password); err == nil {
+				err = provisioner.setStep(StepDiskEncrypted)
+			}
+
+		case StepDiskEncrypted:
+			if err = provisioner.iam.FinishProvisioning(); err == nil {
+				err = provisioner.setStep(StepFinished)
+			}
+
+		case StepFinished:
+			log.Info("Unit is provisioned")
+
+			return nil
+
+		default:
+			return aoserrors.Errorf("unknown provisioning step: %s", state.Step)
+		}
+
+		if err != nil {
+			return aoserrors.Wrap(err)
+		}
+	}
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (provisioner *Provisioner) clear() (err error) {
+	certTypes, err := provisioner.iam.GetCertTypes()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	for _, certType := range certTypes {
+		if certType == connectionCertType {
+			log.WithField("type", certType).Debug("Keep connection certificate")
+
+			continue
+		}
+
+		if err = provisioner.iam.Clear(certType); err != nil {
+			return aoserrors.Wrap(err)
+		}
+	}
+
+	return provisioner.setState(State{Step: StepCleared, CertTypes: certTypes})
+}
+
+func (provisioner *Provisioner) setOwner(password string) (err error) {
+	state := provisioner.GetState()
+
+	for _, certType := range state.CertTypes {
+		if err = provisioner.iam.SetOwner(certType, password); err != nil {
+			return aoserrors.Wrap(err)
+		}
+	}
+
+	return provisioner.setStep(StepOwnerSet)
+}
+
+func (provisioner *Provisioner) requestCerts(certTypes []string, password string) (err error) {
+	requests := make([]cloudprotocol.IssueCertData, 0, len(certTypes))
+
+	for _, certType := range certTypes {
+		csr, err := provisioner.iam.CreateKey(certType, password)
+		if err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		requests = append(requests, cloudprotocol.IssueCertData{Type: certType, Csr: csr})
+	}
+
+	// Drop certificates issued for previous requests
+	select {
+	case <-provisioner.issuedCertsChannel:
+
+	default:
+	}
+
+	if err = provisioner.sender.SendIssueUnitCerts(requests); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	state := provisioner.GetState()
+
+	state.Step = StepCertsRequested
+	state.PendingCerts = certTypes
+
+	return provisioner.setState(state)
+}
+
+func (provisioner *Provisioner) applyCerts(ctx context.Context) (err error) {
+	for {
+		state := provisioner.GetState()
+
+		if len(state.PendingCerts) == 0 {
+			return provisioner.setStep(StepCertsApplied)
+		}
+
+		log.WithField("types", state.PendingCerts).Debug("Wait for issued certificates")
+
+		select {
+		case <-ctx.Done():
+			return aoserrors.Wrap(ctx.Err())
+
+		case certs := <-provisioner.issuedCertsChannel:
+			if err = provisioner.installCerts(certs); err != nil {
+				return aoserrors.Wrap(err)
+			}
+		}
+	}
+}
+
+func (provisioner *Provisioner) installCerts(certs []cloudprotocol.IssuedCertData) (err error) {
+	state := provisioner.GetState()
+	confirmations := make([]cloudprotocol.InstallCertData, 0, len(certs))
+
+	for _, cert := range certs {
+		if !isPending(state.PendingCerts, cert.Type) {
+			log.WithField("type", cert.Type).Warn("Skip not requested certificate")
+
+			continue
+		}
+
+		confirmation := cloudprotocol.InstallCertData{Type: cert.Type}
+
+		certURL, err := provisioner.iam.ApplyCert(cert.Type, cert.CertificateChain)
+		if err == nil {
+			confirmation.Serial, err = provisioner.certProvider.GetCertSerial(certURL)
+		}
+
+		if err != nil {
+			log.WithField("type", cert.Type).Errorf("Can't install certificate: %s", err)
+
+			confirmation.Status = "not installed"
+			confirmation.Description = err.Error()
+		} else {
+			log.WithField("type", cert.Type).Info("Certificate installed")
+
+			confirmation.Status = "installed"
+			state.PendingCerts = removePending(state.PendingCerts, cert.Type)
+		}
+
+		confirmations = append(confirmations, confirmation)
+	}
+
+	if err = provisioner.setState(state); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if len(confirmations) != 0 {
+		if err = provisioner.sender.SendInstallCertsConfirmation(confirmations); err != nil {
+			log.Errorf("Can't send install certificates confirmation: %s", err)
+		}
+	}
+
+	for _, confirmation := range confirmations {
+		if confirmation.Status != "installed" {
+			return aoserrors.Errorf("can't install certificate %s: %s", confirmation.Type, confirmation.Description)
+		}
+	}
+
+	return nil
+}
+
+func (provisioner *Provisioner) storeOwnerPassword(password string) (err error) {
+	wrappedPassword, err := provisioner.keyWrapper.WrapKey([]byte(password))
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = provisioner.storage.SetOwnerPassword(wrappedPassword); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (provisioner *Provisioner) setStep(step string) (err error) {
+	state := provisioner.GetState()
+
+	state.Step = step
+
+	return provisioner.setState(state)
+}
+
+func (provisioner *Provisioner) setState(state State) (err error) {
+	provisioner.Lock()
+	defer provisioner.Unlock()
+
+	if err = provisioner.storage.SetProvisioningState(state); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	provisioner.state = state
+
+	return nil
+}
+
+func isPending(pendingCerts []string, certType string) (pending bool) {
+	for _, pendingType := range pendingCerts {
+		if pendingType == certType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func removePending(pendingCerts []string, certType string) (result []string) {
+	result = make([]string, 0, len(pendingCerts))
+
+	for _, pendingType := range pendingCerts {
+		if pendingType != certType {
+			result = append(result, pendingType)
+		}
+	}
+
+	return result
+}
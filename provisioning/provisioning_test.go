@@ -0,0 +1,364 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioning_test
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aoscloud/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"aos_communicationmanager/cloudprotocol"
+	"aos_communicationmanager/provisioning"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const waitTimeout = 5 * time.Second
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type testIAM struct {
+	sync.Mutex
+
+	calls          []string
+	certs          map[string]bool
+	encryptDiskErr error
+}
+
+// testSender sends requests only if the cloud connection is possible, otherwise requests are queued like in AMQP
+// handler without connection
+type testSender struct {
+	iam            *testIAM
+	requestChannel chan []cloudprotocol.IssueCertData
+	queued         [][]cloudprotocol.IssueCertData
+	confirmations  []cloudprotocol.InstallCertData
+}
+
+type testCertProvider struct {
+}
+
+type testStorage struct {
+	state           provisioning.State
+	wrappedPassword []byte
+}
+
+/***********************************************************************************************************************
+ * Init
+ **********************************************************************************************************************/
+
+func init() {
+	log.SetFormatter(&log.TextFormatter{
+		DisableTimestamp: false,
+		TimestampFormat:  "2006-01-02 15:04:05.000",
+		FullTimestamp:    true})
+	log.SetLevel(log.DebugLevel)
+	log.SetOutput(os.Stdout)
+}
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestProvisioning(t *testing.T) {
+	iam := newTestIAM()
+	sender := newTestSender(iam)
+	storage := &testStorage{}
+
+	provisioner, err := provisioning.New(iam, sender, &testCertProvider{}, &testCertProvider{}, storage)
+	if err != nil {
+		t.Fatalf("Can't create provisioner: %s", err)
+	}
+
+	go issueCerts(provisioner, sender)
+
+	ctx, cancel := context.WithTimeout(context.Background(), waitTimeout)
+	defer cancel()
+
+	if err = provisioner.Provision(ctx, "pwd"); err != nil {
+		t.Fatalf("Provisioning failed: %s", err)
+	}
+
+	expectedCalls := []string{
+		"clear offline", "setOwner online", "setOwner offline",
+		"createKey online", "createKey offline", "applyCert online", "applyCert offline",
+		"encryptDisk", "finishProvisioning",
+	}
+
+	if !reflect.DeepEqual(iam.getCalls(), expectedCalls) {
+		t.Errorf("Wrong IAM calls: %v", iam.getCalls())
+	}
+
+	if len(sender.confirmations) != 2 {
+		t.Errorf("Wrong install confirmations: %v", sender.confirmations)
+	}
+
+	if !provisioner.IsProvisioned() || storage.state.Step != provisioning.StepFinished {
+		t.Errorf("Wrong provisioning state: %v", storage.state)
+	}
+
+	if string(storage.wrappedPassword) != "wrapped:pwd" {
+		t.Errorf("Wrong stored owner password: %s", storage.wrappedPassword)
+	}
+
+	// Provisioning of provisioned unit should do nothing
+
+	iam.resetCalls()
+
+	if err = provisioner.Provision(ctx, "pwd"); err != nil {
+		t.Fatalf("Provisioning failed: %s", err)
+	}
+
+	if len(iam.getCalls()) != 0 {
+		t.Errorf("Unexpected IAM calls: %v", iam.getCalls())
+	}
+}
+
+func TestResumeProvisioning(t *testing.T) {
+	iam := newTestIAM()
+	iam.encryptDiskErr = aoserrors.New("encrypt error")
+	sender := newTestSender(iam)
+	storage := &testStorage{}
+
+	provisioner, err := provisioning.New(iam, sender, &testCertProvider{}, &testCertProvider{}, storage)
+	if err != nil {
+		t.Fatalf("Can't create provisioner: %s", err)
+	}
+
+	go issueCerts(provisioner, sender)
+
+	ctx, cancel := context.WithTimeout(context.Background(), waitTimeout)
+	defer cancel()
+
+	if err = provisioner.Provision(ctx, "pwd"); err == nil {
+		t.Fatal("Provisioning should fail")
+	}
+
+	if storage.state.Step != provisioning.StepCertsApplied {
+		t.Errorf("Wrong provisioning step: %s", storage.state.Step)
+	}
+
+	// Restart provisioning with new instance
+
+	iam.resetCalls()
+	iam.encryptDiskErr = nil
+
+	if provisioner, err = provisioning.New(iam, sender, &testCertProvider{}, &testCertProvider{}, storage); err != nil {
+		t.Fatalf("Can't create provisioner: %s", err)
+	}
+
+	if err = provisioner.Provision(ctx, "pwd"); err != nil {
+		t.Fatalf("Provisioning failed: %s", err)
+	}
+
+	if !reflect.DeepEqual(iam.getCalls(), []string{"encryptDisk", "finishProvisioning"}) {
+		t.Errorf("Wrong IAM calls: %v", iam.getCalls())
+	}
+}
+
+func TestResumeCertsRequest(t *testing.T) {
+	iam := newTestIAM()
+	sender := newTestSender(iam)
+	storage := &testStorage{state: provisioning.State{
+		Step:         provisioning.StepCertsRequested,
+		CertTypes:    []string{"online", "offline"},
+		PendingCerts: []string{"offline"},
+	}}
+
+	provisioner, err := provisioning.New(iam, sender, &testCertProvider{}, &testCertProvider{}, storage)
+	if err != nil {
+		t.Fatalf("Can't create provisioner: %s", err)
+	}
+
+	go issueCerts(provisioner, sender)
+
+	ctx, cancel := context.WithTimeout(context.Background(), waitTimeout)
+	defer cancel()
+
+	if err = provisioner.Provision(ctx, "pwd"); err != nil {
+		t.Fatalf("Provisioning failed: %s", err)
+	}
+
+	expectedCalls := []string{"createKey offline", "applyCert offline", "encryptDisk", "finishProvisioning"}
+
+	if !reflect.DeepEqual(iam.getCalls(), expectedCalls) {
+		t.Errorf("Wrong IAM calls: %v", iam.getCalls())
+	}
+}
+
+/***********************************************************************************************************************
+ * Interfaces
+ **********************************************************************************************************************/
+
+func (iam *testIAM) GetCertTypes() (certTypes []string, err error) {
+	return []string{"online", "offline"}, nil
+}
+
+func (iam *testIAM) Clear(certType string) (err error) {
+	iam.addCall("clear " + certType)
+
+	iam.Lock()
+	defer iam.Unlock()
+
+	delete(iam.certs, certType)
+
+	return nil
+}
+
+func (iam *testIAM) SetOwner(certType, password string) (err error) {
+	iam.addCall("setOwner " + certType)
+
+	return nil
+}
+
+func (iam *testIAM) CreateKey(certType, password string) (csr string, err error) {
+	iam.addCall("createKey " + certType)
+
+	return certType + "CSR", nil
+}
+
+func (iam *testIAM) ApplyCert(certType, cert string) (certURL string, err error) {
+	iam.addCall("applyCert " + certType)
+
+	iam.Lock()
+	defer iam.Unlock()
+
+	iam.certs[certType] = true
+
+	return certType + "URL", nil
+}
+
+func (iam *testIAM) EncryptDisk(password string) (err error) {
+	iam.addCall("encryptDisk")
+
+	return iam.encryptDiskErr
+}
+
+func (iam *testIAM) FinishProvisioning() (err error) {
+	iam.addCall("finishProvisioning")
+
+	return nil
+}
+
+func (iam *testIAM) hasCert(certType string) (exists bool) {
+	iam.Lock()
+	defer iam.Unlock()
+
+	return iam.certs[certType]
+}
+
+func (iam *testIAM) addCall(call string) {
+	iam.Lock()
+	defer iam.Unlock()
+
+	iam.calls = append(iam.calls, call)
+}
+
+func (iam *testIAM) getCalls() (calls []string) {
+	iam.Lock()
+	defer iam.Unlock()
+
+	return iam.calls
+}
+
+func (iam *testIAM) resetCalls() {
+	iam.Lock()
+	defer iam.Unlock()
+
+	iam.calls = nil
+}
+
+func newTestIAM() (iam *testIAM) {
+	// Unit is shipped with connection certificate
+	return &testIAM{certs: map[string]bool{"online": true}}
+}
+
+func newTestSender(iam *testIAM) (sender *testSender) {
+	return &testSender{iam: iam, requestChannel: make(chan []cloudprotocol.IssueCertData, 1)}
+}
+
+func (sender *testSender) SendIssueUnitCerts(requests []cloudprotocol.IssueCertData) (err error) {
+	// Connection requires online certificate
+	if !sender.iam.hasCert("online") {
+		sender.queued = append(sender.queued, requests)
+
+		return nil
+	}
+
+	sender.requestChannel <- requests
+
+	return nil
+}
+
+func (sender *testSender) SendInstallCertsConfirmation(confirmations []cloudprotocol.InstallCertData) (err error) {
+	sender.confirmations = append(sender.confirmations, confirmations...)
+
+	return nil
+}
+
+func (provider *testCertProvider) GetCertSerial(certURL string) (serial string, err error) {
+	return "serial", nil
+}
+
+func (provider *testCertProvider) WrapKey(key []byte) (wrapped []byte, err error) {
+	return append([]byte("wrapped:"), key...), nil
+}
+
+func (storage *testStorage) SetOwnerPassword(wrappedPassword []byte) (err error) {
+	storage.wrappedPassword = wrappedPassword
+
+	return nil
+}
+
+func (storage *testStorage) SetProvisioningState(state provisioning.State) (err error) {
+	storage.state = state
+
+	return nil
+}
+
+func (storage *testStorage) GetProvisioningState() (state provisioning.State, err error) {
+	return storage.state, nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func issueCerts(provisioner *provisioning.Provisioner, sender *testSender) {
+	select {
+	case requests := <-sender.requestChannel:
+		certs := make([]cloudprotocol.IssuedCertData, 0, len(requests))
+
+		for _, request := range requests {
+			certs = append(certs, cloudprotocol.IssuedCertData{Type: request.Type, CertificateChain: request.Csr})
+		}
+
+		provisioner.InstallCertificates(certs)
+
+	case <-time.After(waitTimeout):
+	}
+}
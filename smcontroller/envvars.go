@@ -0,0 +1,251 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package smcontroller
+
+import (
+	"time"
+
+	"github.com/aoscloud/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"aos_communicationmanager/cloudprotocol"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const envVarExpiredError = "TTL expired"
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// OverrideEnvVars overrides service env vars on all SM's. Overrides are stored and expired by CM.
+func (controller *Controller) OverrideEnvVars(envVars cloudprotocol.DecodedOverrideEnvVars) (err error) {
+	controller.envVarsMutex.Lock()
+	defer controller.envVarsMutex.Unlock()
+
+	active, varErrors := splitExpiredEnvVars(envVars.OverrideEnvVars, time.Now())
+
+	if err = controller.storage.SetOverrideEnvVars(active); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	controller.envVars = active
+
+	controller.applyEnvVars(controller.getAliveClients(), varErrors)
+	controller.scheduleEnvVarsExpiration()
+
+	if err = controller.messageSender.SendOverrideEnvVarsStatus(
+		getEnvVarsStatus(envVars.OverrideEnvVars, varErrors)); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (controller *Controller) loadEnvVars() (err error) {
+	controller.envVarsMutex.Lock()
+	defer controller.envVarsMutex.Unlock()
+
+	if controller.envVars, err = controller.storage.GetOverrideEnvVars(); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	controller.scheduleEnvVarsExpiration()
+
+	return nil
+}
+
+func (controller *Controller) stopEnvVarsExpiration() {
+	controller.envVarsMutex.Lock()
+	defer controller.envVarsMutex.Unlock()
+
+	if controller.envVarsTimer != nil {
+		controller.envVarsTimer.Stop()
+		controller.envVarsTimer = nil
+	}
+}
+
+// applyEnvVars sends active env vars to SM's and collects errors per env var
+func (controller *Controller) applyEnvVars(clients map[string]*smClient, varErrors map[string]string) {
+	for _, smID := range getSortedSMIDs(clients) {
+		statuses, err := clients[smID].overrideEnvVars(
+			cloudprotocol.DecodedOverrideEnvVars{OverrideEnvVars: controller.envVars})
+		if err != nil {
+			log.WithField("id", smID).Errorf("Can't override env vars: %s", err)
+
+			for _, item := range controller.envVars {
+				for _, envVar := range item.EnvVars {
+					setEnvVarError(varErrors, item.ServiceID, item.SubjectID, envVar.ID, err.Error())
+				}
+			}
+
+			continue
+		}
+
+		for _, item := range statuses {
+			for _, status := range item.Statuses {
+				if status.Error != "" {
+					setEnvVarError(varErrors, item.ServiceID, item.SubjectID, status.ID, status.Error)
+				}
+			}
+		}
+	}
+}
+
+// restoreEnvVars applies active env vars to connected or recovered SM
+func (controller *Controller) restoreEnvVars(client *smClient) {
+	controller.envVarsMutex.Lock()
+	defer controller.envVarsMutex.Unlock()
+
+	if len(controller.envVars) == 0 || controller.context.Err() != nil {
+		return
+	}
+
+	log.WithField("id", client.cfg.SMID).Debug("Restore override env vars")
+
+	varErrors := make(map[string]string)
+
+	controller.applyEnvVars(map[string]*smClient{client.cfg.SMID: client}, varErrors)
+
+	for key, varError := range varErrors {
+		log.WithFields(log.Fields{"id": client.cfg.SMID, "envVar": key}).Errorf(
+			"Can't restore env var: %s", varError)
+	}
+}
+
+// scheduleEnvVarsExpiration starts timer for the nearest env var TTL. Should be called under envVarsMutex.
+func (controller *Controller) scheduleEnvVarsExpiration() {
+	if controller.envVarsTimer != nil {
+		controller.envVarsTimer.Stop()
+		controller.envVarsTimer = nil
+	}
+
+	var nextTTL *time.Time
+
+	for _, item := range controller.envVars {
+		for _, envVar := range item.EnvVars {
+			if envVar.TTL != nil && (nextTTL == nil || envVar.TTL.Before(*nextTTL)) {
+				nextTTL = envVar.TTL
+			}
+		}
+	}
+
+	if nextTTL == nil {
+		return
+	}
+
+	controller.envVarsTimer = time.AfterFunc(time.Until(*nextTTL), controller.expireEnvVars)
+}
+
+// expireEnvVars removes expired env vars, applies the rest to SM's and reports the change to the cloud
+func (controller *Controller) expireEnvVars() {
+	controller.envVarsMutex.Lock()
+	defer controller.envVarsMutex.Unlock()
+
+	if controller.context.Err() != nil {
+		return
+	}
+
+	defer controller.scheduleEnvVarsExpiration()
+
+	prevEnvVars := controller.envVars
+
+	active, varErrors := splitExpiredEnvVars(prevEnvVars, time.Now())
+	if len(varErrors) == 0 {
+		return
+	}
+
+	log.Debug("Override env vars expired")
+
+	if err := controller.storage.SetOverrideEnvVars(active); err != nil {
+		log.Errorf("Can't store override env vars: %s", err)
+	}
+
+	controller.envVars = active
+
+	controller.applyEnvVars(controller.getAliveClients(), varErrors)
+
+	if err := controller.messageSender.SendOverrideEnvVarsStatus(
+		getEnvVarsStatus(prevEnvVars, varErrors)); err != nil {
+		log.Errorf("Can't send override env vars status: %s", err)
+	}
+}
+
+// splitExpiredEnvVars returns not expired env vars and errors for expired ones
+func splitExpiredEnvVars(envVars []cloudprotocol.OverrideEnvsFromCloud, now time.Time) (
+	active []cloudprotocol.OverrideEnvsFromCloud, varErrors map[string]string) {
+	varErrors = make(map[string]string)
+	active = make([]cloudprotocol.OverrideEnvsFromCloud, 0, len(envVars))
+
+	for _, item := range envVars {
+		activeItem := cloudprotocol.OverrideEnvsFromCloud{ServiceID: item.ServiceID, SubjectID: item.SubjectID}
+
+		for _, envVar := range item.EnvVars {
+			if envVar.TTL != nil && !envVar.TTL.After(now) {
+				setEnvVarError(varErrors, item.ServiceID, item.SubjectID, envVar.ID, envVarExpiredError)
+
+				continue
+			}
+
+			activeItem.EnvVars = append(activeItem.EnvVars, envVar)
+		}
+
+		if len(activeItem.EnvVars) != 0 {
+			active = append(active, activeItem)
+		}
+	}
+
+	return active, varErrors
+}
+
+func getEnvVarsStatus(envVars []cloudprotocol.OverrideEnvsFromCloud,
+	varErrors map[string]string) (statuses []cloudprotocol.EnvVarInfoStatus) {
+	statuses = make([]cloudprotocol.EnvVarInfoStatus, 0, len(envVars))
+
+	for _, item := range envVars {
+		status := cloudprotocol.EnvVarInfoStatus{ServiceID: item.ServiceID, SubjectID: item.SubjectID}
+
+		for _, envVar := range item.EnvVars {
+			status.Statuses = append(status.Statuses, cloudprotocol.EnvVarStatus{
+				ID: envVar.ID, Error: varErrors[getEnvVarKey(item.ServiceID, item.SubjectID, envVar.ID)]})
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+func setEnvVarError(varErrors map[string]string, serviceID, subjectID, varID, varError string) {
+	key := getEnvVarKey(serviceID, subjectID, varID)
+
+	if _, ok := varErrors[key]; !ok {
+		varErrors[key] = varError
+	}
+}
+
+func getEnvVarKey(serviceID, subjectID, varID string) (key string) {
+	return serviceID + "/" + subjectID + "/" + varID
+}
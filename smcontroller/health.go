@@ -0,0 +1,317 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package smcontroller
+
+import (
+	"sort"
+	"time"
+
+	"github.com/aoscloud/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"aos_communicationmanager/cloudprotocol"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const (
+	defaultHealthCheckPeriod = 10 * time.Second
+	maxConsecutiveErrors     = 3
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type nodeHealthState struct {
+	status     string
+	since      time.Time
+	failedOver bool
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// GetNodesHealthChannel returns channel to receive SM's health on change
+func (controller *Controller) GetNodesHealthChannel() (channel <-chan []cloudprotocol.NodeHealth) {
+	return controller.healthChannel
+}
+
+// GetNodesHealth returns current SM's health
+func (controller *Controller) GetNodesHealth() (nodesHealth []cloudprotocol.NodeHealth) {
+	controller.Lock()
+	defer controller.Unlock()
+
+	for _, smID := range controller.getKnownSMIDs() {
+		nodesHealth = append(nodesHealth, controller.getNodeHealth(smID))
+	}
+
+	return nodesHealth
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (controller *Controller) superviseHealth(checkPeriod, gracePeriod time.Duration) {
+	ticker := time.NewTicker(checkPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			controller.checkHealth(gracePeriod)
+
+		case <-controller.context.Done():
+			return
+		}
+	}
+}
+
+func (controller *Controller) checkHealth(gracePeriod time.Duration) {
+	var (
+		nodesHealth   []cloudprotocol.NodeHealth
+		changed       []cloudprotocol.NodeHealth
+		failoverSMIDs []string
+		recovered     []*smClient
+	)
+
+	now := time.Now()
+
+	controller.Lock()
+
+	for _, smID := range controller.getKnownSMIDs() {
+		nodeHealth := controller.getNodeHealth(smID)
+
+		nodesHealth = append(nodesHealth, nodeHealth)
+
+		state, ok := controller.healthStates[smID]
+		if !ok || state.status != nodeHealth.Status {
+			// Newly connected SM is reported only if it is not healthy
+			if ok || nodeHealth.Status != cloudprotocol.NodeHealthy {
+				changed = append(changed, nodeHealth)
+			}
+
+			// Env vars overrides may be lost while SM is dead, restore them on recovery
+			if ok && state.status == cloudprotocol.NodeDead {
+				if client, connected := controller.clients[smID]; connected {
+					recovered = append(recovered, client)
+				}
+			}
+
+			state = &nodeHealthState{status: nodeHealth.Status, since: now}
+			controller.healthStates[smID] = state
+		}
+
+		if state.status == cloudprotocol.NodeDead && !state.failedOver && now.Sub(state.since) >= gracePeriod {
+			state.failedOver = true
+			failoverSMIDs = append(failoverSMIDs, smID)
+		}
+	}
+
+	controller.Unlock()
+
+	for _, client := range recovered {
+		go controller.syncClient(client)
+	}
+
+	if len(changed) == 0 && len(failoverSMIDs) == 0 {
+		return
+	}
+
+	for _, nodeHealth := range changed {
+		log.WithFields(log.Fields{
+			"id": nodeHealth.NodeID, "status": nodeHealth.Status, "errors": nodeHealth.ErrorCount}).Warn(
+			"SM health changed")
+
+		controller.sendAlert("SM " + nodeHealth.NodeID + " is " + nodeHealth.Status)
+	}
+
+	if len(changed) != 0 {
+		// Keep only the latest health in the channel
+		select {
+		case <-controller.healthChannel:
+
+		default:
+		}
+
+		controller.healthChannel <- nodesHealth
+	}
+
+	for _, smID := range failoverSMIDs {
+		controller.failover(smID)
+	}
+}
+
+// getKnownSMIDs returns configured, connected and registered SM's. Should be called under lock.
+func (controller *Controller) getKnownSMIDs() (smIDs []string) {
+	for smID := range controller.smStatuses {
+		smIDs = append(smIDs, smID)
+	}
+
+	for smID := range controller.clients {
+		if _, ok := controller.smStatuses[smID]; !ok {
+			smIDs = append(smIDs, smID)
+		}
+	}
+
+	sort.Strings(smIDs)
+
+	return smIDs
+}
+
+// getNodeHealth returns SM health. Should be called under lock.
+func (controller *Controller) getNodeHealth(smID string) (nodeHealth cloudprotocol.NodeHealth) {
+	nodeHealth = cloudprotocol.NodeHealth{NodeID: smID, Status: cloudprotocol.NodeDead}
+
+	client, ok := controller.clients[smID]
+	if !ok {
+		nodeHealth.LastSeen = controller.smStatuses[smID].Timestamp
+
+		return nodeHealth
+	}
+
+	health := client.getHealth()
+
+	nodeHealth.LastSeen = health.lastSeen
+	nodeHealth.Latency = uint64(health.latency.Milliseconds())
+	nodeHealth.ErrorCount = health.errorCount
+
+	switch {
+	case !health.subscribed:
+		nodeHealth.Status = cloudprotocol.NodeDead
+
+	case health.consecutiveErrors >= maxConsecutiveErrors:
+		nodeHealth.Status = cloudprotocol.NodeUnhealthy
+
+	default:
+		nodeHealth.Status = cloudprotocol.NodeHealthy
+	}
+
+	return nodeHealth
+}
+
+// getAliveClients returns connected clients which are not detected as dead
+func (controller *Controller) getAliveClients() (clients map[string]*smClient) {
+	clients = controller.getClients()
+
+	controller.Lock()
+	defer controller.Unlock()
+
+	for smID := range clients {
+		if state, ok := controller.healthStates[smID]; ok && state.status == cloudprotocol.NodeDead {
+			log.WithField("id", smID).Warn("Skip dead SM")
+
+			delete(clients, smID)
+		}
+	}
+
+	return clients
+}
+
+// failover reschedules services placed on dead SM to alive SM's
+func (controller *Controller) failover(deadSMID string) {
+	log.WithField("id", deadSMID).Warn("Reschedule services from dead SM")
+
+	controller.placementMutex.Lock()
+
+	var placements []ServicePlacement
+
+	for serviceID, placement := range controller.placements {
+		if !contains(placement.SMIDs, deadSMID) {
+			continue
+		}
+
+		// Placement stored before install info was introduced
+		if len(placement.ServiceInfo.URLs) == 0 {
+			log.WithFields(log.Fields{"id": deadSMID, "serviceID": serviceID}).Error(
+				"Can't reschedule service: install info is not available")
+
+			continue
+		}
+
+		placements = append(placements, placement)
+	}
+
+	controller.placementMutex.Unlock()
+
+	for _, placement := range placements {
+		if err := controller.rescheduleService(placement); err != nil {
+			log.WithFields(log.Fields{"id": deadSMID, "serviceID": placement.ServiceID}).Errorf(
+				"Can't reschedule service: %s", err)
+
+			controller.sendAlert("Can't reschedule service " + placement.ServiceID + " from SM " + deadSMID)
+
+			continue
+		}
+
+		log.WithFields(log.Fields{"id": deadSMID, "serviceID": placement.ServiceID}).Info("Service rescheduled")
+	}
+}
+
+func (controller *Controller) rescheduleService(placement ServicePlacement) (err error) {
+	if _, err = controller.installService(
+		controller.getAliveClients(), placement.Users, placement.ServiceInfo); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+// syncClient restores env vars and removes rescheduled services from connected or recovered SM
+func (controller *Controller) syncClient(client *smClient) {
+	controller.restoreEnvVars(client)
+	controller.removeRescheduledServices(client)
+}
+
+// removeRescheduledServices removes services which were rescheduled from the SM while it was dead
+func (controller *Controller) removeRescheduledServices(client *smClient) {
+	if controller.context.Err() != nil {
+		return
+	}
+
+	smID := client.cfg.SMID
+
+	servicesInfo, _, err := client.getAllStatus()
+	if err != nil {
+		log.WithField("id", smID).Errorf("Can't get SM services: %s", err)
+		return
+	}
+
+	for _, serviceInfo := range servicesInfo {
+		controller.placementMutex.Lock()
+		placement, placed := controller.placements[serviceInfo.ID]
+		controller.placementMutex.Unlock()
+
+		// Service installed before placement was introduced is not rescheduled
+		if !placed || contains(placement.SMIDs, smID) {
+			continue
+		}
+
+		log.WithFields(log.Fields{"id": smID, "serviceID": serviceInfo.ID}).Warn(
+			"Remove service rescheduled from SM")
+
+		if err = client.removeService(placement.Users, serviceInfo); err != nil {
+			log.WithFields(log.Fields{"id": smID, "serviceID": serviceInfo.ID}).Errorf(
+				"Can't remove rescheduled service: %s", err)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package smcontroller
+
+import (
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aoscloud/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const fileScheme = "file"
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// keepImage keeps local image in the image dir as downloaded images are removed after update but are required to
+// reschedule services from failed SM. Returns URL of the kept image or original URL if the image can't be kept.
+func (controller *Controller) keepImage(name, inURL string) (outURL string) {
+	imageURL, err := url.Parse(inURL)
+	if err != nil || imageURL.Scheme != fileScheme || controller.imageDir == "" {
+		return inURL
+	}
+
+	imagePath := filepath.Join(controller.imageDir, config.KeptImagePrefix+name)
+
+	if imageURL.Path == imagePath {
+		return inURL
+	}
+
+	if err = linkImage(imageURL.Path, imagePath); err != nil {
+		log.WithField("image", imageURL.Path).Errorf("Can't keep image: %s", err)
+
+		return inURL
+	}
+
+	log.WithField("image", imagePath).Debug("Image kept")
+
+	return (&url.URL{Scheme: fileScheme, Path: imagePath}).String()
+}
+
+func (controller *Controller) removeImage(name string) {
+	if controller.imageDir == "" {
+		return
+	}
+
+	imagePath := filepath.Join(controller.imageDir, config.KeptImagePrefix+name)
+
+	if err := os.RemoveAll(imagePath); err != nil {
+		log.WithField("image", imagePath).Errorf("Can't remove kept image: %s", err)
+	}
+}
+
+func serviceImageName(serviceID string) (name string) {
+	return "service_" + serviceID
+}
+
+func layerImageName(digest string) (name string) {
+	return "layer_" + strings.ReplaceAll(digest, ":", "_")
+}
+
+// linkImage creates hard link to the image or copies it if the image is located on other file system
+func linkImage(srcPath, dstPath string) (err error) {
+	if err = os.RemoveAll(dstPath); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = os.Link(srcPath, dstPath); err == nil {
+		return nil
+	}
+
+	srcFile, err := os.Open(srcPath)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.OpenFile(dstPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if _, err = io.Copy(dstFile, srcFile); err != nil {
+		dstFile.Close()
+		os.Remove(dstPath)
+
+		return aoserrors.Wrap(err)
+	}
+
+	if err = dstFile.Close(); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
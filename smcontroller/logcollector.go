@@ -0,0 +1,187 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package smcontroller
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"aos_communicationmanager/cloudprotocol"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const logRequestTimeout = 5 * time.Minute
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// logCollector forwards log parts from SM's to the cloud as they are received. Log of each SM is sent as separate
+// sequence of parts identified by node ID, SM data is forwarded unchanged as it may be compressed.
+type logCollector struct {
+	sync.Mutex
+
+	messageSender MessageSender
+	requests      map[string]*logRequest
+}
+
+type logRequest struct {
+	nodes map[string]*nodeLog
+	timer *time.Timer
+}
+
+type nodeLog struct {
+	receivedParts uint64
+	done          bool
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func newLogCollector(messageSender MessageSender) (collector *logCollector) {
+	return &logCollector{messageSender: messageSender, requests: make(map[string]*logRequest)}
+}
+
+func (collector *logCollector) close() {
+	collector.Lock()
+	defer collector.Unlock()
+
+	for logID, request := range collector.requests {
+		request.timer.Stop()
+		delete(collector.requests, logID)
+	}
+}
+
+// startRequest starts tracking log parts from SM's
+func (collector *logCollector) startRequest(logID string, smIDs []string) {
+	collector.Lock()
+	defer collector.Unlock()
+
+	if request, ok := collector.requests[logID]; ok {
+		log.WithField("logID", logID).Warn("Log request already in progress, restart it")
+
+		request.timer.Stop()
+	}
+
+	request := &logRequest{nodes: make(map[string]*nodeLog)}
+
+	for _, smID := range smIDs {
+		request.nodes[smID] = &nodeLog{}
+	}
+
+	request.timer = time.AfterFunc(logRequestTimeout, func() {
+		var smIDs []string
+
+		collector.Lock()
+
+		if collector.requests[logID] != request {
+			collector.Unlock()
+			return
+		}
+
+		delete(collector.requests, logID)
+
+		for smID, node := range request.nodes {
+			if !node.done {
+				smIDs = append(smIDs, smID)
+			}
+		}
+
+		collector.Unlock()
+
+		log.WithField("logID", logID).Warn("Log request timeout")
+
+		for _, smID := range smIDs {
+			collector.sendLog(cloudprotocol.PushLog{LogID: logID, NodeID: smID, Error: "log request timeout"})
+		}
+	})
+
+	collector.requests[logID] = request
+}
+
+// setNodeError completes SM log with error
+func (collector *logCollector) setNodeError(logID, smID string, err error) {
+	collector.receiveLog(smID, cloudprotocol.PushLog{LogID: logID, Error: err.Error()})
+}
+
+// receiveLog forwards log part received from SM to the cloud
+func (collector *logCollector) receiveLog(smID string, pushLog cloudprotocol.PushLog) {
+	pushLog.NodeID = smID
+
+	collector.Lock()
+
+	request, ok := collector.requests[pushLog.LogID]
+	if !ok {
+		collector.Unlock()
+
+		// Log is not requested by this CM, forward it as is
+		collector.sendLog(pushLog)
+
+		return
+	}
+
+	node, ok := request.nodes[smID]
+	if !ok || node.done {
+		collector.Unlock()
+
+		log.WithFields(log.Fields{"id": smID, "logID": pushLog.LogID}).Warn("Unexpected log part")
+
+		return
+	}
+
+	if pushLog.Part > 0 {
+		node.receivedParts++
+	}
+
+	node.done = pushLog.Error != "" || node.receivedParts >= pushLog.PartCount
+
+	if collector.isRequestDone(request) {
+		request.timer.Stop()
+		delete(collector.requests, pushLog.LogID)
+	}
+
+	collector.Unlock()
+
+	if pushLog.Error != "" {
+		log.WithFields(log.Fields{"id": smID, "logID": pushLog.LogID}).Warnf("Log is incomplete: %s", pushLog.Error)
+	}
+
+	collector.sendLog(pushLog)
+}
+
+func (collector *logCollector) isRequestDone(request *logRequest) (done bool) {
+	for _, node := range request.nodes {
+		if !node.done {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (collector *logCollector) sendLog(pushLog cloudprotocol.PushLog) {
+	if err := collector.messageSender.SendLog(pushLog); err != nil {
+		log.Errorf("Can't send log: %s", err)
+	}
+}
@@ -0,0 +1,232 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package smcontroller
+
+import (
+	"sort"
+
+	"github.com/aoscloud/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"aos_communicationmanager/cloudprotocol"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const defaultReplicas = 1
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// placeService selects SM's for the service. SM's which already run the service or are planned for it are preferred if
+// they still match placement hints. Other SM's are selected by free RAM, number of placed services and SM ID.
+// Returns SM's where the service should be removed from.
+func (controller *Controller) placeService(clients map[string]*smClient,
+	serviceInfo cloudprotocol.ServiceInfoFromCloud) (placement ServicePlacement, removeSMIDs []string, err error) {
+	var hints cloudprotocol.PlacementHints
+
+	if serviceInfo.Placement != nil {
+		hints = *serviceInfo.Placement
+	}
+
+	replicas := hints.Replicas
+	if replicas <= 0 {
+		replicas = defaultReplicas
+	}
+
+	current := controller.placements[serviceInfo.ID].SMIDs
+	preferred := current
+
+	if planned, ok := controller.planned[serviceInfo.ID]; ok {
+		preferred = planned.SMIDs
+	}
+
+	serviceCounts := controller.getServiceCounts(serviceInfo.ID)
+
+	type candidate struct {
+		smID         string
+		freeRAM      uint64
+		serviceCount int
+	}
+
+	var candidates []candidate
+
+	for smID, client := range clients {
+		freeRAM, freeDisk := client.getFreeResources()
+
+		if !matchPlacementHints(client, hints) {
+			continue
+		}
+
+		// Resources used by the service on its current SM's are already accounted in SM usage
+		if !contains(current, smID) && (hints.RAM > freeRAM || hints.Disk > freeDisk) {
+			continue
+		}
+
+		candidates = append(candidates, candidate{smID: smID, freeRAM: freeRAM, serviceCount: serviceCounts[smID]})
+	}
+
+	if len(candidates) == 0 {
+		return placement, nil, aoserrors.Errorf("no SM matches service %s placement", serviceInfo.ID)
+	}
+
+	if len(candidates) < replicas {
+		log.WithFields(log.Fields{
+			"serviceID": serviceInfo.ID,
+			"replicas":  replicas,
+			"available": len(candidates)}).Warn("Not enough SM's to place all service replicas")
+
+		replicas = len(candidates)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].freeRAM != candidates[j].freeRAM {
+			return candidates[i].freeRAM > candidates[j].freeRAM
+		}
+
+		if candidates[i].serviceCount != candidates[j].serviceCount {
+			return candidates[i].serviceCount < candidates[j].serviceCount
+		}
+
+		return candidates[i].smID < candidates[j].smID
+	})
+
+	placement = ServicePlacement{ServiceID: serviceInfo.ID, Layers: serviceInfo.Layers}
+
+	for _, smID := range preferred {
+		if len(placement.SMIDs) == replicas {
+			break
+		}
+
+		for _, candidate := range candidates {
+			if candidate.smID == smID {
+				placement.SMIDs = append(placement.SMIDs, smID)
+				break
+			}
+		}
+	}
+
+	for _, candidate := range candidates {
+		if len(placement.SMIDs) == replicas {
+			break
+		}
+
+		if !contains(placement.SMIDs, candidate.smID) {
+			placement.SMIDs = append(placement.SMIDs, candidate.smID)
+		}
+	}
+
+	for _, smID := range current {
+		if !contains(placement.SMIDs, smID) {
+			removeSMIDs = append(removeSMIDs, smID)
+		}
+	}
+
+	log.WithFields(log.Fields{"serviceID": serviceInfo.ID, "smIDs": placement.SMIDs}).Debug("Service placed")
+
+	return placement, removeSMIDs, nil
+}
+
+func (controller *Controller) getServiceCounts(excludeServiceID string) (serviceCounts map[string]int) {
+	serviceCounts = make(map[string]int)
+
+	for serviceID, placement := range controller.getPlacements() {
+		if serviceID == excludeServiceID {
+			continue
+		}
+
+		for _, smID := range placement.SMIDs {
+			serviceCounts[smID]++
+		}
+	}
+
+	return serviceCounts
+}
+
+// getPlacements returns current placements overridden by planned ones
+func (controller *Controller) getPlacements() (placements map[string]ServicePlacement) {
+	placements = make(map[string]ServicePlacement, len(controller.placements)+len(controller.planned))
+
+	for serviceID, placement := range controller.placements {
+		placements[serviceID] = placement
+	}
+
+	for serviceID, placement := range controller.planned {
+		placements[serviceID] = placement
+	}
+
+	return placements
+}
+
+// getLayerSMIDs returns SM's which host or are planned to host services required the layer
+func (controller *Controller) getLayerSMIDs(digest string) (smIDs []string) {
+	for _, placement := range controller.getPlacements() {
+		if !contains(placement.Layers, digest) {
+			continue
+		}
+
+		for _, smID := range placement.SMIDs {
+			if !contains(smIDs, smID) {
+				smIDs = append(smIDs, smID)
+			}
+		}
+	}
+
+	sort.Strings(smIDs)
+
+	return smIDs
+}
+
+func matchPlacementHints(client *smClient, hints cloudprotocol.PlacementHints) (match bool) {
+	if len(hints.Nodes) != 0 && !contains(hints.Nodes, client.cfg.SMID) {
+		return false
+	}
+
+	for _, capability := range hints.Capabilities {
+		if !contains(client.cfg.Capabilities, capability) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func getSortedSMIDs(clients map[string]*smClient) (smIDs []string) {
+	smIDs = make([]string, 0, len(clients))
+
+	for smID := range clients {
+		smIDs = append(smIDs, smID)
+	}
+
+	sort.Strings(smIDs)
+
+	return smIDs
+}
+
+func contains(items []string, item string) (result bool) {
+	for _, value := range items {
+		if value == item {
+			return true
+		}
+	}
+
+	return false
+}
@@ -20,7 +20,9 @@ package smcontroller
 import (
 	"context"
 	"encoding/json"
+	"math"
 	"reflect"
+	"sync"
 	"time"
 
 	"github.com/aoscloud/aos_common/aoserrors"
@@ -50,13 +52,34 @@ const (
  **********************************************************************************************************************/
 
 type smClient struct {
+	sync.Mutex
+
 	messageSender    MessageSender
 	alertSender      AlertSender
 	monitoringSender MonitoringSender
+	handler          notificationHandler
 	cfg              config.SMConfig
 	connection       *grpc.ClientConn
 	pbClient         pb.SMServiceClient
 	context          context.Context
+	usedRAM          uint64
+	usedDisk         uint64
+	health           clientHealth
+}
+
+// notificationHandler handles SM notifications which require CM processing
+type notificationHandler interface {
+	receiveLog(smID string, pushLog cloudprotocol.PushLog)
+	receiveNewState(smID, correlationID string, state cloudprotocol.NewState)
+	receiveStateRequest(smID string, request cloudprotocol.StateRequest)
+}
+
+type clientHealth struct {
+	subscribed        bool
+	lastSeen          time.Time
+	latency           time.Duration
+	errorCount        uint64
+	consecutiveErrors uint64
 }
 
 type clientBoardConfig struct {
@@ -72,13 +95,14 @@ type clientBoardConfig struct {
 
 func newSMClient(ctx context.Context, cfg config.SMConfig,
 	messageSender MessageSender, alertSender AlertSender, monitoringSender MonitoringSender,
-	secureOpt grpc.DialOption) (client *smClient, err error) {
+	handler notificationHandler, secureOpt grpc.DialOption) (client *smClient, err error) {
 	client = &smClient{
 		context:          ctx,
 		cfg:              cfg,
 		messageSender:    messageSender,
 		alertSender:      alertSender,
 		monitoringSender: monitoringSender,
+		handler:          handler,
 	}
 
 	log.WithFields(log.Fields{"url": client.cfg.ServerURL, "id": client.cfg.SMID}).Debugf("Connecting to SM...")
@@ -120,6 +144,7 @@ func (client *smClient) getUsersStatus(users []string) (
 
 	ctx, cancel := context.WithTimeout(client.context, smRequestTimeout)
 	defer cancel()
+	defer client.trackRequest(time.Now(), &err)
 
 	status, err := client.pbClient.GetUsersStatus(ctx, &pb.Users{Users: users})
 	if err != nil {
@@ -153,6 +178,7 @@ func (client *smClient) getAllStatus() (
 
 	ctx, cancel := context.WithTimeout(client.context, smRequestTimeout)
 	defer cancel()
+	defer client.trackRequest(time.Now(), &err)
 
 	status, err := client.pbClient.GetAllStatus(ctx, &empty.Empty{})
 	if err != nil {
@@ -185,6 +211,7 @@ func (client *smClient) getBoardConfigStatus() (vendorVersion string, err error)
 
 	ctx, cancel := context.WithTimeout(client.context, smRequestTimeout)
 	defer cancel()
+	defer client.trackRequest(time.Now(), &err)
 
 	boardConfigStatus, err := client.pbClient.GetBoardConfigStatus(ctx, &empty.Empty{})
 	if err != nil {
@@ -206,6 +233,7 @@ func (client *smClient) checkBoardConfig(boardConfig clientBoardConfig) (vendorV
 
 	ctx, cancel := context.WithTimeout(client.context, smRequestTimeout)
 	defer cancel()
+	defer client.trackRequest(time.Now(), &err)
 
 	boardConfigStatus, err := client.pbClient.CheckBoardConfig(ctx, &pb.BoardConfig{BoardConfig: string(configJSON)})
 	if err != nil {
@@ -227,6 +255,7 @@ func (client *smClient) setBoardConfig(boardConfig clientBoardConfig) (err error
 
 	ctx, cancel := context.WithTimeout(client.context, smRequestTimeout)
 	defer cancel()
+	defer client.trackRequest(time.Now(), &err)
 
 	if _, err = client.pbClient.SetBoardConfig(ctx, &pb.BoardConfig{BoardConfig: string(configJSON)}); err != nil {
 		return aoserrors.Wrap(err)
@@ -243,6 +272,7 @@ func (client *smClient) installService(users []string,
 
 	ctx, cancel := context.WithTimeout(client.context, smInstallTimeout)
 	defer cancel()
+	defer client.trackRequest(time.Now(), &err)
 
 	alertRulesJSON, err := json.Marshal(serviceInfo.AlertRules)
 	if err != nil {
@@ -281,6 +311,7 @@ func (client *smClient) removeService(users []string, serviceInfo cloudprotocol.
 
 	ctx, cancel := context.WithTimeout(client.context, smInstallTimeout)
 	defer cancel()
+	defer client.trackRequest(time.Now(), &err)
 
 	if _, err = client.pbClient.RemoveService(ctx, &pb.RemoveServiceRequest{
 		Users:      &pb.Users{Users: users},
@@ -301,6 +332,7 @@ func (client *smClient) installLayer(layerInfo cloudprotocol.LayerInfoFromCloud)
 
 	ctx, cancel := context.WithTimeout(client.context, smInstallTimeout)
 	defer cancel()
+	defer client.trackRequest(time.Now(), &err)
 
 	if _, err = client.pbClient.InstallLayer(ctx, &pb.InstallLayerRequest{
 		Url:           layerInfo.URLs[0],
@@ -331,6 +363,7 @@ func (client *smClient) serviceStateAcceptance(
 
 	ctx, cancel := context.WithTimeout(client.context, smRequestTimeout)
 	defer cancel()
+	defer client.trackRequest(time.Now(), &err)
 
 	if _, err = client.pbClient.ServiceStateAcceptance(ctx, &pb.StateAcceptance{
 		CorrelationId: correlationID,
@@ -352,6 +385,7 @@ func (client *smClient) setServiceState(users []string, state cloudprotocol.Upda
 
 	ctx, cancel := context.WithTimeout(client.context, smRequestTimeout)
 	defer cancel()
+	defer client.trackRequest(time.Now(), &err)
 
 	if _, err = client.pbClient.SetServiceState(ctx, &pb.ServiceState{
 		Users:         &pb.Users{Users: users},
@@ -364,11 +398,13 @@ func (client *smClient) setServiceState(users []string, state cloudprotocol.Upda
 	return nil
 }
 
-func (client *smClient) overrideEnvVars(envVars cloudprotocol.DecodedOverrideEnvVars) (err error) {
-	log.WithFields(log.Fields{"id": client.cfg.SMID}).Debug("SM set service state")
+func (client *smClient) overrideEnvVars(envVars cloudprotocol.DecodedOverrideEnvVars) (
+	status []cloudprotocol.EnvVarInfoStatus, err error) {
+	log.WithFields(log.Fields{"id": client.cfg.SMID}).Debug("SM override env vars")
 
 	ctx, cancel := context.WithTimeout(client.context, smRequestTimeout)
 	defer cancel()
+	defer client.trackRequest(time.Now(), &err)
 
 	request := &pb.OverrideEnvVarsRequest{}
 
@@ -390,10 +426,9 @@ func (client *smClient) overrideEnvVars(envVars cloudprotocol.DecodedOverrideEnv
 
 	envVarStatus, err := client.pbClient.OverrideEnvVars(ctx, request)
 	if err != nil {
-		return aoserrors.Wrap(err)
+		return nil, aoserrors.Wrap(err)
 	}
 
-	response := []cloudprotocol.EnvVarInfoStatus{}
 	for _, item := range envVarStatus.EnvVarStatus {
 		responseItem := cloudprotocol.EnvVarInfoStatus{ServiceID: item.ServiceId, SubjectID: item.SubjectId}
 
@@ -403,14 +438,10 @@ func (client *smClient) overrideEnvVars(envVars cloudprotocol.DecodedOverrideEnv
 				Error: varStatus.Error})
 		}
 
-		response = append(response, responseItem)
+		status = append(status, responseItem)
 	}
 
-	if err = client.messageSender.SendOverrideEnvVarsStatus(response); err != nil {
-		return aoserrors.Wrap(err)
-	}
-
-	return nil
+	return status, nil
 }
 
 func (client *smClient) GetSystemLog(logRequest cloudprotocol.RequestSystemLog) (err error) {
@@ -422,6 +453,7 @@ func (client *smClient) GetSystemLog(logRequest cloudprotocol.RequestSystemLog)
 
 	ctx, cancel := context.WithTimeout(client.context, smRequestTimeout)
 	defer cancel()
+	defer client.trackRequest(time.Now(), &err)
 
 	request := &pb.SystemLogRequest{LogId: logRequest.LogID}
 
@@ -450,6 +482,7 @@ func (client *smClient) GetServiceLog(logRequest cloudprotocol.RequestServiceLog
 
 	ctx, cancel := context.WithTimeout(client.context, smRequestTimeout)
 	defer cancel()
+	defer client.trackRequest(time.Now(), &err)
 
 	request := &pb.ServiceLogRequest{LogId: logRequest.LogID, ServiceId: logRequest.ServiceID}
 
@@ -478,6 +511,7 @@ func (client *smClient) GetServiceCrashLog(logRequest cloudprotocol.RequestServi
 
 	ctx, cancel := context.WithTimeout(client.context, smRequestTimeout)
 	defer cancel()
+	defer client.trackRequest(time.Now(), &err)
 
 	request := &pb.ServiceLogRequest{LogId: logRequest.LogID, ServiceId: logRequest.ServiceID}
 
@@ -498,7 +532,11 @@ func (client *smClient) GetServiceCrashLog(logRequest cloudprotocol.RequestServi
 
 func (client *smClient) handleSMNotifications() {
 	for {
-		if err := client.subscribeSMNotifications(); err != nil {
+		err := client.subscribeSMNotifications()
+
+		client.setSubscribed(false)
+
+		if err != nil {
 			if client.context.Err() == nil {
 				log.Errorf("Error subscribe to SM notifications: %s", err)
 				log.Debugf("Reconnect to SM in %v...", smReconnectTimeout)
@@ -522,12 +560,16 @@ func (client *smClient) subscribeSMNotifications() (err error) {
 		return aoserrors.Wrap(err)
 	}
 
+	client.setSubscribed(true)
+
 	for {
 		notification, err := stream.Recv()
 		if err != nil {
 			return aoserrors.Wrap(err)
 		}
 
+		client.updateLastSeen()
+
 		switch data := notification.SMNotification.(type) {
 		case *pb.SMNotifications_Alert:
 			log.WithFields(log.Fields{
@@ -575,6 +617,7 @@ func (client *smClient) subscribeSMNotifications() (err error) {
 			log.WithFields(log.Fields{"id": client.cfg.SMID}).Debug("Receive SM monitoring")
 
 			monitoringData := cloudprotocol.MonitoringData{
+				NodeID:    client.cfg.SMID,
 				Timestamp: data.Monitoring.Timestamp.AsTime(),
 				Global: cloudprotocol.GlobalMonitoringData{
 					RAM:        data.Monitoring.SystemMonitoring.Ram,
@@ -597,6 +640,8 @@ func (client *smClient) subscribeSMNotifications() (err error) {
 					})
 			}
 
+			client.setUsedResources(monitoringData.Global.RAM, monitoringData.Global.UsedDisk)
+
 			if err = client.monitoringSender.SendMonitoringData(monitoringData); err != nil {
 				log.Errorf("Can't send monitoring data: %s", err)
 			}
@@ -607,14 +652,12 @@ func (client *smClient) subscribeSMNotifications() (err error) {
 				"correlationID": data.NewServiceState.CorrelationId,
 				"serviceID":     data.NewServiceState.ServiceState.ServiceId}).Debug("Receive SM new service state")
 
-			if err = client.messageSender.SendServiceNewState(
-				data.NewServiceState.CorrelationId,
-				data.NewServiceState.ServiceState.ServiceId,
-				string(data.NewServiceState.ServiceState.State),
-				data.NewServiceState.ServiceState.StateChecksum,
-			); err != nil {
-				log.Errorf("Can't send service new state: %s", err)
-			}
+			client.handler.receiveNewState(client.cfg.SMID, data.NewServiceState.CorrelationId,
+				cloudprotocol.NewState{
+					ServiceID: data.NewServiceState.ServiceState.ServiceId,
+					Checksum:  data.NewServiceState.ServiceState.StateChecksum,
+					State:     string(data.NewServiceState.ServiceState.State),
+				})
 
 		case *pb.SMNotifications_ServiceStateRequest:
 			log.WithFields(log.Fields{
@@ -622,10 +665,10 @@ func (client *smClient) subscribeSMNotifications() (err error) {
 				"serviceID": data.ServiceStateRequest.ServiceId,
 				"default":   data.ServiceStateRequest.Default}).Debug("Receive SM service state request")
 
-			if err = client.messageSender.SendServiceStateRequest(
-				data.ServiceStateRequest.ServiceId, data.ServiceStateRequest.Default); err != nil {
-				log.Errorf("Can't send service state request: %s", err)
-			}
+			client.handler.receiveStateRequest(client.cfg.SMID, cloudprotocol.StateRequest{
+				ServiceID: data.ServiceStateRequest.ServiceId,
+				Default:   data.ServiceStateRequest.Default,
+			})
 
 		case *pb.SMNotifications_Log:
 			log.WithFields(log.Fields{
@@ -634,18 +677,86 @@ func (client *smClient) subscribeSMNotifications() (err error) {
 				"part":      data.Log.Part,
 				"partCount": data.Log.PartCount}).Debug("Receive SM push log")
 
-			if err = client.messageSender.SendLog(cloudprotocol.PushLog{
+			client.handler.receiveLog(client.cfg.SMID, cloudprotocol.PushLog{
 				LogID:     data.Log.LogId,
 				PartCount: data.Log.PartCount,
 				Part:      data.Log.Part,
 				Data:      data.Log.Data,
 				Error:     data.Log.Error,
-			}); err != nil {
-				log.Errorf("Can't send log: %s", err)
-			}
+			})
 
 		default:
 			log.Warnf("Receive unsupported SM notification: %s", reflect.TypeOf(data))
 		}
 	}
 }
+
+func (client *smClient) setUsedResources(usedRAM, usedDisk uint64) {
+	client.Lock()
+	defer client.Unlock()
+
+	client.usedRAM = usedRAM
+	client.usedDisk = usedDisk
+}
+
+// getFreeResources returns free SM resources. Zero total resource in config means resource is not limited.
+func (client *smClient) getFreeResources() (freeRAM, freeDisk uint64) {
+	client.Lock()
+	defer client.Unlock()
+
+	return getFreeResource(client.cfg.RAM, client.usedRAM), getFreeResource(client.cfg.Disk, client.usedDisk)
+}
+
+func getFreeResource(total, used uint64) (free uint64) {
+	if total == 0 {
+		return math.MaxUint64
+	}
+
+	if used >= total {
+		return 0
+	}
+
+	return total - used
+}
+
+func (client *smClient) setSubscribed(subscribed bool) {
+	client.Lock()
+	defer client.Unlock()
+
+	client.health.subscribed = subscribed
+
+	if subscribed {
+		client.health.lastSeen = time.Now()
+	}
+}
+
+func (client *smClient) updateLastSeen() {
+	client.Lock()
+	defer client.Unlock()
+
+	client.health.lastSeen = time.Now()
+}
+
+func (client *smClient) trackRequest(start time.Time, err *error) {
+	client.Lock()
+	defer client.Unlock()
+
+	client.health.latency = time.Since(start)
+
+	if *err != nil {
+		client.health.errorCount++
+		client.health.consecutiveErrors++
+
+		return
+	}
+
+	client.health.consecutiveErrors = 0
+	client.health.lastSeen = time.Now()
+}
+
+func (client *smClient) getHealth() (health clientHealth) {
+	client.Lock()
+	defer client.Unlock()
+
+	return client.health
+}
@@ -19,6 +19,7 @@ package smcontroller
 
 import (
 	"context"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -40,6 +41,8 @@ import (
 
 const connectClientTimeout = 1 * time.Minute
 
+const alertSource = "CM"
+
 /***********************************************************************************************************************
  * Types
  **********************************************************************************************************************/
@@ -52,11 +55,77 @@ type Controller struct {
 	alertSender      AlertSender
 	monitoringSender MonitoringSender
 	urlTranslator    URLTranslator
+	imageDir         string
 	clientsWG        sync.WaitGroup
 	readyWG          sync.WaitGroup
 	clients          map[string]*smClient
 	context          context.Context
 	cancelFunction   context.CancelFunc
+
+	secureOpt      grpc.DialOption
+	regServer      *smRegServer
+	smStatuses     map[string]SMStatus
+	storage        Storage
+	placementMutex sync.Mutex
+	placements     map[string]ServicePlacement
+	planned        map[string]ServicePlacement
+	layers         map[string]LayerPlacement
+
+	healthStates  map[string]*nodeHealthState
+	healthChannel chan []cloudprotocol.NodeHealth
+
+	logCollector *logCollector
+	stateBackup  *stateBackup
+	users        []string
+
+	envVarsMutex sync.Mutex
+	envVars      []cloudprotocol.OverrideEnvsFromCloud
+	envVarsTimer *time.Timer
+}
+
+// SMStatus SM connection status
+type SMStatus struct {
+	SMID       string
+	Registered bool
+	Connected  bool
+	Timestamp  time.Time
+}
+
+// ServicePlacement SM's assigned to the service, layers required by the service and service install info used
+// to reschedule the service
+type ServicePlacement struct {
+	ServiceID   string
+	SMIDs       []string
+	Layers      []string
+	Users       []string
+	ServiceInfo cloudprotocol.ServiceInfoFromCloud
+}
+
+// LayerPlacement SM's where the layer is installed and layer install info used to install the layer on other SM's
+type LayerPlacement struct {
+	Digest    string
+	SMIDs     []string
+	LayerInfo cloudprotocol.LayerInfoFromCloud
+}
+
+// Storage stores service and layer placements
+type Storage interface {
+	SetServicePlacement(placement ServicePlacement) (err error)
+	GetServicePlacements() (placements []ServicePlacement, err error)
+	RemoveServicePlacement(serviceID string) (err error)
+	SetLayerPlacement(placement LayerPlacement) (err error)
+	GetLayerPlacements() (placements []LayerPlacement, err error)
+	SetServiceStateBackup(backup ServiceStateBackup) (err error)
+	GetServiceStateBackup(serviceID string, users []string) (backup ServiceStateBackup, err error)
+	RemoveServiceStateBackup(serviceID string, users []string) (err error)
+	SetOverrideEnvVars(envVars []cloudprotocol.OverrideEnvsFromCloud) (err error)
+	GetOverrideEnvVars() (envVars []cloudprotocol.OverrideEnvsFromCloud, err error)
+}
+
+// KeyWrapper protects state backup key with the unit key
+type KeyWrapper interface {
+	WrapKey(key []byte) (wrapped []byte, err error)
+	UnwrapKey(wrapped []byte) (key []byte, err error)
 }
 
 // URLTranslator translates URL from local to remote if required
@@ -88,8 +157,9 @@ type MessageSender interface {
 
 // New creates new SM controller
 func New(
-	cfg *config.Config, messageSender MessageSender, alertSender AlertSender, monitoringSender MonitoringSender,
-	urlTranslator URLTranslator, insecure bool) (controller *Controller, err error) {
+	cfg *config.Config, storage Storage, messageSender MessageSender, alertSender AlertSender,
+	monitoringSender MonitoringSender, urlTranslator URLTranslator, keyWrapper KeyWrapper,
+	insecure bool) (controller *Controller, err error) {
 	log.Debug("Create SM controller")
 
 	controller = &Controller{
@@ -97,7 +167,16 @@ func New(
 		alertSender:      alertSender,
 		monitoringSender: monitoringSender,
 		urlTranslator:    urlTranslator,
-		clients:          make(map[string]*smClient)}
+		imageDir:         cfg.Downloader.DecryptDir,
+		clients:          make(map[string]*smClient),
+		storage:          storage,
+		placements:       make(map[string]ServicePlacement),
+		planned:          make(map[string]ServicePlacement),
+		layers:           make(map[string]LayerPlacement),
+		smStatuses:       make(map[string]SMStatus),
+		healthStates:     make(map[string]*nodeHealthState),
+		healthChannel:    make(chan []cloudprotocol.NodeHealth, 1),
+		logCollector:     newLogCollector(messageSender)}
 	controller.context, controller.cancelFunction = context.WithCancel(context.Background())
 
 	defer func() {
@@ -107,24 +186,61 @@ func New(
 		}
 	}()
 
-	var secureOpt grpc.DialOption
+	placements, err := storage.GetServicePlacements()
+	if err != nil {
+		return controller, aoserrors.Wrap(err)
+	}
+
+	for _, placement := range placements {
+		controller.placements[placement.ServiceID] = placement
+	}
+
+	layerPlacements, err := storage.GetLayerPlacements()
+	if err != nil {
+		return controller, aoserrors.Wrap(err)
+	}
+
+	for _, placement := range layerPlacements {
+		controller.layers[placement.Digest] = placement
+	}
+
+	if controller.stateBackup, err = newStateBackup(cfg.SMController, storage, keyWrapper); err != nil {
+		return controller, aoserrors.Wrap(err)
+	}
+
+	if err = controller.loadEnvVars(); err != nil {
+		return controller, aoserrors.Wrap(err)
+	}
 
 	if insecure {
-		secureOpt = grpc.WithInsecure()
+		controller.secureOpt = grpc.WithInsecure()
 	} else {
 		tlsConfig, err := cryptutils.GetClientMutualTLSConfig(cfg.Crypt.CACert, cfg.CertStorage)
 		if err != nil {
 			return controller, aoserrors.Wrap(err)
 		}
 
-		secureOpt = grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
+		controller.secureOpt = grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
 	}
 
 	for _, smConfig := range cfg.SMController.SMList {
 		controller.readyWG.Add(1)
-		go controller.connectClient(smConfig, secureOpt)
+		go controller.connectClient(smConfig, controller.secureOpt)
 	}
 
+	if cfg.SMController.ServerURL != "" {
+		if controller.regServer, err = newSMRegServer(cfg, controller, insecure); err != nil {
+			return controller, aoserrors.Wrap(err)
+		}
+	}
+
+	healthCheckPeriod := cfg.SMController.HealthCheckPeriod.Duration
+	if healthCheckPeriod <= 0 {
+		healthCheckPeriod = defaultHealthCheckPeriod
+	}
+
+	go controller.superviseHealth(healthCheckPeriod, cfg.SMController.FailoverGracePeriod.Duration)
+
 	return controller, nil
 }
 
@@ -133,6 +249,13 @@ func (controller *Controller) Close() (err error) {
 	log.Debug("Close SM controller")
 
 	controller.cancelFunction()
+
+	controller.logCollector.close()
+	controller.stopEnvVarsExpiration()
+
+	if controller.regServer != nil {
+		controller.regServer.close()
+	}
 	controller.clientsWG.Wait()
 
 	controller.Lock()
@@ -145,21 +268,35 @@ func (controller *Controller) Close() (err error) {
 	return nil
 }
 
+// GetSMStatuses returns statuses of configured and registered SM's
+func (controller *Controller) GetSMStatuses() (statuses []SMStatus) {
+	controller.Lock()
+	defer controller.Unlock()
+
+	for _, status := range controller.smStatuses {
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].SMID < statuses[j].SMID })
+
+	return statuses
+}
+
 // GetUsersStatus returns SM users status
 func (controller *Controller) GetUsersStatus(users []string) (
 	servicesInfo []cloudprotocol.ServiceInfo, layersInfo []cloudprotocol.LayerInfo, err error) {
-	controller.waitAndLock()
-	clients := controller.clients
-	controller.Unlock()
+	controller.setUsers(users)
 
-	for _, client := range clients {
-		clientServices, clientLayers, err := client.getUsersStatus(users)
+	clients := controller.getAliveClients()
+
+	for _, smID := range getSortedSMIDs(clients) {
+		clientServices, clientLayers, err := clients[smID].getUsersStatus(users)
 		if err != nil {
 			return nil, nil, aoserrors.Wrap(err)
 		}
 
-		servicesInfo = append(servicesInfo, clientServices...)
-		layersInfo = append(layersInfo, clientLayers...)
+		servicesInfo = mergeServices(servicesInfo, clientServices, smID)
+		layersInfo = mergeLayers(layersInfo, clientLayers)
 	}
 
 	return servicesInfo, layersInfo, nil
@@ -168,150 +305,174 @@ func (controller *Controller) GetUsersStatus(users []string) (
 // GetAllStatus returns SM all existing layers and services status
 func (controller *Controller) GetAllStatus() (
 	servicesInfo []cloudprotocol.ServiceInfo, layersInfo []cloudprotocol.LayerInfo, err error) {
-	controller.waitAndLock()
-	clients := controller.clients
-	controller.Unlock()
+	clients := controller.getAliveClients()
 
-	for _, client := range clients {
-		clientServices, clientLayers, err := client.getAllStatus()
+	for _, smID := range getSortedSMIDs(clients) {
+		clientServices, clientLayers, err := clients[smID].getAllStatus()
 		if err != nil {
 			return nil, nil, aoserrors.Wrap(err)
 		}
 
-		servicesInfo = append(servicesInfo, clientServices...)
-		layersInfo = append(layersInfo, clientLayers...)
+		servicesInfo = mergeServices(servicesInfo, clientServices, smID)
+		layersInfo = mergeLayers(layersInfo, clientLayers)
 	}
 
 	return servicesInfo, layersInfo, nil
 }
 
-// CheckBoardConfig checks board config
-func (controller *Controller) CheckBoardConfig(boardConfig boardconfig.BoardConfig) (err error) {
-	controller.waitAndLock()
-	clients := controller.clients
-	controller.Unlock()
-
-	// TODO: we do not support multiple SM right now, check the same config for all SM's
+// CheckBoardConfig checks board config of each node on corresponding SM
+func (controller *Controller) CheckBoardConfig(
+	boardConfig boardconfig.BoardConfig) (nodesInfo []cloudprotocol.NodeBoardConfigInfo, err error) {
+	clients := controller.getClients()
 
-	clientBoardConfig := clientBoardConfig{
-		FormatVersion: boardConfig.FormatVersion,
-		VendorVersion: boardConfig.VendorVersion,
-		Devices:       boardConfig.Devices,
-		Resources:     boardConfig.Resources,
+	for nodeID := range boardConfig.Nodes {
+		if _, ok := clients[nodeID]; !ok {
+			log.WithField("id", nodeID).Warn("Board config contains section for unknown SM")
+		}
 	}
 
-	for _, client := range clients {
-		vendorVersion, err := client.checkBoardConfig(clientBoardConfig)
-		if err != nil {
-			return aoserrors.Wrap(err)
-		}
+	for _, smID := range getSortedSMIDs(clients) {
+		nodeInfo := cloudprotocol.NodeBoardConfigInfo{NodeID: smID, Status: cloudprotocol.PendingStatus}
+
+		if checkErr := checkClientBoardConfig(
+			clients[smID], newClientBoardConfig(boardConfig.GetNodeConfig(smID))); checkErr != nil {
+			log.WithField("id", smID).Errorf("Board config check failed: %s", checkErr)
+
+			nodeInfo.Status = cloudprotocol.ErrorStatus
+			nodeInfo.Error = checkErr.Error()
 
-		if vendorVersion != boardConfig.VendorVersion {
-			return aoserrors.New("wrong vendor version")
+			if err == nil {
+				err = aoserrors.Errorf("SM %s board config error: %s", smID, checkErr)
+			}
 		}
+
+		nodesInfo = append(nodesInfo, nodeInfo)
 	}
 
-	return nil
+	return nodesInfo, err
 }
 
-// SetBoardConfig sets board config
-func (controller *Controller) SetBoardConfig(boardConfig boardconfig.BoardConfig) (err error) {
-	controller.waitAndLock()
-	clients := controller.clients
-	controller.Unlock()
+// SetBoardConfig sets board config of each node on corresponding SM
+func (controller *Controller) SetBoardConfig(
+	boardConfig boardconfig.BoardConfig) (nodesInfo []cloudprotocol.NodeBoardConfigInfo, err error) {
+	clients := controller.getClients()
 
-	// TODO: we do not support multiple SM right now, set the same config for all SM's
+	for _, smID := range getSortedSMIDs(clients) {
+		nodeInfo := cloudprotocol.NodeBoardConfigInfo{NodeID: smID, Status: cloudprotocol.InstalledStatus}
 
-	clientBoardConfig := clientBoardConfig{
-		FormatVersion: boardConfig.FormatVersion,
-		VendorVersion: boardConfig.VendorVersion,
-		Devices:       boardConfig.Devices,
-		Resources:     boardConfig.Resources,
-	}
-
-	for _, client := range clients {
-		// Check board config version and set if it is different
+		if setErr := setClientBoardConfig(
+			clients[smID], newClientBoardConfig(boardConfig.GetNodeConfig(smID))); setErr != nil {
+			log.WithField("id", smID).Errorf("Can't set board config: %s", setErr)
 
-		currentVendorVersion, err := client.getBoardConfigStatus()
-		if err != nil {
-			return aoserrors.Wrap(err)
-		}
+			nodeInfo.Status = cloudprotocol.ErrorStatus
+			nodeInfo.Error = setErr.Error()
 
-		if currentVendorVersion == clientBoardConfig.VendorVersion {
-			continue
+			if err == nil {
+				err = aoserrors.Errorf("SM %s board config error: %s", smID, setErr)
+			}
 		}
 
-		if err = client.setBoardConfig(clientBoardConfig); err != nil {
-			return aoserrors.Wrap(err)
-		}
+		nodesInfo = append(nodesInfo, nodeInfo)
 	}
 
-	return nil
+	return nodesInfo, err
 }
 
-// InstallService requests to install servie
+// InstallService installs service on SM's selected according to the service placement hints
 func (controller *Controller) InstallService(users []string,
 	serviceInfo cloudprotocol.ServiceInfoFromCloud) (stateChecksum string, err error) {
-	controller.waitAndLock()
-	clients := controller.clients
-	controller.Unlock()
-
-	if len(serviceInfo.URLs) == 0 {
-		return "", aoserrors.New("no service URL")
-	}
-
-	// TODO: we do not support multiple SM right now, install same service for all SM's
-
-	for _, client := range clients {
-		if serviceInfo.URLs[0], err = controller.urlTranslator.TranslateURL(
-			client.cfg.IsLocal, serviceInfo.URLs[0]); err != nil {
-			return "", aoserrors.Wrap(err)
-		}
-
-		if stateChecksum, err = client.installService(users, serviceInfo); err != nil {
-			return "", aoserrors.Wrap(err)
-		}
+	if stateChecksum, err = controller.installService(controller.getAliveClients(), users, serviceInfo); err != nil {
+		return "", aoserrors.Wrap(err)
 	}
 
 	return stateChecksum, nil
 }
 
-// RemoveService remove service request
+// RemoveService removes service from SM's where it is placed
 func (controller *Controller) RemoveService(users []string, serviceInfo cloudprotocol.ServiceInfo) (err error) {
-	controller.waitAndLock()
-	clients := controller.clients
-	controller.Unlock()
+	clients := controller.getClients()
 
-	// TODO: we do not support multiple SM right now, remove service for all SM's
+	controller.placementMutex.Lock()
+	placement, placed := controller.placements[serviceInfo.ID]
+	controller.placementMutex.Unlock()
+
+	smIDs := placement.SMIDs
+
+	// Service installed before placement was introduced, remove it from all SM's
+	if !placed {
+		smIDs = getSortedSMIDs(clients)
+	}
+
+	for _, smID := range smIDs {
+		client, ok := clients[smID]
+		if !ok {
+			return aoserrors.Errorf("SM %s is not connected", smID)
+		}
 
-	for _, client := range clients {
 		if err = client.removeService(users, serviceInfo); err != nil {
 			return aoserrors.Wrap(err)
 		}
 	}
 
+	if !placed {
+		return nil
+	}
+
+	controller.placementMutex.Lock()
+	delete(controller.placements, serviceInfo.ID)
+	controller.placementMutex.Unlock()
+
+	controller.stateBackup.removeState(serviceInfo.ID, users)
+
+	if err = controller.storage.RemoveServicePlacement(serviceInfo.ID); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	controller.removeImage(serviceImageName(serviceInfo.ID))
+
 	return nil
 }
 
-// InstallLayer install layer request
+// InstallLayer installs layer on SM's which host or are planned to host services required the layer. If no service
+// requires the layer, it is installed on all SM's.
 func (controller *Controller) InstallLayer(layerInfo cloudprotocol.LayerInfoFromCloud) (err error) {
-	controller.waitAndLock()
-	clients := controller.clients
-	controller.Unlock()
+	clients := controller.getClients()
 
 	if len(layerInfo.URLs) == 0 {
 		return aoserrors.New("no layer URL")
 	}
 
-	// TODO: we do not support multiple SM right now, install same layer for all SM's
+	layerInfo.URLs = append([]string{}, layerInfo.URLs...)
+	layerInfo.URLs[0] = controller.keepImage(layerImageName(layerInfo.Digest), layerInfo.URLs[0])
 
-	for _, client := range clients {
-		if layerInfo.URLs[0], err = controller.urlTranslator.TranslateURL(
-			client.cfg.IsLocal, layerInfo.URLs[0]); err != nil {
-			return aoserrors.Wrap(err)
+	controller.placementMutex.Lock()
+
+	placement := controller.layers[layerInfo.Digest]
+
+	placement.Digest = layerInfo.Digest
+	placement.LayerInfo = layerInfo
+	controller.layers[layerInfo.Digest] = placement
+
+	err = controller.storage.SetLayerPlacement(placement)
+	smIDs := controller.getLayerSMIDs(layerInfo.Digest)
+
+	controller.placementMutex.Unlock()
+
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if len(smIDs) == 0 {
+		smIDs = getSortedSMIDs(clients)
+	}
+
+	for _, smID := range smIDs {
+		client, ok := clients[smID]
+		if !ok {
+			return aoserrors.Errorf("SM %s is not connected", smID)
 		}
 
-		if err = client.installLayer(layerInfo); err != nil {
+		if err = controller.installClientLayer(client, layerInfo); err != nil {
 			return aoserrors.Wrap(err)
 		}
 	}
@@ -319,14 +480,41 @@ func (controller *Controller) InstallLayer(layerInfo cloudprotocol.LayerInfoFrom
 	return nil
 }
 
+// PlaceServices selects SM's for services which are going to be installed. It should be called before installing
+// layers of these services in order to install the layers only on SM's where the services will be placed.
+func (controller *Controller) PlaceServices(services []cloudprotocol.ServiceInfoFromCloud) (err error) {
+	clients := controller.getAliveClients()
+
+	controller.placementMutex.Lock()
+	defer controller.placementMutex.Unlock()
+
+	controller.planned = make(map[string]ServicePlacement)
+
+	for _, serviceInfo := range services {
+		placement, _, placeErr := controller.placeService(clients, serviceInfo)
+		if placeErr != nil {
+			if err == nil {
+				err = aoserrors.Wrap(placeErr)
+			}
+
+			continue
+		}
+
+		controller.planned[serviceInfo.ID] = placement
+	}
+
+	return err
+}
+
 // ServiceStateAcceptance handles service state acceptance
 func (controller *Controller) ServiceStateAcceptance(
 	correlationID string, stateAcceptance cloudprotocol.StateAcceptance) (err error) {
-	controller.waitAndLock()
-	clients := controller.clients
-	controller.Unlock()
+	controller.stateBackup.acceptState(correlationID, stateAcceptance)
 
-	// TODO: we do not support multiple SM right now, send service state acceptance for all SM's
+	clients := controller.getServiceClients(stateAcceptance.ServiceID)
+	if len(clients) == 0 {
+		return aoserrors.Errorf("no SM available for service %s", stateAcceptance.ServiceID)
+	}
 
 	for _, client := range clients {
 		if err = client.serviceStateAcceptance(correlationID, stateAcceptance); err != nil {
@@ -339,11 +527,15 @@ func (controller *Controller) ServiceStateAcceptance(
 
 // SetServiceState sets service state
 func (controller *Controller) SetServiceState(users []string, state cloudprotocol.UpdateState) (err error) {
-	controller.waitAndLock()
-	clients := controller.clients
-	controller.Unlock()
+	controller.setUsers(users)
+
+	controller.stateBackup.setState(ServiceStateBackup{
+		ServiceID: state.ServiceID, Users: users, Checksum: state.Checksum, State: []byte(state.State)})
 
-	// TODO: we do not support multiple SM right now, set service state for all SM's
+	clients := controller.getServiceClients(state.ServiceID)
+	if len(clients) == 0 {
+		return aoserrors.Errorf("no SM available for service %s", state.ServiceID)
+	}
 
 	for _, client := range clients {
 		if err = client.setServiceState(users, state); err != nil {
@@ -354,68 +546,391 @@ func (controller *Controller) SetServiceState(users []string, state cloudprotoco
 	return nil
 }
 
-// OverrideEnvVars overrides service env vars
-func (controller *Controller) OverrideEnvVars(envVars cloudprotocol.DecodedOverrideEnvVars) (err error) {
-	controller.waitAndLock()
-	clients := controller.clients
-	controller.Unlock()
+// GetSystemLog requests system log from all SM's
+func (controller *Controller) GetSystemLog(logRequest cloudprotocol.RequestSystemLog) (err error) {
+	clients := controller.getAliveClients()
 
-	// TODO: we do not support multiple SM right now, override service env vars for all SM's
+	return controller.requestLog(logRequest.LogID, clients, func(client *smClient) error {
+		return client.GetSystemLog(logRequest)
+	})
+}
 
-	for _, client := range clients {
-		if err = client.overrideEnvVars(envVars); err != nil {
-			return aoserrors.Wrap(err)
+// GetServiceLog requests service log from SM's where the service is placed
+func (controller *Controller) GetServiceLog(logRequest cloudprotocol.RequestServiceLog) (err error) {
+	clients := controller.getServiceClients(logRequest.ServiceID)
+
+	return controller.requestLog(logRequest.LogID, clients, func(client *smClient) error {
+		return client.GetServiceLog(logRequest)
+	})
+}
+
+// GetServiceCrashLog requests service crash log from SM's where the service is placed
+func (controller *Controller) GetServiceCrashLog(logRequest cloudprotocol.RequestServiceCrashLog) (err error) {
+	clients := controller.getServiceClients(logRequest.ServiceID)
+
+	return controller.requestLog(logRequest.LogID, clients, func(client *smClient) error {
+		return client.GetServiceCrashLog(logRequest)
+	})
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (controller *Controller) installService(clients map[string]*smClient, users []string,
+	serviceInfo cloudprotocol.ServiceInfoFromCloud) (stateChecksum string, err error) {
+	if len(serviceInfo.URLs) == 0 {
+		return "", aoserrors.New("no service URL")
+	}
+
+	controller.placementMutex.Lock()
+
+	prevPlacement, hasPrevPlacement := controller.placements[serviceInfo.ID]
+
+	placement, removeSMIDs, err := controller.placeService(clients, serviceInfo)
+	if err == nil {
+		placement.Users = users
+		placement.ServiceInfo = serviceInfo
+
+		// Reserve placement to take it into account by concurrent installs
+		controller.placements[serviceInfo.ID] = placement
+	}
+
+	delete(controller.planned, serviceInfo.ID)
+
+	controller.placementMutex.Unlock()
+
+	if err != nil {
+		return "", aoserrors.Wrap(err)
+	}
+
+	defer func() {
+		if err != nil {
+			controller.placementMutex.Lock()
+			defer controller.placementMutex.Unlock()
+
+			if hasPrevPlacement {
+				controller.placements[serviceInfo.ID] = prevPlacement
+			} else {
+				delete(controller.placements, serviceInfo.ID)
+			}
+		}
+	}()
+
+	for i, smID := range placement.SMIDs {
+		client := clients[smID]
+
+		if err = controller.installServiceLayers(client, serviceInfo.Layers); err != nil {
+			return "", aoserrors.Wrap(err)
+		}
+
+		clientServiceInfo := serviceInfo
+		clientServiceInfo.URLs = append([]string{}, serviceInfo.URLs...)
+
+		if clientServiceInfo.URLs[0], err = controller.urlTranslator.TranslateURL(
+			client.cfg.IsLocal, serviceInfo.URLs[0]); err != nil {
+			return "", aoserrors.Wrap(err)
+		}
+
+		var clientStateChecksum string
+
+		if clientStateChecksum, err = client.installService(users, clientServiceInfo); err != nil {
+			return "", aoserrors.Wrap(err)
+		}
+
+		if i == 0 {
+			stateChecksum = clientStateChecksum
 		}
 	}
 
-	return nil
+	placement.ServiceInfo.URLs = append([]string{}, serviceInfo.URLs...)
+	placement.ServiceInfo.URLs[0] = controller.keepImage(serviceImageName(serviceInfo.ID), serviceInfo.URLs[0])
+
+	controller.placementMutex.Lock()
+	controller.placements[serviceInfo.ID] = placement
+	controller.placementMutex.Unlock()
+
+	if err = controller.storage.SetServicePlacement(placement); err != nil {
+		return "", aoserrors.Wrap(err)
+	}
+
+	for _, smID := range removeSMIDs {
+		client, ok := clients[smID]
+		if !ok {
+			continue
+		}
+
+		if err := client.removeService(users, cloudprotocol.ServiceInfo{
+			ID: serviceInfo.ID, AosVersion: serviceInfo.AosVersion}); err != nil {
+			log.WithFields(log.Fields{"id": smID, "serviceID": serviceInfo.ID}).Errorf(
+				"Can't remove service from previous SM: %s", err)
+		}
+	}
 
+	return stateChecksum, nil
 }
 
-// GetSystemLog requests system log from SM
-func (controller *Controller) GetSystemLog(logRequest cloudprotocol.RequestSystemLog) (err error) {
-	controller.waitAndLock()
-	clients := controller.clients
-	controller.Unlock()
+// requestLog requests log from SM's. Log parts of each SM are forwarded to the cloud as they are received.
+func (controller *Controller) requestLog(
+	logID string, clients map[string]*smClient, request func(client *smClient) error) (err error) {
+	if len(clients) == 0 {
+		return aoserrors.New("no SM connected")
+	}
 
-	// TODO: we do not support multiple SM right now, get system log from all SM's
+	smIDs := getSortedSMIDs(clients)
 
-	for _, client := range clients {
-		if err = client.GetSystemLog(logRequest); err != nil {
-			return aoserrors.Wrap(err)
+	controller.logCollector.startRequest(logID, smIDs)
+
+	for _, smID := range smIDs {
+		if requestErr := request(clients[smID]); requestErr != nil {
+			log.WithFields(log.Fields{"id": smID, "logID": logID}).Errorf("Can't request log: %s", requestErr)
+
+			controller.logCollector.setNodeError(logID, smID, requestErr)
 		}
 	}
 
 	return nil
 }
 
-// GetServiceLog requests service log from SM
-func (controller *Controller) GetServiceLog(logRequest cloudprotocol.RequestServiceLog) (err error) {
-	controller.waitAndLock()
-	clients := controller.clients
-	controller.Unlock()
+// getServiceClients returns alive clients where the service is placed or all alive clients if it is not placed
+func (controller *Controller) getServiceClients(serviceID string) (clients map[string]*smClient) {
+	clients = controller.getAliveClients()
 
-	// TODO: we do not support multiple SM right now, get service log from all SM's
+	controller.placementMutex.Lock()
+	placement, ok := controller.placements[serviceID]
+	controller.placementMutex.Unlock()
 
-	for _, client := range clients {
-		if err = client.GetServiceLog(logRequest); err != nil {
-			return aoserrors.Wrap(err)
+	if !ok {
+		return clients
+	}
+
+	serviceClients := make(map[string]*smClient)
+
+	for _, smID := range placement.SMIDs {
+		if client, ok := clients[smID]; ok {
+			serviceClients[smID] = client
+		}
+	}
+
+	return serviceClients
+}
+
+func (controller *Controller) receiveLog(smID string, pushLog cloudprotocol.PushLog) {
+	controller.logCollector.receiveLog(smID, pushLog)
+}
+
+func (controller *Controller) receiveNewState(smID, correlationID string, state cloudprotocol.NewState) {
+	controller.stateBackup.addPendingState(correlationID, ServiceStateBackup{
+		ServiceID: state.ServiceID, Users: controller.getServiceUsers(state.ServiceID), Checksum: state.Checksum, State: []byte(state.State)})
+
+	if err := controller.messageSender.SendServiceNewState(
+		correlationID, state.ServiceID, state.State, state.Checksum); err != nil {
+		log.Errorf("Can't send service new state: %s", err)
+	}
+}
+
+// receiveStateRequest restores service state from backup. State is requested from the cloud if there is no backup.
+func (controller *Controller) receiveStateRequest(smID string, request cloudprotocol.StateRequest) {
+	if !request.Default {
+		err := controller.restoreState(smID, request.ServiceID)
+		if err == nil {
+			return
 		}
+
+		log.WithFields(log.Fields{"id": smID, "serviceID": request.ServiceID}).Debugf(
+			"Can't restore service state from backup: %s", err)
+	}
+
+	if err := controller.messageSender.SendServiceStateRequest(request.ServiceID, request.Default); err != nil {
+		log.Errorf("Can't send service state request: %s", err)
+	}
+}
+
+func (controller *Controller) restoreState(smID, serviceID string) (err error) {
+	users := controller.getServiceUsers(serviceID)
+
+	state, err := controller.stateBackup.getState(serviceID, users)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	client, ok := controller.getClients()[smID]
+	if !ok {
+		return aoserrors.Errorf("SM %s is not connected", smID)
+	}
+
+	if err = client.setServiceState(users, cloudprotocol.UpdateState{
+		ServiceID: serviceID, Checksum: state.Checksum, State: string(state.State)}); err != nil {
+		return aoserrors.Wrap(err)
 	}
 
+	log.WithFields(log.Fields{"id": smID, "serviceID": serviceID}).Info("Service state restored from backup")
+
 	return nil
 }
 
-// GetServiceCrashLog requests service crash log from SM
-func (controller *Controller) GetServiceCrashLog(logRequest cloudprotocol.RequestServiceCrashLog) (err error) {
+func (controller *Controller) setUsers(users []string) {
+	controller.Lock()
+	defer controller.Unlock()
+
+	controller.users = users
+}
+
+func (controller *Controller) getUsers() (users []string) {
+	controller.Lock()
+	defer controller.Unlock()
+
+	return controller.users
+}
+
+// getServiceUsers returns current users. Users the service is installed for are used if current users are not
+// received yet after restart.
+func (controller *Controller) getServiceUsers(serviceID string) (users []string) {
+	if users = controller.getUsers(); users != nil {
+		return users
+	}
+
+	controller.placementMutex.Lock()
+	defer controller.placementMutex.Unlock()
+
+	return controller.placements[serviceID].Users
+}
+
+func (controller *Controller) waitAndLock() {
+	controller.readyWG.Wait()
+	controller.Lock()
+}
+
+// getClients returns copy of connected clients as clients may be added and removed by SM registration
+func (controller *Controller) getClients() (clients map[string]*smClient) {
 	controller.waitAndLock()
-	clients := controller.clients
+	defer controller.Unlock()
+
+	clients = make(map[string]*smClient, len(controller.clients))
+
+	for smID, client := range controller.clients {
+		clients[smID] = client
+	}
+
+	return clients
+}
+
+func (controller *Controller) registerClient(client *smClient) {
+	controller.Lock()
+	defer controller.Unlock()
+
+	smID := client.cfg.SMID
+
+	if prevClient, ok := controller.clients[smID]; ok {
+		log.WithField("id", smID).Warn("SM is already connected, replace connection")
+
+		prevClient.close()
+	}
+
+	controller.clients[smID] = client
+	controller.smStatuses[smID] = SMStatus{SMID: smID, Registered: true, Connected: true, Timestamp: time.Now()}
+
+	log.WithField("id", smID).Info("SM registered")
+
+	go controller.syncClient(client)
+}
+
+func (controller *Controller) unregisterClient(client *smClient) {
+	client.close()
+
+	smID := client.cfg.SMID
+
+	controller.Lock()
+
+	// SM may be registered again with new connection
+	if controller.clients[smID] != client {
+		controller.Unlock()
+		return
+	}
+
+	delete(controller.clients, smID)
+
+	// Don't report SM loss on CM shutdown
+	if controller.context.Err() != nil {
+		controller.Unlock()
+		return
+	}
+
+	controller.smStatuses[smID] = SMStatus{SMID: smID, Registered: true, Connected: false, Timestamp: time.Now()}
+
 	controller.Unlock()
 
-	// TODO: we do not support multiple SM right now, get service crash log from all SM's
+	log.WithField("id", smID).Warn("Registered SM disappeared")
 
-	for _, client := range clients {
-		if err = client.GetServiceCrashLog(logRequest); err != nil {
+	controller.sendAlert("SM " + smID + " disappeared")
+}
+
+func (controller *Controller) sendAlert(message string) {
+	if controller.alertSender == nil {
+		return
+	}
+
+	if err := controller.alertSender.SendAlert(cloudprotocol.AlertItem{
+		Timestamp: time.Now(),
+		Tag:       cloudprotocol.AlertTagAosCore,
+		Source:    alertSource,
+		Payload:   cloudprotocol.SystemAlert{Message: message},
+	}); err != nil {
+		log.Errorf("Can't send alert: %s", err)
+	}
+}
+
+func checkClientBoardConfig(client *smClient, boardConfig clientBoardConfig) (err error) {
+	vendorVersion, err := client.checkBoardConfig(boardConfig)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if vendorVersion != boardConfig.VendorVersion {
+		return aoserrors.Errorf("wrong vendor version: %s", vendorVersion)
+	}
+
+	return nil
+}
+
+func setClientBoardConfig(client *smClient, boardConfig clientBoardConfig) (err error) {
+	// Check board config version and set if it is different
+
+	currentVendorVersion, err := client.getBoardConfigStatus()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if currentVendorVersion == boardConfig.VendorVersion {
+		return nil
+	}
+
+	if err = client.setBoardConfig(boardConfig); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (controller *Controller) installServiceLayers(client *smClient, digests []string) (err error) {
+	for _, digest := range digests {
+		controller.placementMutex.Lock()
+		placement, ok := controller.layers[digest]
+		installed := contains(placement.SMIDs, client.cfg.SMID)
+		controller.placementMutex.Unlock()
+
+		if installed {
+			continue
+		}
+
+		if !ok {
+			log.WithFields(log.Fields{"id": client.cfg.SMID, "digest": digest}).Debug(
+				"Layer is not requested for install, skip")
+
+			continue
+		}
+
+		if err = controller.installClientLayer(client, placement.LayerInfo); err != nil {
 			return aoserrors.Wrap(err)
 		}
 	}
@@ -423,13 +938,38 @@ func (controller *Controller) GetServiceCrashLog(logRequest cloudprotocol.Reques
 	return nil
 }
 
-/***********************************************************************************************************************
- * Private
- **********************************************************************************************************************/
+func (controller *Controller) installClientLayer(client *smClient, layerInfo cloudprotocol.LayerInfoFromCloud) (err error) {
+	clientLayerInfo := layerInfo
+	clientLayerInfo.URLs = append([]string{}, layerInfo.URLs...)
 
-func (controller *Controller) waitAndLock() {
-	controller.readyWG.Wait()
-	controller.Lock()
+	if clientLayerInfo.URLs[0], err = controller.urlTranslator.TranslateURL(
+		client.cfg.IsLocal, layerInfo.URLs[0]); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = client.installLayer(clientLayerInfo); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	controller.placementMutex.Lock()
+	defer controller.placementMutex.Unlock()
+
+	placement := controller.layers[layerInfo.Digest]
+
+	if contains(placement.SMIDs, client.cfg.SMID) {
+		return nil
+	}
+
+	placement.Digest = layerInfo.Digest
+	placement.LayerInfo = layerInfo
+	placement.SMIDs = append(placement.SMIDs, client.cfg.SMID)
+	controller.layers[layerInfo.Digest] = placement
+
+	if err = controller.storage.SetLayerPlacement(placement); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
 }
 
 func (controller *Controller) connectClient(smConfig config.SMConfig, secureOpt grpc.DialOption) {
@@ -442,14 +982,18 @@ func (controller *Controller) connectClient(smConfig config.SMConfig, secureOpt
 	go func() {
 		defer controller.clientsWG.Done()
 
-		client, err := newSMClient(controller.context, smConfig,
-			controller.messageSender, controller.alertSender, controller.monitoringSender, secureOpt)
+		client, err := newSMClient(controller.context, smConfig, controller.messageSender, controller.alertSender,
+			controller.monitoringSender, controller, secureOpt)
 
 		connectChannel <- struct{}{}
 
 		if err != nil {
 			if !strings.Contains(err.Error(), context.Canceled.Error()) {
 				log.WithField("id", smConfig.SMID).Errorf("Can't connect to SM: %s", err)
+
+				controller.Lock()
+				controller.smStatuses[smConfig.SMID] = SMStatus{SMID: smConfig.SMID, Timestamp: time.Now()}
+				controller.Unlock()
 			}
 
 			return
@@ -459,6 +1003,9 @@ func (controller *Controller) connectClient(smConfig config.SMConfig, secureOpt
 		defer controller.Unlock()
 
 		controller.clients[smConfig.SMID] = client
+		controller.smStatuses[smConfig.SMID] = SMStatus{SMID: smConfig.SMID, Connected: true, Timestamp: time.Now()}
+
+		go controller.syncClient(client)
 	}()
 
 	select {
@@ -468,3 +1015,54 @@ func (controller *Controller) connectClient(smConfig config.SMConfig, secureOpt
 		log.WithField("id", smConfig.SMID).Error("SM connection timeout")
 	}
 }
+
+func newClientBoardConfig(boardConfig boardconfig.BoardConfig) (clientConfig clientBoardConfig) {
+	return clientBoardConfig{
+		FormatVersion: boardConfig.FormatVersion,
+		VendorVersion: boardConfig.VendorVersion,
+		Devices:       boardConfig.Devices,
+		Resources:     boardConfig.Resources,
+	}
+}
+
+// mergeServices merges services reported by SM into the result. Same service reported by different SM's
+// is merged into one item with corresponding node IDs.
+func mergeServices(servicesInfo, clientServices []cloudprotocol.ServiceInfo,
+	smID string) (result []cloudprotocol.ServiceInfo) {
+	result = servicesInfo
+
+clientServicesLoop:
+	for _, clientService := range clientServices {
+		for i, service := range result {
+			if service.ID == clientService.ID && service.AosVersion == clientService.AosVersion {
+				result[i].NodeIDs = append(result[i].NodeIDs, smID)
+
+				continue clientServicesLoop
+			}
+		}
+
+		clientService.NodeIDs = []string{smID}
+
+		result = append(result, clientService)
+	}
+
+	return result
+}
+
+// mergeLayers merges layers reported by SM into the result
+func mergeLayers(layersInfo, clientLayers []cloudprotocol.LayerInfo) (result []cloudprotocol.LayerInfo) {
+	result = layersInfo
+
+clientLayersLoop:
+	for _, clientLayer := range clientLayers {
+		for _, layer := range result {
+			if layer.Digest == clientLayer.Digest {
+				continue clientLayersLoop
+			}
+		}
+
+		result = append(result, clientLayer)
+	}
+
+	return result
+}
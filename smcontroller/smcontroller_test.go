@@ -18,12 +18,17 @@
 package smcontroller_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"io/ioutil"
 	"net"
+	"net/url"
 	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -35,6 +40,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
+	pbcm "aos_communicationmanager/api/cmserver/v1"
 	"aos_communicationmanager/boardconfig"
 	"aos_communicationmanager/cloudprotocol"
 	"aos_communicationmanager/config"
@@ -45,10 +51,16 @@ import (
  * Consts
  **********************************************************************************************************************/
 
-const smURL = "localhost:8888"
+const (
+	smURL           = "localhost:8888"
+	smURL2          = "localhost:8889"
+	registrationURL = "localhost:8890"
+)
 
 const messageTimeout = 5 * time.Second
 
+const testKeyWrapperPrefix = "wrapped:"
+
 /***********************************************************************************************************************
  * Types
  **********************************************************************************************************************/
@@ -68,8 +80,14 @@ type testSM struct {
 	messageChannel chan interface{}
 
 	boardConfigVersion string
+	boardConfig        clientBoardConfig
 	correlationId      string
 	stateChecksum      string
+	logParts           [][]byte
+	logError           string
+	envVars            []string
+
+	checkBoardConfigError error
 
 	ctx            context.Context
 	cancelFunction context.CancelFunc
@@ -78,6 +96,9 @@ type testSM struct {
 type testURLTranslator struct {
 }
 
+type testKeyWrapper struct {
+}
+
 type testMessageSender struct {
 	messageChannel chan interface{}
 }
@@ -90,6 +111,15 @@ type testMonitoringSender struct {
 	messageChannel chan interface{}
 }
 
+type testStorage struct {
+	sync.Mutex
+
+	placements      map[string]smcontroller.ServicePlacement
+	layerPlacements map[string]smcontroller.LayerPlacement
+	states          map[string]smcontroller.ServiceStateBackup
+	envVars         []cloudprotocol.OverrideEnvsFromCloud
+}
+
 type clientBoardConfig struct {
 	FormatVersion uint64                       `json:"formatVersion"`
 	VendorVersion string                       `json:"vendorVersion"`
@@ -139,7 +169,8 @@ func TestGetUsersStatus(t *testing.T) {
 
 	controller, err := smcontroller.New(&config.Config{
 		SMController: config.SMController{SMList: []config.SMConfig{{SMID: "testSM", ServerURL: smURL}}}},
-		&testMessageSender{}, &testAlertSender{}, &testMonitoringSender{}, &testURLTranslator{}, true)
+		newTestStorage(), &testMessageSender{}, &testAlertSender{}, &testMonitoringSender{}, &testURLTranslator{},
+		nil, true)
 	if err != nil {
 		t.Fatalf("Can't create SM constoller: %s", err)
 	}
@@ -156,7 +187,7 @@ func TestGetUsersStatus(t *testing.T) {
 		t.Errorf("Wrong users: %v", sm.users)
 	}
 
-	if !reflect.DeepEqual(services, sm.usersServices) {
+	if !reflect.DeepEqual(services, setNodeIDs(sm.usersServices, "testSM")) {
 		t.Errorf("Wrong services info: %v", services)
 	}
 
@@ -186,7 +217,8 @@ func TestGetAllStatus(t *testing.T) {
 
 	controller, err := smcontroller.New(&config.Config{
 		SMController: config.SMController{SMList: []config.SMConfig{{SMID: "testSM", ServerURL: smURL}}}},
-		&testMessageSender{}, &testAlertSender{}, &testMonitoringSender{}, &testURLTranslator{}, true)
+		newTestStorage(), &testMessageSender{}, &testAlertSender{}, &testMonitoringSender{}, &testURLTranslator{},
+		nil, true)
 	if err != nil {
 		t.Fatalf("Can't create SM constoller: %s", err)
 	}
@@ -197,7 +229,7 @@ func TestGetAllStatus(t *testing.T) {
 		t.Errorf("Error getting current info: %s", err)
 	}
 
-	if !reflect.DeepEqual(services, sm.allServices) {
+	if !reflect.DeepEqual(services, setNodeIDs(sm.allServices, "testSM")) {
 		t.Errorf("Wrong services info: %v", services)
 	}
 
@@ -215,7 +247,8 @@ func TestCheckBoardConfig(t *testing.T) {
 
 	controller, err := smcontroller.New(&config.Config{
 		SMController: config.SMController{SMList: []config.SMConfig{{SMID: "testSM", ServerURL: smURL}}}},
-		&testMessageSender{}, &testAlertSender{}, &testMonitoringSender{}, &testURLTranslator{}, true)
+		newTestStorage(), &testMessageSender{}, &testAlertSender{}, &testMonitoringSender{}, &testURLTranslator{},
+		nil, true)
 	if err != nil {
 		t.Fatalf("Can't create SM constoller: %s", err)
 	}
@@ -225,7 +258,7 @@ func TestCheckBoardConfig(t *testing.T) {
 		VendorVersion: "3.0",
 	}
 
-	if err = controller.CheckBoardConfig(boardConfig); err != nil {
+	if _, err = controller.CheckBoardConfig(boardConfig); err != nil {
 		t.Errorf("Check board config error: %s", err)
 	}
 
@@ -243,7 +276,8 @@ func TestSetBoardConfig(t *testing.T) {
 
 	controller, err := smcontroller.New(&config.Config{
 		SMController: config.SMController{SMList: []config.SMConfig{{SMID: "testSM", ServerURL: smURL}}}},
-		&testMessageSender{}, &testAlertSender{}, &testMonitoringSender{}, &testURLTranslator{}, true)
+		newTestStorage(), &testMessageSender{}, &testAlertSender{}, &testMonitoringSender{}, &testURLTranslator{},
+		nil, true)
 	if err != nil {
 		t.Fatalf("Can't create SM constoller: %s", err)
 	}
@@ -255,15 +289,99 @@ func TestSetBoardConfig(t *testing.T) {
 		VendorVersion: "4.0",
 	}
 
-	if err = controller.SetBoardConfig(boardConfig); err != nil {
+	nodesInfo, err := controller.SetBoardConfig(boardConfig)
+	if err != nil {
 		t.Errorf("Set board config error: %s", err)
 	}
 
+	if !reflect.DeepEqual(nodesInfo, []cloudprotocol.NodeBoardConfigInfo{
+		{NodeID: "testSM", Status: cloudprotocol.InstalledStatus}}) {
+		t.Errorf("Wrong nodes info: %v", nodesInfo)
+	}
+
 	if sm.boardConfigVersion != boardConfig.VendorVersion {
 		t.Errorf("Wrong board config version: %s", sm.boardConfigVersion)
 	}
 }
 
+func TestNodeBoardConfig(t *testing.T) {
+	sm1, err := newTestSM(smURL)
+	if err != nil {
+		t.Fatalf("Can't create test SM: %s", err)
+	}
+	defer sm1.close()
+
+	sm2, err := newTestSM(smURL2)
+	if err != nil {
+		t.Fatalf("Can't create test SM: %s", err)
+	}
+	defer sm2.close()
+
+	controller, err := smcontroller.New(&config.Config{
+		SMController: config.SMController{SMList: []config.SMConfig{
+			{SMID: "sm1", ServerURL: smURL},
+			{SMID: "sm2", ServerURL: smURL2},
+		}}},
+		newTestStorage(), &testMessageSender{}, &testAlertSender{}, &testMonitoringSender{}, &testURLTranslator{},
+		nil, true)
+	if err != nil {
+		t.Fatalf("Can't create SM constoller: %s", err)
+	}
+	defer controller.Close()
+
+	boardConfig := boardconfig.BoardConfig{
+		VendorVersion: "2.0",
+		Devices:       []boardconfig.DeviceResource{{Name: "camera", HostDevices: []string{"/dev/video0"}}},
+		Nodes: map[string]boardconfig.NodeBoardConfig{
+			"sm2": {Devices: []boardconfig.DeviceResource{{Name: "gpu", HostDevices: []string{"/dev/dri"}}}},
+		},
+	}
+
+	nodesInfo, err := controller.SetBoardConfig(boardConfig)
+	if err != nil {
+		t.Errorf("Set board config error: %s", err)
+	}
+
+	if !reflect.DeepEqual(nodesInfo, []cloudprotocol.NodeBoardConfigInfo{
+		{NodeID: "sm1", Status: cloudprotocol.InstalledStatus},
+		{NodeID: "sm2", Status: cloudprotocol.InstalledStatus}}) {
+		t.Errorf("Wrong nodes info: %v", nodesInfo)
+	}
+
+	if !reflect.DeepEqual(sm1.boardConfig.Devices, boardConfig.Devices) {
+		t.Errorf("Wrong SM1 devices: %v", sm1.boardConfig.Devices)
+	}
+
+	if !reflect.DeepEqual(sm2.boardConfig.Devices, append(boardConfig.Devices, boardConfig.Nodes["sm2"].Devices...)) {
+		t.Errorf("Wrong SM2 devices: %v", sm2.boardConfig.Devices)
+	}
+
+	boardConfig.VendorVersion = "3.0"
+
+	if nodesInfo, err = controller.CheckBoardConfig(boardConfig); err != nil {
+		t.Errorf("Check board config error: %s", err)
+	}
+
+	if !reflect.DeepEqual(nodesInfo, []cloudprotocol.NodeBoardConfigInfo{
+		{NodeID: "sm1", Status: cloudprotocol.PendingStatus},
+		{NodeID: "sm2", Status: cloudprotocol.PendingStatus}}) {
+		t.Errorf("Wrong nodes info: %v", nodesInfo)
+	}
+
+	// Failed node should not stop checking other nodes
+
+	sm1.checkBoardConfigError = aoserrors.New("invalid device")
+
+	if nodesInfo, err = controller.CheckBoardConfig(boardConfig); err == nil {
+		t.Error("Board config check error expected")
+	}
+
+	if len(nodesInfo) != 2 || nodesInfo[0].Status != cloudprotocol.ErrorStatus || nodesInfo[0].Error == "" ||
+		nodesInfo[1].Status != cloudprotocol.PendingStatus {
+		t.Errorf("Wrong nodes info: %v", nodesInfo)
+	}
+}
+
 func TestInstallServices(t *testing.T) {
 	sm, err := newTestSM(smURL)
 	if err != nil {
@@ -273,7 +391,8 @@ func TestInstallServices(t *testing.T) {
 
 	controller, err := smcontroller.New(&config.Config{
 		SMController: config.SMController{SMList: []config.SMConfig{{SMID: "testSM", ServerURL: smURL}}}},
-		&testMessageSender{}, &testAlertSender{}, &testMonitoringSender{}, &testURLTranslator{}, true)
+		newTestStorage(), &testMessageSender{}, &testAlertSender{}, &testMonitoringSender{}, &testURLTranslator{},
+		nil, true)
 	if err != nil {
 		t.Fatalf("Can't create SM constoller: %s", err)
 	}
@@ -350,7 +469,8 @@ func TestRemoveServices(t *testing.T) {
 
 	controller, err := smcontroller.New(&config.Config{
 		SMController: config.SMController{SMList: []config.SMConfig{{SMID: "testSM", ServerURL: smURL}}}},
-		&testMessageSender{}, &testAlertSender{}, &testMonitoringSender{}, &testURLTranslator{}, true)
+		newTestStorage(), &testMessageSender{}, &testAlertSender{}, &testMonitoringSender{}, &testURLTranslator{},
+		nil, true)
 	if err != nil {
 		t.Fatalf("Can't create SM constoller: %s", err)
 	}
@@ -409,7 +529,8 @@ func TestInstallLayers(t *testing.T) {
 
 	controller, err := smcontroller.New(&config.Config{
 		SMController: config.SMController{SMList: []config.SMConfig{{SMID: "testSM", ServerURL: smURL}}}},
-		&testMessageSender{}, &testAlertSender{}, &testMonitoringSender{}, &testURLTranslator{}, true)
+		newTestStorage(), &testMessageSender{}, &testAlertSender{}, &testMonitoringSender{}, &testURLTranslator{},
+		nil, true)
 	if err != nil {
 		t.Fatalf("Can't create SM constoller: %s", err)
 	}
@@ -451,24 +572,785 @@ func TestInstallLayers(t *testing.T) {
 
 	var wg sync.WaitGroup
 
-	for _, layerInfo := range installLayers {
-		wg.Add(1)
+	for _, layerInfo := range installLayers {
+		wg.Add(1)
+
+		go func(layerInfo cloudprotocol.LayerInfoFromCloud) {
+			defer wg.Done()
+
+			if err = controller.InstallLayer(layerInfo); err != nil {
+				t.Errorf("Can't install layer: %s", err)
+			}
+		}(layerInfo)
+	}
+
+	wg.Wait()
+
+	sort.Slice(sm.usersLayers, func(i, j int) (isLess bool) { return sm.usersLayers[i].ID < sm.usersLayers[j].ID })
+
+	if !reflect.DeepEqual(sm.usersLayers, expectedResult) {
+		t.Errorf("Wrong layers: %v", expectedResult)
+	}
+}
+
+func TestServicePlacement(t *testing.T) {
+	sm1, err := newTestSM(smURL)
+	if err != nil {
+		t.Fatalf("Can't create test SM: %s", err)
+	}
+	defer sm1.close()
+
+	sm2, err := newTestSM(smURL2)
+	if err != nil {
+		t.Fatalf("Can't create test SM: %s", err)
+	}
+	defer sm2.close()
+
+	storage := newTestStorage()
+
+	// Previously placed service requires layer1
+	storage.placements["service0"] = smcontroller.ServicePlacement{
+		ServiceID: "service0", SMIDs: []string{"sm2"}, Layers: []string{"digest1"}}
+
+	controller, err := smcontroller.New(&config.Config{
+		SMController: config.SMController{SMList: []config.SMConfig{
+			{SMID: "sm1", ServerURL: smURL, Capabilities: []string{"gpu"}},
+			{SMID: "sm2", ServerURL: smURL2},
+		}}},
+		storage, &testMessageSender{}, &testAlertSender{}, &testMonitoringSender{}, &testURLTranslator{},
+		nil, true)
+	if err != nil {
+		t.Fatalf("Can't create SM constoller: %s", err)
+	}
+	defer controller.Close()
+
+	// Layers should be installed only where required
+
+	for _, layerInfo := range []cloudprotocol.LayerInfoFromCloud{
+		{ID: "layer1", Digest: "digest1", DecryptDataStruct: cloudprotocol.DecryptDataStruct{URLs: []string{"url1"}}},
+		{ID: "layer2", Digest: "digest2", DecryptDataStruct: cloudprotocol.DecryptDataStruct{URLs: []string{"url2"}}},
+	} {
+		if err = controller.InstallLayer(layerInfo); err != nil {
+			t.Fatalf("Can't install layer: %s", err)
+		}
+	}
+
+	if layers := getLayerDigests(sm1.usersLayers); !reflect.DeepEqual(layers, []string{"digest2"}) {
+		t.Errorf("Wrong SM1 layers: %v", layers)
+	}
+
+	if layers := getLayerDigests(sm2.usersLayers); !reflect.DeepEqual(layers, []string{"digest1", "digest2"}) {
+		t.Errorf("Wrong SM2 layers: %v", layers)
+	}
+
+	users := []string{"user1"}
+
+	installServices := []struct {
+		serviceInfo cloudprotocol.ServiceInfoFromCloud
+		smIDs       []string
+	}{
+		{
+			serviceInfo: cloudprotocol.ServiceInfoFromCloud{ID: "service1", Layers: []string{"digest1"},
+				Placement: &cloudprotocol.PlacementHints{Capabilities: []string{"gpu"}}},
+			smIDs: []string{"sm1"},
+		},
+		{
+			serviceInfo: cloudprotocol.ServiceInfoFromCloud{ID: "service2",
+				Placement: &cloudprotocol.PlacementHints{Replicas: 2}},
+			smIDs: []string{"sm1", "sm2"},
+		},
+		{
+			serviceInfo: cloudprotocol.ServiceInfoFromCloud{ID: "service3"},
+			smIDs:       []string{"sm1"},
+		},
+	}
+
+	for _, item := range installServices {
+		item.serviceInfo.URLs = []string{"url"}
+
+		if _, err = controller.InstallService(users, item.serviceInfo); err != nil {
+			t.Fatalf("Can't install service: %s", err)
+		}
+
+		if smIDs := storage.getSMIDs(item.serviceInfo.ID); !reflect.DeepEqual(smIDs, item.smIDs) {
+			t.Errorf("Wrong service %s placement: %v", item.serviceInfo.ID, smIDs)
+		}
+	}
+
+	// Layer required by service1 should be installed on SM1
+
+	if layers := getLayerDigests(sm1.usersLayers); !reflect.DeepEqual(layers, []string{"digest1", "digest2"}) {
+		t.Errorf("Wrong SM1 layers: %v", layers)
+	}
+
+	// Services installed on both SM's should be reported once
+
+	services, _, err := controller.GetUsersStatus(users)
+	if err != nil {
+		t.Fatalf("Can't get users status: %s", err)
+	}
+
+	for _, service := range services {
+		if service.ID != "service2" {
+			continue
+		}
+
+		if !reflect.DeepEqual(service.NodeIDs, []string{"sm1", "sm2"}) {
+			t.Errorf("Wrong service node IDs: %v", service.NodeIDs)
+		}
+	}
+
+	// Moved service should be removed from previous SM
+
+	if _, err = controller.InstallService(users, cloudprotocol.ServiceInfoFromCloud{ID: "service1",
+		Placement:         &cloudprotocol.PlacementHints{Nodes: []string{"sm2"}},
+		DecryptDataStruct: cloudprotocol.DecryptDataStruct{URLs: []string{"url"}}}); err != nil {
+		t.Fatalf("Can't install service: %s", err)
+	}
+
+	if smIDs := storage.getSMIDs("service1"); !reflect.DeepEqual(smIDs, []string{"sm2"}) {
+		t.Errorf("Wrong service placement: %v", smIDs)
+	}
+
+	if hasService(sm1.usersServices, "service1") {
+		t.Error("Service should be removed from SM1")
+	}
+
+	// Service should be removed only from assigned SM's
+
+	if err = controller.RemoveService(users, cloudprotocol.ServiceInfo{ID: "service3"}); err != nil {
+		t.Fatalf("Can't remove service: %s", err)
+	}
+
+	if hasService(sm1.usersServices, "service3") {
+		t.Error("Service should be removed from SM1")
+	}
+
+	if smIDs := storage.getSMIDs("service3"); smIDs != nil {
+		t.Errorf("Service placement should be removed: %v", smIDs)
+	}
+
+	// Service without matching SM should not be installed
+
+	if _, err = controller.InstallService(users, cloudprotocol.ServiceInfoFromCloud{ID: "service4",
+		Placement:         &cloudprotocol.PlacementHints{Capabilities: []string{"npu"}},
+		DecryptDataStruct: cloudprotocol.DecryptDataStruct{URLs: []string{"url"}}}); err == nil {
+		t.Error("Error expected on installing service without matching SM")
+	}
+
+	// Layers of new service should be installed only on SM's planned for the service
+
+	newService := cloudprotocol.ServiceInfoFromCloud{ID: "service5", Layers: []string{"digest3"},
+		Placement:         &cloudprotocol.PlacementHints{Nodes: []string{"sm2"}},
+		DecryptDataStruct: cloudprotocol.DecryptDataStruct{URLs: []string{"url"}}}
+
+	if err = controller.PlaceServices([]cloudprotocol.ServiceInfoFromCloud{newService}); err != nil {
+		t.Fatalf("Can't place services: %s", err)
+	}
+
+	if err = controller.InstallLayer(cloudprotocol.LayerInfoFromCloud{ID: "layer3", Digest: "digest3",
+		DecryptDataStruct: cloudprotocol.DecryptDataStruct{URLs: []string{"url3"}}}); err != nil {
+		t.Fatalf("Can't install layer: %s", err)
+	}
+
+	if layers := getLayerDigests(sm1.usersLayers); !reflect.DeepEqual(layers, []string{"digest1", "digest2"}) {
+		t.Errorf("Wrong SM1 layers: %v", layers)
+	}
+
+	if layers := getLayerDigests(sm2.usersLayers); !reflect.DeepEqual(
+		layers, []string{"digest1", "digest2", "digest3"}) {
+		t.Errorf("Wrong SM2 layers: %v", layers)
+	}
+
+	if _, err = controller.InstallService(users, newService); err != nil {
+		t.Fatalf("Can't install service: %s", err)
+	}
+
+	// Service state should be sent only to SM's where the service is placed
+
+	if err = controller.SetServiceState(users, cloudprotocol.UpdateState{
+		ServiceID: "service5", Checksum: "checksum5"}); err != nil {
+		t.Fatalf("Can't set service state: %s", err)
+	}
+
+	if checksum := sm2.getStateChecksum(); checksum != "checksum5" {
+		t.Errorf("Wrong SM2 state checksum: %s", checksum)
+	}
+
+	if checksum := sm1.getStateChecksum(); checksum == "checksum5" {
+		t.Error("Service state should not be sent to SM1")
+	}
+
+	// Layer placement should be restored after restart
+
+	controller.Close()
+
+	if controller, err = smcontroller.New(&config.Config{
+		SMController: config.SMController{SMList: []config.SMConfig{
+			{SMID: "sm1", ServerURL: smURL, Capabilities: []string{"gpu"}},
+			{SMID: "sm2", ServerURL: smURL2},
+		}}},
+		storage, &testMessageSender{}, &testAlertSender{}, &testMonitoringSender{}, &testURLTranslator{},
+		nil, true); err != nil {
+		t.Fatalf("Can't create SM constoller: %s", err)
+	}
+	defer controller.Close()
+
+	if _, err = controller.InstallService(users, cloudprotocol.ServiceInfoFromCloud{ID: "service6",
+		Layers: []string{"digest3"}, Placement: &cloudprotocol.PlacementHints{Nodes: []string{"sm1"}},
+		DecryptDataStruct: cloudprotocol.DecryptDataStruct{URLs: []string{"url"}}}); err != nil {
+		t.Fatalf("Can't install service: %s", err)
+	}
+
+	if layers := getLayerDigests(sm1.usersLayers); !reflect.DeepEqual(
+		layers, []string{"digest1", "digest2", "digest3"}) {
+		t.Errorf("Wrong SM1 layers: %v", layers)
+	}
+
+	if placement := storage.layerPlacements["digest3"]; !reflect.DeepEqual(placement.SMIDs, []string{"sm2", "sm1"}) {
+		t.Errorf("Wrong layer placement: %v", placement)
+	}
+}
+
+func TestSMRegistration(t *testing.T) {
+	sm, err := newTestSM(smURL)
+	if err != nil {
+		t.Fatalf("Can't create test SM: %s", err)
+	}
+	defer sm.close()
+
+	sm.usersServices = []cloudprotocol.ServiceInfo{
+		{ID: "id1", AosVersion: 1, Status: cloudprotocol.InstalledStatus},
+	}
+
+	alertSender := newTestAlertSender()
+
+	controller, err := smcontroller.New(&config.Config{
+		SMController: config.SMController{ServerURL: registrationURL}},
+		newTestStorage(), &testMessageSender{}, alertSender, &testMonitoringSender{}, &testURLTranslator{},
+		nil, true)
+	if err != nil {
+		t.Fatalf("Can't create SM constoller: %s", err)
+	}
+	defer controller.Close()
+
+	connection, err := grpc.Dial(registrationURL, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		t.Fatalf("Can't connect to registration server: %s", err)
+	}
+	defer connection.Close()
+
+	// Invalid registration should be rejected
+
+	if err = registerSM(connection, &pbcm.SMRegistration{SmId: "dynamicSM"}, true); err == nil {
+		t.Error("Error expected on registration without server URL")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := pbcm.NewSMRegistrationServiceClient(connection).RegisterSM(ctx)
+	if err != nil {
+		t.Fatalf("Can't create registration stream: %s", err)
+	}
+
+	if err = stream.Send(&pbcm.SMRegistration{
+		SmId: "dynamicSM", ServerUrl: smURL, Capabilities: []string{"gpu"}}); err != nil {
+		t.Fatalf("Can't send registration: %s", err)
+	}
+
+	status, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Can't receive registration status: %s", err)
+	}
+
+	if !status.Registered {
+		t.Fatalf("SM is not registered: %s", status.Error)
+	}
+
+	services, _, err := controller.GetUsersStatus([]string{"user1"})
+	if err != nil {
+		t.Fatalf("Can't get users status: %s", err)
+	}
+
+	if !reflect.DeepEqual(services, setNodeIDs(sm.usersServices, "dynamicSM")) {
+		t.Errorf("Wrong services info: %v", services)
+	}
+
+	if statuses := controller.GetSMStatuses(); len(statuses) != 1 || !statuses[0].Connected ||
+		!statuses[0].Registered || statuses[0].SMID != "dynamicSM" {
+		t.Errorf("Wrong SM statuses: %v", statuses)
+	}
+
+	// Closing registration stream should unregister SM
+
+	cancel()
+
+	message, err := waitMessage(alertSender.messageChannel, messageTimeout)
+	if err != nil {
+		t.Fatalf("Wait alert error: %s", err)
+	}
+
+	if alert, ok := message.(cloudprotocol.AlertItem); !ok || alert.Tag != cloudprotocol.AlertTagAosCore {
+		t.Errorf("Wrong alert: %v", message)
+	}
+
+	if statuses := controller.GetSMStatuses(); len(statuses) != 1 || statuses[0].Connected {
+		t.Errorf("Wrong SM statuses: %v", statuses)
+	}
+
+	if services, _, err = controller.GetUsersStatus([]string{"user1"}); err != nil {
+		t.Fatalf("Can't get users status: %s", err)
+	}
+
+	if len(services) != 0 {
+		t.Errorf("Unexpected services: %v", services)
+	}
+}
+
+func TestHealthFailover(t *testing.T) {
+	sm1, err := newTestSM(smURL)
+	if err != nil {
+		t.Fatalf("Can't create test SM: %s", err)
+	}
+	defer sm1.close()
+
+	sm2, err := newTestSM(smURL2)
+	if err != nil {
+		t.Fatalf("Can't create test SM: %s", err)
+	}
+	defer sm2.close()
+
+	imageDir, err := ioutil.TempDir("", "sm_")
+	if err != nil {
+		t.Fatalf("Can't create image dir: %s", err)
+	}
+	defer os.RemoveAll(imageDir)
+
+	alertSender := newTestAlertSender()
+	storage := newTestStorage()
+
+	// Service rescheduled to SM2 while SM1 was dead should be removed from SM1 on connect
+
+	sm1.usersServices = []cloudprotocol.ServiceInfo{{ID: "service0", Status: cloudprotocol.InstalledStatus}}
+	sm1.allServices = []cloudprotocol.ServiceInfo{{ID: "service0", Status: cloudprotocol.InstalledStatus}}
+
+	storage.placements["service0"] = smcontroller.ServicePlacement{
+		ServiceID: "service0", SMIDs: []string{"sm2"}, Users: []string{"user1"},
+		ServiceInfo: cloudprotocol.ServiceInfoFromCloud{
+			ID: "service0", DecryptDataStruct: cloudprotocol.DecryptDataStruct{URLs: []string{"url"}}},
+	}
+
+	// Service placed before CM restart should be rescheduled from stored install info
+
+	storage.placements["service2"] = smcontroller.ServicePlacement{
+		ServiceID: "service2", SMIDs: []string{"sm1"}, Users: []string{"user1"},
+		ServiceInfo: cloudprotocol.ServiceInfoFromCloud{
+			ID: "service2", DecryptDataStruct: cloudprotocol.DecryptDataStruct{URLs: []string{"url"}}},
+	}
+
+	controller, err := smcontroller.New(&config.Config{
+		SMController: config.SMController{
+			SMList: []config.SMConfig{
+				{SMID: "sm1", ServerURL: smURL},
+				{SMID: "sm2", ServerURL: smURL2},
+			},
+			HealthCheckPeriod:   config.Duration{Duration: 100 * time.Millisecond},
+			FailoverGracePeriod: config.Duration{Duration: 500 * time.Millisecond},
+		},
+		Downloader: config.Downloader{DecryptDir: filepath.Join(imageDir, "decrypt")}},
+		storage, &testMessageSender{}, alertSender, &testMonitoringSender{}, &testURLTranslator{},
+		nil, true)
+	if err != nil {
+		t.Fatalf("Can't create SM constoller: %s", err)
+	}
+	defer controller.Close()
+
+	timeout := time.After(messageTimeout)
+
+	for hasService(sm1.getUsersServices(), "service0") {
+		select {
+		case <-timeout:
+			t.Fatal("Rescheduled service is not removed from SM1")
+
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	// Downloaded images are removed after update, service and its layer should be rescheduled from kept images
+
+	if err = os.MkdirAll(filepath.Join(imageDir, "decrypt"), 0755); err != nil {
+		t.Fatalf("Can't create decrypt dir: %s", err)
+	}
+
+	serviceImage := filepath.Join(imageDir, "decrypt", "service1.dec")
+	layerImage := filepath.Join(imageDir, "decrypt", "layer1.dec")
+
+	for _, image := range []string{serviceImage, layerImage} {
+		if err = ioutil.WriteFile(image, []byte("image"), 0644); err != nil {
+			t.Fatalf("Can't create image: %s", err)
+		}
+	}
+
+	serviceInfo := cloudprotocol.ServiceInfoFromCloud{
+		ID: "service1", Layers: []string{"sha256:layer1"},
+		DecryptDataStruct: cloudprotocol.DecryptDataStruct{URLs: []string{"file://" + serviceImage}}}
+
+	if err = controller.PlaceServices([]cloudprotocol.ServiceInfoFromCloud{serviceInfo}); err != nil {
+		t.Fatalf("Can't place services: %s", err)
+	}
+
+	if err = controller.InstallLayer(cloudprotocol.LayerInfoFromCloud{
+		ID: "layer1", Digest: "sha256:layer1",
+		DecryptDataStruct: cloudprotocol.DecryptDataStruct{URLs: []string{"file://" + layerImage}}}); err != nil {
+		t.Fatalf("Can't install layer: %s", err)
+	}
+
+	if _, err = controller.InstallService([]string{"user1"}, serviceInfo); err != nil {
+		t.Fatalf("Can't install service: %s", err)
+	}
+
+	if !hasService(sm1.getUsersServices(), "service1") {
+		t.Fatal("Service is not installed on SM1")
+	}
+
+	if layers := getLayerDigests(sm2.getUsersLayers()); len(layers) != 0 {
+		t.Errorf("Wrong SM2 layers: %v", layers)
+	}
+
+	for _, image := range []string{serviceImage, layerImage} {
+		if err = os.Remove(image); err != nil {
+			t.Fatalf("Can't remove image: %s", err)
+		}
+	}
+
+	for _, nodeHealth := range controller.GetNodesHealth() {
+		if nodeHealth.Status != cloudprotocol.NodeHealthy {
+			t.Errorf("Wrong node %s status: %s", nodeHealth.NodeID, nodeHealth.Status)
+		}
+	}
+
+	// Lost SM should be reported as dead and its services should be rescheduled
+
+	sm1.close()
+
+	message, err := waitMessage(alertSender.messageChannel, messageTimeout)
+	if err != nil {
+		t.Fatalf("Wait alert error: %s", err)
+	}
+
+	if alert, ok := message.(cloudprotocol.AlertItem); !ok || alert.Tag != cloudprotocol.AlertTagAosCore {
+		t.Errorf("Wrong alert: %v", message)
+	}
+
+	select {
+	case nodesHealth := <-controller.GetNodesHealthChannel():
+		if len(nodesHealth) != 2 || nodesHealth[0].NodeID != "sm1" || nodesHealth[0].Status != cloudprotocol.NodeDead {
+			t.Errorf("Wrong nodes health: %v", nodesHealth)
+		}
+
+	case <-time.After(messageTimeout):
+		t.Error("Wait nodes health timeout")
+	}
+
+	timeout = time.After(messageTimeout)
+
+	for !hasService(sm2.getUsersServices(), "service1") || !hasService(sm2.getUsersServices(), "service2") {
+		select {
+		case <-timeout:
+			t.Fatal("Service is not rescheduled to SM2")
+
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	if layers := getLayerDigests(sm2.getUsersLayers()); !reflect.DeepEqual(layers, []string{"sha256:layer1"}) {
+		t.Errorf("Wrong SM2 layers: %v", layers)
+	}
+
+	// Kept image should be removed with the service
+
+	if err = controller.RemoveService([]string{"user1"}, cloudprotocol.ServiceInfo{ID: "service1"}); err != nil {
+		t.Fatalf("Can't remove service: %s", err)
+	}
+
+	keptImage := filepath.Join(imageDir, "decrypt", config.KeptImagePrefix+"service_service1")
+
+	if _, err = os.Stat(keptImage); !os.IsNotExist(err) {
+		t.Errorf("Kept image is not removed: %v", err)
+	}
+}
+
+func TestCollectLogs(t *testing.T) {
+	sm1, err := newTestSM(smURL)
+	if err != nil {
+		t.Fatalf("Can't create test SM: %s", err)
+	}
+	defer sm1.close()
+
+	sm2, err := newTestSM(smURL2)
+	if err != nil {
+		t.Fatalf("Can't create test SM: %s", err)
+	}
+	defer sm2.close()
+
+	sm1.logParts = [][]byte{[]byte("sm1 part1 "), []byte("sm1 part2 ")}
+	sm2.logParts = [][]byte{[]byte("sm2 part1")}
+
+	storage := newTestStorage()
+
+	storage.placements["service1"] = smcontroller.ServicePlacement{ServiceID: "service1", SMIDs: []string{"sm2"}}
+
+	messageSender := newTestMessageSender()
+
+	controller, err := smcontroller.New(&config.Config{
+		SMController: config.SMController{SMList: []config.SMConfig{
+			{SMID: "sm1", ServerURL: smURL},
+			{SMID: "sm2", ServerURL: smURL2},
+		}}},
+		storage, messageSender, &testAlertSender{}, &testMonitoringSender{}, &testURLTranslator{}, nil, true)
+	if err != nil {
+		t.Fatalf("Can't create SM constoller: %s", err)
+	}
+	defer controller.Close()
+
+	// System log should be collected from all SM's, each SM log is sent as separate sequence
+
+	if err = controller.GetSystemLog(cloudprotocol.RequestSystemLog{LogID: "log1"}); err != nil {
+		t.Fatalf("Can't get system log: %s", err)
+	}
+
+	logs, err := waitNodeLogs(messageSender.messageChannel, 3)
+	if err != nil {
+		t.Fatalf("Wait logs error: %s", err)
+	}
+
+	if !reflect.DeepEqual(logs, map[string][]cloudprotocol.PushLog{
+		"sm1": {
+			{LogID: "log1", NodeID: "sm1", PartCount: 2, Part: 1, Data: []byte("sm1 part1 ")},
+			{LogID: "log1", NodeID: "sm1", PartCount: 2, Part: 2, Data: []byte("sm1 part2 ")},
+		},
+		"sm2": {{LogID: "log1", NodeID: "sm2", PartCount: 1, Part: 1, Data: []byte("sm2 part1")}},
+	}) {
+		t.Errorf("Wrong logs: %v", logs)
+	}
+
+	// Service log should be collected from SM's where the service is placed
+
+	if err = controller.GetServiceLog(cloudprotocol.RequestServiceLog{
+		LogID: "log2", ServiceID: "service1"}); err != nil {
+		t.Fatalf("Can't get service log: %s", err)
+	}
+
+	if logs, err = waitNodeLogs(messageSender.messageChannel, 1); err != nil {
+		t.Fatalf("Wait logs error: %s", err)
+	}
+
+	if !reflect.DeepEqual(logs, map[string][]cloudprotocol.PushLog{
+		"sm2": {{LogID: "log2", NodeID: "sm2", PartCount: 1, Part: 1, Data: []byte("sm2 part1")}},
+	}) {
+		t.Errorf("Wrong logs: %v", logs)
+	}
+
+	// SM error should be reported along with log data of other SM's
+
+	sm2.logError = "journal error"
+
+	if err = controller.GetSystemLog(cloudprotocol.RequestSystemLog{LogID: "log3"}); err != nil {
+		t.Fatalf("Can't get system log: %s", err)
+	}
+
+	if logs, err = waitNodeLogs(messageSender.messageChannel, 3); err != nil {
+		t.Fatalf("Wait logs error: %s", err)
+	}
+
+	if !reflect.DeepEqual(logs, map[string][]cloudprotocol.PushLog{
+		"sm1": {
+			{LogID: "log3", NodeID: "sm1", PartCount: 2, Part: 1, Data: []byte("sm1 part1 ")},
+			{LogID: "log3", NodeID: "sm1", PartCount: 2, Part: 2, Data: []byte("sm1 part2 ")},
+		},
+		"sm2": {{LogID: "log3", NodeID: "sm2", Error: "journal error"}},
+	}) {
+		t.Errorf("Wrong logs: %v", logs)
+	}
+}
+
+func TestServiceStateBackup(t *testing.T) {
+	sm, err := newTestSM(smURL)
+	if err != nil {
+		t.Fatalf("Can't create test SM: %s", err)
+	}
+	defer sm.close()
+
+	keyDir, err := ioutil.TempDir("", "sm_")
+	if err != nil {
+		t.Fatalf("Can't create tmp dir: %s", err)
+	}
+	defer os.RemoveAll(keyDir)
+
+	users := []string{"user1"}
+	storage := newTestStorage()
+	messageSender := newTestMessageSender()
+	keyFile := filepath.Join(keyDir, "statebackup.key")
+
+	storage.placements["service1"] = smcontroller.ServicePlacement{
+		ServiceID: "service1", SMIDs: []string{"testSM"}, Users: users}
+
+	cfg := &config.Config{
+		SMController: config.SMController{
+			SMList:             []config.SMConfig{{SMID: "testSM", ServerURL: smURL}},
+			StateBackupKeyFile: keyFile,
+			MaxStateBackupSize: 16,
+		},
+	}
+
+	controller, err := smcontroller.New(cfg, storage, messageSender, &testAlertSender{}, &testMonitoringSender{},
+		&testURLTranslator{}, &testKeyWrapper{}, true)
+	if err != nil {
+		t.Fatalf("Can't create SM constoller: %s", err)
+	}
+	defer func() {
+		controller.Close()
+	}()
+
+	// Backup key should be stored wrapped
+
+	wrappedKey, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		t.Fatalf("Can't read backup key: %s", err)
+	}
+
+	if !bytes.HasPrefix(wrappedKey, []byte(testKeyWrapperPrefix)) {
+		t.Error("Backup key is not wrapped")
+	}
+
+	if _, _, err = controller.GetUsersStatus(users); err != nil {
+		t.Fatalf("Can't get users status: %s", err)
+	}
+
+	// Accepted state should be stored encrypted
+
+	sm.correlationId = "correlation1"
+	sm.messageChannel <- cloudprotocol.NewState{ServiceID: "service1", Checksum: "checksum1", State: "state1"}
+
+	if _, err = waitMessage(messageSender.messageChannel, messageTimeout); err != nil {
+		t.Fatalf("Wait message error: %s", err)
+	}
+
+	if err = controller.ServiceStateAcceptance("correlation1", cloudprotocol.StateAcceptance{
+		ServiceID: "service1", Checksum: "checksum1", Result: cloudprotocol.StateAccepted}); err != nil {
+		t.Fatalf("Can't accept service state: %s", err)
+	}
+
+	backup, err := storage.GetServiceStateBackup("service1", users)
+	if err != nil {
+		t.Fatalf("Can't get service state backup: %s", err)
+	}
+
+	if backup.Checksum != "checksum1" || bytes.Contains(backup.State, []byte("state1")) {
+		t.Errorf("Wrong service state backup: %v", backup)
+	}
+
+	// State exceeding size limit should not be stored
+
+	if err = controller.SetServiceState(users, cloudprotocol.UpdateState{
+		ServiceID: "service1", Checksum: "checksum2", State: "state exceeding limit"}); err != nil {
+		t.Fatalf("Can't set service state: %s", err)
+	}
+
+	if backup, err = storage.GetServiceStateBackup("service1", users); err != nil || backup.Checksum != "checksum1" {
+		t.Errorf("Wrong service state backup: %v", backup)
+	}
+
+	// State should be restored from backup without cloud request
+
+	sm.messageChannel <- cloudprotocol.StateRequest{ServiceID: "service1"}
+
+	timeout := time.After(messageTimeout)
+
+	for sm.getStateChecksum() != "checksum1" {
+		select {
+		case <-timeout:
+			t.Fatal("Service state is not restored")
+
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	// State without backup should be requested from the cloud
+
+	sm.messageChannel <- cloudprotocol.StateRequest{ServiceID: "service2"}
+
+	message, err := waitMessage(messageSender.messageChannel, messageTimeout)
+	if err != nil {
+		t.Fatalf("Wait message error: %s", err)
+	}
+
+	if !reflect.DeepEqual(message, cloudprotocol.StateRequest{ServiceID: "service2"}) {
+		t.Errorf("Wrong message: %v", message)
+	}
+
+	// State should be restored after restart before users are received
+
+	controller.Close()
+
+	if controller, err = smcontroller.New(cfg, storage, messageSender, &testAlertSender{}, &testMonitoringSender{},
+		&testURLTranslator{}, &testKeyWrapper{}, true); err != nil {
+		t.Fatalf("Can't create SM constoller: %s", err)
+	}
+
+	sm.setStateChecksum("")
+
+	timeout = time.After(messageTimeout)
+
+	// Request is repeated as notification stream of the previous connection may consume it
+	for sm.getStateChecksum() != "checksum1" {
+		sm.messageChannel <- cloudprotocol.StateRequest{ServiceID: "service1"}
+
+		select {
+		case <-timeout:
+			t.Fatal("Service state is not restored after restart")
+
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	// Backup should be disabled and key file should be kept if the key can't be unwrapped
+
+	controller.Close()
+
+	if err = ioutil.WriteFile(keyFile, []byte("corrupted key"), 0600); err != nil {
+		t.Fatalf("Can't write backup key: %s", err)
+	}
+
+	if controller, err = smcontroller.New(cfg, storage, messageSender, &testAlertSender{}, &testMonitoringSender{},
+		&testURLTranslator{}, &testKeyWrapper{}, true); err != nil {
+		t.Fatalf("Can't create SM constoller: %s", err)
+	}
+
+	if wrappedKey, err = ioutil.ReadFile(keyFile); err != nil || string(wrappedKey) != "corrupted key" {
+		t.Errorf("Backup key file is changed: %v", err)
+	}
+
+	timeout = time.After(messageTimeout)
 
-		go func(layerInfo cloudprotocol.LayerInfoFromCloud) {
-			defer wg.Done()
+	for {
+		sm.messageChannel <- cloudprotocol.StateRequest{ServiceID: "service1"}
 
-			if err = controller.InstallLayer(layerInfo); err != nil {
-				t.Errorf("Can't install layer: %s", err)
-			}
-		}(layerInfo)
-	}
+		if message, err = waitMessage(messageSender.messageChannel, 100*time.Millisecond); err == nil {
+			break
+		}
 
-	wg.Wait()
+		select {
+		case <-timeout:
+			t.Fatal("Service state is not requested from the cloud")
 
-	sort.Slice(sm.usersLayers, func(i, j int) (isLess bool) { return sm.usersLayers[i].ID < sm.usersLayers[j].ID })
+		default:
+		}
+	}
 
-	if !reflect.DeepEqual(sm.usersLayers, expectedResult) {
-		t.Errorf("Wrong layers: %v", expectedResult)
+	if !reflect.DeepEqual(message, cloudprotocol.StateRequest{ServiceID: "service1"}) {
+		t.Errorf("Wrong message: %v", message)
 	}
 }
 
@@ -481,7 +1363,8 @@ func TestServiceStateAcceptance(t *testing.T) {
 
 	controller, err := smcontroller.New(&config.Config{
 		SMController: config.SMController{SMList: []config.SMConfig{{SMID: "testSM", ServerURL: smURL}}}},
-		&testMessageSender{}, &testAlertSender{}, &testMonitoringSender{}, &testURLTranslator{}, true)
+		newTestStorage(), &testMessageSender{}, &testAlertSender{}, &testMonitoringSender{}, &testURLTranslator{},
+		nil, true)
 	if err != nil {
 		t.Fatalf("Can't create SM constoller: %s", err)
 	}
@@ -505,7 +1388,8 @@ func TestSetServiceState(t *testing.T) {
 
 	controller, err := smcontroller.New(&config.Config{
 		SMController: config.SMController{SMList: []config.SMConfig{{SMID: "testSM", ServerURL: smURL}}}},
-		&testMessageSender{}, &testAlertSender{}, &testMonitoringSender{}, &testURLTranslator{}, true)
+		newTestStorage(), &testMessageSender{}, &testAlertSender{}, &testMonitoringSender{}, &testURLTranslator{},
+		nil, true)
 	if err != nil {
 		t.Fatalf("Can't create SM constoller: %s", err)
 	}
@@ -537,7 +1421,8 @@ func TestOverrideEnvVars(t *testing.T) {
 
 	controller, err := smcontroller.New(&config.Config{
 		SMController: config.SMController{SMList: []config.SMConfig{{SMID: "testSM", ServerURL: smURL}}}},
-		messageSender, &testAlertSender{}, &testMonitoringSender{}, &testURLTranslator{}, true)
+		newTestStorage(), messageSender, &testAlertSender{}, &testMonitoringSender{}, &testURLTranslator{},
+		nil, true)
 	if err != nil {
 		t.Fatalf("Can't create SM constoller: %s", err)
 	}
@@ -581,6 +1466,97 @@ func TestOverrideEnvVars(t *testing.T) {
 	}
 }
 
+func TestOverrideEnvVarsExpiration(t *testing.T) {
+	sm, err := newTestSM(smURL)
+	if err != nil {
+		t.Fatalf("Can't create test SM: %s", err)
+	}
+	defer sm.close()
+
+	messageSender := newTestMessageSender()
+	storage := newTestStorage()
+
+	// Stored env vars should be restored on SM connection
+
+	storage.envVars = []cloudprotocol.OverrideEnvsFromCloud{
+		{ServiceID: "service0", SubjectID: "subject0", EnvVars: []cloudprotocol.EnvVarInfo{
+			{ID: "id0", Variable: "var0"}}},
+	}
+
+	controller, err := smcontroller.New(&config.Config{
+		SMController: config.SMController{SMList: []config.SMConfig{{SMID: "testSM", ServerURL: smURL}}}},
+		storage, messageSender, &testAlertSender{}, &testMonitoringSender{}, &testURLTranslator{}, nil, true)
+	if err != nil {
+		t.Fatalf("Can't create SM constoller: %s", err)
+	}
+	defer controller.Close()
+
+	if err = sm.waitEnvVars([]string{"id0"}, messageTimeout); err != nil {
+		t.Errorf("Env vars are not restored: %s", err)
+	}
+
+	// Expired env vars should be removed and reported
+
+	expired := time.Now().Add(-time.Second)
+	ttl := time.Now().Add(500 * time.Millisecond)
+
+	envVars := []cloudprotocol.OverrideEnvsFromCloud{
+		{ServiceID: "service1", SubjectID: "subject1", EnvVars: []cloudprotocol.EnvVarInfo{
+			{ID: "id1", Variable: "var1", TTL: &ttl}, {ID: "id2", Variable: "var2"}}},
+		{ServiceID: "service2", SubjectID: "subject2", EnvVars: []cloudprotocol.EnvVarInfo{
+			{ID: "id3", Variable: "var3", TTL: &expired}}},
+	}
+
+	if err = controller.OverrideEnvVars(cloudprotocol.DecodedOverrideEnvVars{OverrideEnvVars: envVars}); err != nil {
+		t.Errorf("Error sending override env vars: %s", err)
+	}
+
+	message, err := waitMessage(messageSender.messageChannel, messageTimeout)
+	if err != nil {
+		t.Fatalf("Wait message error: %s", err)
+	}
+
+	expectedStatus := []cloudprotocol.EnvVarInfoStatus{
+		{ServiceID: "service1", SubjectID: "subject1", Statuses: []cloudprotocol.EnvVarStatus{{ID: "id1"}, {ID: "id2"}}},
+		{ServiceID: "service2", SubjectID: "subject2", Statuses: []cloudprotocol.EnvVarStatus{
+			{ID: "id3", Error: "TTL expired"}}},
+	}
+
+	if !reflect.DeepEqual(message, expectedStatus) {
+		t.Errorf("Wrong env var status: %v", message)
+	}
+
+	if err = sm.waitEnvVars([]string{"id1", "id2"}, messageTimeout); err != nil {
+		t.Errorf("Env vars are not applied: %s", err)
+	}
+
+	if message, err = waitMessage(messageSender.messageChannel, messageTimeout); err != nil {
+		t.Fatalf("Wait message error: %s", err)
+	}
+
+	expectedStatus = []cloudprotocol.EnvVarInfoStatus{
+		{ServiceID: "service1", SubjectID: "subject1", Statuses: []cloudprotocol.EnvVarStatus{
+			{ID: "id1", Error: "TTL expired"}, {ID: "id2"}}},
+	}
+
+	if !reflect.DeepEqual(message, expectedStatus) {
+		t.Errorf("Wrong env var status: %v", message)
+	}
+
+	if err = sm.waitEnvVars([]string{"id2"}, messageTimeout); err != nil {
+		t.Errorf("Expired env vars are not removed: %s", err)
+	}
+
+	storedEnvVars, err := storage.GetOverrideEnvVars()
+	if err != nil {
+		t.Fatalf("Can't get stored env vars: %s", err)
+	}
+
+	if len(storedEnvVars) != 1 || len(storedEnvVars[0].EnvVars) != 1 || storedEnvVars[0].EnvVars[0].ID != "id2" {
+		t.Errorf("Wrong stored env vars: %v", storedEnvVars)
+	}
+}
+
 func TestSMNotifications(t *testing.T) {
 	sm, err := newTestSM(smURL)
 	if err != nil {
@@ -594,7 +1570,7 @@ func TestSMNotifications(t *testing.T) {
 
 	controller, err := smcontroller.New(&config.Config{
 		SMController: config.SMController{SMList: []config.SMConfig{{SMID: "testSM", ServerURL: smURL}}}},
-		messageSender, alertSender, monitoringSender, &testURLTranslator{}, true)
+		newTestStorage(), messageSender, alertSender, monitoringSender, &testURLTranslator{}, nil, true)
 	if err != nil {
 		t.Fatalf("Can't create SM constoller: %s", err)
 	}
@@ -605,7 +1581,8 @@ func TestSMNotifications(t *testing.T) {
 	testMessages := []interface{}{
 		cloudprotocol.NewState{ServiceID: "service0", Checksum: "checksum0", State: "state0"},
 		cloudprotocol.StateRequest{ServiceID: "service1", Default: true},
-		cloudprotocol.PushLog{LogID: "log0", PartCount: 2, Part: 1, Data: []byte("this is log"), Error: "this is error"},
+		cloudprotocol.PushLog{
+			LogID: "log0", NodeID: "testSM", PartCount: 2, Part: 1, Data: []byte("this is log"), Error: "this is error"},
 	}
 
 	for _, sendMessage := range testMessages {
@@ -655,7 +1632,7 @@ func TestSMNotifications(t *testing.T) {
 	// Test monitoring
 
 	testMonitoringData := []interface{}{
-		cloudprotocol.MonitoringData{Timestamp: time.Now().UTC(),
+		cloudprotocol.MonitoringData{NodeID: "testSM", Timestamp: time.Now().UTC(),
 			Global: cloudprotocol.GlobalMonitoringData{
 				RAM: 10, CPU: 20, UsedDisk: 30, InTraffic: 40, OutTraffic: 50},
 			ServicesData: []cloudprotocol.ServiceMonitoringData{
@@ -663,7 +1640,7 @@ func TestSMNotifications(t *testing.T) {
 				{ServiceID: "service1", RAM: 61, CPU: 71, InTraffic: 81, OutTraffic: 91},
 			},
 		},
-		cloudprotocol.MonitoringData{Timestamp: time.Now().UTC(),
+		cloudprotocol.MonitoringData{NodeID: "testSM", Timestamp: time.Now().UTC(),
 			Global: cloudprotocol.GlobalMonitoringData{
 				RAM: 11, CPU: 21, UsedDisk: 31, InTraffic: 41, OutTraffic: 51},
 			ServicesData: []cloudprotocol.ServiceMonitoringData{
@@ -700,6 +1677,28 @@ func (translator *testURLTranslator) TranslateURL(isLocal bool, inURL string) (o
 	return outURL, nil
 }
 
+func (wrapper *testKeyWrapper) WrapKey(key []byte) (wrapped []byte, err error) {
+	return append([]byte(testKeyWrapperPrefix), wrapper.xor(key)...), nil
+}
+
+func (wrapper *testKeyWrapper) UnwrapKey(wrapped []byte) (key []byte, err error) {
+	if !bytes.HasPrefix(wrapped, []byte(testKeyWrapperPrefix)) {
+		return nil, aoserrors.New("key is not wrapped")
+	}
+
+	return wrapper.xor(wrapped[len(testKeyWrapperPrefix):]), nil
+}
+
+func (wrapper *testKeyWrapper) xor(data []byte) (result []byte) {
+	result = make([]byte, len(data))
+
+	for i, value := range data {
+		result[i] = value ^ 0x5A
+	}
+
+	return result
+}
+
 func newTestAlertSender() (sender *testAlertSender) {
 	return &testAlertSender{messageChannel: make(chan interface{}, 1)}
 }
@@ -720,6 +1719,125 @@ func (sender *testMonitoringSender) SendMonitoringData(monitoringData cloudproto
 	return nil
 }
 
+func newTestStorage() (storage *testStorage) {
+	return &testStorage{
+		placements:      make(map[string]smcontroller.ServicePlacement),
+		layerPlacements: make(map[string]smcontroller.LayerPlacement),
+		states:          make(map[string]smcontroller.ServiceStateBackup),
+	}
+}
+
+func (storage *testStorage) SetServicePlacement(placement smcontroller.ServicePlacement) (err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	storage.placements[placement.ServiceID] = placement
+
+	return nil
+}
+
+func (storage *testStorage) GetServicePlacements() (placements []smcontroller.ServicePlacement, err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	for _, placement := range storage.placements {
+		placements = append(placements, placement)
+	}
+
+	return placements, nil
+}
+
+func (storage *testStorage) RemoveServicePlacement(serviceID string) (err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	if _, ok := storage.placements[serviceID]; !ok {
+		return aoserrors.New("placement not found")
+	}
+
+	delete(storage.placements, serviceID)
+
+	return nil
+}
+
+func (storage *testStorage) SetLayerPlacement(placement smcontroller.LayerPlacement) (err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	storage.layerPlacements[placement.Digest] = placement
+
+	return nil
+}
+
+func (storage *testStorage) GetLayerPlacements() (placements []smcontroller.LayerPlacement, err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	for _, placement := range storage.layerPlacements {
+		placements = append(placements, placement)
+	}
+
+	return placements, nil
+}
+
+func (storage *testStorage) SetServiceStateBackup(backup smcontroller.ServiceStateBackup) (err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	storage.states[backup.ServiceID+strings.Join(backup.Users, ",")] = backup
+
+	return nil
+}
+
+func (storage *testStorage) GetServiceStateBackup(
+	serviceID string, users []string) (backup smcontroller.ServiceStateBackup, err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	backup, ok := storage.states[serviceID+strings.Join(users, ",")]
+	if !ok {
+		return backup, aoserrors.New("state not found")
+	}
+
+	return backup, nil
+}
+
+func (storage *testStorage) RemoveServiceStateBackup(serviceID string, users []string) (err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	if _, ok := storage.states[serviceID+strings.Join(users, ",")]; !ok {
+		return aoserrors.New("state not found")
+	}
+
+	delete(storage.states, serviceID+strings.Join(users, ","))
+
+	return nil
+}
+
+func (storage *testStorage) SetOverrideEnvVars(envVars []cloudprotocol.OverrideEnvsFromCloud) (err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	storage.envVars = envVars
+
+	return nil
+}
+
+func (storage *testStorage) GetOverrideEnvVars() (envVars []cloudprotocol.OverrideEnvsFromCloud, err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	return storage.envVars, nil
+}
+
+func (storage *testStorage) getSMIDs(serviceID string) (smIDs []string) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	return storage.placements[serviceID].SMIDs
+}
+
 func newTestMessageSender() (sender *testMessageSender) {
 	return &testMessageSender{messageChannel: make(chan interface{}, 1)}
 }
@@ -750,13 +1868,7 @@ func (sender *testMessageSender) SendOverrideEnvVarsStatus(envStatus []cloudprot
 }
 
 func (sender *testMessageSender) SendLog(serviceLog cloudprotocol.PushLog) (err error) {
-	sender.messageChannel <- cloudprotocol.PushLog{
-		LogID:     serviceLog.LogID,
-		PartCount: serviceLog.PartCount,
-		Part:      serviceLog.Part,
-		Data:      serviceLog.Data,
-		Error:     serviceLog.Error,
-	}
+	sender.messageChannel <- serviceLog
 
 	return nil
 }
@@ -775,6 +1887,99 @@ func waitMessage(messageChannel <-chan interface{}, timeout time.Duration) (mess
 	}
 }
 
+func registerSM(connection *grpc.ClientConn, registration *pbcm.SMRegistration, wait bool) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), messageTimeout)
+	defer cancel()
+
+	stream, err := pbcm.NewSMRegistrationServiceClient(connection).RegisterSM(ctx)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = stream.Send(registration); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	status, err := stream.Recv()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if !status.Registered {
+		return aoserrors.New(status.Error)
+	}
+
+	return nil
+}
+
+func setNodeIDs(services []cloudprotocol.ServiceInfo, nodeID string) (result []cloudprotocol.ServiceInfo) {
+	for _, service := range services {
+		service.NodeIDs = []string{nodeID}
+		result = append(result, service)
+	}
+
+	return result
+}
+
+func getLayerDigests(layers []cloudprotocol.LayerInfo) (digests []string) {
+	for _, layer := range layers {
+		digests = append(digests, layer.Digest)
+	}
+
+	sort.Strings(digests)
+
+	return digests
+}
+
+// waitNodeLogs waits for the number of push logs and groups them by node ID
+func waitNodeLogs(messageChannel <-chan interface{}, count int) (logs map[string][]cloudprotocol.PushLog, err error) {
+	logs = make(map[string][]cloudprotocol.PushLog)
+
+	for i := 0; i < count; i++ {
+		message, err := waitMessage(messageChannel, messageTimeout)
+		if err != nil {
+			return nil, err
+		}
+
+		pushLog, ok := message.(cloudprotocol.PushLog)
+		if !ok {
+			return nil, aoserrors.Errorf("unexpected message: %v", message)
+		}
+
+		logs[pushLog.NodeID] = append(logs[pushLog.NodeID], pushLog)
+	}
+
+	return logs, nil
+}
+
+func hasService(services []cloudprotocol.ServiceInfo, serviceID string) (result bool) {
+	for _, service := range services {
+		if service.ID == serviceID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkImage checks that local image is available
+func checkImage(imageURL string) (err error) {
+	parsedURL, err := url.Parse(imageURL)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if parsedURL.Scheme != "file" {
+		return nil
+	}
+
+	if _, err = os.Stat(parsedURL.Path); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
 func newTestSM(url string) (sm *testSM, err error) {
 	sm = &testSM{messageChannel: make(chan interface{}, 1)}
 
@@ -794,6 +1999,51 @@ func newTestSM(url string) (sm *testSM, err error) {
 	return sm, nil
 }
 
+func (sm *testSM) getUsersServices() (services []cloudprotocol.ServiceInfo) {
+	sm.Lock()
+	defer sm.Unlock()
+
+	return append(services, sm.usersServices...)
+}
+
+func (sm *testSM) getUsersLayers() (layers []cloudprotocol.LayerInfo) {
+	sm.Lock()
+	defer sm.Unlock()
+
+	return append(layers, sm.usersLayers...)
+}
+
+func (sm *testSM) setStateChecksum(checksum string) {
+	sm.Lock()
+	defer sm.Unlock()
+
+	sm.stateChecksum = checksum
+}
+
+func (sm *testSM) getStateChecksum() (checksum string) {
+	sm.Lock()
+	defer sm.Unlock()
+
+	return sm.stateChecksum
+}
+
+func (sm *testSM) getEnvVars() (envVars []string) {
+	sm.Lock()
+	defer sm.Unlock()
+
+	return sm.envVars
+}
+
+func (sm *testSM) waitEnvVars(envVars []string, timeout time.Duration) (err error) {
+	for start := time.Now(); time.Since(start) < timeout; time.Sleep(10 * time.Millisecond) {
+		if reflect.DeepEqual(sm.getEnvVars(), envVars) {
+			return nil
+		}
+	}
+
+	return aoserrors.Errorf("wrong SM env vars: %v", sm.getEnvVars())
+}
+
 func (sm *testSM) close() (err error) {
 	if sm.grpcServer != nil {
 		sm.grpcServer.Stop()
@@ -969,12 +2219,17 @@ func (sm *testSM) SetBoardConfig(ctx context.Context, request *pb.BoardConfig) (
 	}
 
 	sm.boardConfigVersion = boardconfig.VendorVersion
+	sm.boardConfig = boardconfig
 
 	return &empty.Empty{}, nil
 }
 
 func (sm *testSM) CheckBoardConfig(
 	ctx context.Context, request *pb.BoardConfig) (response *pb.BoardConfigStatus, err error) {
+	if sm.checkBoardConfigError != nil {
+		return nil, sm.checkBoardConfigError
+	}
+
 	var boardconfig clientBoardConfig
 
 	if err = json.Unmarshal([]byte(request.BoardConfig), &boardconfig); err != nil {
@@ -993,6 +2248,10 @@ func (sm *testSM) InstallService(ctx context.Context,
 
 	log.Debug("=== ", request.Users)
 
+	if err = checkImage(request.Url); err != nil {
+		return nil, err
+	}
+
 	sm.users = request.Users.Users
 
 	serviceInfo := cloudprotocol.ServiceInfo{
@@ -1039,6 +2298,10 @@ func (sm *testSM) InstallLayer(ctx context.Context, request *pb.InstallLayerRequ
 	sm.Lock()
 	defer sm.Unlock()
 
+	if err = checkImage(request.Url); err != nil {
+		return nil, err
+	}
+
 	layerInfo := cloudprotocol.LayerInfo{
 		ID:         request.LayerId,
 		Digest:     request.Digest,
@@ -1065,15 +2328,47 @@ func (sm *testSM) ServiceStateAcceptance(ctx context.Context, request *pb.StateA
 }
 
 func (sm *testSM) SetServiceState(ctx context.Context, request *pb.ServiceState) (response *empty.Empty, err error) {
+	sm.Lock()
+	defer sm.Unlock()
+
 	sm.users = request.Users.Users
 	sm.stateChecksum = request.StateChecksum
 
 	return &empty.Empty{}, nil
 }
 
+func (sm *testSM) GetSystemLog(ctx context.Context, request *pb.SystemLogRequest) (response *empty.Empty, err error) {
+	sm.sendLog(request.LogId)
+
+	return &empty.Empty{}, nil
+}
+
+func (sm *testSM) GetServiceLog(ctx context.Context, request *pb.ServiceLogRequest) (response *empty.Empty, err error) {
+	sm.sendLog(request.LogId)
+
+	return &empty.Empty{}, nil
+}
+
+func (sm *testSM) sendLog(logID string) {
+	if sm.logError != "" {
+		sm.messageChannel <- cloudprotocol.PushLog{LogID: logID, Error: sm.logError}
+
+		return
+	}
+
+	for i, data := range sm.logParts {
+		sm.messageChannel <- cloudprotocol.PushLog{
+			LogID: logID, PartCount: uint64(len(sm.logParts)), Part: uint64(i + 1), Data: data}
+	}
+}
+
 func (sm *testSM) OverrideEnvVars(ctx context.Context,
 	request *pb.OverrideEnvVarsRequest) (response *pb.OverrideEnvVarStatus, err error) {
+	sm.Lock()
+	defer sm.Unlock()
+
 	response = &pb.OverrideEnvVarStatus{}
+	sm.envVars = []string{}
 
 	for _, item := range request.EnvVars {
 		envVarStatus := &pb.EnvVarStatus{
@@ -1085,6 +2380,8 @@ func (sm *testSM) OverrideEnvVars(ctx context.Context,
 				VarId: envVar.VarId,
 				Error: "",
 			})
+
+			sm.envVars = append(sm.envVars, envVar.VarId)
 		}
 
 		response.EnvVarStatus = append(response.EnvVarStatus, envVarStatus)
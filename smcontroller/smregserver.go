@@ -0,0 +1,185 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package smcontroller
+
+import (
+	"context"
+	"io"
+	"net"
+	"time"
+
+	"github.com/aoscloud/aos_common/aoserrors"
+	"github.com/aoscloud/aos_common/utils/cryptutils"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	pb "aos_communicationmanager/api/cmserver/v1"
+	"aos_communicationmanager/config"
+	"aos_communicationmanager/grpcauth"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// smRegServer gRPC server which accepts SM registrations
+type smRegServer struct {
+	pb.UnimplementedSMRegistrationServiceServer
+
+	controller *Controller
+	insecure   bool
+	grpcServer *grpc.Server
+	listener   net.Listener
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func newSMRegServer(cfg *config.Config, controller *Controller, insecure bool) (server *smRegServer, err error) {
+	log.WithField("url", cfg.SMController.ServerURL).Debug("Start SM registration server")
+
+	server = &smRegServer{controller: controller, insecure: insecure}
+
+	var opts []grpc.ServerOption
+
+	if !insecure {
+		tlsConfig, err := cryptutils.GetServerMutualTLSConfig(cfg.Crypt.CACert, cfg.CertStorage)
+		if err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	} else {
+		log.Info("SM registration server starts in insecure mode")
+	}
+
+	server.grpcServer = grpc.NewServer(opts...)
+
+	pb.RegisterSMRegistrationServiceServer(server.grpcServer, server)
+
+	if server.listener, err = net.Listen("tcp", cfg.SMController.ServerURL); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	go server.grpcServer.Serve(server.listener)
+
+	return server, nil
+}
+
+func (server *smRegServer) close() {
+	if server.grpcServer != nil {
+		server.grpcServer.Stop()
+	}
+
+	if server.listener != nil {
+		server.listener.Close()
+	}
+}
+
+// RegisterSM handles SM registration stream. SM is registered while the stream is open.
+func (server *smRegServer) RegisterSM(stream pb.SMRegistrationService_RegisterSMServer) (err error) {
+	registration, err := stream.Recv()
+	if err != nil {
+		if err != io.EOF {
+			log.Errorf("Can't receive SM registration: %s", err)
+		}
+
+		return aoserrors.Wrap(err)
+	}
+
+	smConfig := config.SMConfig{
+		SMID:         registration.SmId,
+		ServerURL:    registration.ServerUrl,
+		IsLocal:      registration.IsLocal,
+		Capabilities: registration.Capabilities,
+		RAM:          registration.Ram,
+		Disk:         registration.Disk,
+	}
+
+	log.WithFields(log.Fields{"id": smConfig.SMID, "url": smConfig.ServerURL}).Debug("Register SM")
+
+	ctx, cancel := context.WithCancel(server.controller.context)
+	defer cancel()
+
+	client, err := server.registerSM(ctx, cancel, stream.Context(), smConfig)
+	if err != nil {
+		log.WithField("id", smConfig.SMID).Errorf("Can't register SM: %s", err)
+
+		if sendErr := stream.Send(&pb.SMRegistrationStatus{Error: err.Error()}); sendErr != nil {
+			log.WithField("id", smConfig.SMID).Errorf("Can't send SM registration status: %s", sendErr)
+		}
+
+		return aoserrors.Wrap(err)
+	}
+
+	defer server.controller.unregisterClient(client)
+
+	if err = stream.Send(&pb.SMRegistrationStatus{Registered: true}); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	// Further messages keep registration alive, SM is unregistered when the stream is closed
+	for {
+		if _, err = stream.Recv(); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			log.WithField("id", smConfig.SMID).Warnf("SM registration stream error: %s", err)
+
+			return nil
+		}
+	}
+}
+
+func (server *smRegServer) registerSM(ctx context.Context, cancel context.CancelFunc,
+	streamCtx context.Context, smConfig config.SMConfig) (client *smClient, err error) {
+	if smConfig.SMID == "" || smConfig.ServerURL == "" {
+		return nil, aoserrors.New("SM ID and server URL should be set")
+	}
+
+	if !server.insecure {
+		if commonName := grpcauth.GetPeerCommonName(streamCtx); commonName != smConfig.SMID {
+			return nil, aoserrors.Errorf("certificate common name %s doesn't match SM ID", commonName)
+		}
+	}
+
+	timer := time.AfterFunc(connectClientTimeout, func() {
+		log.WithField("id", smConfig.SMID).Error("SM connection timeout")
+		cancel()
+	})
+
+	if client, err = newSMClient(ctx, smConfig, server.controller.messageSender, server.controller.alertSender,
+		server.controller.monitoringSender, server.controller, server.controller.secureOpt); err != nil {
+		timer.Stop()
+
+		return nil, aoserrors.Wrap(err)
+	}
+
+	if !timer.Stop() {
+		client.close()
+
+		return nil, aoserrors.New("SM connection timeout")
+	}
+
+	server.controller.registerClient(client)
+
+	return client, nil
+}
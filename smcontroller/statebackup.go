@@ -0,0 +1,259 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package smcontroller
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/aoscloud/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"aos_communicationmanager/cloudprotocol"
+	"aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const stateBackupKeySize = 32
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// ServiceStateBackup service state backup. State is stored encrypted.
+type ServiceStateBackup struct {
+	ServiceID string
+	Users     []string
+	Checksum  string
+	State     []byte
+}
+
+// stateBackup keeps the last accepted service states to restore them on SM without cloud connection
+type stateBackup struct {
+	sync.Mutex
+
+	storage      Storage
+	aead         cipher.AEAD
+	maxStateSize uint64
+	// new states waiting for cloud acceptance by correlation ID
+	pendingStates map[string]ServiceStateBackup
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func newStateBackup(
+	cfg config.SMController, storage Storage, keyWrapper KeyWrapper) (backup *stateBackup, err error) {
+	backup = &stateBackup{
+		storage:       storage,
+		maxStateSize:  cfg.MaxStateBackupSize,
+		pendingStates: make(map[string]ServiceStateBackup),
+	}
+
+	if cfg.StateBackupKeyFile == "" {
+		log.Warn("State backup key file is not set, service state backup is disabled")
+
+		return backup, nil
+	}
+
+	if keyWrapper == nil {
+		log.Warn("State backup key can't be protected, service state backup is disabled")
+
+		return backup, nil
+	}
+
+	key, err := getStateBackupKey(cfg.StateBackupKeyFile, keyWrapper)
+	if err != nil {
+		// Key file is kept as it may be unwrapped later e.g. when unit key becomes available
+		log.WithField("file", cfg.StateBackupKeyFile).Errorf(
+			"Can't get state backup key, service state backup is disabled: %s", err)
+
+		return backup, nil
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	if backup.aead, err = cipher.NewGCM(block); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return backup, nil
+}
+
+// addPendingState stores new state until it is accepted by the cloud
+func (backup *stateBackup) addPendingState(correlationID string, state ServiceStateBackup) {
+	if backup.aead == nil {
+		return
+	}
+
+	backup.Lock()
+	defer backup.Unlock()
+
+	backup.pendingStates[correlationID] = state
+}
+
+// acceptState backups pending state if it is accepted by the cloud
+func (backup *stateBackup) acceptState(correlationID string, stateAcceptance cloudprotocol.StateAcceptance) {
+	backup.Lock()
+
+	state, ok := backup.pendingStates[correlationID]
+	if !ok {
+		backup.Unlock()
+		return
+	}
+
+	delete(backup.pendingStates, correlationID)
+
+	if stateAcceptance.Result != cloudprotocol.StateAccepted || state.Checksum != stateAcceptance.Checksum {
+		backup.Unlock()
+		return
+	}
+
+	// Older pending states of the service are superseded by the accepted one
+	for pendingID, pendingState := range backup.pendingStates {
+		if pendingState.ServiceID == state.ServiceID {
+			delete(backup.pendingStates, pendingID)
+		}
+	}
+
+	backup.Unlock()
+
+	backup.setState(state)
+}
+
+// setState encrypts and stores service state
+func (backup *stateBackup) setState(state ServiceStateBackup) {
+	if backup.aead == nil {
+		return
+	}
+
+	if backup.maxStateSize != 0 && uint64(len(state.State)) > backup.maxStateSize {
+		log.WithFields(log.Fields{"serviceID": state.ServiceID, "size": len(state.State)}).Warn(
+			"Service state exceeds backup size limit, skip backup")
+
+		return
+	}
+
+	nonce := make([]byte, backup.aead.NonceSize())
+
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		log.WithField("serviceID", state.ServiceID).Errorf("Can't generate nonce: %s", err)
+
+		return
+	}
+
+	state.State = backup.aead.Seal(nonce, nonce, state.State, []byte(state.ServiceID))
+
+	if err := backup.storage.SetServiceStateBackup(state); err != nil {
+		log.WithField("serviceID", state.ServiceID).Errorf("Can't store service state backup: %s", err)
+
+		return
+	}
+
+	log.WithFields(log.Fields{"serviceID": state.ServiceID, "checksum": state.Checksum}).Debug(
+		"Service state backed up")
+}
+
+// getState returns decrypted service state
+func (backup *stateBackup) getState(serviceID string, users []string) (state ServiceStateBackup, err error) {
+	if backup.aead == nil {
+		return state, aoserrors.New("service state backup is disabled")
+	}
+
+	if state, err = backup.storage.GetServiceStateBackup(serviceID, users); err != nil {
+		return state, aoserrors.Wrap(err)
+	}
+
+	nonceSize := backup.aead.NonceSize()
+
+	if len(state.State) < nonceSize {
+		return state, aoserrors.New("invalid service state backup")
+	}
+
+	if state.State, err = backup.aead.Open(
+		nil, state.State[:nonceSize], state.State[nonceSize:], []byte(serviceID)); err != nil {
+		return state, aoserrors.Wrap(err)
+	}
+
+	return state, nil
+}
+
+func (backup *stateBackup) removeState(serviceID string, users []string) {
+	if backup.aead == nil {
+		return
+	}
+
+	if err := backup.storage.RemoveServiceStateBackup(serviceID, users); err != nil {
+		log.WithField("serviceID", serviceID).Debugf("Can't remove service state backup: %s", err)
+	}
+}
+
+// getStateBackupKey reads and unwraps backup key. New key is generated only if the key file doesn't exist.
+func getStateBackupKey(keyFile string, keyWrapper KeyWrapper) (key []byte, err error) {
+	wrappedKey, err := ioutil.ReadFile(keyFile)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	if err == nil {
+		if key, err = keyWrapper.UnwrapKey(wrappedKey); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		if len(key) != stateBackupKeySize {
+			return nil, aoserrors.Errorf("invalid state backup key size: %d", len(key))
+		}
+
+		return key, nil
+	}
+
+	log.WithField("file", keyFile).Info("Generate state backup key")
+
+	key = make([]byte, stateBackupKeySize)
+
+	if _, err = io.ReadFull(rand.Reader, key); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	if wrappedKey, err = keyWrapper.WrapKey(key); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(keyFile), 0755); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	if err = ioutil.WriteFile(keyFile, wrappedKey, 0600); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,218 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package umcontroller
+
+import (
+	"time"
+
+	"github.com/aoscloud/aos_common/aoserrors"
+	"github.com/looplab/fsm"
+	log "github.com/sirupsen/logrus"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const defaultHealthCheckTimeout = 300 * time.Second
+
+const (
+	healthCheckTimeoutError = "health check timeout"
+	unhealthyError          = "component is unhealthy"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type umHealth struct {
+	healthy bool
+	err     string
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// processHealthCheckState waits for UMs to confirm health of updated components before the update is applied.
+// Applied update can't be reverted, so missing or failed confirmation reverts the update.
+func (umCtrl *Controller) processHealthCheckState(e *fsm.Event) {
+	components := umCtrl.getHealthCheckComponents("")
+	if len(components) == 0 {
+		go umCtrl.generateFSMEvent(evHealthConfirmed)
+		return
+	}
+
+	// Deadline is stored with update components to survive CM restart
+	if components[0].HealthDeadline == nil {
+		deadline := time.Now().Add(umCtrl.healthCheckTimeout)
+
+		for _, component := range components {
+			component.HealthDeadline = &deadline
+		}
+
+		if err := umCtrl.storeUpdateStages(); err != nil {
+			go umCtrl.generateFSMEvent(evUpdateFailed, aoserrors.Wrap(err))
+			return
+		}
+	}
+
+	log.Debugf("Wait for health confirmation till %s", components[0].HealthDeadline)
+
+	umCtrl.stopHealthCheckTimer()
+
+	umCtrl.healthCheckTimer = time.AfterFunc(time.Until(*components[0].HealthDeadline), func() {
+		umCtrl.eventChannel <- umCtrlInternalMsg{requestType: healthCheckTimeout}
+	})
+
+	umCtrl.checkHealth()
+}
+
+func (umCtrl *Controller) handleHealthStatus(umID string, health umHealth) {
+	switch umCtrl.fsm.Current() {
+	case stateStartUpdate, stateUpdateUmStatusOnStartUpdate, stateHealthCheck:
+
+	default:
+		log.WithField("umID", umID).Warn("Unexpected health status, no health check in progress")
+		return
+	}
+
+	components := umCtrl.getHealthCheckComponents(umID)
+	if len(components) == 0 {
+		log.WithField("umID", umID).Warn("Unexpected health status, UM health check is not required")
+		return
+	}
+
+	log.WithFields(log.Fields{"umID": umID, "healthy": health.healthy, "error": health.err}).Debug("UM health status")
+
+	if !health.healthy && health.err == "" {
+		health.err = unhealthyError
+	}
+
+	for _, component := range components {
+		component.HealthConfirmed = health.healthy
+		component.HealthError = health.err
+	}
+
+	if err := umCtrl.storeUpdateStages(); err != nil {
+		log.Errorf("Can't store components update info: %s", err)
+	}
+
+	if umCtrl.fsm.Current() == stateHealthCheck {
+		umCtrl.checkHealth()
+	}
+}
+
+func (umCtrl *Controller) handleHealthCheckTimeout() {
+	if umCtrl.fsm.Current() != stateHealthCheck {
+		return
+	}
+
+	umCtrl.checkHealth()
+}
+
+// checkHealth finishes health check if all components are confirmed, any is failed or deadline is expired
+func (umCtrl *Controller) checkHealth() {
+	components := umCtrl.getHealthCheckComponents("")
+	confirmed := true
+
+	for _, component := range components {
+		if component.HealthError != "" {
+			umCtrl.stopHealthCheckTimer()
+
+			go umCtrl.generateFSMEvent(evUpdateFailed, aoserrors.Errorf(
+				"health check failed id = %s: %s", component.ID, component.HealthError))
+
+			return
+		}
+
+		if !component.HealthConfirmed {
+			confirmed = false
+		}
+	}
+
+	if confirmed {
+		log.Debug("Health of updated components confirmed")
+
+		umCtrl.stopHealthCheckTimer()
+
+		go umCtrl.generateFSMEvent(evHealthConfirmed)
+
+		return
+	}
+
+	if components[0].HealthDeadline != nil && !time.Now().Before(*components[0].HealthDeadline) {
+		umCtrl.stopHealthCheckTimer()
+
+		var notConfirmed []string
+
+		for _, component := range components {
+			if !component.HealthConfirmed {
+				notConfirmed = append(notConfirmed, component.ID)
+			}
+		}
+
+		// Health status is not received: UM is not started or does not support health reporting
+		log.Warnf("No health status received for components %v, check UM supports health reporting", notConfirmed)
+
+		go umCtrl.generateFSMEvent(evUpdateFailed, aoserrors.New(healthCheckTimeoutError))
+	}
+}
+
+// getHealthCheckComponents returns components of current stage which health should be confirmed by UM.
+// Components of all UMs are returned if umID is empty.
+func (umCtrl *Controller) getHealthCheckComponents(umID string) (components []*SystemComponent) {
+	if umCtrl.currentStage >= len(umCtrl.updateStages) {
+		return nil
+	}
+
+	stage := umCtrl.updateStages[umCtrl.currentStage]
+
+	for _, conn := range umCtrl.connections {
+		if !conn.healthCheck || (umID != "" && conn.umID != umID) {
+			continue
+		}
+
+		for _, updatePackage := range conn.updatePackages {
+			for i := range stage {
+				if stage[i].ID == updatePackage.ID && stage[i].VendorVersion == updatePackage.VendorVersion {
+					components = append(components, &stage[i])
+				}
+			}
+		}
+	}
+
+	return components
+}
+
+func (umCtrl *Controller) storeUpdateStages() (err error) {
+	var updateComponents []SystemComponent
+
+	for _, stage := range umCtrl.updateStages {
+		updateComponents = append(updateComponents, stage...)
+	}
+
+	return aoserrors.Wrap(umCtrl.storage.SetComponentsUpdateInfo(updateComponents))
+}
+
+func (umCtrl *Controller) stopHealthCheckTimer() {
+	if umCtrl.healthCheckTimer != nil {
+		umCtrl.healthCheckTimer.Stop()
+		umCtrl.healthCheckTimer = nil
+	}
+}
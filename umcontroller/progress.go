@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package umcontroller
+
+import (
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+
+	"aos_communicationmanager/cloudprotocol"
+)
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// GetProgressChannel returns channel with progress of components being updated
+func (umCtrl *Controller) GetProgressChannel() (channel <-chan []cloudprotocol.ComponentProgress) {
+	return umCtrl.progressChannel
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (umCtrl *Controller) handleProgress(umID string, progress []cloudprotocol.ComponentProgress) {
+	switch umCtrl.fsm.Current() {
+	case stateInit, stateIdle, stateFaultState:
+		log.WithField("umID", umID).Warn("Unexpected update progress, no update in progress")
+		return
+	}
+
+	var conn *umConnection
+
+	for i := range umCtrl.connections {
+		if umCtrl.connections[i].umID == umID {
+			conn = &umCtrl.connections[i]
+			break
+		}
+	}
+
+	if conn == nil || conn.handler == nil {
+		log.WithField("umID", umID).Warn("Update progress from not connected UM")
+		return
+	}
+
+	changed := false
+
+	for _, item := range progress {
+		if !isComponentUpdated(conn.updatePackages, item) {
+			log.WithFields(log.Fields{"umID": umID, "id": item.ID}).Warn("Progress of not updated component")
+			continue
+		}
+
+		if item.Percentage > 100 {
+			item.Percentage = 100
+		}
+
+		if umCtrl.progress[item.ID] != item {
+			umCtrl.progress[item.ID] = item
+			changed = true
+		}
+	}
+
+	if changed {
+		umCtrl.sendProgress()
+	}
+}
+
+func (umCtrl *Controller) resetProgress() {
+	if len(umCtrl.progress) == 0 {
+		return
+	}
+
+	umCtrl.progress = make(map[string]cloudprotocol.ComponentProgress)
+
+	umCtrl.sendProgress()
+}
+
+func (umCtrl *Controller) sendProgress() {
+	progress := make([]cloudprotocol.ComponentProgress, 0, len(umCtrl.progress))
+
+	for _, item := range umCtrl.progress {
+		progress = append(progress, item)
+	}
+
+	sort.Slice(progress, func(i, j int) bool { return progress[i].ID < progress[j].ID })
+
+	// Keep only the latest progress in the channel
+	select {
+	case <-umCtrl.progressChannel:
+
+	default:
+	}
+
+	umCtrl.progressChannel <- progress
+}
+
+func isComponentUpdated(updatePackages []SystemComponent, progress cloudprotocol.ComponentProgress) (result bool) {
+	for _, component := range updatePackages {
+		if component.ID == progress.ID && component.VendorVersion == progress.VendorVersion {
+			return true
+		}
+	}
+
+	return false
+}
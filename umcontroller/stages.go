@@ -0,0 +1,220 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package umcontroller
+
+import (
+	"encoding/json"
+
+	"github.com/aoscloud/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"aos_communicationmanager/cloudprotocol"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// componentAnnotations component annotations used by CM
+type componentAnnotations struct {
+	// IDs of components which should be updated before this one
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// getUpdateStages splits components into ordered stages: each component is placed into the stage after
+// all components it depends on. Dependencies on components which are not updated are ignored.
+func getUpdateStages(components []SystemComponent) (stages [][]SystemComponent, err error) {
+	const (
+		notVisited = iota
+		visiting
+		visited
+	)
+
+	indexes := make(map[string]int)
+
+	for i, component := range components {
+		indexes[component.ID] = i
+	}
+
+	levels := make([]int, len(components))
+	marks := make([]int, len(components))
+
+	var getLevel func(i int) (level int, err error)
+
+	getLevel = func(i int) (level int, err error) {
+		switch marks[i] {
+		case visited:
+			return levels[i], nil
+
+		case visiting:
+			return 0, aoserrors.Errorf("cyclic dependency of component %s", components[i].ID)
+		}
+
+		marks[i] = visiting
+
+		for _, depID := range getComponentDependencies(components[i]) {
+			depIndex, ok := indexes[depID]
+			if !ok {
+				continue
+			}
+
+			depLevel, err := getLevel(depIndex)
+			if err != nil {
+				return 0, err
+			}
+
+			if depLevel+1 > level {
+				level = depLevel + 1
+			}
+		}
+
+		marks[i] = visited
+		levels[i] = level
+
+		return level, nil
+	}
+
+	for i := range components {
+		level, err := getLevel(i)
+		if err != nil {
+			return nil, err
+		}
+
+		for len(stages) <= level {
+			stages = append(stages, []SystemComponent{})
+		}
+
+		stages[level] = append(stages[level], components[i])
+	}
+
+	return stages, nil
+}
+
+func getComponentDependencies(component SystemComponent) (dependencies []string) {
+	if component.Annotations == "" {
+		return nil
+	}
+
+	var annotations componentAnnotations
+
+	if err := json.Unmarshal([]byte(component.Annotations), &annotations); err != nil {
+		log.WithField("id", component.ID).Warnf("Can't parse component annotations: %s", err)
+
+		return nil
+	}
+
+	return annotations.DependsOn
+}
+
+// setUpdateStages splits update components into stages and assigns components of the first not installed
+// stage to UMs
+func (umCtrl *Controller) setUpdateStages(components []SystemComponent) (err error) {
+	if umCtrl.updateStages, err = getUpdateStages(components); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	umCtrl.currentStage = 0
+
+	// Stages applied before CM restart are skipped
+	for umCtrl.currentStage < len(umCtrl.updateStages)-1 && umCtrl.isStageInstalled(umCtrl.currentStage) {
+		umCtrl.currentStage++
+	}
+
+	return umCtrl.assignStageComponents()
+}
+
+// nextUpdateStage verifies current stage and assigns components of the next stage to UMs
+func (umCtrl *Controller) nextUpdateStage() (err error) {
+	if !umCtrl.isStageInstalled(umCtrl.currentStage) {
+		return aoserrors.Errorf("update stage %d is not installed", umCtrl.currentStage+1)
+	}
+
+	umCtrl.currentStage++
+
+	log.Debugf("Start update stage %d of %d", umCtrl.currentStage+1, len(umCtrl.updateStages))
+
+	return umCtrl.assignStageComponents()
+}
+
+func (umCtrl *Controller) hasNextUpdateStage() (result bool) {
+	return umCtrl.currentStage+1 < len(umCtrl.updateStages)
+}
+
+// isStageRestored returns true if some stages were applied before and the next one is not started yet
+func (umCtrl *Controller) isStageRestored() (result bool) {
+	return umCtrl.currentStage > 0 && umCtrl.currentStage < len(umCtrl.updateStages)
+}
+
+func (umCtrl *Controller) assignStageComponents() (err error) {
+	for i := range umCtrl.connections {
+		umCtrl.connections[i].updatePackages = []SystemComponent{}
+	}
+
+	if umCtrl.currentStage >= len(umCtrl.updateStages) {
+		return nil
+	}
+
+	for _, component := range umCtrl.updateStages[umCtrl.currentStage] {
+		if err = umCtrl.addComponentForUpdateToUm(component); err != nil {
+			return aoserrors.Wrap(err)
+		}
+	}
+
+	return nil
+}
+
+func (umCtrl *Controller) isStageInstalled(stage int) (result bool) {
+	for _, component := range umCtrl.updateStages[stage] {
+		installed := false
+
+		for _, curComponent := range umCtrl.currentComponents {
+			if curComponent.ID == component.ID && curComponent.VendorVersion == component.VendorVersion &&
+				curComponent.Status == cloudprotocol.InstalledStatus {
+				installed = true
+				break
+			}
+		}
+
+		if !installed {
+			return false
+		}
+	}
+
+	return true
+}
+
+// skipNextStages reports components of not started stages as failed
+func (umCtrl *Controller) skipNextStages(reason string) {
+	for stage := umCtrl.currentStage + 1; stage < len(umCtrl.updateStages); stage++ {
+		for _, component := range umCtrl.updateStages[stage] {
+			umCtrl.updateComponentElement(systemComponentStatus{
+				id: component.ID, vendorVersion: component.VendorVersion, aosVersion: component.AosVersion,
+				status: cloudprotocol.ErrorStatus, err: reason,
+			})
+		}
+	}
+}
+
+func (umCtrl *Controller) resetUpdateStages() {
+	umCtrl.updateStages = nil
+	umCtrl.currentStage = 0
+}
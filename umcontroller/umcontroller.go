@@ -19,6 +19,10 @@ package umcontroller
 
 import (
 	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
 	"sort"
 	"sync"
 	"time"
@@ -40,6 +44,11 @@ type URLTranslator interface {
 	TranslateURL(isLocal bool, inURL string) (outURL string, err error)
 }
 
+// AlertSender sends alert
+type AlertSender interface {
+	SendAlert(alert cloudprotocol.AlertItem) (err error)
+}
+
 // Controller update managers controller
 type Controller struct {
 	storage       storage
@@ -49,15 +58,26 @@ type Controller struct {
 	stopChannel   chan bool
 
 	updateDir string
+	imageDir  string
 
 	connections       []umConnection
 	currentComponents []cloudprotocol.ComponentInfo
 	fsm               *fsm.FSM
 	connectionMonitor allConnectionMonitor
+	connectionTimeout time.Duration
 	operable          bool
 	updateFinishCond  *sync.Cond
 
 	updateError error
+
+	updateStages [][]SystemComponent
+	currentStage int
+
+	healthCheckTimeout time.Duration
+	healthCheckTimer   *time.Timer
+
+	progress        map[string]cloudprotocol.ComponentProgress
+	progressChannel chan []cloudprotocol.ComponentProgress
 }
 
 // SystemComponent information about system component update
@@ -70,16 +90,30 @@ type SystemComponent struct {
 	Sha256        []byte `json:"sha256"`
 	Sha512        []byte `json:"sha512"`
 	Size          uint64 `json:"size"`
+
+	HealthDeadline  *time.Time `json:"healthDeadline,omitempty"`
+	HealthConfirmed bool       `json:"healthConfirmed,omitempty"`
+	HealthError     string     `json:"healthError,omitempty"`
+}
+
+// UMInfo components of update manager received on the last connection and components which update is deferred
+// until update manager is connected
+type UMInfo struct {
+	UMID               string
+	Components         []string
+	DeferredComponents []SystemComponent
 }
 
 type umConnection struct {
-	umID           string
-	isLocalClient  bool
-	handler        *umHandler
-	updatePriority uint32
-	state          string
-	components     []string
-	updatePackages []SystemComponent
+	umID               string
+	isLocalClient      bool
+	handler            *umHandler
+	updatePriority     uint32
+	healthCheck        bool
+	state              string
+	components         []string
+	updatePackages     []SystemComponent
+	deferredComponents []SystemComponent
 }
 
 type umCtrlInternalMsg struct {
@@ -87,6 +121,8 @@ type umCtrlInternalMsg struct {
 	handler     *umHandler
 	requestType int
 	status      umStatus
+	progress    []cloudprotocol.ComponentProgress
+	health      umHealth
 }
 
 type umStatus struct {
@@ -113,6 +149,8 @@ type allConnectionMonitor struct {
 type storage interface {
 	GetComponentsUpdateInfo() (updateInfo []SystemComponent, err error)
 	SetComponentsUpdateInfo(updateInfo []SystemComponent) (err error)
+	SetUMInfo(info UMInfo) (err error)
+	GetUMInfos() (infos []UMInfo, err error)
 }
 
 /***********************************************************************************************************************
@@ -123,6 +161,9 @@ const (
 	openConnection = iota
 	closeConnection
 	umStatusUpdate
+	umProgressUpdate
+	umHealthUpdate
+	healthCheckTimeout
 )
 
 // FSM states
@@ -134,6 +175,7 @@ const (
 	stateUpdateUmStatusOnPrepareUpdate = "updateUmStatusOnPrepareUpdate"
 	stateStartUpdate                   = "startUpdate"
 	stateUpdateUmStatusOnStartUpdate   = "updateUmStatusOnStartUpdate"
+	stateHealthCheck                   = "healthCheck"
 	stateStartApply                    = "startApply"
 	stateUpdateUmStatusOnStartApply    = "updateUmStatusOnStartApply"
 	stateStartRevert                   = "startRevert"
@@ -149,12 +191,14 @@ const (
 	evUpdatePrepared      = "updatePrepared"
 	evUmStateUpdated      = "umStateUpdated"
 	evSystemUpdated       = "systemUpdated"
+	evHealthConfirmed     = "healthConfirmed"
 	evApplyComplete       = "applyComplete"
+	evStageApplied        = "stageApplied"
 
-	evContinuePrepare = "continuePrepare"
-	evContinueUpdate  = "continueUpdate"
-	evContinueApply   = "continueApply"
-	evContinueRevert  = "continueRevert"
+	evContinuePrepare     = "continuePrepare"
+	evContinueUpdate      = "continueUpdate"
+	evContinueHealthCheck = "continueHealthCheck"
+	evContinueRevert      = "continueRevert"
 
 	evUpdateFailed   = "updateFailed"
 	evSystemReverted = "systemReverted"
@@ -168,33 +212,62 @@ const (
 	umFailed   = "FAILED"
 )
 
-const connectionTimeout = 300 * time.Second
+const defaultConnectionTimeout = 300 * time.Second
+
+const (
+	fileScheme           = "file"
+	componentImagePrefix = "component_"
+)
+
+const (
+	umUnavailableError = "update manager is not connected"
+	umDeferredError    = "update manager is not connected, update is deferred"
+)
 
 /***********************************************************************************************************************
  * Public
  **********************************************************************************************************************/
 
 // New creates new update managers controller
-func New(config *config.Config, storage storage, urlTranslator URLTranslator, insecure bool) (umCtrl *Controller, err error) {
+func New(config *config.Config, storage storage, urlTranslator URLTranslator, alertSender AlertSender,
+	insecure bool) (umCtrl *Controller, err error) {
 	umCtrl = &Controller{
-		storage:           storage,
-		urlTranslator:     urlTranslator,
-		eventChannel:      make(chan umCtrlInternalMsg),
-		stopChannel:       make(chan bool),
-		connectionMonitor: allConnectionMonitor{stopTimerChan: make(chan bool, 1), timeoutChan: make(chan bool, 1)},
-		operable:          true,
-		updateFinishCond:  sync.NewCond(&sync.Mutex{}),
+		storage:            storage,
+		urlTranslator:      urlTranslator,
+		eventChannel:       make(chan umCtrlInternalMsg),
+		stopChannel:        make(chan bool),
+		connectionMonitor:  allConnectionMonitor{stopTimerChan: make(chan bool, 1), timeoutChan: make(chan bool, 1)},
+		connectionTimeout:  config.UMController.ConnectionTimeout.Duration,
+		operable:           true,
+		updateFinishCond:   sync.NewCond(&sync.Mutex{}),
+		progress:           make(map[string]cloudprotocol.ComponentProgress),
+		progressChannel:    make(chan []cloudprotocol.ComponentProgress, 1),
+		healthCheckTimeout: config.UMController.HealthCheckTimeout.Duration,
+		imageDir:           config.Downloader.DecryptDir,
+	}
+
+	if umCtrl.connectionTimeout <= 0 {
+		umCtrl.connectionTimeout = defaultConnectionTimeout
+	}
+
+	if umCtrl.healthCheckTimeout <= 0 {
+		umCtrl.healthCheckTimeout = defaultHealthCheckTimeout
 	}
 
 	for _, client := range config.UMController.UMClients {
 		umCtrl.connections = append(umCtrl.connections, umConnection{umID: client.UMID,
-			isLocalClient: client.IsLocal, updatePriority: client.Priority, handler: nil})
+			isLocalClient: client.IsLocal, updatePriority: client.Priority, healthCheck: client.HealthCheck,
+			handler: nil})
 	}
 
 	sort.Slice(umCtrl.connections, func(i, j int) bool {
 		return umCtrl.connections[i].updatePriority < umCtrl.connections[j].updatePriority
 	})
 
+	if err = umCtrl.loadUMInfos(); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
 	umCtrl.fsm = fsm.NewFSM(
 		stateInit,
 		fsm.Events{
@@ -203,7 +276,7 @@ func New(config *config.Config, storage storage, urlTranslator URLTranslator, in
 			{Name: evUpdateRequest, Src: []string{stateIdle}, Dst: statePrepareUpdate},
 			{Name: evContinuePrepare, Src: []string{stateIdle}, Dst: statePrepareUpdate},
 			{Name: evContinueUpdate, Src: []string{stateIdle}, Dst: stateStartUpdate},
-			{Name: evContinueApply, Src: []string{stateIdle}, Dst: stateStartApply},
+			{Name: evContinueHealthCheck, Src: []string{stateIdle}, Dst: stateHealthCheck},
 			{Name: evContinueRevert, Src: []string{stateIdle}, Dst: stateStartRevert},
 			//process prepare
 			{Name: evUmStateUpdated, Src: []string{statePrepareUpdate}, Dst: stateUpdateUmStatusOnPrepareUpdate},
@@ -212,20 +285,25 @@ func New(config *config.Config, storage storage, urlTranslator URLTranslator, in
 			{Name: evUpdatePrepared, Src: []string{statePrepareUpdate}, Dst: stateStartUpdate},
 			{Name: evUmStateUpdated, Src: []string{stateStartUpdate}, Dst: stateUpdateUmStatusOnStartUpdate},
 			{Name: evContinue, Src: []string{stateUpdateUmStatusOnStartUpdate}, Dst: stateStartUpdate},
+			//process health check
+			{Name: evSystemUpdated, Src: []string{stateStartUpdate}, Dst: stateHealthCheck},
+			{Name: evHealthConfirmed, Src: []string{stateHealthCheck}, Dst: stateStartApply},
 			//process start apply
-			{Name: evSystemUpdated, Src: []string{stateStartUpdate}, Dst: stateStartApply},
 			{Name: evUmStateUpdated, Src: []string{stateStartApply}, Dst: stateUpdateUmStatusOnStartApply},
 			{Name: evContinue, Src: []string{stateUpdateUmStatusOnStartApply}, Dst: stateStartApply},
 			{Name: evApplyComplete, Src: []string{stateStartApply}, Dst: stateIdle},
+			{Name: evStageApplied, Src: []string{stateStartApply}, Dst: statePrepareUpdate},
 			//process revert
 			{Name: evUpdateFailed, Src: []string{statePrepareUpdate}, Dst: stateStartRevert},
 			{Name: evUpdateFailed, Src: []string{stateStartUpdate}, Dst: stateStartRevert},
+			{Name: evUpdateFailed, Src: []string{stateHealthCheck}, Dst: stateStartRevert},
 			{Name: evUpdateFailed, Src: []string{stateStartApply}, Dst: stateStartRevert},
 			{Name: evUmStateUpdated, Src: []string{stateStartRevert}, Dst: stateUpdateUmStatusOnRevert},
 			{Name: evContinue, Src: []string{stateUpdateUmStatusOnRevert}, Dst: stateStartRevert},
 			{Name: evSystemReverted, Src: []string{stateStartRevert}, Dst: stateIdle},
 
-			{Name: evConnectionTimeout, Src: []string{stateInit}, Dst: stateFaultState},
+			// continue with connected UMs
+			{Name: evConnectionTimeout, Src: []string{stateInit}, Dst: stateIdle},
 		},
 		fsm.Callbacks{
 			"enter_" + stateIdle:                          umCtrl.processIdleState,
@@ -233,6 +311,7 @@ func New(config *config.Config, storage storage, urlTranslator URLTranslator, in
 			"enter_" + stateUpdateUmStatusOnPrepareUpdate: umCtrl.processUpdateUmState,
 			"enter_" + stateStartUpdate:                   umCtrl.processStartUpdateState,
 			"enter_" + stateUpdateUmStatusOnStartUpdate:   umCtrl.processUpdateUmState,
+			"enter_" + stateHealthCheck:                   umCtrl.processHealthCheckState,
 			"enter_" + stateStartApply:                    umCtrl.processStartApplyState,
 			"enter_" + stateUpdateUmStatusOnStartApply:    umCtrl.processUpdateUmState,
 			"enter_" + stateStartRevert:                   umCtrl.processStartRevertState,
@@ -246,14 +325,14 @@ func New(config *config.Config, storage storage, urlTranslator URLTranslator, in
 		},
 	)
 
-	umCtrl.server, err = newServer(config, umCtrl.eventChannel, insecure)
+	umCtrl.server, err = newServer(config, umCtrl.eventChannel, alertSender, insecure)
 	if err != nil {
 		return nil, aoserrors.Wrap(err)
 	}
 
 	go umCtrl.processInternallMessages()
 	umCtrl.connectionMonitor.wg.Add(1)
-	go umCtrl.connectionMonitor.startConnectionTimer(len(umCtrl.connections))
+	go umCtrl.connectionMonitor.startConnectionTimer(len(umCtrl.connections), umCtrl.connectionTimeout)
 	go umCtrl.server.Start()
 
 	return umCtrl, nil
@@ -277,6 +356,19 @@ func (umCtrl *Controller) GetStatus() (info []cloudprotocol.ComponentInfo, err e
 	return umCtrl.currentComponents, nil
 }
 
+// GetComponentUM returns ID of UM which updates component
+func (umCtrl *Controller) GetComponentUM(componentID string) (umID string, err error) {
+	for _, connection := range umCtrl.connections {
+		for _, id := range connection.components {
+			if id == componentID {
+				return connection.umID, nil
+			}
+		}
+	}
+
+	return "", aoserrors.Errorf("component id %s not found", componentID)
+}
+
 // UpdateComponents updates components
 func (umCtrl *Controller) UpdateComponents(
 	components []cloudprotocol.ComponentInfoFromCloud) (status []cloudprotocol.ComponentInfo, err error) {
@@ -292,22 +384,57 @@ func (umCtrl *Controller) UpdateComponents(
 		}
 
 		componentsUpdateInfo := []SystemComponent{}
+		deferredComponents := []SystemComponent{}
 
 		for _, component := range components {
-			componentStatus := systemComponentStatus{id: component.ID, vendorVersion: component.VendorVersion,
-				aosVersion: component.AosVersion, status: cloudprotocol.DownloadedStatus}
-
 			componentInfo := SystemComponent{ID: component.ID, VendorVersion: component.VendorVersion,
 				AosVersion: component.AosVersion, URL: component.URLs[0], Annotations: string(component.Annotations),
 				Sha256: component.Sha256, Sha512: component.Sha512, Size: component.Size}
 
+			var available bool
+
+			if available, err = umCtrl.isComponentAvailable(componentInfo.ID); err != nil {
+				return umCtrl.currentComponents, aoserrors.Wrap(err)
+			}
+
+			if !available {
+				log.WithField("id", componentInfo.ID).Warn("Component is unavailable, defer update")
+
+				// Downloaded image is removed after update, keep it until the component is updated
+				componentInfo.URL = umCtrl.keepImage(componentInfo.ID, componentInfo.URL)
+
+				deferredComponents = append(deferredComponents, componentInfo)
+
+				umCtrl.updateComponentElement(systemComponentStatus{id: component.ID,
+					vendorVersion: component.VendorVersion, aosVersion: component.AosVersion,
+					status: cloudprotocol.PendingStatus, err: umDeferredError})
+
+				continue
+			}
+
 			if err = umCtrl.addComponentForUpdateToUm(componentInfo); err != nil {
 				return umCtrl.currentComponents, aoserrors.Wrap(err)
 			}
 
 			componentsUpdateInfo = append(componentsUpdateInfo, componentInfo)
+		}
 
-			umCtrl.updateComponentElement(componentStatus)
+		umCtrl.setDeferredComponents(deferredComponents)
+
+		if len(componentsUpdateInfo) == 0 {
+			return umCtrl.currentComponents, nil
+		}
+
+		if err = umCtrl.setUpdateStages(componentsUpdateInfo); err != nil {
+			return umCtrl.currentComponents, aoserrors.Wrap(err)
+		}
+
+		log.Debugf("Update components in %d stage(s)", len(umCtrl.updateStages))
+
+		for _, component := range componentsUpdateInfo {
+			umCtrl.updateComponentElement(systemComponentStatus{id: component.ID,
+				vendorVersion: component.VendorVersion, aosVersion: component.AosVersion,
+				status: cloudprotocol.DownloadedStatus})
 		}
 
 		if err = umCtrl.storage.SetComponentsUpdateInfo(componentsUpdateInfo); err != nil {
@@ -337,8 +464,7 @@ func (umCtrl *Controller) processInternallMessages() {
 			if len(umCtrl.connections) == 0 {
 				umCtrl.generateFSMEvent(evAllClientsConnected)
 			} else {
-				log.Error("Ums connection timeout")
-				umCtrl.generateFSMEvent(evConnectionTimeout)
+				umCtrl.handleConnectionTimeout()
 			}
 
 		case internalMsg := <-umCtrl.eventChannel:
@@ -349,11 +475,20 @@ func (umCtrl *Controller) processInternallMessages() {
 				umCtrl.handleNewConnection(internalMsg.umID, internalMsg.handler, internalMsg.status)
 
 			case closeConnection:
-				umCtrl.handleCloseConnection(internalMsg.umID)
+				umCtrl.handleCloseConnection(internalMsg.umID, internalMsg.handler)
 
 			case umStatusUpdate:
 				umCtrl.generateFSMEvent(evUmStateUpdated, internalMsg.umID, internalMsg.status)
 
+			case umProgressUpdate:
+				umCtrl.handleProgress(internalMsg.umID, internalMsg.progress)
+
+			case umHealthUpdate:
+				umCtrl.handleHealthStatus(internalMsg.umID, internalMsg.health)
+
+			case healthCheckTimeout:
+				umCtrl.handleHealthCheckTimeout()
+
 			default:
 				log.Error("Unsupported internal message ", internalMsg.requestType)
 			}
@@ -361,6 +496,7 @@ func (umCtrl *Controller) processInternallMessages() {
 		case <-umCtrl.stopChannel:
 			log.Debug("Close all connections")
 
+			umCtrl.stopHealthCheckTimer()
 			umCtrl.server.Stop()
 			umCtrl.updateFinishCond.Broadcast()
 
@@ -420,15 +556,32 @@ func (umCtrl *Controller) handleNewConnection(umID string, handler *umHandler, s
 		return
 	}
 
+	umCtrl.storeUMInfos()
+
 	for _, value := range umCtrl.connections {
 		if value.handler == nil {
+			// Deferred update of connected UM doesn't wait for other UMs
+			if umCtrl.fsm.Current() == stateIdle {
+				umCtrl.resumeDeferredComponents()
+			}
+
 			return
 		}
 	}
 
 	log.Debug("All connection to Ums established")
 
-	umCtrl.connectionMonitor.stopConnectionTimer()
+	switch umCtrl.fsm.Current() {
+	case stateInit:
+		umCtrl.connectionMonitor.stopConnectionTimer()
+
+	case stateIdle:
+		// Late UM registration after connection timeout: reconcile postponed update
+		umCtrl.fsm.SetState(stateInit)
+
+	default:
+		return
+	}
 
 	if err := umCtrl.getUpdateComponentsFromStorage(); err != nil {
 		log.Error("Can't read update components from storage: ", err)
@@ -439,15 +592,232 @@ func (umCtrl *Controller) handleNewConnection(umID string, handler *umHandler, s
 	return
 }
 
-func (umCtrl *Controller) handleCloseConnection(umID string) {
+func (umCtrl *Controller) handleConnectionTimeout() {
+	missingUMs := umCtrl.getMissingUMs()
+
+	log.Warnf("UMs connection timeout, continue without UMs: %v", missingUMs)
+
+	for _, conn := range umCtrl.connections {
+		if conn.handler != nil {
+			continue
+		}
+
+		for _, id := range conn.components {
+			umCtrl.setComponentUnavailable(id)
+		}
+	}
+
+	if err := umCtrl.getUpdateComponentsFromStorage(); err != nil {
+		log.Error("Can't read update components from storage: ", err)
+	}
+
+	umCtrl.generateFSMEvent(evConnectionTimeout)
+}
+
+func (umCtrl *Controller) getMissingUMs() (umIDs []string) {
+	for _, conn := range umCtrl.connections {
+		if conn.handler == nil {
+			umIDs = append(umIDs, conn.umID)
+		}
+	}
+
+	return umIDs
+}
+
+// isComponentAvailable returns false if component belongs to not connected UM
+func (umCtrl *Controller) isComponentAvailable(id string) (available bool, err error) {
+	for _, conn := range umCtrl.connections {
+		for _, componentID := range conn.components {
+			if componentID == id {
+				return conn.handler != nil, nil
+			}
+		}
+	}
+
+	return false, aoserrors.Errorf("component id %s not found", id)
+}
+
+// setDeferredComponents stores components which update is deferred until their UMs are connected. Previously
+// deferred components are replaced as the new update request supersedes them.
+func (umCtrl *Controller) setDeferredComponents(components []SystemComponent) {
+	for i, conn := range umCtrl.connections {
+		for _, deferred := range conn.deferredComponents {
+			if !containsComponent(components, deferred.ID) {
+				umCtrl.removeImage(deferred.ID)
+			}
+		}
+
+		umCtrl.connections[i].deferredComponents = nil
+	}
+
+	for _, component := range components {
+		for i, conn := range umCtrl.connections {
+			if conn.hasComponent(component.ID) {
+				umCtrl.connections[i].deferredComponents = append(conn.deferredComponents, component)
+
+				break
+			}
+		}
+	}
+
+	umCtrl.storeUMInfos()
+}
+
+// resumeDeferredComponents starts update of deferred components of connected UMs
+func (umCtrl *Controller) resumeDeferredComponents() {
+	var components []SystemComponent
+
+	for i, conn := range umCtrl.connections {
+		if conn.handler == nil || len(conn.deferredComponents) == 0 {
+			continue
+		}
+
+		log.WithField("umID", conn.umID).Info("Resume deferred components update")
+
+		components = append(components, conn.deferredComponents...)
+		umCtrl.connections[i].deferredComponents = nil
+	}
+
+	if len(components) == 0 {
+		return
+	}
+
+	if err := umCtrl.setUpdateStages(components); err != nil {
+		log.Errorf("Can't resume deferred components update: %s", err)
+
+		for _, component := range components {
+			umCtrl.updateComponentElement(systemComponentStatus{id: component.ID,
+				vendorVersion: component.VendorVersion, aosVersion: component.AosVersion,
+				status: cloudprotocol.ErrorStatus, err: aoserrors.Wrap(err).Error()})
+		}
+
+		umCtrl.resetUpdateStages()
+		umCtrl.storeUMInfos()
+
+		return
+	}
+
+	for _, component := range components {
+		umCtrl.updateComponentElement(systemComponentStatus{id: component.ID,
+			vendorVersion: component.VendorVersion, aosVersion: component.AosVersion,
+			status: cloudprotocol.DownloadedStatus})
+	}
+
+	if err := umCtrl.storage.SetComponentsUpdateInfo(components); err != nil {
+		log.Errorf("Can't store deferred components update: %s", err)
+
+		return
+	}
+
+	// Deferred components are removed after update info is stored to not lose them on CM restart
+	umCtrl.storeUMInfos()
+
+	go umCtrl.generateFSMEvent(evUpdateRequest, nil)
+}
+
+// keepImage keeps local component image in the image dir. Returns URL of the kept image or original URL if the image
+// can't be kept.
+func (umCtrl *Controller) keepImage(id, inURL string) (outURL string) {
+	imageURL, err := url.Parse(inURL)
+	if err != nil || imageURL.Scheme != fileScheme || umCtrl.imageDir == "" {
+		return inURL
+	}
+
+	imagePath := filepath.Join(umCtrl.imageDir, config.KeptImagePrefix+componentImagePrefix+id)
+
+	if imageURL.Path == imagePath {
+		return inURL
+	}
+
+	if err = os.RemoveAll(imagePath); err == nil {
+		// Hard link keeps the image without copying when the original image is removed
+		if err = os.Link(imageURL.Path, imagePath); err != nil {
+			err = copyImage(imageURL.Path, imagePath)
+		}
+	}
+
+	if err != nil {
+		log.WithField("id", id).Errorf("Can't keep component image: %s", err)
+
+		return inURL
+	}
+
+	return (&url.URL{Scheme: fileScheme, Path: imagePath}).String()
+}
+
+func (umCtrl *Controller) removeImage(id string) {
+	if umCtrl.imageDir == "" {
+		return
+	}
+
+	imagePath := filepath.Join(umCtrl.imageDir, config.KeptImagePrefix+componentImagePrefix+id)
+
+	if err := os.RemoveAll(imagePath); err != nil {
+		log.WithField("id", id).Errorf("Can't remove kept component image: %s", err)
+	}
+}
+
+func (conn *umConnection) hasComponent(id string) (result bool) {
+	for _, componentID := range conn.components {
+		if componentID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (umCtrl *Controller) loadUMInfos() (err error) {
+	infos, err := umCtrl.storage.GetUMInfos()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	for _, info := range infos {
+		for i := range umCtrl.connections {
+			if umCtrl.connections[i].umID == info.UMID {
+				umCtrl.connections[i].components = info.Components
+				umCtrl.connections[i].deferredComponents = info.DeferredComponents
+			}
+		}
+	}
+
+	return nil
+}
+
+func (umCtrl *Controller) storeUMInfos() {
+	for _, conn := range umCtrl.connections {
+		if err := umCtrl.storage.SetUMInfo(UMInfo{
+			UMID: conn.umID, Components: conn.components, DeferredComponents: conn.deferredComponents,
+		}); err != nil {
+			log.WithField("umID", conn.umID).Errorf("Can't store UM info: %s", err)
+		}
+	}
+}
+
+func (umCtrl *Controller) setComponentUnavailable(id string) {
+	for i, component := range umCtrl.currentComponents {
+		if component.ID == id && component.Status == cloudprotocol.InstalledStatus {
+			umCtrl.currentComponents[i].Status = cloudprotocol.ErrorStatus
+			umCtrl.currentComponents[i].Error = umUnavailableError
+		}
+	}
+}
+
+func (umCtrl *Controller) handleCloseConnection(umID string, handler *umHandler) {
 	log.Debug("Close UM connection umid = ", umID)
 	for i, value := range umCtrl.connections {
 		if value.umID == umID {
+			// UM may be already reconnected with new handler
+			if value.handler != handler {
+				return
+			}
+
 			umCtrl.connections[i].handler = nil
 
 			umCtrl.fsm.SetState(stateInit)
 			umCtrl.connectionMonitor.wg.Add(1)
-			go umCtrl.connectionMonitor.startConnectionTimer(len(umCtrl.connections))
+			go umCtrl.connectionMonitor.startConnectionTimer(len(umCtrl.connections), umCtrl.connectionTimeout)
 
 			return
 		}
@@ -514,7 +884,9 @@ func (umCtrl *Controller) cleanupCurrentComponentStatus() {
 	i := 0
 
 	for _, component := range umCtrl.currentComponents {
-		if component.Status == cloudprotocol.InstalledStatus || component.Status == cloudprotocol.ErrorStatus {
+		// Pending status is kept for deferred components
+		if component.Status == cloudprotocol.InstalledStatus || component.Status == cloudprotocol.ErrorStatus ||
+			component.Status == cloudprotocol.PendingStatus {
 			umCtrl.currentComponents[i] = component
 			i++
 		}
@@ -555,6 +927,8 @@ func (umCtrl *Controller) getCurrentUpdateState() (state string) {
 }
 
 func (umCtrl *Controller) getUpdateComponentsFromStorage() (err error) {
+	umCtrl.resetUpdateStages()
+
 	for i := range umCtrl.connections {
 		umCtrl.connections[i].updatePackages = []SystemComponent{}
 	}
@@ -564,8 +938,8 @@ func (umCtrl *Controller) getUpdateComponentsFromStorage() (err error) {
 		return aoserrors.Wrap(err)
 	}
 
-	for _, component := range updatecomponents {
-		umCtrl.addComponentForUpdateToUm(component)
+	if err = umCtrl.setUpdateStages(updatecomponents); err != nil {
+		return aoserrors.Wrap(err)
 	}
 
 	return nil
@@ -593,6 +967,8 @@ func (umCtrl *Controller) addComponentForUpdateToUm(componentInfo SystemComponen
 }
 
 func (umCtrl *Controller) cleanupUpdateData() {
+	umCtrl.resetUpdateStages()
+
 	for i := range umCtrl.connections {
 		umCtrl.connections[i].updatePackages = []SystemComponent{}
 	}
@@ -611,6 +987,11 @@ func (umCtrl *Controller) cleanupUpdateData() {
 		log.Error("Can't clean components update info ", err)
 	}
 
+	// Remove images kept for resumed deferred components
+	for _, component := range updatecomponents {
+		umCtrl.removeImage(component.ID)
+	}
+
 	return
 }
 
@@ -627,7 +1008,7 @@ func (umCtrl *Controller) generateFSMEvent(event string, args ...interface{}) (e
 	return aoserrors.Wrap(err)
 }
 
-func (monitor *allConnectionMonitor) startConnectionTimer(connectionsCount int) {
+func (monitor *allConnectionMonitor) startConnectionTimer(connectionsCount int, timeout time.Duration) {
 	monitor.Lock()
 	defer monitor.Unlock()
 
@@ -643,7 +1024,7 @@ func (monitor *allConnectionMonitor) startConnectionTimer(connectionsCount int)
 		return
 	}
 
-	monitor.connTimer = time.NewTimer(connectionTimeout)
+	monitor.connTimer = time.NewTimer(timeout)
 
 	monitor.Unlock()
 
@@ -673,6 +1054,14 @@ func (umCtrl *Controller) onEvent(e *fsm.Event) {
 }
 
 func (umCtrl *Controller) processIdleState(e *fsm.Event) {
+	if missingUMs := umCtrl.getMissingUMs(); len(missingUMs) != 0 && len(umCtrl.updateStages) != 0 {
+		log.Warnf("Interrupted update is postponed until UMs are connected: %v", missingUMs)
+
+		umCtrl.updateFinishCond.Broadcast()
+
+		return
+	}
+
 	umState := umCtrl.getCurrentUpdateState()
 
 	switch umState {
@@ -685,13 +1074,22 @@ func (umCtrl *Controller) processIdleState(e *fsm.Event) {
 		return
 
 	case stateStartApply:
-		go umCtrl.generateFSMEvent(evContinueApply)
+		go umCtrl.generateFSMEvent(evContinueHealthCheck)
+		return
+	}
+
+	if umCtrl.isStageRestored() {
+		log.Debugf("Continue update from stage %d of %d", umCtrl.currentStage+1, len(umCtrl.updateStages))
+
+		go umCtrl.generateFSMEvent(evContinuePrepare)
 		return
 	}
 
 	umCtrl.cleanupUpdateData()
 
 	umCtrl.updateFinishCond.Broadcast()
+
+	umCtrl.resumeDeferredComponents()
 }
 
 func (umCtrl *Controller) processFaultState(e *fsm.Event) {
@@ -731,6 +1129,11 @@ func (umCtrl *Controller) processStartUpdateState(e *fsm.Event) {
 		}
 
 		if umCtrl.connections[i].handler == nil {
+			// Not connected UM without update components doesn't block the update
+			if len(umCtrl.connections[i].updatePackages) == 0 {
+				continue
+			}
+
 			log.Warnf("Connection to um %s closed", umCtrl.connections[i].umID)
 			return
 		}
@@ -786,6 +1189,10 @@ func (umCtrl *Controller) processStartApplyState(e *fsm.Event) {
 		}
 
 		if umCtrl.connections[i].handler == nil {
+			if len(umCtrl.connections[i].updatePackages) == 0 {
+				continue
+			}
+
 			log.Warnf("Connection to um %s closed", umCtrl.connections[i].umID)
 			return
 		}
@@ -795,6 +1202,23 @@ func (umCtrl *Controller) processStartApplyState(e *fsm.Event) {
 		}
 	}
 
+	if umCtrl.hasNextUpdateStage() {
+		// Applied stage can't be reverted: stop the update without starting dependent stages
+		if err := umCtrl.nextUpdateStage(); err != nil {
+			umCtrl.updateError = aoserrors.Wrap(err)
+
+			log.Error("Update error: ", umCtrl.updateError)
+
+			umCtrl.skipNextStages(err.Error())
+
+			go umCtrl.generateFSMEvent(evApplyComplete)
+			return
+		}
+
+		go umCtrl.generateFSMEvent(evStageApplied)
+		return
+	}
+
 	go umCtrl.generateFSMEvent(evApplyComplete)
 }
 
@@ -821,18 +1245,26 @@ func (umCtrl *Controller) processError(e *fsm.Event) {
 
 	log.Error("Update error: ", umCtrl.updateError)
 
+	umCtrl.stopHealthCheckTimer()
+
+	umCtrl.skipNextStages("previous update stage failed")
+
 	umCtrl.cleanupCurrentComponentStatus()
 }
 
 func (umCtrl *Controller) revertComplete(e *fsm.Event) {
 	log.Debug("Revert complete")
 
+	umCtrl.resetUpdateStages()
+	umCtrl.resetProgress()
 	umCtrl.cleanupCurrentComponentStatus()
 }
 
 func (umCtrl *Controller) updateComplete(e *fsm.Event) {
 	log.Debug("Update finished")
 
+	umCtrl.resetUpdateStages()
+	umCtrl.resetProgress()
 	umCtrl.cleanupCurrentComponentStatus()
 }
 
@@ -840,3 +1272,39 @@ func (status systemComponentStatus) String() string {
 	return fmt.Sprintf("{id: %s, status: %s, vendorVersion: %s aosVersion: %d }",
 		status.id, status.status, status.vendorVersion, status.aosVersion)
 }
+
+func containsComponent(components []SystemComponent, id string) (result bool) {
+	for _, component := range components {
+		if component.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func copyImage(srcPath, dstPath string) (err error) {
+	srcFile, err := os.Open(srcPath)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.OpenFile(dstPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if _, err = io.Copy(dstFile, srcFile); err != nil {
+		dstFile.Close()
+		os.Remove(dstPath)
+
+		return aoserrors.Wrap(err)
+	}
+
+	if err = dstFile.Close(); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
@@ -20,6 +20,7 @@ package umcontroller
 import (
 	"io"
 	"os"
+	"reflect"
 	"testing"
 
 	log "github.com/sirupsen/logrus"
@@ -80,6 +81,44 @@ func init() {
 /***********************************************************************************************************************
  * Tests
  **********************************************************************************************************************/
+func TestUpdateStages(t *testing.T) {
+	components := []SystemComponent{
+		{ID: "rootfs", Annotations: `{"dependsOn": ["kernel"]}`},
+		{ID: "app"},
+		{ID: "kernel", Annotations: `{"dependsOn": ["bootloader", "unknown"]}`},
+		{ID: "bootloader", Annotations: `{"other": "value"}`},
+	}
+
+	stages, err := getUpdateStages(components)
+	if err != nil {
+		t.Fatalf("Can't get update stages: %s", err)
+	}
+
+	expectedStages := [][]string{{"app", "bootloader"}, {"kernel"}, {"rootfs"}}
+
+	var stageIDs [][]string
+
+	for _, stage := range stages {
+		var ids []string
+
+		for _, component := range stage {
+			ids = append(ids, component.ID)
+		}
+
+		stageIDs = append(stageIDs, ids)
+	}
+
+	if !reflect.DeepEqual(stageIDs, expectedStages) {
+		t.Errorf("Wrong update stages: %v", stageIDs)
+	}
+
+	components[3].Annotations = `{"dependsOn": ["rootfs"]}`
+
+	if _, err = getUpdateStages(components); err == nil {
+		t.Error("Error expected on cyclic dependency")
+	}
+}
+
 func TestNormalUpdate(t *testing.T) {
 	eventChannel := make(chan umCtrlInternalMsg)
 
@@ -20,11 +20,16 @@ package umcontroller_test
 import (
 	"context"
 	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/aoscloud/aos_common/aoserrors"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 
@@ -44,12 +49,19 @@ const (
 )
 
 type testStorage struct {
+	sync.Mutex
 	updateInfo []umcontroller.SystemComponent
+	umInfos    []umcontroller.UMInfo
 }
 
 type testURLTranslator struct {
 }
 
+type testAlertSender struct {
+	sync.Mutex
+	alerts []cloudprotocol.AlertItem
+}
+
 type testUmConnection struct {
 	stream         pb.UMService_RegisterUMClient
 	notifyTestChan chan bool
@@ -87,7 +99,7 @@ func TestConnection(t *testing.T) {
 	}
 	smConfig := config.Config{UMController: umCtrlConfig}
 
-	umCtrl, err := umcontroller.New(&smConfig, &testStorage{}, &testURLTranslator{}, true)
+	umCtrl, err := umcontroller.New(&smConfig, &testStorage{}, &testURLTranslator{}, nil, true)
 	if err != nil {
 		t.Fatalf("Can't create: UM controller %s", err)
 	}
@@ -124,6 +136,19 @@ func TestConnection(t *testing.T) {
 		t.Errorf("Incorrect count of components %d", len(newComponents))
 	}
 
+	umID, err := umCtrl.GetComponentUM("component3")
+	if err != nil {
+		t.Errorf("Can't get component UM: %s", err)
+	}
+
+	if umID != "umID2" {
+		t.Errorf("Wrong component UM: %s", umID)
+	}
+
+	if _, err = umCtrl.GetComponentUM("unknown"); err == nil {
+		t.Error("Error expected for unknown component")
+	}
+
 	umCtrl.Close()
 
 	streamUM1.CloseSend()
@@ -182,7 +207,7 @@ func TestFullUpdate(t *testing.T) {
 
 	var updateStorage testStorage
 
-	umCtrl, err := umcontroller.New(&smConfig, &updateStorage, &testURLTranslator{}, true)
+	umCtrl, err := umcontroller.New(&smConfig, &updateStorage, &testURLTranslator{}, nil, true)
 	if err != nil {
 		t.Errorf("Can't create: UM controller %s", err)
 	}
@@ -314,7 +339,7 @@ func TestFullUpdateWithDisconnect(t *testing.T) {
 
 	var updateStorage testStorage
 
-	umCtrl, err := umcontroller.New(&smConfig, &updateStorage, &testURLTranslator{}, true)
+	umCtrl, err := umcontroller.New(&smConfig, &updateStorage, &testURLTranslator{}, nil, true)
 	if err != nil {
 		t.Errorf("Can't create: UM controller %s", err)
 	}
@@ -462,7 +487,7 @@ func TestFullUpdateWithReboot(t *testing.T) {
 
 	var updateStorage testStorage
 
-	umCtrl, err := umcontroller.New(&smConfig, &updateStorage, &testURLTranslator{}, true)
+	umCtrl, err := umcontroller.New(&smConfig, &updateStorage, &testURLTranslator{}, nil, true)
 	if err != nil {
 		t.Errorf("Can't create: UM controller %s", err)
 	}
@@ -532,7 +557,7 @@ func TestFullUpdateWithReboot(t *testing.T) {
 	<-um6.notifyTestChan
 	<-finishChannel
 
-	umCtrl, err = umcontroller.New(&smConfig, &updateStorage, &testURLTranslator{}, true)
+	umCtrl, err = umcontroller.New(&smConfig, &updateStorage, &testURLTranslator{}, nil, true)
 	if err != nil {
 		t.Errorf("Can't create: UM controller %s", err)
 	}
@@ -567,7 +592,7 @@ func TestFullUpdateWithReboot(t *testing.T) {
 	<-um5.notifyTestChan
 	<-um6.notifyTestChan
 
-	umCtrl, err = umcontroller.New(&smConfig, &updateStorage, &testURLTranslator{}, true)
+	umCtrl, err = umcontroller.New(&smConfig, &updateStorage, &testURLTranslator{}, nil, true)
 	if err != nil {
 		t.Errorf("Can't create: UM controller %s", err)
 	}
@@ -633,7 +658,7 @@ func TestRevertOnPrepare(t *testing.T) {
 
 	var updateStorage testStorage
 
-	umCtrl, err := umcontroller.New(&smConfig, &updateStorage, &testURLTranslator{}, true)
+	umCtrl, err := umcontroller.New(&smConfig, &updateStorage, &testURLTranslator{}, nil, true)
 	if err != nil {
 		t.Errorf("Can't create: UM controller %s", err)
 	}
@@ -750,7 +775,7 @@ func TestRevertOnUpdate(t *testing.T) {
 
 	var updateStorage testStorage
 
-	umCtrl, err := umcontroller.New(&smConfig, &updateStorage, &testURLTranslator{}, true)
+	umCtrl, err := umcontroller.New(&smConfig, &updateStorage, &testURLTranslator{}, nil, true)
 	if err != nil {
 		t.Errorf("Can't create: UM controller %s", err)
 	}
@@ -885,7 +910,7 @@ func TestRevertOnUpdateWithDisconnect(t *testing.T) {
 
 	var updateStorage testStorage
 
-	umCtrl, err := umcontroller.New(&smConfig, &updateStorage, &testURLTranslator{}, true)
+	umCtrl, err := umcontroller.New(&smConfig, &updateStorage, &testURLTranslator{}, nil, true)
 	if err != nil {
 		t.Errorf("Can't create: UM controller %s", err)
 	}
@@ -1025,7 +1050,7 @@ func TestRevertOnUpdateWithReboot(t *testing.T) {
 
 	var updateStorage testStorage
 
-	umCtrl, err := umcontroller.New(&smConfig, &updateStorage, &testURLTranslator{}, true)
+	umCtrl, err := umcontroller.New(&smConfig, &updateStorage, &testURLTranslator{}, nil, true)
 	if err != nil {
 		t.Errorf("Can't create: UM controller %s", err)
 	}
@@ -1105,7 +1130,7 @@ func TestRevertOnUpdateWithReboot(t *testing.T) {
 	<-finishChannel
 	// um14  reboot
 
-	umCtrl, err = umcontroller.New(&smConfig, &updateStorage, &testURLTranslator{}, true)
+	umCtrl, err = umcontroller.New(&smConfig, &updateStorage, &testURLTranslator{}, nil, true)
 	if err != nil {
 		t.Errorf("Can't create: UM controller %s", err)
 	}
@@ -1170,6 +1195,630 @@ func TestRevertOnUpdateWithReboot(t *testing.T) {
 	time.Sleep(time.Second)
 }
 
+func TestStagedUpdate(t *testing.T) {
+	umCtrlConfig := config.UMController{
+		ServerURL: "localhost:8091",
+		UMClients: []config.UMClientConfig{
+			{UMID: "testUM15", Priority: 1},
+			{UMID: "testUM16", Priority: 10}},
+	}
+
+	smConfig := config.Config{UMController: umCtrlConfig}
+
+	var updateStorage testStorage
+
+	umCtrl, err := umcontroller.New(&smConfig, &updateStorage, &testURLTranslator{}, nil, true)
+	if err != nil {
+		t.Errorf("Can't create: UM controller %s", err)
+	}
+
+	um15Components := []*pb.SystemComponent{
+		{Id: "bootloader", VendorVersion: "1", Status: pb.ComponentStatus_INSTALLED},
+		{Id: "rootfs", VendorVersion: "1", Status: pb.ComponentStatus_INSTALLED}}
+
+	um15 := newTestUM("testUM15", pb.UmState_IDLE, "init", um15Components, t)
+	go um15.processMessages()
+
+	um16Components := []*pb.SystemComponent{
+		{Id: "kernel", VendorVersion: "1", Status: pb.ComponentStatus_INSTALLED}}
+
+	um16 := newTestUM("testUM16", pb.UmState_IDLE, "init", um16Components, t)
+	go um16.processMessages()
+
+	// bootloader -> kernel -> rootfs
+	updateComponents := []cloudprotocol.ComponentInfoFromCloud{
+		{ID: "rootfs", VersionFromCloud: cloudprotocol.VersionFromCloud{VendorVersion: "2"},
+			Annotations:       []byte(`{"dependsOn": ["kernel"]}`),
+			DecryptDataStruct: cloudprotocol.DecryptDataStruct{URLs: []string{"someFile"}}},
+		{ID: "kernel", VersionFromCloud: cloudprotocol.VersionFromCloud{VendorVersion: "2"},
+			Annotations:       []byte(`{"dependsOn": ["bootloader"]}`),
+			DecryptDataStruct: cloudprotocol.DecryptDataStruct{URLs: []string{"someFile"}}},
+		{ID: "bootloader", VersionFromCloud: cloudprotocol.VersionFromCloud{VendorVersion: "2"},
+			DecryptDataStruct: cloudprotocol.DecryptDataStruct{URLs: []string{"someFile"}}},
+	}
+
+	finishChannel := make(chan error)
+
+	go func() {
+		_, err := umCtrl.UpdateComponents(updateComponents)
+		finishChannel <- err
+	}()
+
+	// Stage 1: bootloader
+
+	um15.setComponents(append(um15Components,
+		&pb.SystemComponent{Id: "bootloader", VendorVersion: "2", Status: pb.ComponentStatus_INSTALLING}))
+
+	um15.step = "prepare"
+	um15.continueChan <- true
+	<-um15.notifyTestChan
+	um15.sendState(pb.UmState_PREPARED)
+
+	um15.step = "update"
+	um15.continueChan <- true
+	<-um15.notifyTestChan
+	um15.sendState(pb.UmState_UPDATED)
+
+	um15Components = []*pb.SystemComponent{
+		{Id: "bootloader", VendorVersion: "2", Status: pb.ComponentStatus_INSTALLED},
+		{Id: "rootfs", VendorVersion: "1", Status: pb.ComponentStatus_INSTALLED}}
+	um15.setComponents(um15Components)
+
+	um15.step = "apply"
+	um15.continueChan <- true
+	<-um15.notifyTestChan
+	um15.sendState(pb.UmState_IDLE)
+
+	// Stage 2: kernel fails, only this stage is reverted
+
+	um16.setComponents(append(um16Components,
+		&pb.SystemComponent{Id: "kernel", VendorVersion: "2", Status: pb.ComponentStatus_ERROR}))
+
+	um16.step = "prepare"
+	um16.continueChan <- true
+	<-um16.notifyTestChan
+	um16.sendState(pb.UmState_FAILED)
+
+	um16.setComponents([]*pb.SystemComponent{
+		{Id: "kernel", VendorVersion: "1", Status: pb.ComponentStatus_INSTALLED},
+		{Id: "kernel", VendorVersion: "2", Status: pb.ComponentStatus_ERROR}})
+
+	um16.step = "revert"
+	um16.continueChan <- true
+	<-um16.notifyTestChan
+	um16.sendState(pb.UmState_IDLE)
+
+	um15.step = "finish"
+	um16.step = "finish"
+
+	select {
+	case err = <-finishChannel:
+		if err == nil {
+			t.Error("Update error expected")
+		}
+
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait update finish timeout")
+	}
+
+	etalonComponents := []cloudprotocol.ComponentInfo{
+		{ID: "rootfs", VendorVersion: "1", Status: "installed"},
+		{ID: "kernel", VendorVersion: "1", Status: "installed"},
+		{ID: "rootfs", VendorVersion: "2", Status: "error", Error: "previous update stage failed"},
+		{ID: "kernel", VendorVersion: "2", Status: "error"},
+		{ID: "bootloader", VendorVersion: "2", Status: "installed"}}
+
+	currentComponents, err := umCtrl.GetStatus()
+	if err != nil {
+		t.Fatalf("Can't get components info: %s", err)
+	}
+
+	if !reflect.DeepEqual(etalonComponents, currentComponents) {
+		log.Debug(currentComponents)
+		t.Error("incorrect result component list")
+	}
+
+	um15.closeConnection()
+	um16.closeConnection()
+
+	<-um15.notifyTestChan
+	<-um16.notifyTestChan
+
+	umCtrl.Close()
+
+	time.Sleep(time.Second)
+}
+
+func TestDegradedUpdate(t *testing.T) {
+	umCtrlConfig := config.UMController{
+		ServerURL: "localhost:8091",
+		UMClients: []config.UMClientConfig{
+			{UMID: "testUM17", Priority: 1},
+			{UMID: "testUM18", Priority: 10}},
+		ConnectionTimeout: config.Duration{Duration: 2 * time.Second},
+	}
+
+	decryptDir, err := ioutil.TempDir("", "um_")
+	if err != nil {
+		t.Fatalf("Can't create decrypt dir: %s", err)
+	}
+	defer os.RemoveAll(decryptDir)
+
+	um18Image := filepath.Join(decryptDir, "um18C1.dec")
+
+	if err = ioutil.WriteFile(um18Image, []byte("image"), 0644); err != nil {
+		t.Fatalf("Can't create image: %s", err)
+	}
+
+	smConfig := config.Config{UMController: umCtrlConfig, Downloader: config.Downloader{DecryptDir: decryptDir}}
+
+	// testUM18 components are known from the previous connection
+	updateStorage := testStorage{umInfos: []umcontroller.UMInfo{{UMID: "testUM18", Components: []string{"um18C1"}}}}
+
+	umCtrl, err := umcontroller.New(&smConfig, &updateStorage, &testURLTranslator{}, nil, true)
+	if err != nil {
+		t.Errorf("Can't create: UM controller %s", err)
+	}
+
+	um17Components := []*pb.SystemComponent{
+		{Id: "um17C1", VendorVersion: "1", Status: pb.ComponentStatus_INSTALLED}}
+
+	um17 := newTestUM("testUM17", pb.UmState_IDLE, "init", um17Components, t)
+	go um17.processMessages()
+
+	// testUM18 is not connected: update available components after connection timeout
+
+	currentComponents, err := umCtrl.GetStatus()
+	if err != nil {
+		t.Fatalf("Can't get components info: %s", err)
+	}
+
+	if !reflect.DeepEqual(currentComponents, []cloudprotocol.ComponentInfo{
+		{ID: "um17C1", VendorVersion: "1", Status: "installed"}}) {
+		t.Errorf("Wrong components info: %v", currentComponents)
+	}
+
+	// Unknown component can't be updated
+
+	if _, err = umCtrl.UpdateComponents([]cloudprotocol.ComponentInfoFromCloud{
+		{ID: "unknownC1", VersionFromCloud: cloudprotocol.VersionFromCloud{VendorVersion: "2"},
+			DecryptDataStruct: cloudprotocol.DecryptDataStruct{URLs: []string{"someFile"}}},
+	}); err == nil {
+		t.Error("Error expected for unknown component")
+	}
+
+	updateComponents := []cloudprotocol.ComponentInfoFromCloud{
+		{ID: "um17C1", VersionFromCloud: cloudprotocol.VersionFromCloud{VendorVersion: "2"},
+			DecryptDataStruct: cloudprotocol.DecryptDataStruct{URLs: []string{"someFile"}}},
+		{ID: "um18C1", VersionFromCloud: cloudprotocol.VersionFromCloud{VendorVersion: "2"},
+			DecryptDataStruct: cloudprotocol.DecryptDataStruct{URLs: []string{"file://" + um18Image}}},
+	}
+
+	finishChannel := make(chan error)
+
+	go func() {
+		_, err := umCtrl.UpdateComponents(updateComponents)
+		finishChannel <- err
+	}()
+
+	um17.setComponents(append(um17Components,
+		&pb.SystemComponent{Id: "um17C1", VendorVersion: "2", Status: pb.ComponentStatus_INSTALLING}))
+
+	um17.step = "prepare"
+	um17.continueChan <- true
+	<-um17.notifyTestChan
+	um17.sendState(pb.UmState_PREPARED)
+
+	um17.step = "update"
+	um17.continueChan <- true
+	<-um17.notifyTestChan
+	um17.sendState(pb.UmState_UPDATED)
+
+	um17.setComponents([]*pb.SystemComponent{
+		{Id: "um17C1", VendorVersion: "2", Status: pb.ComponentStatus_INSTALLED}})
+
+	um17.step = "apply"
+	um17.continueChan <- true
+	<-um17.notifyTestChan
+	um17.sendState(pb.UmState_IDLE)
+
+	select {
+	case err = <-finishChannel:
+		if err != nil {
+			t.Errorf("Update error: %s", err)
+		}
+
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait update finish timeout")
+	}
+
+	if currentComponents, err = umCtrl.GetStatus(); err != nil {
+		t.Fatalf("Can't get components info: %s", err)
+	}
+
+	if !reflect.DeepEqual(currentComponents, []cloudprotocol.ComponentInfo{
+		{ID: "um18C1", VendorVersion: "2", Status: "pending",
+			Error: "update manager is not connected, update is deferred"},
+		{ID: "um17C1", VendorVersion: "2", Status: "installed"}}) {
+		t.Errorf("Wrong components info: %v", currentComponents)
+	}
+
+	if deferred := updateStorage.getDeferredComponents("testUM18"); len(deferred) != 1 ||
+		deferred[0].ID != "um18C1" || deferred[0].VendorVersion != "2" {
+		t.Errorf("Wrong deferred components: %v", deferred)
+	}
+
+	// Downloaded image is removed after update, deferred component image should be kept
+
+	if err = os.Remove(um18Image); err != nil {
+		t.Fatalf("Can't remove image: %s", err)
+	}
+
+	keptImage := filepath.Join(decryptDir, config.KeptImagePrefix+"component_um18C1")
+
+	if deferred := updateStorage.getDeferredComponents("testUM18"); len(deferred) != 1 ||
+		deferred[0].URL != "file://"+keptImage {
+		t.Errorf("Wrong deferred components: %v", deferred)
+	}
+
+	if _, err = os.Stat(keptImage); err != nil {
+		t.Errorf("Deferred component image is not kept: %s", err)
+	}
+
+	// Late UM registration resumes deferred update
+
+	um18Components := []*pb.SystemComponent{
+		{Id: "um18C1", VendorVersion: "1", Status: pb.ComponentStatus_INSTALLED}}
+
+	um18 := newTestUM("testUM18", pb.UmState_IDLE, "init", um18Components, t)
+	go um18.processMessages()
+
+	um18.setComponents(append(um18Components,
+		&pb.SystemComponent{Id: "um18C1", VendorVersion: "2", Status: pb.ComponentStatus_INSTALLING}))
+
+	um18.step = "prepare"
+	um18.continueChan <- true
+	<-um18.notifyTestChan
+	um18.sendState(pb.UmState_PREPARED)
+
+	um18.step = "update"
+	um18.continueChan <- true
+	<-um18.notifyTestChan
+	um18.sendState(pb.UmState_UPDATED)
+
+	um18.setComponents([]*pb.SystemComponent{
+		{Id: "um18C1", VendorVersion: "2", Status: pb.ComponentStatus_INSTALLED}})
+
+	um18.step = "apply"
+	um18.continueChan <- true
+	<-um18.notifyTestChan
+	um18.sendState(pb.UmState_IDLE)
+
+	expectedComponents := []cloudprotocol.ComponentInfo{
+		{ID: "um18C1", VendorVersion: "2", Status: "installed"},
+		{ID: "um17C1", VendorVersion: "2", Status: "installed"}}
+
+	timeout := time.After(5 * time.Second)
+
+	for !reflect.DeepEqual(currentComponents, expectedComponents) {
+		select {
+		case <-timeout:
+			t.Fatalf("Wrong components info: %v", currentComponents)
+
+		case <-time.After(100 * time.Millisecond):
+			if currentComponents, err = umCtrl.GetStatus(); err != nil {
+				t.Fatalf("Can't get components info: %s", err)
+			}
+		}
+	}
+
+	if deferred := updateStorage.getDeferredComponents("testUM18"); len(deferred) != 0 {
+		t.Errorf("Wrong deferred components: %v", deferred)
+	}
+
+	// Kept image should be removed after deferred component is updated
+
+	timeout = time.After(5 * time.Second)
+
+	for _, err = os.Stat(keptImage); !os.IsNotExist(err); _, err = os.Stat(keptImage) {
+		select {
+		case <-timeout:
+			t.Fatalf("Kept image is not removed: %v", err)
+
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	um17.step = "finish"
+	um18.step = "finish"
+
+	um17.closeConnection()
+	um18.closeConnection()
+
+	<-um17.notifyTestChan
+	<-um18.notifyTestChan
+
+	umCtrl.Close()
+
+	time.Sleep(time.Second)
+}
+
+func TestUpdateProgress(t *testing.T) {
+	umCtrlConfig := config.UMController{
+		ServerURL: "localhost:8091",
+		UMClients: []config.UMClientConfig{{UMID: "testUM19", Priority: 1}},
+	}
+
+	smConfig := config.Config{UMController: umCtrlConfig}
+
+	var updateStorage testStorage
+
+	umCtrl, err := umcontroller.New(&smConfig, &updateStorage, &testURLTranslator{}, nil, true)
+	if err != nil {
+		t.Fatalf("Can't create: UM controller %s", err)
+	}
+
+	um19Components := []*pb.SystemComponent{
+		{Id: "um19C1", VendorVersion: "1", Status: pb.ComponentStatus_INSTALLED}}
+
+	um19 := newTestUM("testUM19", pb.UmState_IDLE, "init", um19Components, t)
+	go um19.processMessages()
+
+	updateComponents := []cloudprotocol.ComponentInfoFromCloud{
+		{ID: "um19C1", VersionFromCloud: cloudprotocol.VersionFromCloud{VendorVersion: "2"},
+			DecryptDataStruct: cloudprotocol.DecryptDataStruct{URLs: []string{"someFile"}}},
+	}
+
+	finishChannel := make(chan bool)
+
+	go func() {
+		umCtrl.UpdateComponents(updateComponents)
+		finishChannel <- true
+	}()
+
+	um19Components = append(um19Components,
+		&pb.SystemComponent{Id: "um19C1", VendorVersion: "2", Status: pb.ComponentStatus_INSTALLING})
+	um19.setComponents(um19Components)
+
+	um19.step = "prepare"
+	um19.continueChan <- true
+	<-um19.notifyTestChan
+
+	um19.sendStatusNotification(pb.UmState_IDLE, &pb.UpdateStatus{Progress: []*pb.ComponentProgress{
+		{Id: "um19C1", VendorVersion: "2", Percentage: 40, Phase: "flashing"},
+		{Id: "unknown", VendorVersion: "1", Percentage: 10, Phase: "flashing"}}})
+
+	if err = waitProgress(umCtrl, []cloudprotocol.ComponentProgress{
+		{ID: "um19C1", VendorVersion: "2", Percentage: 40, Phase: "flashing"}}); err != nil {
+		t.Errorf("Wrong update progress: %s", err)
+	}
+
+	um19.sendState(pb.UmState_PREPARED)
+
+	um19.step = "update"
+	um19.continueChan <- true
+	<-um19.notifyTestChan
+	um19.sendState(pb.UmState_UPDATED)
+
+	um19Components = []*pb.SystemComponent{
+		{Id: "um19C1", VendorVersion: "2", Status: pb.ComponentStatus_INSTALLED}}
+	um19.setComponents(um19Components)
+
+	um19.step = "apply"
+	um19.continueChan <- true
+	<-um19.notifyTestChan
+	um19.sendState(pb.UmState_IDLE)
+
+	<-finishChannel
+
+	if err = waitProgress(umCtrl, []cloudprotocol.ComponentProgress{}); err != nil {
+		t.Errorf("Progress is not reset: %s", err)
+	}
+
+	um19.step = "finish"
+
+	um19.closeConnection()
+
+	<-um19.notifyTestChan
+
+	umCtrl.Close()
+
+	time.Sleep(time.Second)
+}
+
+func TestHealthCheck(t *testing.T) {
+	umCtrlConfig := config.UMController{
+		ServerURL:          "localhost:8091",
+		UMClients:          []config.UMClientConfig{{UMID: "testUM20", Priority: 1, HealthCheck: true}},
+		HealthCheckTimeout: config.Duration{Duration: 2 * time.Second},
+	}
+
+	smConfig := config.Config{UMController: umCtrlConfig}
+
+	var updateStorage testStorage
+
+	umCtrl, err := umcontroller.New(&smConfig, &updateStorage, &testURLTranslator{}, nil, true)
+	if err != nil {
+		t.Fatalf("Can't create: UM controller %s", err)
+	}
+
+	installedComponents := []*pb.SystemComponent{
+		{Id: "um20C1", VendorVersion: "1", Status: pb.ComponentStatus_INSTALLED}}
+
+	um20 := newTestUM("testUM20", pb.UmState_IDLE, "init", installedComponents, t)
+	go um20.processMessages()
+
+	data := []struct {
+		vendorVersion      string
+		health             *pb.UMHealth
+		expectedError      string
+		expectedComponents []cloudprotocol.ComponentInfo
+	}{
+		{
+			vendorVersion: "2",
+			health:        &pb.UMHealth{Healthy: true},
+			expectedComponents: []cloudprotocol.ComponentInfo{
+				{ID: "um20C1", VendorVersion: "2", Status: "installed"}},
+		},
+		{
+			vendorVersion: "3",
+			health:        &pb.UMHealth{Healthy: false, Error: "watchdog reset"},
+			expectedError: "watchdog reset",
+			expectedComponents: []cloudprotocol.ComponentInfo{
+				{ID: "um20C1", VendorVersion: "2", Status: "installed"}},
+		},
+		{
+			vendorVersion: "4",
+			expectedError: "health check timeout",
+			expectedComponents: []cloudprotocol.ComponentInfo{
+				{ID: "um20C1", VendorVersion: "2", Status: "installed"}},
+		},
+	}
+
+	for _, item := range data {
+		updateComponents := []cloudprotocol.ComponentInfoFromCloud{
+			{ID: "um20C1", VersionFromCloud: cloudprotocol.VersionFromCloud{VendorVersion: item.vendorVersion},
+				DecryptDataStruct: cloudprotocol.DecryptDataStruct{URLs: []string{"someFile"}}},
+		}
+
+		finishChannel := make(chan error)
+
+		go func() {
+			_, err := umCtrl.UpdateComponents(updateComponents)
+			finishChannel <- err
+		}()
+
+		um20.setComponents(append(installedComponents, &pb.SystemComponent{
+			Id: "um20C1", VendorVersion: item.vendorVersion, Status: pb.ComponentStatus_INSTALLING}))
+
+		um20.step = "prepare"
+		um20.continueChan <- true
+		<-um20.notifyTestChan
+		um20.sendState(pb.UmState_PREPARED)
+
+		um20.step = "update"
+		um20.continueChan <- true
+		<-um20.notifyTestChan
+		um20.sendState(pb.UmState_UPDATED)
+
+		if item.health != nil {
+			um20.sendStatusNotification(pb.UmState_UPDATED, &pb.UpdateStatus{Health: item.health})
+		}
+
+		if item.expectedError == "" {
+			installedComponents = []*pb.SystemComponent{
+				{Id: "um20C1", VendorVersion: item.vendorVersion, Status: pb.ComponentStatus_INSTALLED}}
+			um20.setComponents(installedComponents)
+
+			um20.step = "apply"
+		} else {
+			um20.setComponents(installedComponents)
+
+			um20.step = "revert"
+		}
+
+		um20.continueChan <- true
+		<-um20.notifyTestChan
+		um20.sendState(pb.UmState_IDLE)
+
+		err = <-finishChannel
+
+		if item.expectedError == "" && err != nil {
+			t.Errorf("Update failed: %s", err)
+		}
+
+		if item.expectedError != "" && (err == nil || !strings.Contains(err.Error(), item.expectedError)) {
+			t.Errorf("Wrong update error: %v", err)
+		}
+
+		currentComponents, err := umCtrl.GetStatus()
+		if err != nil {
+			t.Fatalf("Can't get components info: %s", err)
+		}
+
+		if !reflect.DeepEqual(currentComponents, item.expectedComponents) {
+			t.Errorf("Wrong components info: %v", currentComponents)
+		}
+
+		if len(updateStorage.updateInfo) != 0 {
+			t.Errorf("Update info is not cleared: %v", updateStorage.updateInfo)
+		}
+	}
+
+	um20.step = "finish"
+
+	um20.closeConnection()
+
+	<-um20.notifyTestChan
+
+	umCtrl.Close()
+
+	time.Sleep(time.Second)
+}
+
+func TestRejectRegistration(t *testing.T) {
+	umCtrlConfig := config.UMController{
+		ServerURL: "localhost:8091",
+		UMClients: []config.UMClientConfig{{UMID: "testUM21", Priority: 1}},
+	}
+
+	smConfig := config.Config{UMController: umCtrlConfig}
+
+	alertSender := &testAlertSender{}
+
+	umCtrl, err := umcontroller.New(&smConfig, &testStorage{}, &testURLTranslator{}, alertSender, true)
+	if err != nil {
+		t.Fatalf("Can't create: UM controller %s", err)
+	}
+
+	components := []*pb.SystemComponent{{Id: "um21C1", VendorVersion: "1", Status: pb.ComponentStatus_INSTALLED}}
+
+	um21 := newTestUM("testUM21", pb.UmState_IDLE, "init", components, t)
+	go um21.processMessages()
+
+	for _, umID := range []string{"unknownUM", "testUM21"} {
+		stream, conn, err := createClientConnection(umID, pb.UmState_IDLE, components)
+		if err != nil {
+			t.Fatalf("Error connect %s", err)
+		}
+
+		if _, err = stream.Recv(); err == nil {
+			t.Errorf("UM %s registration should be rejected", umID)
+		}
+
+		conn.Close()
+
+		if alerts := alertSender.getAlerts(); len(alerts) == 0 ||
+			!strings.Contains(alerts[len(alerts)-1].Payload.(cloudprotocol.SystemAlert).Message, "UM "+umID) {
+			t.Errorf("Wrong alerts: %v", alerts)
+		}
+	}
+
+	if alerts := alertSender.getAlerts(); len(alerts) != 2 {
+		t.Errorf("Wrong alerts count: %d", len(alerts))
+	}
+
+	currentComponents, err := umCtrl.GetStatus()
+	if err != nil {
+		t.Fatalf("Can't get components info: %s", err)
+	}
+
+	if !reflect.DeepEqual(currentComponents, []cloudprotocol.ComponentInfo{
+		{ID: "um21C1", VendorVersion: "1", Status: "installed"}}) {
+		t.Errorf("Wrong components info: %v", currentComponents)
+	}
+
+	um21.step = "finish"
+
+	um21.closeConnection()
+
+	<-um21.notifyTestChan
+
+	umCtrl.Close()
+
+	time.Sleep(time.Second)
+}
+
 /*******************************************************************************
  * Interfaces
  ******************************************************************************/
@@ -1183,6 +1832,43 @@ func (storage *testStorage) SetComponentsUpdateInfo(updateInfo []umcontroller.Sy
 	return err
 }
 
+func (storage *testStorage) SetUMInfo(info umcontroller.UMInfo) (err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	for i, storedInfo := range storage.umInfos {
+		if storedInfo.UMID == info.UMID {
+			storage.umInfos[i] = info
+
+			return nil
+		}
+	}
+
+	storage.umInfos = append(storage.umInfos, info)
+
+	return nil
+}
+
+func (storage *testStorage) GetUMInfos() (infos []umcontroller.UMInfo, err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	return append(infos, storage.umInfos...), nil
+}
+
+func (storage *testStorage) getDeferredComponents(umID string) (components []umcontroller.SystemComponent) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	for _, info := range storage.umInfos {
+		if info.UMID == umID {
+			return info.DeferredComponents
+		}
+	}
+
+	return nil
+}
+
 func (um *testUmConnection) processMessages() {
 	defer func() { um.notifyTestChan <- true }()
 	for {
@@ -1271,12 +1957,51 @@ func (um *testUmConnection) sendState(state pb.UmState) {
 	}
 }
 
+func (um *testUmConnection) sendStatusNotification(state pb.UmState, notification *pb.UpdateStatus) {
+	umMsg := &pb.UpdateStatus{UmId: um.umId, UmState: state, Components: um.components,
+		Progress: notification.Progress, Health: notification.Health}
+
+	if err := um.stream.Send(umMsg); err != nil {
+		um.test.Errorf("Fail send update status message %s", err)
+	}
+}
+
 func (um *testUmConnection) closeConnection() {
 	um.continueChan <- true
 	um.conn.Close()
 	um.stream.CloseSend()
 }
 
+func (sender *testAlertSender) SendAlert(alert cloudprotocol.AlertItem) (err error) {
+	sender.Lock()
+	defer sender.Unlock()
+
+	sender.alerts = append(sender.alerts, alert)
+
+	return nil
+}
+
+func (sender *testAlertSender) getAlerts() (alerts []cloudprotocol.AlertItem) {
+	sender.Lock()
+	defer sender.Unlock()
+
+	return append(alerts, sender.alerts...)
+}
+
+func waitProgress(umCtrl *umcontroller.Controller, expectedProgress []cloudprotocol.ComponentProgress) (err error) {
+	select {
+	case progress := <-umCtrl.GetProgressChannel():
+		if !reflect.DeepEqual(progress, expectedProgress) {
+			return aoserrors.Errorf("wrong progress: %v", progress)
+		}
+
+		return nil
+
+	case <-time.After(5 * time.Second):
+		return aoserrors.New("wait progress timeout")
+	}
+}
+
 func (translator *testURLTranslator) TranslateURL(isLocal bool, inURL string) (outURL string, err error) {
 	return "file://" + inURL, nil
 }
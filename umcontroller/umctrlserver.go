@@ -18,9 +18,12 @@
 package umcontroller
 
 import (
+	"context"
 	"io"
 	"net"
 	"strings"
+	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
@@ -30,21 +33,37 @@ import (
 	pb "github.com/aoscloud/aos_common/api/updatemanager/v1"
 	"github.com/aoscloud/aos_common/utils/cryptutils"
 
+	"aos_communicationmanager/cloudprotocol"
 	"aos_communicationmanager/config"
+	"aos_communicationmanager/grpcauth"
 )
 
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Previous connection of rebooted UM may be not closed yet
+const duplicateRegistrationTimeout = 1 * time.Second
+
+const alertSource = "CM"
+
 /***********************************************************************************************************************
  * Types
  **********************************************************************************************************************/
 
 // UmCtrlServer gRPC update managers controller server
 type umCtrlServer struct {
+	sync.Mutex
 	pb.UnimplementedUMServiceServer
 
-	url          string
-	grpcServer   *grpc.Server
-	listener     net.Listener
-	controllerCh chan umCtrlInternalMsg
+	url           string
+	insecure      bool
+	grpcServer    *grpc.Server
+	listener      net.Listener
+	controllerCh  chan umCtrlInternalMsg
+	alertSender   AlertSender
+	commonNames   map[string]string
+	registrations map[string]context.Context
 }
 
 /***********************************************************************************************************************
@@ -52,9 +71,26 @@ type umCtrlServer struct {
  **********************************************************************************************************************/
 
 // NewServer create update controller server
-func newServer(cfg *config.Config, ch chan umCtrlInternalMsg, insecure bool) (server *umCtrlServer, err error) {
+func newServer(cfg *config.Config, ch chan umCtrlInternalMsg, alertSender AlertSender,
+	insecure bool) (server *umCtrlServer, err error) {
 	log.WithField("host", cfg.UMController.ServerURL).Debug("Start UM server")
-	server = &umCtrlServer{controllerCh: ch}
+	server = &umCtrlServer{
+		controllerCh:  ch,
+		insecure:      insecure,
+		alertSender:   alertSender,
+		commonNames:   make(map[string]string),
+		registrations: make(map[string]context.Context),
+	}
+
+	// Only configured UMs are allowed to register. UM certificate common name should match UM ID if other is
+	// not configured.
+	for _, client := range cfg.UMController.UMClients {
+		server.commonNames[client.UMID] = client.UMID
+
+		if client.CommonName != "" {
+			server.commonNames[client.UMID] = client.CommonName
+		}
+	}
 
 	var opts []grpc.ServerOption
 
@@ -114,6 +150,18 @@ func (server *umCtrlServer) RegisterUM(stream pb.UMService_RegisterUMServer) (er
 
 	log.Debugf("Register UM id %s status %s", statusMsg.GetUmId(), statusMsg.GetUmState().String())
 
+	if err = server.authenticateUM(stream.Context(), statusMsg.GetUmId()); err != nil {
+		server.rejectUM(statusMsg.GetUmId(), err)
+		return aoserrors.Wrap(err)
+	}
+
+	if err = server.addRegistration(stream.Context(), statusMsg.GetUmId()); err != nil {
+		server.rejectUM(statusMsg.GetUmId(), err)
+		return aoserrors.Wrap(err)
+	}
+
+	defer server.removeRegistration(stream.Context(), statusMsg.GetUmId())
+
 	handler, ch, err := newUmHandler(statusMsg.GetUmId(), stream, server.controllerCh, statusMsg.GetUmState())
 	if err != nil {
 		return aoserrors.Wrap(err)
@@ -133,6 +181,7 @@ func (server *umCtrlServer) RegisterUM(stream pb.UMService_RegisterUMServer) (er
 
 	closeConnectionMsg := umCtrlInternalMsg{
 		umID:        statusMsg.GetUmId(),
+		handler:     handler,
 		requestType: closeConnection,
 	}
 	server.controllerCh <- closeConnectionMsg
@@ -140,6 +189,76 @@ func (server *umCtrlServer) RegisterUM(stream pb.UMService_RegisterUMServer) (er
 	return nil
 }
 
+// authenticateUM checks that UM is configured and its certificate identity matches UM ID
+func (server *umCtrlServer) authenticateUM(ctx context.Context, umID string) (err error) {
+	commonName, ok := server.commonNames[umID]
+	if !ok {
+		return aoserrors.Errorf("unknown UM %s", umID)
+	}
+
+	if server.insecure {
+		return nil
+	}
+
+	if peerCommonName := grpcauth.GetPeerCommonName(ctx); peerCommonName != commonName {
+		return aoserrors.Errorf("certificate common name %s doesn't match UM %s", peerCommonName, umID)
+	}
+
+	return nil
+}
+
+// addRegistration registers UM stream. Registration is rejected if other stream of this UM is still active.
+func (server *umCtrlServer) addRegistration(ctx context.Context, umID string) (err error) {
+	timeout := time.After(duplicateRegistrationTimeout)
+
+	for {
+		server.Lock()
+
+		registration, ok := server.registrations[umID]
+		if !ok || registration.Err() != nil {
+			server.registrations[umID] = ctx
+			server.Unlock()
+
+			return nil
+		}
+
+		server.Unlock()
+
+		select {
+		case <-registration.Done():
+
+		case <-timeout:
+			return aoserrors.Errorf("UM %s is already registered", umID)
+		}
+	}
+}
+
+func (server *umCtrlServer) removeRegistration(ctx context.Context, umID string) {
+	server.Lock()
+	defer server.Unlock()
+
+	if server.registrations[umID] == ctx {
+		delete(server.registrations, umID)
+	}
+}
+
+func (server *umCtrlServer) rejectUM(umID string, err error) {
+	log.WithField("umID", umID).Errorf("UM rejected: %s", err)
+
+	if server.alertSender == nil {
+		return
+	}
+
+	if alertErr := server.alertSender.SendAlert(cloudprotocol.AlertItem{
+		Timestamp: time.Now(),
+		Tag:       cloudprotocol.AlertTagAosCore,
+		Source:    alertSource,
+		Payload:   cloudprotocol.SystemAlert{Message: "UM " + umID + " rejected: " + err.Error()},
+	}); alertErr != nil {
+		log.Errorf("Can't send alert: %s", alertErr)
+	}
+}
+
 func getUmStatusFromUmMessage(msg *pb.UpdateStatus) (status umStatus) {
 	status.umState = msg.GetUmState().String()
 
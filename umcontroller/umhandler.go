@@ -25,6 +25,8 @@ import (
 
 	"github.com/aoscloud/aos_common/aoserrors"
 	pb "github.com/aoscloud/aos_common/api/updatemanager/v1"
+
+	"aos_communicationmanager/cloudprotocol"
 )
 
 /***********************************************************************************************************************
@@ -190,6 +192,10 @@ func (handler *umHandler) receiveData() {
 			return
 		}
 
+		if handler.handleStatusNotification(statusMsg) {
+			continue
+		}
+
 		var evt string
 		state := statusMsg.GetUmState()
 		switch state {
@@ -212,6 +218,39 @@ func (handler *umHandler) receiveData() {
 	}
 }
 
+// handleStatusNotification forwards progress and health of update status message. Returns true if the message
+// is a notification and shouldn't be processed as UM state change.
+func (handler *umHandler) handleStatusNotification(statusMsg *pb.UpdateStatus) (handled bool) {
+	if len(statusMsg.GetProgress()) != 0 {
+		progressMsg := umCtrlInternalMsg{umID: handler.umID, requestType: umProgressUpdate}
+
+		for _, component := range statusMsg.GetProgress() {
+			progressMsg.progress = append(progressMsg.progress, cloudprotocol.ComponentProgress{
+				ID:            component.GetId(),
+				VendorVersion: component.GetVendorVersion(),
+				Percentage:    component.GetPercentage(),
+				Phase:         component.GetPhase(),
+			})
+		}
+
+		handler.messageChannel <- progressMsg
+
+		handled = true
+	}
+
+	if health := statusMsg.GetHealth(); health != nil {
+		handler.messageChannel <- umCtrlInternalMsg{
+			umID:        handler.umID,
+			requestType: umHealthUpdate,
+			health:      umHealth{healthy: health.GetHealthy(), err: health.GetError()},
+		}
+
+		handled = true
+	}
+
+	return handled
+}
+
 func (handler *umHandler) sendPrepareUpdateRequest(e *fsm.Event) {
 	log.Debug("Send prepare request for UMID = ", handler.umID)
 
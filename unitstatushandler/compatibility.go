@@ -0,0 +1,271 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitstatushandler
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aoscloud/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"aos_communicationmanager/cloudprotocol"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const incompatibleUpdateError = "update rejected: desired components are incompatible"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// componentAnnotations component annotations used by unit status handler
+type componentAnnotations struct {
+	// Compatibility constraints in format "<component id> <operator> <version>", e.g. "bootloader >= 3.2"
+	Requires []string `json:"requires,omitempty"`
+}
+
+// installedAnnotations annotations of installed component version
+type installedAnnotations struct {
+	VendorVersion string          `json:"vendorVersion"`
+	Annotations   json.RawMessage `json:"annotations,omitempty"`
+}
+
+type componentConstraint struct {
+	id       string
+	operator string
+	version  string
+}
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+var constraintOperators = map[string]func(result int) bool{
+	"=":  func(result int) bool { return result == 0 },
+	"==": func(result int) bool { return result == 0 },
+	"!=": func(result int) bool { return result != 0 },
+	">":  func(result int) bool { return result > 0 },
+	">=": func(result int) bool { return result >= 0 },
+	"<":  func(result int) bool { return result < 0 },
+	"<=": func(result int) bool { return result <= 0 },
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// getCompatibilityErrors evaluates constraints of desired components and installed components which are not in
+// desired set against installed components replaced by desired ones. Constraints of installed components are taken
+// from annotations stored for installed versions. Errors are returned only for updated components: a component
+// which constraint is violated or a component which version violates the constraint.
+func getCompatibilityErrors(desiredComponents []cloudprotocol.ComponentInfoFromCloud,
+	installedComponents []cloudprotocol.ComponentInfo, updateComponents []cloudprotocol.ComponentInfoFromCloud,
+	annotations map[string]installedAnnotations) (componentErrors map[string]string) {
+	componentErrors = make(map[string]string)
+	versions := make(map[string]string)
+	updated := make(map[string]bool)
+	checkComponents := make([]cloudprotocol.ComponentInfoFromCloud, 0, len(desiredComponents))
+
+	for _, component := range desiredComponents {
+		versions[component.ID] = component.VendorVersion
+		checkComponents = append(checkComponents, component)
+	}
+
+	for _, component := range installedComponents {
+		if component.Status != cloudprotocol.InstalledStatus {
+			continue
+		}
+
+		if _, ok := versions[component.ID]; ok {
+			continue
+		}
+
+		versions[component.ID] = component.VendorVersion
+
+		if installed, ok := annotations[component.ID]; ok && installed.VendorVersion == component.VendorVersion {
+			checkComponent := cloudprotocol.ComponentInfoFromCloud{ID: component.ID}
+
+			checkComponent.VendorVersion = component.VendorVersion
+			checkComponent.Annotations = installed.Annotations
+
+			checkComponents = append(checkComponents, checkComponent)
+		}
+	}
+
+	for _, component := range updateComponents {
+		updated[component.ID] = true
+	}
+
+	setError := func(id, componentErr string) {
+		if _, ok := componentErrors[id]; updated[id] && !ok {
+			componentErrors[id] = componentErr
+		}
+	}
+
+	for _, component := range checkComponents {
+		constraints, err := getComponentConstraints(component.Annotations)
+		if err != nil {
+			setError(component.ID, aoserrors.Wrap(err).Error())
+			continue
+		}
+
+		for _, constraint := range constraints {
+			version, ok := versions[constraint.id]
+			if ok && constraint.isSatisfied(version) {
+				continue
+			}
+
+			componentErr := fmt.Sprintf("%s %s requires %s", component.ID, component.VendorVersion, constraint)
+
+			if ok {
+				componentErr = componentErr + ", found " + version
+			} else {
+				componentErr = componentErr + ", component is not installed"
+			}
+
+			if !updated[component.ID] && !updated[constraint.id] {
+				log.WithField("id", component.ID).Warnf("Installed components are incompatible: %s", componentErr)
+				continue
+			}
+
+			setError(component.ID, componentErr)
+			setError(constraint.id, componentErr)
+		}
+	}
+
+	return componentErrors
+}
+
+func getComponentConstraints(annotations json.RawMessage) (constraints []componentConstraint, err error) {
+	if len(annotations) == 0 {
+		return nil, nil
+	}
+
+	var componentAnnotations componentAnnotations
+
+	if err = json.Unmarshal(annotations, &componentAnnotations); err != nil {
+		return nil, aoserrors.Errorf("can't parse annotations: %s", err)
+	}
+
+	for _, item := range componentAnnotations.Requires {
+		fields := strings.Fields(item)
+
+		if len(fields) != 3 {
+			return nil, aoserrors.Errorf("wrong constraint format: %s", item)
+		}
+
+		if _, ok := constraintOperators[fields[1]]; !ok {
+			return nil, aoserrors.Errorf("wrong constraint operator: %s", item)
+		}
+
+		constraints = append(constraints, componentConstraint{id: fields[0], operator: fields[1], version: fields[2]})
+	}
+
+	return constraints, nil
+}
+
+func (constraint componentConstraint) isSatisfied(version string) (result bool) {
+	return constraintOperators[constraint.operator](compareVersions(version, constraint.version))
+}
+
+func (constraint componentConstraint) String() string {
+	return constraint.id + " " + constraint.operator + " " + constraint.version
+}
+
+// compareVersions compares versions in format "<release>[-<pre-release>][+<build>]". Release and pre-release are
+// dot separated: numeric parts are compared as numbers, other parts as strings. Missing release parts are treated
+// as zero, so "3.2" is equal to "3.2.0". Pre-release version precedes its release, so "3.10-rc1" is greater than
+// "3.9" and less than "3.10". Build metadata is ignored.
+func compareVersions(version1, version2 string) (result int) {
+	release1, preRelease1 := splitVersion(version1)
+	release2, preRelease2 := splitVersion(version2)
+
+	if result = compareVersionParts(release1, release2, "0"); result != 0 {
+		return result
+	}
+
+	switch {
+	case preRelease1 == preRelease2:
+		return 0
+
+	case preRelease1 == "":
+		return 1
+
+	case preRelease2 == "":
+		return -1
+
+	default:
+		return compareVersionParts(preRelease1, preRelease2, "")
+	}
+}
+
+func splitVersion(version string) (release, preRelease string) {
+	if i := strings.Index(version, "+"); i >= 0 {
+		version = version[:i]
+	}
+
+	if i := strings.Index(version, "-"); i >= 0 {
+		return version[:i], version[i+1:]
+	}
+
+	return version, ""
+}
+
+func compareVersionParts(version1, version2, missingPart string) (result int) {
+	parts1 := strings.Split(version1, ".")
+	parts2 := strings.Split(version2, ".")
+
+	for i := 0; i < len(parts1) || i < len(parts2); i++ {
+		part1, part2 := missingPart, missingPart
+
+		if i < len(parts1) {
+			part1 = parts1[i]
+		}
+
+		if i < len(parts2) {
+			part2 = parts2[i]
+		}
+
+		num1, err1 := strconv.ParseUint(part1, 10, 64)
+		num2, err2 := strconv.ParseUint(part2, 10, 64)
+
+		switch {
+		case err1 == nil && err2 == nil:
+			if num1 != num2 {
+				if num1 > num2 {
+					return 1
+				}
+
+				return -1
+			}
+
+		default:
+			if result = strings.Compare(part1, part2); result != 0 {
+				return result
+			}
+		}
+	}
+
+	return 0
+}
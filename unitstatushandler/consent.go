@@ -0,0 +1,167 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitstatushandler
+
+import (
+	"time"
+
+	"github.com/aoscloud/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"aos_communicationmanager/cloudprotocol"
+)
+
+/***********************************************************************************************************************
+ * Interface
+ **********************************************************************************************************************/
+
+// PostponeFOTAUpdate postpones FOTA update on user request
+func (instance *Instance) PostponeFOTAUpdate(reason string, duration time.Duration) (err error) {
+	instance.Lock()
+	defer instance.Unlock()
+
+	return instance.firmwareManager.postponeUpdate(reason, duration)
+}
+
+// PostponeSOTAUpdate postpones SOTA update on user request
+func (instance *Instance) PostponeSOTAUpdate(reason string, duration time.Duration) (err error) {
+	instance.Lock()
+	defer instance.Unlock()
+
+	return instance.softwareManager.postponeUpdate(reason, duration)
+}
+
+// DeclineFOTAUpdate declines FOTA update on user request
+func (instance *Instance) DeclineFOTAUpdate(reason string) (err error) {
+	instance.Lock()
+	defer instance.Unlock()
+
+	return instance.firmwareManager.declineUpdate(reason)
+}
+
+// DeclineSOTAUpdate declines SOTA update on user request
+func (instance *Instance) DeclineSOTAUpdate(reason string) (err error) {
+	instance.Lock()
+	defer instance.Unlock()
+
+	return instance.softwareManager.declineUpdate(reason)
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (instance *Instance) updateConsentStatus(updateType string, consent *cloudprotocol.UpdateConsent) {
+	instance.statusMutex.Lock()
+	defer instance.statusMutex.Unlock()
+
+	if consent == nil {
+		delete(instance.updateConsents, updateType)
+	} else {
+		log.WithFields(log.Fields{
+			"type":     updateType,
+			"decision": consent.Decision,
+			"reason":   consent.Reason}).Debug("Update consent status")
+
+		instance.updateConsents[updateType] = *consent
+	}
+
+	instance.statusChanged()
+}
+
+// checkConsentState checks that there is update waiting for user consent
+func checkConsentState(state string) (err error) {
+	if state != stateDownloading && state != stateReadyToUpdate {
+		return aoserrors.New("no update waiting for consent")
+	}
+
+	return nil
+}
+
+// newAcceptedConsent returns accepted consent keeping postponements of the current consent
+func newAcceptedConsent(
+	updateType string, current *cloudprotocol.UpdateConsent) (consent *cloudprotocol.UpdateConsent) {
+	consent = &cloudprotocol.UpdateConsent{
+		UpdateType: updateType, Decision: cloudprotocol.ConsentAccepted, Timestamp: time.Now().UTC(),
+	}
+
+	if current != nil {
+		consent.Postponements = current.Postponements
+	}
+
+	return consent
+}
+
+// newPostponedConsent returns postponed consent if postponement is allowed by the update schedule. Zero maximum
+// postponements means the number of postponements is not limited. Update can't be postponed beyond the deadline or
+// the update TTL as the update expires before it is started.
+func newPostponedConsent(updateType string, current *cloudprotocol.UpdateConsent, schedule cloudprotocol.ScheduleRule,
+	ttlDate time.Time, reason string, duration time.Duration) (consent *cloudprotocol.UpdateConsent, err error) {
+	if duration <= 0 {
+		return nil, aoserrors.New("wrong postpone duration")
+	}
+
+	now := time.Now().UTC()
+
+	consent = &cloudprotocol.UpdateConsent{
+		UpdateType: updateType, Decision: cloudprotocol.ConsentPostponed, Reason: reason,
+		Postponements: 1, PostponedTill: now.Add(duration), Timestamp: now,
+	}
+
+	if current != nil {
+		consent.Postponements = current.Postponements + 1
+	}
+
+	if schedule.MaxPostponements != 0 && consent.Postponements > schedule.MaxPostponements {
+		return nil, aoserrors.Errorf("max postponements %d reached", schedule.MaxPostponements)
+	}
+
+	if !schedule.Deadline.IsZero() && consent.PostponedTill.After(schedule.Deadline) {
+		return nil, aoserrors.Errorf("update can't be postponed beyond deadline %s",
+			schedule.Deadline.Format(time.RFC3339))
+	}
+
+	if !ttlDate.IsZero() && consent.PostponedTill.After(ttlDate) {
+		return nil, aoserrors.Errorf("update can't be postponed beyond TTL %s", ttlDate.Format(time.RFC3339))
+	}
+
+	return consent, nil
+}
+
+// newDeclinedConsent returns declined consent. Update with deadline is mandatory and can't be declined.
+func newDeclinedConsent(updateType string, current *cloudprotocol.UpdateConsent, schedule cloudprotocol.ScheduleRule,
+	reason string) (consent *cloudprotocol.UpdateConsent, err error) {
+	if !schedule.Deadline.IsZero() {
+		return nil, aoserrors.Errorf("mandatory update with deadline %s can't be declined",
+			schedule.Deadline.Format(time.RFC3339))
+	}
+
+	consent = &cloudprotocol.UpdateConsent{
+		UpdateType: updateType, Decision: cloudprotocol.ConsentDeclined, Reason: reason, Timestamp: time.Now().UTC(),
+	}
+
+	if current != nil {
+		consent.Postponements = current.Postponements
+	}
+
+	return consent, nil
+}
+
+func isDeclined(consent *cloudprotocol.UpdateConsent) (declined bool) {
+	return consent != nil && consent.Decision == cloudprotocol.ConsentDeclined
+}
@@ -0,0 +1,268 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitstatushandler
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/aoscloud/aos_common/aoserrors"
+
+	"aos_communicationmanager/cloudprotocol"
+	"aos_communicationmanager/cmserver"
+)
+
+/***********************************************************************************************************************
+ * Interface
+ **********************************************************************************************************************/
+
+// DryRun returns update plan for desired status without performing any update
+func (instance *Instance) DryRun(desiredStatus cloudprotocol.DecodedDesiredStatus) (
+	plan cmserver.UpdatePlan, err error) {
+	instance.Lock()
+	defer instance.Unlock()
+
+	if plan.FOTA, err = instance.firmwareManager.getUpdatePlan(desiredStatus); err != nil {
+		return plan, aoserrors.Wrap(err)
+	}
+
+	if plan.SOTA, err = instance.softwareManager.getUpdatePlan(desiredStatus); err != nil {
+		return plan, aoserrors.Wrap(err)
+	}
+
+	return plan, nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (manager *firmwareManager) getUpdatePlan(desiredStatus cloudprotocol.DecodedDesiredStatus) (
+	plan cmserver.FOTAPlan, err error) {
+	manager.Lock()
+	defer manager.Unlock()
+
+	update, installedComponents, err := manager.getDesiredUpdate(desiredStatus)
+	if err != nil {
+		return plan, aoserrors.Wrap(err)
+	}
+
+	componentErrors := getCompatibilityErrors(desiredStatus.Components, installedComponents, update.Components,
+		manager.InstalledAnnotations)
+
+	for _, component := range update.Components {
+		componentPlan := cmserver.ComponentPlan{ComponentInfo: cloudprotocol.ComponentInfo{
+			ID: component.ID, AosVersion: component.AosVersion, VendorVersion: component.VendorVersion,
+			Status: cloudprotocol.PendingStatus,
+		}}
+
+		if componentPlan.UMID, err = manager.firmwareUpdater.GetComponentUM(component.ID); err != nil {
+			componentPlan.Status = cloudprotocol.ErrorStatus
+			componentPlan.Error = aoserrors.Wrap(err).Error()
+		}
+
+		if componentErr, ok := componentErrors[component.ID]; ok {
+			componentPlan.Status = cloudprotocol.ErrorStatus
+			componentPlan.Error = componentErr
+		}
+
+		plan.Components = append(plan.Components, componentPlan)
+
+		plan.Downloads = append(plan.Downloads, cmserver.DownloadPlan{
+			Type: cmserver.DownloadComponent, ID: component.ID, Size: component.Size,
+			Cached: manager.isComponentDownloaded(component),
+		})
+	}
+
+	if len(update.BoardConfig) != 0 {
+		plan.BoardConfig = manager.getBoardConfigPlan(update.BoardConfig)
+	}
+
+	plan.Schedule = getSchedulePlan(update.Schedule, manager.stateMachine.defaultTTL)
+
+	return plan, nil
+}
+
+func (manager *firmwareManager) getBoardConfigPlan(boardConfig []byte) (plan *cmserver.BoardConfigPlan) {
+	plan = &cmserver.BoardConfigPlan{}
+
+	currentInfo, err := manager.boardConfigUpdater.GetStatus()
+	if err != nil {
+		plan.Error = aoserrors.Wrap(err).Error()
+
+		return plan
+	}
+
+	plan.CurrentVersion = currentInfo.VendorVersion
+
+	if plan.NewVersion, err = manager.boardConfigUpdater.GetBoardConfigVersion(boardConfig); err != nil {
+		plan.Error = aoserrors.Wrap(err).Error()
+	}
+
+	return plan
+}
+
+// isComponentDownloaded checks if component is already downloaded by current update
+func (manager *firmwareManager) isComponentDownloaded(component cloudprotocol.ComponentInfoFromCloud) (result bool) {
+	if manager.CurrentUpdate == nil || !isDownloaded(manager.DownloadResult, component.ID) {
+		return false
+	}
+
+	for _, currentComponent := range manager.CurrentUpdate.Components {
+		if currentComponent.ID == component.ID && currentComponent.VendorVersion == component.VendorVersion &&
+			bytes.Equal(currentComponent.Sha256, component.Sha256) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (manager *softwareManager) getUpdatePlan(desiredStatus cloudprotocol.DecodedDesiredStatus) (
+	plan cmserver.SOTAPlan, err error) {
+	manager.Lock()
+	defer manager.Unlock()
+
+	update, err := manager.getDesiredUpdate(desiredStatus)
+	if err != nil {
+		return plan, aoserrors.Wrap(err)
+	}
+
+	// Services and layers installed for other users are not downloaded again
+	for _, service := range update.InstallServices {
+		plan.Downloads = append(plan.Downloads, cmserver.DownloadPlan{
+			Type: cmserver.DownloadService, ID: service.ID, Size: service.Size, Cached: true})
+	}
+
+	for _, service := range update.DownloadServices {
+		plan.Downloads = append(plan.Downloads, cmserver.DownloadPlan{
+			Type: cmserver.DownloadService, ID: service.ID, Size: service.Size,
+			Cached: manager.isServiceDownloaded(service)})
+	}
+
+	for _, service := range append(update.InstallServices, update.DownloadServices...) {
+		plan.InstallServices = append(plan.InstallServices, cloudprotocol.ServiceInfo{
+			ID: service.ID, AosVersion: service.AosVersion, Status: cloudprotocol.PendingStatus})
+	}
+
+	for _, service := range update.RemoveServices {
+		plan.RemoveServices = append(plan.RemoveServices, cloudprotocol.ServiceInfo{
+			ID: service.ID, AosVersion: service.AosVersion, Status: cloudprotocol.PendingStatus})
+	}
+
+	for _, layer := range update.InstallLayers {
+		plan.Downloads = append(plan.Downloads, cmserver.DownloadPlan{
+			Type: cmserver.DownloadLayer, ID: layer.Digest, Size: layer.Size, Cached: true})
+	}
+
+	for _, layer := range update.DownloadLayers {
+		plan.Downloads = append(plan.Downloads, cmserver.DownloadPlan{
+			Type: cmserver.DownloadLayer, ID: layer.Digest, Size: layer.Size,
+			Cached: manager.isLayerDownloaded(layer)})
+	}
+
+	for _, layer := range append(update.InstallLayers, update.DownloadLayers...) {
+		plan.InstallLayers = append(plan.InstallLayers, cloudprotocol.LayerInfo{
+			ID: layer.ID, Digest: layer.Digest, AosVersion: layer.AosVersion, Status: cloudprotocol.PendingStatus})
+	}
+
+	for _, layer := range update.RemoveLayers {
+		plan.RemoveLayers = append(plan.RemoveLayers, cloudprotocol.LayerInfo{
+			ID: layer.ID, Digest: layer.Digest, AosVersion: layer.AosVersion, Status: cloudprotocol.PendingStatus})
+	}
+
+	plan.Schedule = getSchedulePlan(update.Schedule, manager.stateMachine.defaultTTL)
+
+	return plan, nil
+}
+
+// isServiceDownloaded checks if service is already downloaded by current update
+func (manager *softwareManager) isServiceDownloaded(service cloudprotocol.ServiceInfoFromCloud) (result bool) {
+	if manager.CurrentUpdate == nil || !isDownloaded(manager.DownloadResult, service.ID) {
+		return false
+	}
+
+	for _, currentService := range manager.CurrentUpdate.DownloadServices {
+		if currentService.ID == service.ID && currentService.AosVersion == service.AosVersion &&
+			bytes.Equal(currentService.Sha256, service.Sha256) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isLayerDownloaded checks if layer is already downloaded by current update
+func (manager *softwareManager) isLayerDownloaded(layer cloudprotocol.LayerInfoFromCloud) (result bool) {
+	if manager.CurrentUpdate == nil || !isDownloaded(manager.DownloadResult, layer.Digest) {
+		return false
+	}
+
+	for _, currentLayer := range manager.CurrentUpdate.DownloadLayers {
+		if currentLayer.Digest == layer.Digest && bytes.Equal(currentLayer.Sha256, layer.Sha256) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isDownloaded(result map[string]*downloadResult, id string) (downloaded bool) {
+	item, ok := result[id]
+
+	return ok && item.Error == "" && item.FileName != ""
+}
+
+// getSchedulePlan returns update schedule the same way as update state machine does
+func getSchedulePlan(schedule cloudprotocol.ScheduleRule, defaultTTL time.Duration) (plan cmserver.SchedulePlan) {
+	now := time.Now()
+
+	plan.Type = schedule.Type
+	plan.TTL = time.Duration(schedule.TTL) * time.Second
+
+	if plan.TTL == 0 {
+		plan.TTL = defaultTTL
+	}
+
+	switch schedule.Type {
+	case "", cloudprotocol.ForceUpdate:
+		plan.Type = cloudprotocol.ForceUpdate
+		plan.EarliestStart = now
+
+	case cloudprotocol.TriggerUpdate:
+
+	case cloudprotocol.TimetableUpdate:
+		if err := validateTimetable(schedule.Timetable); err != nil {
+			plan.Error = aoserrors.Wrap(err).Error()
+			break
+		}
+
+		availableTime, err := getAvailableTimetableTime(now, schedule.Timetable)
+		if err != nil {
+			plan.Error = aoserrors.Wrap(err).Error()
+			break
+		}
+
+		plan.EarliestStart = now.Add(availableTime)
+
+	default:
+		plan.Error = aoserrors.New("wrong update type").Error()
+	}
+
+	return plan
+}
@@ -34,6 +34,7 @@ import (
 
 	"aos_communicationmanager/cloudprotocol"
 	"aos_communicationmanager/cmserver"
+	"aos_communicationmanager/updatehistory"
 )
 
 /***********************************************************************************************************************
@@ -50,6 +51,7 @@ type firmwareStatusHandler interface {
 		chains []cloudprotocol.CertificateChain, certs []cloudprotocol.Certificate) (result map[string]*downloadResult)
 	updateComponentStatus(componentInfo cloudprotocol.ComponentInfo)
 	updateBoardConfigStatus(boardConfigInfo cloudprotocol.BoardConfigInfo)
+	updateConsentStatus(updateType string, consent *cloudprotocol.UpdateConsent)
 }
 
 type firmwareUpdate struct {
@@ -58,6 +60,8 @@ type firmwareUpdate struct {
 	Components  []cloudprotocol.ComponentInfoFromCloud `json:"components,omitempty"`
 	CertChains  []cloudprotocol.CertificateChain       `json:"certChains,omitempty"`
 	Certs       []cloudprotocol.Certificate            `json:"certs,omitempty"`
+
+	DesiredStatusHash string `json:"desiredStatusHash,omitempty"`
 }
 
 type firmwareManager struct {
@@ -69,10 +73,13 @@ type firmwareManager struct {
 	firmwareUpdater    FirmwareUpdater
 	boardConfigUpdater BoardConfigUpdater
 	storage            Storage
+	history            UpdateHistory
 
 	stateMachine  *updateStateMachine
 	statusMutex   sync.RWMutex
 	pendingUpdate *firmwareUpdate
+	progress      []cloudprotocol.ComponentProgress
+	preconditions []string
 
 	ComponentStatuses map[string]*cloudprotocol.ComponentInfo `json:"componentStatuses,omitempty"`
 	BoardConfigStatus cloudprotocol.BoardConfigInfo           `json:"boardConfigStatus,omitempty"`
@@ -81,6 +88,10 @@ type firmwareManager struct {
 	CurrentState      string                                  `json:"currentState,omitempty"`
 	UpdateErr         string                                  `json:"updateErr,omitempty"`
 	TTLDate           time.Time                               `json:"ttlDate,omitempty"`
+	Consent           *cloudprotocol.UpdateConsent            `json:"consent,omitempty"`
+	HistoryRecord     *updatehistory.Record                   `json:"historyRecord,omitempty"`
+
+	InstalledAnnotations map[string]installedAnnotations `json:"installedAnnotations,omitempty"`
 }
 
 /***********************************************************************************************************************
@@ -89,13 +100,15 @@ type firmwareManager struct {
 
 func newFirmwareManager(statusHandler firmwareStatusHandler,
 	firmwareUpdater FirmwareUpdater, boardConfigUpdater BoardConfigUpdater,
-	storage Storage, defaultTTL time.Duration) (manager *firmwareManager, err error) {
+	storage Storage, defaultTTL time.Duration, preconditions PreconditionChecker,
+	preconditionsPeriod time.Duration, history UpdateHistory) (manager *firmwareManager, err error) {
 	manager = &firmwareManager{
 		statusChannel:      make(chan cmserver.UpdateFOTAStatus, 1),
 		statusHandler:      statusHandler,
 		firmwareUpdater:    firmwareUpdater,
 		boardConfigUpdater: boardConfigUpdater,
 		storage:            storage,
+		history:            history,
 		CurrentState:       stateNoUpdate,
 	}
 
@@ -116,7 +129,7 @@ func newFirmwareManager(statusHandler firmwareStatusHandler,
 		{Name: eventStartUpdate, Src: []string{stateReadyToUpdate}, Dst: stateUpdating},
 		// updating state
 		{Name: eventFinishUpdate, Src: []string{stateUpdating}, Dst: stateNoUpdate},
-	}, manager, defaultTTL)
+	}, manager, defaultTTL, preconditions, preconditionsPeriod)
 
 	if err = manager.stateMachine.init(manager.TTLDate); err != nil {
 		return nil, aoserrors.Wrap(err)
@@ -143,6 +156,7 @@ func (manager *firmwareManager) close() (err error) {
 func (manager *firmwareManager) getCurrentStatus() (status cmserver.UpdateFOTAStatus) {
 	status.State = convertState(manager.CurrentState)
 	status.Error = manager.UpdateErr
+	status.Preconditions = manager.preconditions
 
 	if status.State == cmserver.NoUpdate || manager.CurrentUpdate == nil {
 		return status
@@ -158,6 +172,8 @@ func (manager *firmwareManager) getCurrentStatus() (status cmserver.UpdateFOTASt
 		status.BoardConfig = &cloudprotocol.BoardConfigInfo{VendorVersion: version}
 	}
 
+	status.Progress = manager.progress
+
 	return status
 }
 
@@ -172,17 +188,48 @@ func (manager *firmwareManager) processDesiredStatus(desiredStatus cloudprotocol
 	manager.Lock()
 	defer manager.Unlock()
 
-	update := &firmwareUpdate{
+	update, installedComponents, err := manager.getDesiredUpdate(desiredStatus)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if componentErrors := getCompatibilityErrors(desiredStatus.Components, installedComponents, update.Components,
+		manager.InstalledAnnotations); len(componentErrors) != 0 {
+		manager.rejectComponents(update.Components, componentErrors)
+
+		return aoserrors.New(incompatibleUpdateError)
+	}
+
+	if manager.setInstalledAnnotations(desiredStatus.Components, installedComponents) {
+		if err = manager.saveState(); err != nil {
+			return aoserrors.Wrap(err)
+		}
+	}
+
+	if len(update.BoardConfig) != 0 || len(update.Components) != 0 {
+		if err = manager.newUpdate(update); err != nil {
+			return aoserrors.Wrap(err)
+		}
+	}
+
+	return nil
+}
+
+// getDesiredUpdate returns firmware update required to reach desired status
+func (manager *firmwareManager) getDesiredUpdate(desiredStatus cloudprotocol.DecodedDesiredStatus) (
+	update *firmwareUpdate, installedComponents []cloudprotocol.ComponentInfo, err error) {
+	update = &firmwareUpdate{
 		Schedule:    desiredStatus.FOTASchedule,
 		BoardConfig: desiredStatus.BoardConfig,
 		Components:  make([]cloudprotocol.ComponentInfoFromCloud, 0),
 		CertChains:  desiredStatus.CertificateChains,
 		Certs:       desiredStatus.Certificates,
+
+		DesiredStatusHash: getDesiredStatusHash(desiredStatus),
 	}
 
-	installedComponents, err := manager.firmwareUpdater.GetStatus()
-	if err != nil {
-		return aoserrors.Wrap(err)
+	if installedComponents, err = manager.firmwareUpdater.GetStatus(); err != nil {
+		return nil, nil, aoserrors.Wrap(err)
 	}
 
 desiredLoop:
@@ -204,13 +251,21 @@ desiredLoop:
 			"vendorVersion": desiredComponent.VendorVersion}).Error("Desired component not found")
 	}
 
-	if len(update.BoardConfig) != 0 || len(update.Components) != 0 {
-		if err = manager.newUpdate(update); err != nil {
-			return aoserrors.Wrap(err)
-		}
+	return update, installedComponents, nil
+}
+
+func (manager *firmwareManager) updateProgress(progress []cloudprotocol.ComponentProgress) {
+	manager.Lock()
+	defer manager.Unlock()
+
+	// Progress is reported only while components are being updated
+	if manager.CurrentState != stateUpdating {
+		return
 	}
 
-	return nil
+	manager.progress = progress
+
+	manager.sendCurrentStatus()
 }
 
 func (manager *firmwareManager) startUpdate() (err error) {
@@ -219,6 +274,17 @@ func (manager *firmwareManager) startUpdate() (err error) {
 
 	log.Debug("Start firmware update")
 
+	if manager.CurrentState == stateReadyToUpdate {
+		if preconditions := manager.stateMachine.checkPreconditions(); len(preconditions) != 0 {
+			if !reflect.DeepEqual(preconditions, manager.preconditions) {
+				manager.preconditions = preconditions
+				manager.sendCurrentStatus()
+			}
+
+			return nil
+		}
+	}
+
 	if err = manager.stateMachine.sendEvent(eventStartUpdate, ""); err != nil {
 		return aoserrors.Wrap(err)
 	}
@@ -226,6 +292,75 @@ func (manager *firmwareManager) startUpdate() (err error) {
 	return nil
 }
 
+// acceptUpdate records user consent on update start
+func (manager *firmwareManager) acceptUpdate() {
+	manager.Lock()
+	defer manager.Unlock()
+
+	if manager.CurrentState != stateReadyToUpdate {
+		return
+	}
+
+	manager.setConsent(newAcceptedConsent(cloudprotocol.FOTAUpdate, manager.Consent))
+}
+
+func (manager *firmwareManager) postponeUpdate(reason string, duration time.Duration) (err error) {
+	manager.Lock()
+	defer manager.Unlock()
+
+	log.WithFields(log.Fields{"reason": reason, "duration": duration}).Debug("Postpone firmware update")
+
+	if err = checkConsentState(manager.CurrentState); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	consent, err := newPostponedConsent(
+		cloudprotocol.FOTAUpdate, manager.Consent, manager.CurrentUpdate.Schedule, manager.TTLDate, reason, duration)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	manager.setConsent(consent)
+
+	if manager.CurrentState == stateReadyToUpdate {
+		manager.stateMachine.scheduleUpdate(manager.CurrentUpdate.Schedule, manager.Consent)
+	}
+
+	return nil
+}
+
+func (manager *firmwareManager) declineUpdate(reason string) (err error) {
+	manager.Lock()
+	defer manager.Unlock()
+
+	log.WithFields(log.Fields{"reason": reason}).Debug("Decline firmware update")
+
+	if err = checkConsentState(manager.CurrentState); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	consent, err := newDeclinedConsent(cloudprotocol.FOTAUpdate, manager.Consent, manager.CurrentUpdate.Schedule, reason)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	manager.setConsent(consent)
+
+	if err = manager.stateMachine.sendEvent(
+		eventCancel, aoserrors.Errorf("update declined: %s", reason).Error()); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (manager *firmwareManager) getConsent() (consent *cloudprotocol.UpdateConsent) {
+	manager.Lock()
+	defer manager.Unlock()
+
+	return manager.Consent
+}
+
 func (manager *firmwareManager) getComponentStatuses() (status []cloudprotocol.ComponentInfo, err error) {
 	manager.Lock()
 	defer manager.Unlock()
@@ -305,11 +440,15 @@ func (manager *firmwareManager) stateChanged(event, state string, updateErr stri
 
 	manager.CurrentState = state
 	manager.UpdateErr = updateErr
+	manager.progress = nil
+	manager.preconditions = nil
 
 	log.WithFields(log.Fields{
 		"state": state,
 		"event": event}).Debug("Firmware manager state changed")
 
+	manager.updateHistory(event, state, updateErr)
+
 	if updateErr != "" {
 		log.Errorf("Firmware update error: %s", updateErr)
 	}
@@ -338,6 +477,7 @@ func (manager *firmwareManager) noUpdate() {
 
 		manager.CurrentUpdate = manager.pendingUpdate
 		manager.pendingUpdate = nil
+		manager.setConsent(nil)
 
 		go func() {
 			manager.Lock()
@@ -373,6 +513,7 @@ func (manager *firmwareManager) download(ctx context.Context) {
 
 	if len(manager.CurrentUpdate.BoardConfig) != 0 {
 		manager.BoardConfigStatus.VendorVersion = ""
+		manager.BoardConfigStatus.Nodes = nil
 
 		version, err := manager.boardConfigUpdater.GetBoardConfigVersion(manager.CurrentUpdate.BoardConfig)
 
@@ -444,7 +585,7 @@ func (manager *firmwareManager) download(ctx context.Context) {
 }
 
 func (manager *firmwareManager) readyToUpdate() {
-	manager.stateMachine.scheduleUpdate(manager.CurrentUpdate.Schedule)
+	manager.stateMachine.scheduleUpdate(manager.CurrentUpdate.Schedule, manager.Consent)
 }
 
 func (manager *firmwareManager) update(ctx context.Context) {
@@ -499,7 +640,15 @@ func (manager *firmwareManager) newUpdate(update *firmwareUpdate) (err error) {
 
 	switch manager.CurrentState {
 	case stateNoUpdate:
+		// Declined update is not started again until desired status is changed
+		if isDeclined(manager.Consent) && manager.CurrentUpdate != nil && manager.isCurrentUpdate(update) {
+			log.Debug("Skip declined firmware update")
+
+			return nil
+		}
+
 		manager.CurrentUpdate = update
+		manager.setConsent(nil)
 
 		if manager.TTLDate, err = manager.stateMachine.startNewUpdate(
 			time.Duration(manager.CurrentUpdate.Schedule.TTL) * time.Second); err != nil {
@@ -507,8 +656,7 @@ func (manager *firmwareManager) newUpdate(update *firmwareUpdate) (err error) {
 		}
 
 	default:
-		if reflect.DeepEqual(update.Components, manager.CurrentUpdate.Components) &&
-			boardConfigsEqual(update.BoardConfig, manager.CurrentUpdate.BoardConfig) {
+		if manager.isCurrentUpdate(update) {
 			if reflect.DeepEqual(update.Schedule, manager.CurrentUpdate.Schedule) {
 				return nil
 			}
@@ -518,7 +666,7 @@ func (manager *firmwareManager) newUpdate(update *firmwareUpdate) (err error) {
 			if manager.CurrentState == stateReadyToUpdate && (manager.CurrentUpdate.Schedule.Type != cloudprotocol.ForceUpdate) {
 				manager.CurrentUpdate.Schedule = update.Schedule
 
-				manager.stateMachine.scheduleUpdate(manager.CurrentUpdate.Schedule)
+				manager.stateMachine.scheduleUpdate(manager.CurrentUpdate.Schedule, manager.Consent)
 
 				return nil
 			}
@@ -539,19 +687,41 @@ func (manager *firmwareManager) newUpdate(update *firmwareUpdate) (err error) {
 	return nil
 }
 
+// isCurrentUpdate checks if update contains the same items as current one
+func (manager *firmwareManager) isCurrentUpdate(update *firmwareUpdate) (result bool) {
+	return reflect.DeepEqual(update.Components, manager.CurrentUpdate.Components) &&
+		boardConfigsEqual(update.BoardConfig, manager.CurrentUpdate.BoardConfig)
+}
+
 func (manager *firmwareManager) updateComponents(ctx context.Context) (componentsErr string) {
 	defer func() {
 		switch {
 		case strings.Contains(componentsErr, context.Canceled.Error()):
 
 		case componentsErr == "":
+			installedComponents := make([]cloudprotocol.ComponentInfo, 0, len(manager.ComponentStatuses))
+
 			for _, status := range manager.ComponentStatuses {
+				if status.Status == cloudprotocol.PendingStatus {
+					log.WithFields(log.Fields{
+						"id":      status.ID,
+						"version": status.VendorVersion,
+					}).Warnf("Component update is deferred: %s", status.Error)
+
+					continue
+				}
+
 				log.WithFields(log.Fields{
 					"id":      status.ID,
 					"version": status.VendorVersion,
 				}).Info("Component successfully updated")
+
+				installedComponents = append(installedComponents, *status)
 			}
 
+			// Deferred components are not installed yet, their annotations are set when they are reported installed
+			manager.setInstalledAnnotations(manager.CurrentUpdate.Components, installedComponents)
+
 		default:
 			for id, status := range manager.ComponentStatuses {
 				if status.Status != cloudprotocol.ErrorStatus {
@@ -611,10 +781,82 @@ func (manager *firmwareManager) updateComponents(ctx context.Context) (component
 	}
 }
 
+// setInstalledAnnotations stores annotations of installed component versions. If installed components are
+// specified, only components which versions are installed are stored.
+func (manager *firmwareManager) setInstalledAnnotations(components []cloudprotocol.ComponentInfoFromCloud,
+	installedComponents []cloudprotocol.ComponentInfo) (changed bool) {
+	installedVersions := make(map[string]string)
+
+	for _, component := range installedComponents {
+		if component.Status == cloudprotocol.InstalledStatus {
+			installedVersions[component.ID] = component.VendorVersion
+		}
+	}
+
+	for _, component := range components {
+		if installedComponents != nil && installedVersions[component.ID] != component.VendorVersion {
+			continue
+		}
+
+		annotations := installedAnnotations{
+			VendorVersion: component.VendorVersion, Annotations: component.Annotations,
+		}
+
+		if reflect.DeepEqual(manager.InstalledAnnotations[component.ID], annotations) {
+			continue
+		}
+
+		if manager.InstalledAnnotations == nil {
+			manager.InstalledAnnotations = make(map[string]installedAnnotations)
+		}
+
+		manager.InstalledAnnotations[component.ID] = annotations
+		changed = true
+	}
+
+	return changed
+}
+
+func (manager *firmwareManager) setConsent(consent *cloudprotocol.UpdateConsent) {
+	if consent == nil && manager.Consent == nil {
+		return
+	}
+
+	manager.Consent = consent
+	manager.statusHandler.updateConsentStatus(cloudprotocol.FOTAUpdate, consent)
+
+	if err := manager.saveState(); err != nil {
+		log.Errorf("Can't save current firmware manager state: %s", err)
+	}
+}
+
 func (manager *firmwareManager) sendCurrentStatus() {
 	manager.statusChannel <- manager.getCurrentStatus()
 }
 
+// rejectComponents reports errors for components of rejected update before download is started
+func (manager *firmwareManager) rejectComponents(
+	components []cloudprotocol.ComponentInfoFromCloud, componentErrors map[string]string) {
+	for _, component := range components {
+		componentErr, ok := componentErrors[component.ID]
+		if !ok {
+			componentErr = incompatibleUpdateError
+		}
+
+		log.WithFields(log.Fields{
+			"id":            component.ID,
+			"vendorVersion": component.VendorVersion}).Errorf("Component rejected: %s", componentErr)
+
+		manager.statusHandler.updateComponentStatus(cloudprotocol.ComponentInfo{
+			ID:            component.ID,
+			AosVersion:    component.AosVersion,
+			VendorVersion: component.VendorVersion,
+			Status:        cloudprotocol.ErrorStatus,
+			Error:         componentErr,
+		})
+	}
+}
+
 func (manager *firmwareManager) updateComponentStatusByID(id, status, componentErr string) {
 	manager.statusMutex.Lock()
 	defer manager.statusMutex.Unlock()
@@ -670,7 +912,13 @@ func (manager *firmwareManager) updateBoardConfig(ctx context.Context) (boardCon
 		}
 	}()
 
-	if _, err := manager.boardConfigUpdater.CheckBoardConfig(manager.CurrentUpdate.BoardConfig); err != nil {
+	if _, nodesInfo, err := manager.boardConfigUpdater.CheckBoardConfig(
+		manager.CurrentUpdate.BoardConfig); err != nil {
+		// Report check result of each node with the error status
+		manager.statusMutex.Lock()
+		manager.BoardConfigStatus.Nodes = nodesInfo
+		manager.statusMutex.Unlock()
+
 		return aoserrors.Wrap(err).Error()
 	}
 
@@ -699,7 +947,8 @@ func (manager *firmwareManager) asyncUpdate(updateComponents []cloudprotocol.Com
 		for id, status := range manager.ComponentStatuses {
 			for _, item := range updateResult {
 				if item.ID == status.ID && item.VendorVersion == status.VendorVersion {
-					if errorStr == "" {
+					// Deferred component is not an error, it is updated when its UM is connected
+					if errorStr == "" && item.Status != cloudprotocol.PendingStatus {
 						errorStr = item.Error
 					}
 
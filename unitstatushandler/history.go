@@ -0,0 +1,283 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitstatushandler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"aos_communicationmanager/cloudprotocol"
+	"aos_communicationmanager/updatehistory"
+)
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (manager *firmwareManager) updateHistory(event, state, updateErr string) {
+	if manager.history == nil {
+		return
+	}
+
+	if event == eventStartDownload {
+		manager.HistoryRecord = &updatehistory.Record{
+			UpdateType:        cloudprotocol.FOTAUpdate,
+			DesiredStatusHash: manager.CurrentUpdate.DesiredStatusHash,
+			Items:             manager.getHistoryItems(),
+			Started:           time.Now().UTC(),
+		}
+	}
+
+	if state == stateNoUpdate && manager.HistoryRecord != nil {
+		manager.finishHistoryItems(manager.HistoryRecord)
+	}
+
+	manager.HistoryRecord = storeHistoryRecord(manager.history, manager.HistoryRecord, state, updateErr)
+}
+
+func (manager *firmwareManager) getHistoryItems() (items []updatehistory.Item) {
+	installedVersions := make(map[string]string)
+
+	if installedComponents, err := manager.firmwareUpdater.GetStatus(); err != nil {
+		log.Errorf("Can't get components status: %s", err)
+	} else {
+		for _, component := range installedComponents {
+			if component.Status == cloudprotocol.InstalledStatus {
+				installedVersions[component.ID] = component.VendorVersion
+			}
+		}
+	}
+
+	for _, component := range manager.CurrentUpdate.Components {
+		items = append(items, updatehistory.Item{
+			Type: updatehistory.ComponentItem, ID: component.ID,
+			FromVersion: installedVersions[component.ID], ToVersion: component.VendorVersion,
+		})
+	}
+
+	if len(manager.CurrentUpdate.BoardConfig) != 0 {
+		item := updatehistory.Item{Type: updatehistory.BoardConfigItem, ID: updatehistory.BoardConfigItem}
+
+		if currentInfo, err := manager.boardConfigUpdater.GetStatus(); err != nil {
+			log.Errorf("Can't get board config status: %s", err)
+		} else {
+			item.FromVersion = currentInfo.VendorVersion
+		}
+
+		version, err := manager.boardConfigUpdater.GetBoardConfigVersion(manager.CurrentUpdate.BoardConfig)
+		if err != nil {
+			log.Errorf("Can't get board config version: %s", err)
+		}
+
+		item.ToVersion = version
+
+		items = append(items, item)
+	}
+
+	return items
+}
+
+// finishHistoryItems sets final item statuses. Failed component is considered as reverted if update was applied and
+// the previous component version is installed.
+func (manager *firmwareManager) finishHistoryItems(record *updatehistory.Record) {
+	manager.statusMutex.RLock()
+	defer manager.statusMutex.RUnlock()
+
+	installedVersions := make(map[string]string)
+
+	if isHistoryPhasePassed(record, stateUpdating) {
+		if installedComponents, err := manager.firmwareUpdater.GetStatus(); err != nil {
+			log.Errorf("Can't get components status: %s", err)
+		} else {
+			for _, component := range installedComponents {
+				if component.Status == cloudprotocol.InstalledStatus {
+					installedVersions[component.ID] = component.VendorVersion
+				}
+			}
+		}
+	}
+
+	for i, item := range record.Items {
+		switch item.Type {
+		case updatehistory.ComponentItem:
+			if status, ok := manager.ComponentStatuses[item.ID]; ok {
+				record.Items[i].Status, record.Items[i].Error = status.Status, status.Error
+			}
+
+			if installedVersion, ok := installedVersions[item.ID]; ok &&
+				record.Items[i].Status == cloudprotocol.ErrorStatus && installedVersion == item.FromVersion {
+				record.Items[i].Reverted = true
+			}
+
+		case updatehistory.BoardConfigItem:
+			record.Items[i].Status, record.Items[i].Error = manager.BoardConfigStatus.Status,
+				manager.BoardConfigStatus.Error
+		}
+	}
+}
+
+func (manager *softwareManager) updateHistory(event, state, updateErr string) {
+	if manager.history == nil {
+		return
+	}
+
+	if event == eventStartDownload {
+		manager.HistoryRecord = &updatehistory.Record{
+			UpdateType:        cloudprotocol.SOTAUpdate,
+			DesiredStatusHash: manager.CurrentUpdate.DesiredStatusHash,
+			Items:             manager.getHistoryItems(),
+			Started:           time.Now().UTC(),
+		}
+	}
+
+	if state == stateNoUpdate && manager.HistoryRecord != nil {
+		manager.finishHistoryItems(manager.HistoryRecord)
+	}
+
+	manager.HistoryRecord = storeHistoryRecord(manager.history, manager.HistoryRecord, state, updateErr)
+}
+
+func (manager *softwareManager) getHistoryItems() (items []updatehistory.Item) {
+	installedVersions := make(map[string]uint64)
+
+	if installedServices, _, err := manager.softwareUpdater.GetUsersStatus(manager.currentUsers); err != nil {
+		log.Errorf("Can't get services status: %s", err)
+	} else {
+		for _, service := range installedServices {
+			if service.Status == cloudprotocol.InstalledStatus {
+				installedVersions[service.ID] = service.AosVersion
+			}
+		}
+	}
+
+	for _, service := range append(manager.CurrentUpdate.InstallServices, manager.CurrentUpdate.DownloadServices...) {
+		item := updatehistory.Item{
+			Type: updatehistory.ServiceItem, ID: service.ID, ToVersion: formatAosVersion(service.AosVersion)}
+
+		if version, ok := installedVersions[service.ID]; ok {
+			item.FromVersion = formatAosVersion(version)
+		}
+
+		items = append(items, item)
+	}
+
+	for _, service := range manager.CurrentUpdate.RemoveServices {
+		items = append(items, updatehistory.Item{
+			Type: updatehistory.ServiceItem, ID: service.ID, FromVersion: formatAosVersion(service.AosVersion)})
+	}
+
+	for _, layer := range append(manager.CurrentUpdate.InstallLayers, manager.CurrentUpdate.DownloadLayers...) {
+		items = append(items, updatehistory.Item{
+			Type: updatehistory.LayerItem, ID: layer.ID, Digest: layer.Digest,
+			ToVersion: formatAosVersion(layer.AosVersion),
+		})
+	}
+
+	for _, layer := range manager.CurrentUpdate.RemoveLayers {
+		items = append(items, updatehistory.Item{
+			Type: updatehistory.LayerItem, ID: layer.ID, Digest: layer.Digest,
+			FromVersion: formatAosVersion(layer.AosVersion),
+		})
+	}
+
+	return items
+}
+
+func (manager *softwareManager) finishHistoryItems(record *updatehistory.Record) {
+	manager.statusMutex.RLock()
+	defer manager.statusMutex.RUnlock()
+
+	for i, item := range record.Items {
+		switch item.Type {
+		case updatehistory.ServiceItem:
+			if status, ok := manager.ServiceStatuses[item.ID]; ok {
+				record.Items[i].Status, record.Items[i].Error = status.Status, status.Error
+			}
+
+		case updatehistory.LayerItem:
+			if status, ok := manager.LayerStatuses[item.Digest]; ok {
+				record.Items[i].Status, record.Items[i].Error = status.Status, status.Error
+			}
+		}
+	}
+}
+
+// storeHistoryRecord adds update phase to the record and stores it. Finished record is released.
+func storeHistoryRecord(history UpdateHistory, record *updatehistory.Record,
+	state, updateErr string) (currentRecord *updatehistory.Record) {
+	// Update could be started before history is enabled
+	if record == nil {
+		return nil
+	}
+
+	now := time.Now().UTC()
+
+	record.Phases = append(record.Phases, updatehistory.Phase{State: state, Timestamp: now})
+
+	if state == stateNoUpdate {
+		record.Finished = now
+		record.Error = updateErr
+	}
+
+	if record.ID == 0 {
+		if err := history.AddRecord(record); err != nil {
+			log.Errorf("Can't add update history record: %s", err)
+		}
+	} else {
+		if err := history.UpdateRecord(*record); err != nil {
+			log.Errorf("Can't update history record: %s", err)
+		}
+	}
+
+	if state == stateNoUpdate {
+		return nil
+	}
+
+	return record
+}
+
+func isHistoryPhasePassed(record *updatehistory.Record, state string) (passed bool) {
+	for _, phase := range record.Phases {
+		if phase.State == state {
+			return true
+		}
+	}
+
+	return false
+}
+
+func getDesiredStatusHash(desiredStatus cloudprotocol.DecodedDesiredStatus) (hash string) {
+	data, err := json.Marshal(desiredStatus)
+	if err != nil {
+		log.Errorf("Can't marshal desired status: %s", err)
+		return ""
+	}
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+func formatAosVersion(version uint64) (result string) {
+	return strconv.FormatUint(version, 10)
+}
@@ -19,7 +19,9 @@ package unitstatushandler
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"net/url"
 	"os"
@@ -34,6 +36,7 @@ import (
 
 	"aos_communicationmanager/cloudprotocol"
 	"aos_communicationmanager/cmserver"
+	"aos_communicationmanager/updatehistory"
 )
 
 /***********************************************************************************************************************
@@ -52,6 +55,7 @@ type softwareStatusHandler interface {
 		chains []cloudprotocol.CertificateChain, certs []cloudprotocol.Certificate) (result map[string]*downloadResult)
 	updateLayerStatus(layerInfo cloudprotocol.LayerInfo)
 	updateServiceStatus(serviceInfo cloudprotocol.ServiceInfo)
+	updateConsentStatus(updateType string, consent *cloudprotocol.UpdateConsent)
 }
 
 type softwareUpdate struct {
@@ -64,6 +68,8 @@ type softwareUpdate struct {
 	RemoveLayers     []cloudprotocol.LayerInfo            `json:"removeLayers,omitempty"`
 	CertChains       []cloudprotocol.CertificateChain     `json:"certChains,omitempty"`
 	Certs            []cloudprotocol.Certificate          `json:"certs,omitempty"`
+
+	DesiredStatusHash string `json:"desiredStatusHash,omitempty"`
 }
 
 type softwareManager struct {
@@ -71,15 +77,18 @@ type softwareManager struct {
 
 	statusChannel chan cmserver.UpdateSOTAStatus
 
-	statusHandler   softwareStatusHandler
-	softwareUpdater SoftwareUpdater
-	storage         Storage
+	statusHandler    softwareStatusHandler
+	softwareUpdater  SoftwareUpdater
+	serviceRegistrar ServiceRegistrar
+	storage          Storage
+	history          UpdateHistory
 
 	stateMachine  *updateStateMachine
 	actionHandler *action.Handler
 	statusMutex   sync.RWMutex
 	pendingUpdate *softwareUpdate
 	currentUsers  []string
+	preconditions []string
 
 	LayerStatuses   map[string]*cloudprotocol.LayerInfo   `json:"layerStatuses,omitempty"`
 	ServiceStatuses map[string]*cloudprotocol.ServiceInfo `json:"serviceStatuses,omitempty"`
@@ -88,27 +97,38 @@ type softwareManager struct {
 	CurrentState    string                                `json:"currentState,omitempty"`
 	UpdateErr       string                                `json:"updateErr,omitempty"`
 	TTLDate         time.Time                             `json:"ttlDate,omitempty"`
+	Consent         *cloudprotocol.UpdateConsent          `json:"consent,omitempty"`
+	HistoryRecord   *updatehistory.Record                 `json:"historyRecord,omitempty"`
+	// RegisteredServices contains permissions hash of services registered in IAM
+	RegisteredServices map[string]string `json:"registeredServices,omitempty"`
 }
 
 /***********************************************************************************************************************
  * Interface
  **********************************************************************************************************************/
 
-func newSoftwareManager(statusHandler softwareStatusHandler,
-	softwareUpdater SoftwareUpdater, storage Storage, defaultTTL time.Duration) (manager *softwareManager, err error) {
+func newSoftwareManager(statusHandler softwareStatusHandler, softwareUpdater SoftwareUpdater,
+	serviceRegistrar ServiceRegistrar, storage Storage, defaultTTL time.Duration, preconditions PreconditionChecker,
+	preconditionsPeriod time.Duration, history UpdateHistory) (manager *softwareManager, err error) {
 	manager = &softwareManager{
-		statusChannel:   make(chan cmserver.UpdateSOTAStatus, 1),
-		statusHandler:   statusHandler,
-		softwareUpdater: softwareUpdater,
-		actionHandler:   action.New(maxConcurrentActions),
-		storage:         storage,
-		CurrentState:    stateNoUpdate,
+		statusChannel:    make(chan cmserver.UpdateSOTAStatus, 1),
+		statusHandler:    statusHandler,
+		softwareUpdater:  softwareUpdater,
+		serviceRegistrar: serviceRegistrar,
+		actionHandler:    action.New(maxConcurrentActions),
+		storage:          storage,
+		history:          history,
+		CurrentState:     stateNoUpdate,
 	}
 
 	if err = manager.loadState(); err != nil {
 		return nil, aoserrors.Wrap(err)
 	}
 
+	if manager.RegisteredServices == nil {
+		manager.RegisteredServices = make(map[string]string)
+	}
+
 	log.WithFields(log.Fields{"state": manager.CurrentState, "error": manager.UpdateErr}).Debug("New software manager")
 
 	manager.stateMachine = newUpdateStateMachine(manager.CurrentState, fsm.Events{
@@ -123,7 +143,7 @@ func newSoftwareManager(statusHandler softwareStatusHandler,
 		// updating state
 		{Name: eventFinishUpdate, Src: []string{stateUpdating}, Dst: stateNoUpdate},
 		{Name: eventCancel, Src: []string{stateUpdating}, Dst: stateNoUpdate},
-	}, manager, defaultTTL)
+	}, manager, defaultTTL, preconditions, preconditionsPeriod)
 
 	if err = manager.stateMachine.init(manager.TTLDate); err != nil {
 		return nil, aoserrors.Wrap(err)
@@ -150,6 +170,7 @@ func (manager *softwareManager) close() (err error) {
 func (manager *softwareManager) getCurrentStatus() (status cmserver.UpdateSOTAStatus) {
 	status.State = convertState(manager.CurrentState)
 	status.Error = manager.UpdateErr
+	status.Preconditions = manager.preconditions
 
 	if status.State == cmserver.NoUpdate || manager.CurrentUpdate == nil {
 		return status
@@ -199,7 +220,27 @@ func (manager *softwareManager) processDesiredStatus(desiredStatus cloudprotocol
 	manager.Lock()
 	defer manager.Unlock()
 
-	update := &softwareUpdate{
+	update, err := manager.getDesiredUpdate(desiredStatus)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	manager.registerInstalledServices(desiredStatus.Services)
+
+	if len(update.DownloadServices) != 0 || len(update.InstallServices) != 0 || len(update.RemoveServices) != 0 ||
+		len(update.DownloadLayers) != 0 || len(update.InstallLayers) != 0 || len(update.RemoveLayers) != 0 {
+		if err := manager.newUpdate(update); err != nil {
+			return aoserrors.Wrap(err)
+		}
+	}
+
+	return nil
+}
+
+// getDesiredUpdate returns software update required to reach desired status
+func (manager *softwareManager) getDesiredUpdate(
+	desiredStatus cloudprotocol.DecodedDesiredStatus) (update *softwareUpdate, err error) {
+	update = &softwareUpdate{
 		Schedule:         desiredStatus.SOTASchedule,
 		DownloadServices: make([]cloudprotocol.ServiceInfoFromCloud, 0),
 		InstallServices:  make([]cloudprotocol.ServiceInfoFromCloud, 0),
@@ -209,16 +250,18 @@ func (manager *softwareManager) processDesiredStatus(desiredStatus cloudprotocol
 		RemoveLayers:     make([]cloudprotocol.LayerInfo, 0),
 		CertChains:       desiredStatus.CertificateChains,
 		Certs:            desiredStatus.Certificates,
+
+		DesiredStatusHash: getDesiredStatusHash(desiredStatus),
 	}
 
 	usersServices, usersLayers, err := manager.softwareUpdater.GetUsersStatus(manager.currentUsers)
 	if err != nil {
-		return aoserrors.Wrap(err)
+		return nil, aoserrors.Wrap(err)
 	}
 
 	allServices, allLayers, err := manager.softwareUpdater.GetAllStatus()
 	if err != nil {
-		return aoserrors.Wrap(err)
+		return nil, aoserrors.Wrap(err)
 	}
 
 desiredServicesLoop:
@@ -289,29 +332,102 @@ usersLayersLoop:
 		update.RemoveLayers = append(update.RemoveLayers, installedLayer)
 	}
 
-	if len(update.DownloadServices) != 0 || len(update.InstallServices) != 0 || len(update.RemoveServices) != 0 ||
-		len(update.DownloadLayers) != 0 || len(update.InstallLayers) != 0 || len(update.RemoveLayers) != 0 {
-		if err := manager.newUpdate(update); err != nil {
-			return aoserrors.Wrap(err)
+	return update, nil
+}
+
+func (manager *softwareManager) startUpdate() (err error) {
+	manager.Lock()
+	defer manager.Unlock()
+
+	log.Debug("Start software update")
+
+	if manager.CurrentState == stateReadyToUpdate {
+		if preconditions := manager.stateMachine.checkPreconditions(); len(preconditions) != 0 {
+			if !reflect.DeepEqual(preconditions, manager.preconditions) {
+				manager.preconditions = preconditions
+				manager.sendCurrentStatus()
+			}
+
+			return nil
 		}
 	}
 
+	if err = manager.stateMachine.sendEvent(eventStartUpdate, ""); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
 	return nil
 }
 
-func (manager *softwareManager) startUpdate() (err error) {
+// acceptUpdate records user consent on update start
+func (manager *softwareManager) acceptUpdate() {
 	manager.Lock()
 	defer manager.Unlock()
 
-	log.Debug("Start software update")
+	if manager.CurrentState != stateReadyToUpdate {
+		return
+	}
 
-	if err = manager.stateMachine.sendEvent(eventStartUpdate, ""); err != nil {
+	manager.setConsent(newAcceptedConsent(cloudprotocol.SOTAUpdate, manager.Consent))
+}
+
+func (manager *softwareManager) postponeUpdate(reason string, duration time.Duration) (err error) {
+	manager.Lock()
+	defer manager.Unlock()
+
+	log.WithFields(log.Fields{"reason": reason, "duration": duration}).Debug("Postpone software update")
+
+	if err = checkConsentState(manager.CurrentState); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	consent, err := newPostponedConsent(
+		cloudprotocol.SOTAUpdate, manager.Consent, manager.CurrentUpdate.Schedule, manager.TTLDate, reason, duration)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	manager.setConsent(consent)
+
+	if manager.CurrentState == stateReadyToUpdate {
+		manager.stateMachine.scheduleUpdate(manager.CurrentUpdate.Schedule, manager.Consent)
+	}
+
+	return nil
+}
+
+func (manager *softwareManager) declineUpdate(reason string) (err error) {
+	manager.Lock()
+	defer manager.Unlock()
+
+	log.WithFields(log.Fields{"reason": reason}).Debug("Decline software update")
+
+	if err = checkConsentState(manager.CurrentState); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	consent, err := newDeclinedConsent(cloudprotocol.SOTAUpdate, manager.Consent, manager.CurrentUpdate.Schedule, reason)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	manager.setConsent(consent)
+
+	if err = manager.stateMachine.sendEvent(
+		eventCancel, aoserrors.Errorf("update declined: %s", reason).Error()); err != nil {
 		return aoserrors.Wrap(err)
 	}
 
 	return nil
 }
 
+func (manager *softwareManager) getConsent() (consent *cloudprotocol.UpdateConsent) {
+	manager.Lock()
+	defer manager.Unlock()
+
+	return manager.Consent
+}
+
 func (manager *softwareManager) getItemStatuses() (serviceStatuses []cloudprotocol.ServiceInfo,
 	layerStatuses []cloudprotocol.LayerInfo, err error) {
 	manager.Lock()
@@ -396,11 +512,14 @@ func (manager *softwareManager) stateChanged(event, state string, updateErr stri
 
 	manager.CurrentState = state
 	manager.UpdateErr = updateErr
+	manager.preconditions = nil
 
 	log.WithFields(log.Fields{
 		"state": state,
 		"event": event}).Debug("Software manager state changed")
 
+	manager.updateHistory(event, state, updateErr)
+
 	if updateErr != "" {
 		log.Errorf("Software update error: %s", updateErr)
 	}
@@ -429,6 +548,7 @@ func (manager *softwareManager) noUpdate() {
 
 		manager.CurrentUpdate = manager.pendingUpdate
 		manager.pendingUpdate = nil
+		manager.setConsent(nil)
 
 		go func() {
 			manager.Lock()
@@ -586,7 +706,7 @@ func (manager *softwareManager) download(ctx context.Context) {
 }
 
 func (manager *softwareManager) readyToUpdate() {
-	manager.stateMachine.scheduleUpdate(manager.CurrentUpdate.Schedule)
+	manager.stateMachine.scheduleUpdate(manager.CurrentUpdate.Schedule, manager.Consent)
 }
 
 func (manager *softwareManager) update(ctx context.Context) {
@@ -601,6 +721,9 @@ func (manager *softwareManager) update(ctx context.Context) {
 		}()
 	}()
 
+	// Place services before installing layers to install the layers only where they are required
+	manager.placeServices()
+
 	if errorStr := manager.installLayers(); errorStr != "" {
 		if updateErr == "" {
 			updateErr = errorStr
@@ -651,7 +774,15 @@ func (manager *softwareManager) newUpdate(update *softwareUpdate) (err error) {
 
 	switch manager.CurrentState {
 	case stateNoUpdate:
+		// Declined update is not started again until desired status is changed
+		if isDeclined(manager.Consent) && manager.CurrentUpdate != nil && manager.isCurrentUpdate(update) {
+			log.Debug("Skip declined software update")
+
+			return nil
+		}
+
 		manager.CurrentUpdate = update
+		manager.setConsent(nil)
 
 		if manager.TTLDate, err = manager.stateMachine.startNewUpdate(
 			time.Duration(manager.CurrentUpdate.Schedule.TTL) * time.Second); err != nil {
@@ -659,10 +790,7 @@ func (manager *softwareManager) newUpdate(update *softwareUpdate) (err error) {
 		}
 
 	default:
-		if reflect.DeepEqual(update.InstallLayers, manager.CurrentUpdate.InstallLayers) &&
-			reflect.DeepEqual(update.RemoveLayers, manager.CurrentUpdate.RemoveLayers) &&
-			reflect.DeepEqual(update.InstallServices, manager.CurrentUpdate.InstallServices) &&
-			reflect.DeepEqual(update.RemoveServices, manager.CurrentUpdate.RemoveServices) {
+		if manager.isCurrentUpdate(update) {
 			if reflect.DeepEqual(update.Schedule, manager.CurrentUpdate.Schedule) {
 				return nil
 			}
@@ -672,7 +800,7 @@ func (manager *softwareManager) newUpdate(update *softwareUpdate) (err error) {
 			if manager.CurrentState == stateReadyToUpdate && (manager.CurrentUpdate.Schedule.Type != cloudprotocol.ForceUpdate) {
 				manager.CurrentUpdate.Schedule = update.Schedule
 
-				manager.stateMachine.scheduleUpdate(manager.CurrentUpdate.Schedule)
+				manager.stateMachine.scheduleUpdate(manager.CurrentUpdate.Schedule, manager.Consent)
 
 				return nil
 			}
@@ -693,6 +821,27 @@ func (manager *softwareManager) newUpdate(update *softwareUpdate) (err error) {
 	return nil
 }
 
+// isCurrentUpdate checks if update contains the same items as current one
+func (manager *softwareManager) isCurrentUpdate(update *softwareUpdate) (result bool) {
+	return reflect.DeepEqual(update.InstallLayers, manager.CurrentUpdate.InstallLayers) &&
+		reflect.DeepEqual(update.RemoveLayers, manager.CurrentUpdate.RemoveLayers) &&
+		reflect.DeepEqual(update.InstallServices, manager.CurrentUpdate.InstallServices) &&
+		reflect.DeepEqual(update.RemoveServices, manager.CurrentUpdate.RemoveServices)
+}
+
+func (manager *softwareManager) setConsent(consent *cloudprotocol.UpdateConsent) {
+	if consent == nil && manager.Consent == nil {
+		return
+	}
+
+	manager.Consent = consent
+	manager.statusHandler.updateConsentStatus(cloudprotocol.SOTAUpdate, consent)
+
+	if err := manager.saveState(); err != nil {
+		log.Errorf("Can't save current software manager state: %s", err)
+	}
+}
+
 func (manager *softwareManager) sendCurrentStatus() {
 	manager.statusChannel <- manager.getCurrentStatus()
 }
@@ -770,6 +919,23 @@ func (manager *softwareManager) saveState() (err error) {
 	return nil
 }
 
+func (manager *softwareManager) placeServices() {
+	services := make([]cloudprotocol.ServiceInfoFromCloud, 0,
+		len(manager.CurrentUpdate.DownloadServices)+len(manager.CurrentUpdate.InstallServices))
+
+	services = append(services, manager.CurrentUpdate.DownloadServices...)
+	services = append(services, manager.CurrentUpdate.InstallServices...)
+
+	if len(services) == 0 {
+		return
+	}
+
+	// Services which can't be placed are reported on install
+	if err := manager.softwareUpdater.PlaceServices(services); err != nil {
+		log.Warnf("Can't place services: %s", err)
+	}
+}
+
 func (manager *softwareManager) installLayers() (installErr string) {
 	var mutex sync.Mutex
 
@@ -944,6 +1110,15 @@ func (manager *softwareManager) installServices() (installErr string) {
 
 	installServices = append(installServices, manager.CurrentUpdate.InstallServices...)
 
+	installedServices, err := manager.getInstalledServices()
+	if err != nil {
+		for _, service := range installServices {
+			handleError(service, aoserrors.Wrap(err).Error())
+		}
+
+		return installErr
+	}
+
 	for _, service := range installServices {
 		log.WithFields(log.Fields{
 			"id":         service.ID,
@@ -955,13 +1130,28 @@ func (manager *softwareManager) installServices() (installErr string) {
 		// Create new variable to be captured by action function
 		serviceInfo := service
 
+		// Registration of previously installed services should not be rolled back on failure
+		newService := !installedServices[serviceInfo.ID]
+
 		manager.actionHandler.Execute(serviceInfo.ID, func(serviceID string) {
+			// Register service on each install to update its permissions
+			if err := manager.registerService(serviceInfo); err != nil {
+				handleError(serviceInfo, aoserrors.Wrap(err).Error())
+				return
+			}
+
 			stateChecksum, err := manager.softwareUpdater.InstallService(manager.currentUsers, serviceInfo)
 			if err != nil {
+				if newService {
+					manager.unregisterService(serviceInfo.ID)
+				}
+
 				handleError(serviceInfo, aoserrors.Wrap(err).Error())
 				return
 			}
 
+			manager.setServiceRegistered(serviceInfo)
+
 			log.WithFields(log.Fields{
 				"id":            serviceInfo.ID,
 				"aosVersion":    serviceInfo.AosVersion,
@@ -1026,6 +1216,14 @@ func (manager *softwareManager) removeServices() (removeErr string) {
 				return
 			}
 
+			// Service is removed for current users only, keep registration while other users have it
+			installedServices, err := manager.getInstalledServices()
+			if err != nil {
+				log.WithField("id", serviceStatus.ID).Errorf("Can't get installed services: %s", err)
+			} else if !installedServices[serviceStatus.ID] {
+				manager.unregisterService(serviceStatus.ID)
+			}
+
 			log.WithFields(log.Fields{
 				"id":         serviceStatus.ID,
 				"aosVersion": serviceStatus.AosVersion,
@@ -1040,6 +1238,138 @@ func (manager *softwareManager) removeServices() (removeErr string) {
 	return removeErr
 }
 
+func (manager *softwareManager) getInstalledServices() (installedServices map[string]bool, err error) {
+	allServices, _, err := manager.softwareUpdater.GetAllStatus()
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	installedServices = make(map[string]bool)
+
+	for _, service := range allServices {
+		if service.Status == cloudprotocol.InstalledStatus {
+			installedServices[service.ID] = true
+		}
+	}
+
+	return installedServices, nil
+}
+
+// registerInstalledServices registers installed services which are not registered in IAM or have changed
+// permissions, e.g. services installed before CM registered services. Such services are not reinstalled.
+func (manager *softwareManager) registerInstalledServices(desiredServices []cloudprotocol.ServiceInfoFromCloud) {
+	if manager.serviceRegistrar == nil {
+		return
+	}
+
+	usersServices, _, err := manager.softwareUpdater.GetUsersStatus(manager.currentUsers)
+	if err != nil {
+		log.Errorf("Can't get users services: %s", err)
+		return
+	}
+
+	registered := false
+
+	for _, desiredService := range desiredServices {
+		if manager.isServiceRegistered(desiredService) {
+			continue
+		}
+
+		for _, usersService := range usersServices {
+			if desiredService.ID != usersService.ID || desiredService.AosVersion != usersService.AosVersion ||
+				usersService.Status != cloudprotocol.InstalledStatus {
+				continue
+			}
+
+			if err = manager.registerService(desiredService); err != nil {
+				log.WithField("id", desiredService.ID).Errorf("Can't register service: %s", err)
+				break
+			}
+
+			manager.setServiceRegistered(desiredService)
+
+			registered = true
+
+			break
+		}
+	}
+
+	if registered {
+		if err = manager.saveState(); err != nil {
+			log.Errorf("Can't save software manager state: %s", err)
+		}
+	}
+}
+
+func (manager *softwareManager) registerService(service cloudprotocol.ServiceInfoFromCloud) (err error) {
+	if manager.serviceRegistrar == nil {
+		return nil
+	}
+
+	log.WithField("id", service.ID).Debug("Register service")
+
+	// Only permissions are registered, CM doesn't deliver the secret to the service
+	if _, err = manager.serviceRegistrar.RegisterService(service.ID, service.Permissions); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (manager *softwareManager) unregisterService(serviceID string) {
+	if manager.serviceRegistrar == nil {
+		return
+	}
+
+	log.WithField("id", serviceID).Debug("Unregister service")
+
+	if err := manager.serviceRegistrar.UnregisterService(serviceID); err != nil {
+		log.WithField("id", serviceID).Errorf("Can't unregister service: %s", err)
+	}
+
+	manager.statusMutex.Lock()
+	defer manager.statusMutex.Unlock()
+
+	delete(manager.RegisteredServices, serviceID)
+}
+
+func (manager *softwareManager) isServiceRegistered(service cloudprotocol.ServiceInfoFromCloud) (registered bool) {
+	if manager.serviceRegistrar == nil {
+		return true
+	}
+
+	manager.statusMutex.RLock()
+	defer manager.statusMutex.RUnlock()
+
+	hash, ok := manager.RegisteredServices[service.ID]
+
+	return ok && hash == getPermissionsHash(service.Permissions)
+}
+
+func (manager *softwareManager) setServiceRegistered(service cloudprotocol.ServiceInfoFromCloud) {
+	if manager.serviceRegistrar == nil {
+		return
+	}
+
+	manager.statusMutex.Lock()
+	defer manager.statusMutex.Unlock()
+
+	manager.RegisteredServices[service.ID] = getPermissionsHash(service.Permissions)
+}
+
+func getPermissionsHash(permissions map[string]map[string]string) (hash string) {
+	// Map keys are sorted by json marshaller, so the hash doesn't depend on map order
+	data, err := json.Marshal(permissions)
+	if err != nil {
+		log.Errorf("Can't marshal service permissions: %s", err)
+		return ""
+	}
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
 func getLayerUpdateID(layer cloudprotocol.LayerInfoFromCloud) (id string) {
 	return base64.URLEncoding.EncodeToString(layer.DecryptDataStruct.Sha256)
 }
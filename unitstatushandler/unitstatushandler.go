@@ -24,6 +24,7 @@ import (
 	"os"
 	"path"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -34,6 +35,7 @@ import (
 	"aos_communicationmanager/cmserver"
 	"aos_communicationmanager/config"
 	"aos_communicationmanager/downloader"
+	"aos_communicationmanager/updatehistory"
 )
 
 /***********************************************************************************************************************
@@ -57,7 +59,8 @@ type StatusSender interface {
 type BoardConfigUpdater interface {
 	GetStatus() (boardConfigInfo cloudprotocol.BoardConfigInfo, err error)
 	GetBoardConfigVersion(configJSON json.RawMessage) (vendorVersion string, err error)
-	CheckBoardConfig(configJSON json.RawMessage) (vendorVersion string, err error)
+	CheckBoardConfig(configJSON json.RawMessage) (
+		vendorVersion string, nodesInfo []cloudprotocol.NodeBoardConfigInfo, err error)
 	UpdateBoardConfig(configJSON json.RawMessage) (err error)
 }
 
@@ -66,6 +69,7 @@ type FirmwareUpdater interface {
 	GetStatus() (componentsInfo []cloudprotocol.ComponentInfo, err error)
 	UpdateComponents(components []cloudprotocol.ComponentInfoFromCloud) (
 		status []cloudprotocol.ComponentInfo, err error)
+	GetComponentUM(componentID string) (umID string, err error)
 }
 
 // SoftwareUpdater updates services, layers
@@ -73,11 +77,29 @@ type SoftwareUpdater interface {
 	GetUsersStatus(users []string) (servicesInfo []cloudprotocol.ServiceInfo,
 		layersInfo []cloudprotocol.LayerInfo, err error)
 	GetAllStatus() (servicesInfo []cloudprotocol.ServiceInfo, layersInfo []cloudprotocol.LayerInfo, err error)
+	PlaceServices(services []cloudprotocol.ServiceInfoFromCloud) (err error)
 	InstallService(users []string, serviceInfo cloudprotocol.ServiceInfoFromCloud) (stateChecksum string, err error)
 	RemoveService(users []string, serviceInfo cloudprotocol.ServiceInfo) (err error)
 	InstallLayer(layerInfo cloudprotocol.LayerInfoFromCloud) (err error)
 }
 
+// ServiceRegistrar registers services in IAM
+type ServiceRegistrar interface {
+	RegisterService(serviceID string, permissions map[string]map[string]string) (secret string, err error)
+	UnregisterService(serviceID string) (err error)
+}
+
+// PreconditionChecker checks preconditions which should be satisfied before update is started
+type PreconditionChecker interface {
+	CheckPreconditions() (unsatisfied []string)
+}
+
+// UpdateHistory stores history of performed updates
+type UpdateHistory interface {
+	AddRecord(record *updatehistory.Record) (err error)
+	UpdateRecord(record updatehistory.Record) (err error)
+}
+
 // Storage used to store unit status handler states
 type Storage interface {
 	SetFirmwareUpdateState(state json.RawMessage) (err error)
@@ -100,6 +122,8 @@ type Instance struct {
 	componentStatuses map[string]*itemStatus
 	layerStatuses     map[string]*itemStatus
 	serviceStatuses   map[string]*itemStatus
+	nodesHealth       []cloudprotocol.NodeHealth
+	updateConsents    map[string]cloudprotocol.UpdateConsent
 
 	sendStatusPeriod time.Duration
 
@@ -125,9 +149,12 @@ func New(
 	boardConfigUpdater BoardConfigUpdater,
 	firmwareUpdater FirmwareUpdater,
 	softwareUpdater SoftwareUpdater,
+	serviceRegistrar ServiceRegistrar,
 	downloader Downloader,
 	storage Storage,
-	statusSender StatusSender) (instance *Instance, err error) {
+	statusSender StatusSender,
+	preconditions PreconditionChecker,
+	history UpdateHistory) (instance *Instance, err error) {
 	log.Debug("Create unit status handler")
 
 	instance = &Instance{
@@ -141,14 +168,17 @@ func New(
 	instance.componentStatuses = make(map[string]*itemStatus)
 	instance.layerStatuses = make(map[string]*itemStatus)
 	instance.serviceStatuses = make(map[string]*itemStatus)
+	instance.updateConsents = make(map[string]cloudprotocol.UpdateConsent)
 
 	if instance.firmwareManager, err = newFirmwareManager(instance, firmwareUpdater, boardConfigUpdater,
-		storage, cfg.UMController.UpdateTTL.Duration); err != nil {
+		storage, cfg.UMController.UpdateTTL.Duration, preconditions, cfg.Preconditions.CheckPeriod.Duration,
+		history); err != nil {
 		return nil, aoserrors.Wrap(err)
 	}
 
 	if instance.softwareManager, err = newSoftwareManager(instance, softwareUpdater,
-		storage, cfg.SMController.UpdateTTL.Duration); err != nil {
+		serviceRegistrar, storage, cfg.SMController.UpdateTTL.Duration, preconditions,
+		cfg.Preconditions.CheckPeriod.Duration, history); err != nil {
 		return nil, aoserrors.Wrap(err)
 	}
 
@@ -303,11 +333,41 @@ func (instance *Instance) SendUnitStatus() (err error) {
 		instance.processLayerStatus(status)
 	}
 
+	// Get update consents
+
+	instance.updateConsents = make(map[string]cloudprotocol.UpdateConsent)
+
+	if consent := instance.firmwareManager.getConsent(); consent != nil {
+		instance.updateConsents[cloudprotocol.FOTAUpdate] = *consent
+	}
+
+	if consent := instance.softwareManager.getConsent(); consent != nil {
+		instance.updateConsents[cloudprotocol.SOTAUpdate] = *consent
+	}
+
 	instance.sendCurrentStatus()
 
 	return nil
 }
 
+// UpdateNodesHealth updates SM nodes health in unit status
+func (instance *Instance) UpdateNodesHealth(nodesHealth []cloudprotocol.NodeHealth) {
+	instance.statusMutex.Lock()
+	defer instance.statusMutex.Unlock()
+
+	instance.nodesHealth = nodesHealth
+
+	instance.statusChanged()
+}
+
+// UpdateComponentsProgress updates progress of components being updated
+func (instance *Instance) UpdateComponentsProgress(progress []cloudprotocol.ComponentProgress) {
+	instance.Lock()
+	defer instance.Unlock()
+
+	instance.firmwareManager.updateProgress(progress)
+}
+
 // GetFOTAStatusChannel returns FOTA status channels
 func (instance *Instance) GetFOTAStatusChannel() (channel <-chan cmserver.UpdateFOTAStatus) {
 	instance.Lock()
@@ -345,6 +405,8 @@ func (instance *Instance) StartFOTAUpdate() (err error) {
 	instance.Lock()
 	defer instance.Unlock()
 
+	instance.firmwareManager.acceptUpdate()
+
 	return instance.firmwareManager.startUpdate()
 }
 
@@ -353,6 +415,8 @@ func (instance *Instance) StartSOTAUpdate() (err error) {
 	instance.Lock()
 	defer instance.Unlock()
 
+	instance.softwareManager.acceptUpdate()
+
 	return instance.softwareManager.startUpdate()
 }
 
@@ -539,6 +603,7 @@ func (instance *Instance) sendCurrentStatus() {
 		Components:  make([]cloudprotocol.ComponentInfo, 0, len(instance.componentStatuses)),
 		Layers:      make([]cloudprotocol.LayerInfo, 0, len(instance.layerStatuses)),
 		Services:    make([]cloudprotocol.ServiceInfo, 0, len(instance.serviceStatuses)),
+		Nodes:       instance.nodesHealth,
 	}
 
 	for _, status := range instance.boardConfigStatus {
@@ -563,6 +628,12 @@ func (instance *Instance) sendCurrentStatus() {
 		}
 	}
 
+	for _, updateType := range []string{cloudprotocol.FOTAUpdate, cloudprotocol.SOTAUpdate} {
+		if consent, ok := instance.updateConsents[updateType]; ok {
+			unitStatus.Consents = append(unitStatus.Consents, consent)
+		}
+	}
+
 	if err := instance.statusSender.SendUnitStatus(unitStatus); err != nil {
 		log.Errorf("Can't send unit status: %s", err)
 	}
@@ -580,6 +651,11 @@ func (instance *Instance) clearDecryptDir() (err error) {
 	}
 
 	for _, file := range files {
+		// Kept images are required to reschedule services and to install deferred components
+		if strings.HasPrefix(file.Name(), config.KeptImagePrefix) {
+			continue
+		}
+
 		fileName := path.Join(instance.decryptDir, file.Name())
 
 		log.WithFields(log.Fields{"file": fileName}).Debug("Remove outdated decrypt file")
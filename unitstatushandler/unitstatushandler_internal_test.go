@@ -23,7 +23,10 @@ import (
 	"errors"
 	"io/ioutil"
 	"os"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -34,6 +37,7 @@ import (
 	"aos_communicationmanager/cmserver"
 	"aos_communicationmanager/config"
 	"aos_communicationmanager/downloader"
+	"aos_communicationmanager/updatehistory"
 )
 
 /***********************************************************************************************************************
@@ -60,6 +64,8 @@ type TestBoardConfigUpdater struct {
 	BoardConfigInfo cloudprotocol.BoardConfigInfo
 	UpdateVersion   string
 	UpdateError     error
+	CheckError      error
+	NodesInfo       []cloudprotocol.NodeBoardConfigInfo
 }
 
 type TestFirmwareUpdater struct {
@@ -70,6 +76,8 @@ type TestFirmwareUpdater struct {
 }
 
 type TestSoftwareUpdater struct {
+	sync.Mutex
+
 	UsersServices []cloudprotocol.ServiceInfo
 	UsersLayers   []cloudprotocol.LayerInfo
 	AllServices   []cloudprotocol.ServiceInfo
@@ -77,6 +85,13 @@ type TestSoftwareUpdater struct {
 	UpdateError   error
 }
 
+type TestServiceRegistrar struct {
+	sync.Mutex
+
+	registered   map[string]map[string]map[string]string
+	unregistered []string
+}
+
 type TestDownloader struct {
 	DownloadTime   time.Duration
 	DownloadedURLs []string
@@ -102,6 +117,16 @@ type TestStorage struct {
 	fotaState json.RawMessage
 }
 
+type testPreconditions struct {
+	sync.Mutex
+	unsatisfied []string
+}
+
+type testHistory struct {
+	sync.Mutex
+	records []updatehistory.Record
+}
+
 /***********************************************************************************************************************
  * Vars
  **********************************************************************************************************************/
@@ -151,7 +176,7 @@ func TestDownload(t *testing.T) {
 
 	statusHandler, err := New(&config.Config{},
 		NewTestBoardConfigUpdater(cloudprotocol.BoardConfigInfo{}), NewTestFirmwareUpdater(nil),
-		NewTestSoftwareUpdater(nil, nil), testDownloader, NewTestStorage(), NewTestSender())
+		NewTestSoftwareUpdater(nil, nil), nil, testDownloader, NewTestStorage(), NewTestSender(), nil, nil)
 	if err != nil {
 		t.Fatalf("Can't create unit status handler: %s", err)
 	}
@@ -246,6 +271,7 @@ func TestFirmwareManager(t *testing.T) {
 		boardConfigError        error
 		triggerUpdate           bool
 		updateWaitStatuses      []cmserver.UpdateStatus
+		installedAnnotations    map[string]installedAnnotations
 	}
 
 	updateComponents := []cloudprotocol.ComponentInfoFromCloud{
@@ -310,6 +336,28 @@ func TestFirmwareManager(t *testing.T) {
 				{State: cmserver.Downloading}, {State: cmserver.ReadyToUpdate},
 				{State: cmserver.Updating}, {State: cmserver.NoUpdate}},
 		},
+		{
+			testID:     "update with deferred component",
+			initStatus: &cmserver.UpdateStatus{State: cmserver.NoUpdate},
+			initComponentStatuses: []cloudprotocol.ComponentInfo{
+				{ID: "comp1", VendorVersion: "0.0", Status: cloudprotocol.InstalledStatus},
+				{ID: "comp2", VendorVersion: "1.0", Status: cloudprotocol.InstalledStatus},
+			},
+			desiredStatus: &cloudprotocol.DecodedDesiredStatus{Components: updateComponents},
+			downloadResult: map[string]*downloadResult{
+				updateComponents[0].ID: {},
+				updateComponents[1].ID: {},
+			},
+			updateComponentStatuses: []cloudprotocol.ComponentInfo{
+				{ID: "comp1", VendorVersion: "1.0", Status: cloudprotocol.InstalledStatus},
+				{ID: "comp2", VendorVersion: "2.0", Status: cloudprotocol.PendingStatus,
+					Error: "update manager is not connected, update is deferred"},
+			},
+			updateWaitStatuses: []cmserver.UpdateStatus{
+				{State: cmserver.Downloading}, {State: cmserver.ReadyToUpdate},
+				{State: cmserver.Updating}, {State: cmserver.NoUpdate}},
+			installedAnnotations: map[string]installedAnnotations{"comp1": {VendorVersion: "1.0"}},
+		},
 		{
 			testID:     "download error",
 			initStatus: &cmserver.UpdateStatus{State: cmserver.NoUpdate},
@@ -675,7 +723,7 @@ func TestFirmwareManager(t *testing.T) {
 		// Create firmware manager
 
 		firmwareManager, err := newFirmwareManager(statusHandler, firmwareUpdater, boardConfigUpdater,
-			testStorage, 30*time.Second)
+			testStorage, 30*time.Second, nil, 0, nil)
 		if err != nil {
 			t.Errorf("Can't create firmware manager: %s", err)
 			continue
@@ -716,6 +764,13 @@ func TestFirmwareManager(t *testing.T) {
 			}
 		}
 
+		// Check annotations of installed components
+
+		if item.installedAnnotations != nil &&
+			!reflect.DeepEqual(firmwareManager.InstalledAnnotations, item.installedAnnotations) {
+			t.Errorf("Wrong installed annotations: %v", firmwareManager.InstalledAnnotations)
+		}
+
 	close:
 		// Close firmware manager
 
@@ -725,6 +780,470 @@ func TestFirmwareManager(t *testing.T) {
 	}
 }
 
+func TestFirmwareManagerProgress(t *testing.T) {
+	updateComponents := []cloudprotocol.ComponentInfoFromCloud{
+		{
+			ID:                "comp1",
+			VersionFromCloud:  cloudprotocol.VersionFromCloud{VendorVersion: "1.0"},
+			DecryptDataStruct: cloudprotocol.DecryptDataStruct{Sha256: []byte{1}},
+		},
+	}
+
+	progress := []cloudprotocol.ComponentProgress{
+		{ID: "comp1", VendorVersion: "1.0", Percentage: 50, Phase: "flashing"},
+	}
+
+	firmwareUpdater := NewTestFirmwareUpdater(nil)
+	firmwareUpdater.UpdateTime = time.Second
+	firmwareUpdater.UpdateComponentsInfo = []cloudprotocol.ComponentInfo{
+		{ID: "comp1", VendorVersion: "1.0", Status: cloudprotocol.InstalledStatus},
+	}
+
+	testStorage := NewTestStorage()
+
+	if err := testStorage.saveFirmwareState(&firmwareManager{
+		CurrentState: stateReadyToUpdate,
+		CurrentUpdate: &firmwareUpdate{
+			Schedule:   cloudprotocol.ScheduleRule{Type: cloudprotocol.TriggerUpdate},
+			Components: updateComponents},
+		DownloadResult: map[string]*downloadResult{updateComponents[0].ID: {}},
+		ComponentStatuses: map[string]*cloudprotocol.ComponentInfo{
+			updateComponents[0].ID: {ID: updateComponents[0].ID, VendorVersion: updateComponents[0].VendorVersion},
+		},
+	}); err != nil {
+		t.Fatalf("Can't save init state: %s", err)
+	}
+
+	firmwareManager, err := newFirmwareManager(newTestStatusHandler(), firmwareUpdater,
+		NewTestBoardConfigUpdater(cloudprotocol.BoardConfigInfo{}), testStorage, 30*time.Second, nil, 0, nil)
+	if err != nil {
+		t.Fatalf("Can't create firmware manager: %s", err)
+	}
+	defer firmwareManager.close()
+
+	// Progress is ignored if update is not started
+
+	firmwareManager.updateProgress(progress)
+
+	if status := firmwareManager.getCurrentStatus(); status.Progress != nil {
+		t.Errorf("Unexpected progress: %v", status.Progress)
+	}
+
+	if err = firmwareManager.startUpdate(); err != nil {
+		t.Fatalf("Start update failed: %s", err)
+	}
+
+	if err = waitForFOTAUpdateStatus(
+		firmwareManager.statusChannel, cmserver.UpdateStatus{State: cmserver.Updating}); err != nil {
+		t.Fatalf("Wait for update status error: %s", err)
+	}
+
+	firmwareManager.updateProgress(progress)
+
+	select {
+	case status := <-firmwareManager.statusChannel:
+		if !reflect.DeepEqual(status.Progress, progress) {
+			t.Errorf("Wrong progress: %v", status.Progress)
+		}
+
+	case <-time.After(waitStatusTimeout):
+		t.Fatal("Wait for progress timeout")
+	}
+
+	if err = waitForFOTAUpdateStatus(
+		firmwareManager.statusChannel, cmserver.UpdateStatus{State: cmserver.NoUpdate}); err != nil {
+		t.Fatalf("Wait for update status error: %s", err)
+	}
+
+	if status := firmwareManager.getCurrentStatus(); status.Progress != nil {
+		t.Errorf("Progress is not reset: %v", status.Progress)
+	}
+}
+
+func TestFirmwareManagerPreconditions(t *testing.T) {
+	updateComponents := []cloudprotocol.ComponentInfoFromCloud{
+		{
+			ID:                "comp1",
+			VersionFromCloud:  cloudprotocol.VersionFromCloud{VendorVersion: "1.0"},
+			DecryptDataStruct: cloudprotocol.DecryptDataStruct{Sha256: []byte{1}},
+		},
+	}
+
+	blocked := []string{"parked: vehicle is not parked", "battery: battery level 10% is below 40%"}
+
+	newManager := func(ttlDate time.Time, preconditions *testPreconditions) (manager *firmwareManager) {
+		firmwareUpdater := NewTestFirmwareUpdater(nil)
+		firmwareUpdater.UpdateComponentsInfo = []cloudprotocol.ComponentInfo{
+			{ID: "comp1", VendorVersion: "1.0", Status: cloudprotocol.InstalledStatus},
+		}
+
+		testStorage := NewTestStorage()
+
+		if err := testStorage.saveFirmwareState(&firmwareManager{
+			CurrentState: stateReadyToUpdate,
+			CurrentUpdate: &firmwareUpdate{
+				Schedule:   cloudprotocol.ScheduleRule{Type: cloudprotocol.TriggerUpdate},
+				Components: updateComponents},
+			DownloadResult: map[string]*downloadResult{updateComponents[0].ID: {}},
+			ComponentStatuses: map[string]*cloudprotocol.ComponentInfo{
+				updateComponents[0].ID: {ID: updateComponents[0].ID, VendorVersion: updateComponents[0].VendorVersion},
+			},
+			TTLDate: ttlDate,
+		}); err != nil {
+			t.Fatalf("Can't save init state: %s", err)
+		}
+
+		manager, err := newFirmwareManager(newTestStatusHandler(), firmwareUpdater,
+			NewTestBoardConfigUpdater(cloudprotocol.BoardConfigInfo{}), testStorage, 30*time.Second,
+			preconditions, 100*time.Millisecond, nil)
+		if err != nil {
+			t.Fatalf("Can't create firmware manager: %s", err)
+		}
+
+		return manager
+	}
+
+	waitPreconditions := func(manager *firmwareManager, expected []string) {
+		select {
+		case status := <-manager.statusChannel:
+			if status.State != cmserver.ReadyToUpdate || !reflect.DeepEqual(status.Preconditions, expected) {
+				t.Errorf("Wrong status: %v", status)
+			}
+
+		case <-time.After(waitStatusTimeout):
+			t.Fatal("Wait for preconditions status timeout")
+		}
+	}
+
+	// Update waits until preconditions are satisfied
+
+	preconditions := &testPreconditions{unsatisfied: blocked}
+
+	firmwareManager := newManager(time.Now().Add(time.Minute), preconditions)
+	defer firmwareManager.close()
+
+	if err := firmwareManager.startUpdate(); err != nil {
+		t.Fatalf("Start update failed: %s", err)
+	}
+
+	waitPreconditions(firmwareManager, blocked)
+
+	preconditions.set(blocked[1:])
+
+	waitPreconditions(firmwareManager, blocked[1:])
+
+	if state := firmwareManager.getCurrentUpdateState(); state != cmserver.ReadyToUpdate {
+		t.Errorf("Wrong update state: %d", state)
+	}
+
+	preconditions.set(nil)
+
+	if err := waitForFOTAUpdateStatus(
+		firmwareManager.statusChannel, cmserver.UpdateStatus{State: cmserver.Updating}); err != nil {
+		t.Fatalf("Wait for update status error: %s", err)
+	}
+
+	if err := waitForFOTAUpdateStatus(
+		firmwareManager.statusChannel, cmserver.UpdateStatus{State: cmserver.NoUpdate}); err != nil {
+		t.Fatalf("Wait for update status error: %s", err)
+	}
+
+	// TTL keeps counting while update is blocked
+
+	ttlManager := newManager(time.Now().Add(500*time.Millisecond), &testPreconditions{unsatisfied: blocked})
+	defer ttlManager.close()
+
+	if err := ttlManager.startUpdate(); err != nil {
+		t.Fatalf("Start update failed: %s", err)
+	}
+
+	waitPreconditions(ttlManager, blocked)
+
+	if err := waitForFOTAUpdateStatus(
+		ttlManager.statusChannel, cmserver.UpdateStatus{State: cmserver.NoUpdate, Error: "update timeout"}); err != nil {
+		t.Fatalf("Wait for update status error: %s", err)
+	}
+}
+
+func TestFirmwareManagerConsent(t *testing.T) {
+	updateComponents := []cloudprotocol.ComponentInfoFromCloud{
+		{
+			ID:                "comp1",
+			VersionFromCloud:  cloudprotocol.VersionFromCloud{VendorVersion: "1.0"},
+			DecryptDataStruct: cloudprotocol.DecryptDataStruct{Sha256: []byte{1}},
+		},
+	}
+
+	newManager := func(
+		schedule cloudprotocol.ScheduleRule, consent *cloudprotocol.UpdateConsent) (manager *firmwareManager) {
+		firmwareUpdater := NewTestFirmwareUpdater(nil)
+		firmwareUpdater.UpdateComponentsInfo = []cloudprotocol.ComponentInfo{
+			{ID: "comp1", VendorVersion: "1.0", Status: cloudprotocol.InstalledStatus},
+		}
+
+		testStorage := NewTestStorage()
+
+		if err := testStorage.saveFirmwareState(&firmwareManager{
+			CurrentState:   stateReadyToUpdate,
+			CurrentUpdate:  &firmwareUpdate{Schedule: schedule, Components: updateComponents},
+			DownloadResult: map[string]*downloadResult{updateComponents[0].ID: {}},
+			ComponentStatuses: map[string]*cloudprotocol.ComponentInfo{
+				updateComponents[0].ID: {ID: updateComponents[0].ID, VendorVersion: updateComponents[0].VendorVersion},
+			},
+			Consent: consent,
+		}); err != nil {
+			t.Fatalf("Can't save init state: %s", err)
+		}
+
+		manager, err := newFirmwareManager(newTestStatusHandler(), firmwareUpdater,
+			NewTestBoardConfigUpdater(cloudprotocol.BoardConfigInfo{}), testStorage, 30*time.Second, nil, 0, nil)
+		if err != nil {
+			t.Fatalf("Can't create firmware manager: %s", err)
+		}
+
+		return manager
+	}
+
+	// Postponed forced update is not started and postponements are limited
+
+	postponedManager := newManager(
+		cloudprotocol.ScheduleRule{Type: cloudprotocol.ForceUpdate, MaxPostponements: 2},
+		&cloudprotocol.UpdateConsent{
+			UpdateType: cloudprotocol.FOTAUpdate, Decision: cloudprotocol.ConsentPostponed, Postponements: 1,
+			PostponedTill: time.Now().Add(time.Hour),
+		})
+	defer postponedManager.close()
+
+	if err := postponedManager.postponeUpdate("driving", 0); err == nil {
+		t.Error("Error expected for zero postpone duration")
+	}
+
+	if err := postponedManager.postponeUpdate("driving", time.Hour); err != nil {
+		t.Fatalf("Can't postpone update: %s", err)
+	}
+
+	if consent := postponedManager.getConsent(); consent == nil ||
+		consent.Decision != cloudprotocol.ConsentPostponed || consent.Postponements != 2 || consent.Reason != "driving" {
+		t.Errorf("Wrong consent: %v", consent)
+	}
+
+	if err := postponedManager.postponeUpdate("driving", time.Hour); err == nil ||
+		!strings.Contains(err.Error(), "max postponements") {
+		t.Errorf("Wrong postpone error: %v", err)
+	}
+
+	if state := postponedManager.getCurrentUpdateState(); state != cmserver.ReadyToUpdate {
+		t.Errorf("Wrong update state: %d", state)
+	}
+
+	// Declined update is canceled and not started again for the same desired status
+
+	if err := postponedManager.declineUpdate("not now"); err != nil {
+		t.Fatalf("Can't decline update: %s", err)
+	}
+
+	if err := waitForFOTAUpdateStatus(postponedManager.statusChannel, cmserver.UpdateStatus{
+		State: cmserver.NoUpdate, Error: "update declined: not now"}); err != nil {
+		t.Fatalf("Wait for update status error: %s", err)
+	}
+
+	if consent := postponedManager.getConsent(); consent == nil || consent.Decision != cloudprotocol.ConsentDeclined ||
+		consent.Postponements != 2 {
+		t.Errorf("Wrong consent: %v", consent)
+	}
+
+	if err := postponedManager.declineUpdate("not now"); err == nil {
+		t.Error("Error expected for declining without update")
+	}
+
+	if err := postponedManager.processDesiredStatus(cloudprotocol.DecodedDesiredStatus{
+		Components: []cloudprotocol.ComponentInfoFromCloud{{
+			ID:                "comp1",
+			VersionFromCloud:  cloudprotocol.VersionFromCloud{VendorVersion: "1.0"},
+			DecryptDataStruct: cloudprotocol.DecryptDataStruct{Sha256: []byte{1}},
+		}},
+		FOTASchedule: cloudprotocol.ScheduleRule{Type: cloudprotocol.ForceUpdate, MaxPostponements: 2},
+	}); err != nil {
+		t.Fatalf("Process desired status failed: %s", err)
+	}
+
+	if state := postponedManager.getCurrentUpdateState(); state != cmserver.NoUpdate {
+		t.Errorf("Wrong update state: %d", state)
+	}
+
+	// Update can't be postponed beyond TTL
+
+	ttlManager := newManager(cloudprotocol.ScheduleRule{Type: cloudprotocol.TriggerUpdate}, nil)
+	defer ttlManager.close()
+
+	ttlManager.Lock()
+	ttlManager.TTLDate = time.Now().Add(time.Minute)
+	ttlManager.Unlock()
+
+	if err := ttlManager.postponeUpdate("driving", time.Hour); err == nil || !strings.Contains(err.Error(), "TTL") {
+		t.Errorf("Wrong postpone error: %v", err)
+	}
+
+	if consent := ttlManager.getConsent(); consent != nil {
+		t.Errorf("Unexpected consent: %v", consent)
+	}
+
+	if err := ttlManager.postponeUpdate("driving", time.Second); err != nil {
+		t.Errorf("Can't postpone update: %s", err)
+	}
+
+	// Mandatory update can't be declined or postponed beyond deadline and is started at deadline
+
+	deadlineManager := newManager(cloudprotocol.ScheduleRule{
+		Type: cloudprotocol.TriggerUpdate, Deadline: time.Now().Add(500 * time.Millisecond)}, nil)
+	defer deadlineManager.close()
+
+	if err := deadlineManager.postponeUpdate("driving", time.Hour); err == nil ||
+		!strings.Contains(err.Error(), "deadline") {
+		t.Errorf("Wrong postpone error: %v", err)
+	}
+
+	if err := deadlineManager.declineUpdate("not now"); err == nil || !strings.Contains(err.Error(), "mandatory") {
+		t.Errorf("Wrong decline error: %v", err)
+	}
+
+	if consent := deadlineManager.getConsent(); consent != nil {
+		t.Errorf("Unexpected consent: %v", consent)
+	}
+
+	if err := waitForFOTAUpdateStatus(
+		deadlineManager.statusChannel, cmserver.UpdateStatus{State: cmserver.Updating}); err != nil {
+		t.Fatalf("Wait for update status error: %s", err)
+	}
+
+	if err := waitForFOTAUpdateStatus(
+		deadlineManager.statusChannel, cmserver.UpdateStatus{State: cmserver.NoUpdate}); err != nil {
+		t.Fatalf("Wait for update status error: %s", err)
+	}
+}
+
+func TestFirmwareUpdateHistory(t *testing.T) {
+	updateComponents := []cloudprotocol.ComponentInfoFromCloud{
+		{
+			ID:                "comp1",
+			VersionFromCloud:  cloudprotocol.VersionFromCloud{VendorVersion: "1.0"},
+			DecryptDataStruct: cloudprotocol.DecryptDataStruct{Sha256: []byte{1}},
+		},
+		{
+			ID:                "comp2",
+			VersionFromCloud:  cloudprotocol.VersionFromCloud{VendorVersion: "2.0"},
+			DecryptDataStruct: cloudprotocol.DecryptDataStruct{Sha256: []byte{2}},
+		},
+	}
+
+	firmwareUpdater := NewTestFirmwareUpdater([]cloudprotocol.ComponentInfo{
+		{ID: "comp1", VendorVersion: "0.0", Status: cloudprotocol.InstalledStatus},
+		{ID: "comp2", VendorVersion: "1.0", Status: cloudprotocol.InstalledStatus},
+	})
+	firmwareUpdater.UpdateComponentsInfo = []cloudprotocol.ComponentInfo{
+		{ID: "comp1", VendorVersion: "1.0", Status: cloudprotocol.InstalledStatus},
+		{ID: "comp2", VendorVersion: "2.0", Status: cloudprotocol.InstalledStatus},
+	}
+
+	statusHandler := newTestStatusHandler()
+	history := &testHistory{}
+
+	firmwareManager, err := newFirmwareManager(statusHandler, firmwareUpdater,
+		NewTestBoardConfigUpdater(cloudprotocol.BoardConfigInfo{}), NewTestStorage(), 30*time.Second, nil, 0, history)
+	if err != nil {
+		t.Fatalf("Can't create firmware manager: %s", err)
+	}
+	defer firmwareManager.close()
+
+	expectedPhases := []string{stateDownloading, stateReadyToUpdate, stateUpdating, stateNoUpdate}
+
+	checkRecord := func(record updatehistory.Record, expectedItems []updatehistory.Item, withError bool) {
+		if record.UpdateType != cloudprotocol.FOTAUpdate || record.DesiredStatusHash == "" ||
+			record.Started.IsZero() || record.Finished.IsZero() || (record.Error != "") != withError {
+			t.Errorf("Wrong history record: %v", record)
+		}
+
+		phases := make([]string, 0, len(record.Phases))
+
+		for _, phase := range record.Phases {
+			phases = append(phases, phase.State)
+		}
+
+		if !reflect.DeepEqual(phases, expectedPhases) {
+			t.Errorf("Wrong history phases: %v", phases)
+		}
+
+		for i := range record.Items {
+			record.Items[i].Error = ""
+		}
+
+		if !reflect.DeepEqual(record.Items, expectedItems) {
+			t.Errorf("Wrong history items: %v", record.Items)
+		}
+	}
+
+	runUpdate := func(expectedStatus cmserver.UpdateStatus) {
+		statusHandler.result = map[string]*downloadResult{
+			updateComponents[0].ID: {},
+			updateComponents[1].ID: {},
+		}
+
+		if err := firmwareManager.processDesiredStatus(
+			cloudprotocol.DecodedDesiredStatus{Components: updateComponents}); err != nil {
+			t.Fatalf("Process desired status failed: %s", err)
+		}
+
+		for _, status := range []cmserver.UpdateStatus{
+			{State: cmserver.Downloading}, {State: cmserver.ReadyToUpdate}, {State: cmserver.Updating}, expectedStatus,
+		} {
+			if err := waitForFOTAUpdateStatus(firmwareManager.statusChannel, status); err != nil {
+				t.Fatalf("Wait for update status error: %s", err)
+			}
+		}
+	}
+
+	// Successful update
+
+	runUpdate(cmserver.UpdateStatus{State: cmserver.NoUpdate})
+
+	records := history.getRecords()
+	if len(records) != 1 {
+		t.Fatalf("Wrong history records count: %d", len(records))
+	}
+
+	checkRecord(records[0], []updatehistory.Item{
+		{Type: updatehistory.ComponentItem, ID: "comp1", FromVersion: "0.0", ToVersion: "1.0",
+			Status: cloudprotocol.InstalledStatus},
+		{Type: updatehistory.ComponentItem, ID: "comp2", FromVersion: "1.0", ToVersion: "2.0",
+			Status: cloudprotocol.InstalledStatus},
+	}, false)
+
+	if firmwareManager.HistoryRecord != nil {
+		t.Errorf("History record is not released: %v", firmwareManager.HistoryRecord)
+	}
+
+	// Failed update is reverted to previous versions
+
+	firmwareUpdater.UpdateError = aoserrors.New("update failed")
+	firmwareUpdater.UpdateComponentsInfo = []cloudprotocol.ComponentInfo{
+		{ID: "comp1", VendorVersion: "1.0", Status: cloudprotocol.ErrorStatus, Error: "update failed"},
+		{ID: "comp2", VendorVersion: "2.0", Status: cloudprotocol.ErrorStatus, Error: "update failed"},
+	}
+
+	runUpdate(cmserver.UpdateStatus{State: cmserver.NoUpdate, Error: "update failed"})
+
+	if records = history.getRecords(); len(records) != 2 {
+		t.Fatalf("Wrong history records count: %d", len(records))
+	}
+
+	checkRecord(records[1], []updatehistory.Item{
+		{Type: updatehistory.ComponentItem, ID: "comp1", FromVersion: "0.0", ToVersion: "1.0",
+			Status: cloudprotocol.ErrorStatus, Reverted: true},
+		{Type: updatehistory.ComponentItem, ID: "comp2", FromVersion: "1.0", ToVersion: "2.0",
+			Status: cloudprotocol.ErrorStatus, Reverted: true},
+	}, true)
+}
+
 func TestSoftwareManager(t *testing.T) {
 	type testData struct {
 		testID             string
@@ -937,7 +1456,8 @@ func TestSoftwareManager(t *testing.T) {
 
 		// Create software manager
 
-		softwareManager, err := newSoftwareManager(statusHandler, softwareUpdater, testStorage, 30*time.Second)
+		softwareManager, err := newSoftwareManager(statusHandler, softwareUpdater, nil, testStorage, 30*time.Second,
+			nil, 0, nil)
 		if err != nil {
 			t.Errorf("Can't create software manager: %s", err)
 			continue
@@ -987,6 +1507,156 @@ func TestSoftwareManager(t *testing.T) {
 	}
 }
 
+func TestServiceRegistration(t *testing.T) {
+	type testData struct {
+		testID               string
+		allServices          []cloudprotocol.ServiceInfo
+		usersServices        []cloudprotocol.ServiceInfo
+		desiredServices      []cloudprotocol.ServiceInfoFromCloud
+		updateError          error
+		noUpdate             bool
+		expectedRegistered   []string
+		expectedUnregistered []string
+	}
+
+	permissions := map[string]map[string]string{"cm": {"update.read": "true"}}
+
+	updateServices := []cloudprotocol.ServiceInfoFromCloud{
+		{
+			ID:               "service1",
+			VersionFromCloud: cloudprotocol.VersionFromCloud{AosVersion: 1},
+			Permissions:      permissions,
+		},
+		{
+			ID:               "service2",
+			VersionFromCloud: cloudprotocol.VersionFromCloud{AosVersion: 1},
+		},
+	}
+
+	data := []testData{
+		{
+			testID:             "register installed services",
+			desiredServices:    updateServices,
+			expectedRegistered: []string{"service1", "service2"},
+		},
+		{
+			testID: "rollback registration on install error",
+			allServices: []cloudprotocol.ServiceInfo{
+				{ID: "service1", AosVersion: 0, Status: cloudprotocol.InstalledStatus},
+			},
+			desiredServices:      updateServices,
+			updateError:          errors.New("update error"),
+			expectedRegistered:   []string{"service1", "service2"},
+			expectedUnregistered: []string{"service2"},
+		},
+		{
+			testID: "register previously installed services",
+			usersServices: []cloudprotocol.ServiceInfo{
+				{ID: "service1", AosVersion: 1, Status: cloudprotocol.InstalledStatus},
+			},
+			desiredServices:    updateServices[:1],
+			noUpdate:           true,
+			expectedRegistered: []string{"service1"},
+		},
+		{
+			testID: "unregister removed services",
+			usersServices: []cloudprotocol.ServiceInfo{
+				{ID: "service3", AosVersion: 1, Status: cloudprotocol.InstalledStatus},
+			},
+			expectedUnregistered: []string{"service3"},
+		},
+		{
+			testID: "keep registration of services installed for other users",
+			usersServices: []cloudprotocol.ServiceInfo{
+				{ID: "service3", AosVersion: 1, Status: cloudprotocol.InstalledStatus},
+			},
+			allServices: []cloudprotocol.ServiceInfo{
+				{ID: "service3", AosVersion: 1, Status: cloudprotocol.InstalledStatus},
+			},
+		},
+	}
+
+	for _, item := range data {
+		t.Logf("Test item: %s", item.testID)
+
+		softwareUpdater := NewTestSoftwareUpdater(item.usersServices, nil)
+		softwareUpdater.AllServices = item.allServices
+		softwareUpdater.UpdateError = item.updateError
+
+		statusHandler := newTestStatusHandler()
+		statusHandler.result = make(map[string]*downloadResult)
+
+		for _, service := range item.desiredServices {
+			statusHandler.result[service.ID] = &downloadResult{}
+		}
+
+		serviceRegistrar := NewTestServiceRegistrar()
+
+		softwareManager, err := newSoftwareManager(statusHandler, softwareUpdater, serviceRegistrar,
+			NewTestStorage(), 30*time.Second, nil, 0, nil)
+		if err != nil {
+			t.Fatalf("Can't create software manager: %s", err)
+		}
+
+		if err = softwareManager.processDesiredStatus(cloudprotocol.DecodedDesiredStatus{
+			Services: item.desiredServices}); err != nil {
+			t.Fatalf("Process desired status failed: %s", err)
+		}
+
+		finishStatus := cmserver.UpdateStatus{State: cmserver.NoUpdate}
+
+		if item.updateError != nil {
+			finishStatus.Error = item.updateError.Error()
+		}
+
+		expectedStatuses := []cmserver.UpdateStatus{
+			{State: cmserver.Downloading}, {State: cmserver.ReadyToUpdate},
+			{State: cmserver.Updating}, finishStatus}
+
+		// Installed services are registered without update
+		if item.noUpdate {
+			expectedStatuses = nil
+
+			if softwareManager.CurrentUpdate != nil {
+				t.Errorf("Unexpected software update: %v", softwareManager.CurrentUpdate)
+			}
+		}
+
+		for _, expectedStatus := range expectedStatuses {
+			if err = waitForSOTAUpdateStatus(softwareManager.statusChannel, expectedStatus); err != nil {
+				t.Fatalf("Wait for update status error: %s", err)
+			}
+		}
+
+		registered, unregistered := serviceRegistrar.getServices()
+
+		if !reflect.DeepEqual(registered, item.expectedRegistered) {
+			t.Errorf("Wrong registered services: %v", registered)
+		}
+
+		if !reflect.DeepEqual(unregistered, item.expectedUnregistered) {
+			t.Errorf("Wrong unregistered services: %v", unregistered)
+		}
+
+		if servicePermissions, ok := serviceRegistrar.registered["service1"]; ok &&
+			!reflect.DeepEqual(servicePermissions, permissions) {
+			t.Errorf("Wrong service permissions: %v", servicePermissions)
+		}
+
+		if item.updateError == nil {
+			for _, service := range item.desiredServices {
+				if !softwareManager.isServiceRegistered(service) {
+					t.Errorf("Service %s should be marked as registered", service.ID)
+				}
+			}
+		}
+
+		if err = softwareManager.close(); err != nil {
+			t.Errorf("Error closing software manager: %s", err)
+		}
+	}
+}
+
 func TestTimeTable(t *testing.T) {
 	type testData struct {
 		fromDate  time.Time
@@ -1183,6 +1853,161 @@ func TestTimeTable(t *testing.T) {
 	}
 }
 
+func TestCompatibility(t *testing.T) {
+	type testData struct {
+		testID         string
+		installed      []cloudprotocol.ComponentInfo
+		annotations    map[string]installedAnnotations
+		desired        []cloudprotocol.ComponentInfoFromCloud
+		update         []string
+		expectedErrors map[string]string
+	}
+
+	installed := []cloudprotocol.ComponentInfo{
+		{ID: "bootloader", VendorVersion: "3.1", Status: cloudprotocol.InstalledStatus},
+		{ID: "rootfs", VendorVersion: "4.0", Status: cloudprotocol.InstalledStatus},
+	}
+
+	newComponent := func(id, version, annotations string) (component cloudprotocol.ComponentInfoFromCloud) {
+		component.ID = id
+		component.VendorVersion = version
+
+		if annotations != "" {
+			component.Annotations = json.RawMessage(annotations)
+		}
+
+		return component
+	}
+
+	data := []testData{
+		{
+			testID:    "no constraints",
+			installed: installed,
+			desired: []cloudprotocol.ComponentInfoFromCloud{
+				newComponent("bootloader", "3.1", ""), newComponent("rootfs", "5.0", "")},
+			update:         []string{"rootfs"},
+			expectedErrors: map[string]string{},
+		},
+		{
+			testID:    "constraint satisfied by desired version",
+			installed: installed,
+			desired: []cloudprotocol.ComponentInfoFromCloud{
+				newComponent("bootloader", "3.2.0", ""),
+				newComponent("rootfs", "5.0", `{"requires": ["bootloader >= 3.2"]}`)},
+			update:         []string{"bootloader", "rootfs"},
+			expectedErrors: map[string]string{},
+		},
+		{
+			testID:    "constraint violated by installed version",
+			installed: installed,
+			desired: []cloudprotocol.ComponentInfoFromCloud{
+				newComponent("bootloader", "3.1", ""),
+				newComponent("rootfs", "5.0", `{"requires": ["bootloader >= 3.2"]}`)},
+			update: []string{"rootfs"},
+			expectedErrors: map[string]string{
+				"rootfs": "rootfs 5.0 requires bootloader >= 3.2, found 3.1"},
+		},
+		{
+			testID:    "constraint of installed component violated by update",
+			installed: installed,
+			desired: []cloudprotocol.ComponentInfoFromCloud{
+				newComponent("bootloader", "3.10", ""),
+				newComponent("rootfs", "4.0", `{"requires": ["bootloader < 3.9"]}`)},
+			update: []string{"bootloader"},
+			expectedErrors: map[string]string{
+				"bootloader": "rootfs 4.0 requires bootloader < 3.9, found 3.10"},
+		},
+		{
+			testID:    "constraint of installed component not in desired set",
+			installed: installed,
+			annotations: map[string]installedAnnotations{
+				"rootfs": {VendorVersion: "4.0", Annotations: json.RawMessage(`{"requires": ["bootloader < 3.9"]}`)},
+			},
+			desired: []cloudprotocol.ComponentInfoFromCloud{
+				newComponent("bootloader", "3.10", "")},
+			update: []string{"bootloader"},
+			expectedErrors: map[string]string{
+				"bootloader": "rootfs 4.0 requires bootloader < 3.9, found 3.10"},
+		},
+		{
+			testID:    "annotations of other installed component version",
+			installed: installed,
+			annotations: map[string]installedAnnotations{
+				"rootfs": {VendorVersion: "3.0", Annotations: json.RawMessage(`{"requires": ["bootloader < 3.9"]}`)},
+			},
+			desired: []cloudprotocol.ComponentInfoFromCloud{
+				newComponent("bootloader", "3.10", "")},
+			update:         []string{"bootloader"},
+			expectedErrors: map[string]string{},
+		},
+		{
+			testID:    "pre-release version",
+			installed: installed,
+			desired: []cloudprotocol.ComponentInfoFromCloud{
+				newComponent("bootloader", "3.10-rc1", ""),
+				newComponent("rootfs", "5.0", `{"requires": ["bootloader >= 3.9", "bootloader < 3.10"]}`)},
+			update:         []string{"bootloader", "rootfs"},
+			expectedErrors: map[string]string{},
+		},
+		{
+			testID:    "pre-release version precedes release",
+			installed: installed,
+			desired: []cloudprotocol.ComponentInfoFromCloud{
+				newComponent("bootloader", "3.10-rc1", ""),
+				newComponent("rootfs", "5.0", `{"requires": ["bootloader >= 3.10"]}`)},
+			update: []string{"bootloader", "rootfs"},
+			expectedErrors: map[string]string{
+				"bootloader": "rootfs 5.0 requires bootloader >= 3.10, found 3.10-rc1",
+				"rootfs":     "rootfs 5.0 requires bootloader >= 3.10, found 3.10-rc1"},
+		},
+		{
+			testID:    "required component not installed",
+			installed: installed,
+			desired: []cloudprotocol.ComponentInfoFromCloud{
+				newComponent("rootfs", "5.0", `{"requires": ["kernel = 5.10"]}`)},
+			update: []string{"rootfs"},
+			expectedErrors: map[string]string{
+				"rootfs": "rootfs 5.0 requires kernel = 5.10, component is not installed"},
+		},
+		{
+			testID:    "wrong constraint",
+			installed: installed,
+			desired: []cloudprotocol.ComponentInfoFromCloud{
+				newComponent("rootfs", "5.0", `{"requires": ["bootloader ~ 3.2"]}`)},
+			update: []string{"rootfs"},
+			expectedErrors: map[string]string{
+				"rootfs": "wrong constraint operator: bootloader ~ 3.2"},
+		},
+	}
+
+	for _, item := range data {
+		t.Logf("Test item: %s", item.testID)
+
+		var update []cloudprotocol.ComponentInfoFromCloud
+
+		for _, id := range item.update {
+			for _, component := range item.desired {
+				if component.ID == id {
+					update = append(update, component)
+				}
+			}
+		}
+
+		componentErrors := getCompatibilityErrors(item.desired, item.installed, update, item.annotations)
+
+		if len(componentErrors) != len(item.expectedErrors) {
+			t.Errorf("Wrong component errors: %v", componentErrors)
+			continue
+		}
+
+		for id, expectedErr := range item.expectedErrors {
+			if !strings.Contains(componentErrors[id], expectedErr) {
+				t.Errorf("Wrong component %s error: %s", id, componentErrors[id])
+			}
+		}
+	}
+}
+
 func TestSyncExecutor(t *testing.T) {
 	const (
 		numExecuteTasks  = 10
@@ -1281,8 +2106,13 @@ func (updater *TestBoardConfigUpdater) GetBoardConfigVersion(configJSON json.Raw
 	return updater.UpdateVersion, updater.UpdateError
 }
 
-func (updater *TestBoardConfigUpdater) CheckBoardConfig(configJSON json.RawMessage) (version string, err error) {
-	return updater.UpdateVersion, updater.UpdateError
+func (updater *TestBoardConfigUpdater) CheckBoardConfig(configJSON json.RawMessage) (
+	version string, nodesInfo []cloudprotocol.NodeBoardConfigInfo, err error) {
+	if updater.CheckError != nil {
+		return updater.UpdateVersion, updater.NodesInfo, updater.CheckError
+	}
+
+	return updater.UpdateVersion, updater.NodesInfo, updater.UpdateError
 }
 
 func (updater *TestBoardConfigUpdater) UpdateBoardConfig(configJSON json.RawMessage) (err error) {
@@ -1307,6 +2137,16 @@ func (updater *TestFirmwareUpdater) UpdateComponents(components []cloudprotocol.
 	return updater.UpdateComponentsInfo, updater.UpdateError
 }
 
+func (updater *TestFirmwareUpdater) GetComponentUM(componentID string) (umID string, err error) {
+	for _, component := range updater.InitComponentsInfo {
+		if component.ID == componentID {
+			return "um" + componentID, nil
+		}
+	}
+
+	return "", aoserrors.Errorf("component %s not found", componentID)
+}
+
 /***********************************************************************************************************************
  * TestSoftwareUpdater
  **********************************************************************************************************************/
@@ -1326,6 +2166,10 @@ func (updater *TestSoftwareUpdater) GetAllStatus() (
 	return updater.AllServices, updater.AllLayers, nil
 }
 
+func (updater *TestSoftwareUpdater) PlaceServices(services []cloudprotocol.ServiceInfoFromCloud) (err error) {
+	return nil
+}
+
 func (updater *TestSoftwareUpdater) InstallService(users []string,
 	serviceInfo cloudprotocol.ServiceInfoFromCloud) (stateChecksum string, err error) {
 	return "", updater.UpdateError
@@ -1339,6 +2183,50 @@ func (updater *TestSoftwareUpdater) InstallLayer(layerInfo cloudprotocol.LayerIn
 	return updater.UpdateError
 }
 
+/***********************************************************************************************************************
+ * TestServiceRegistrar
+ **********************************************************************************************************************/
+
+func NewTestServiceRegistrar() (registrar *TestServiceRegistrar) {
+	return &TestServiceRegistrar{registered: make(map[string]map[string]map[string]string)}
+}
+
+func (registrar *TestServiceRegistrar) RegisterService(
+	serviceID string, permissions map[string]map[string]string) (secret string, err error) {
+	registrar.Lock()
+	defer registrar.Unlock()
+
+	registrar.registered[serviceID] = permissions
+
+	return serviceID + "Secret", nil
+}
+
+func (registrar *TestServiceRegistrar) UnregisterService(serviceID string) (err error) {
+	registrar.Lock()
+	defer registrar.Unlock()
+
+	registrar.unregistered = append(registrar.unregistered, serviceID)
+
+	return nil
+}
+
+func (registrar *TestServiceRegistrar) getServices() (registered, unregistered []string) {
+	registrar.Lock()
+	defer registrar.Unlock()
+
+	for serviceID := range registrar.registered {
+		registered = append(registered, serviceID)
+	}
+
+	sort.Strings(registered)
+
+	unregistered = append(unregistered, registrar.unregistered...)
+
+	sort.Strings(unregistered)
+
+	return registered, unregistered
+}
+
 /***********************************************************************************************************************
  * TestDownloader
  **********************************************************************************************************************/
@@ -1480,6 +2368,72 @@ func (statusHandler *testStatusHandler) updateServiceStatus(serviceInfo cloudpro
 	}).Debug("Update service status")
 }
 
+func (statusHandler *testStatusHandler) updateConsentStatus(updateType string, consent *cloudprotocol.UpdateConsent) {
+	if consent == nil {
+		log.WithField("type", updateType).Debug("Reset update consent")
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"type":          updateType,
+		"decision":      consent.Decision,
+		"reason":        consent.Reason,
+		"postponements": consent.Postponements,
+	}).Debug("Update consent status")
+}
+
+/***********************************************************************************************************************
+ * testPreconditions
+ **********************************************************************************************************************/
+
+func (preconditions *testPreconditions) set(unsatisfied []string) {
+	preconditions.Lock()
+	defer preconditions.Unlock()
+
+	preconditions.unsatisfied = unsatisfied
+}
+
+func (preconditions *testPreconditions) CheckPreconditions() (unsatisfied []string) {
+	preconditions.Lock()
+	defer preconditions.Unlock()
+
+	return preconditions.unsatisfied
+}
+
+/***********************************************************************************************************************
+ * testHistory
+ **********************************************************************************************************************/
+
+func (history *testHistory) AddRecord(record *updatehistory.Record) (err error) {
+	history.Lock()
+	defer history.Unlock()
+
+	record.ID = uint64(len(history.records) + 1)
+	history.records = append(history.records, *record)
+
+	return nil
+}
+
+func (history *testHistory) UpdateRecord(record updatehistory.Record) (err error) {
+	history.Lock()
+	defer history.Unlock()
+
+	if record.ID == 0 || record.ID > uint64(len(history.records)) {
+		return aoserrors.Errorf("record %d not found", record.ID)
+	}
+
+	history.records[record.ID-1] = record
+
+	return nil
+}
+
+func (history *testHistory) getRecords() (records []updatehistory.Record) {
+	history.Lock()
+	defer history.Unlock()
+
+	return append(records, history.records...)
+}
+
 /***********************************************************************************************************************
  * testStorage
  **********************************************************************************************************************/
@@ -19,12 +19,14 @@ package unitstatushandler_test
 
 import (
 	"encoding/json"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/aoscloud/aos_common/aoserrors"
 
 	"aos_communicationmanager/cloudprotocol"
+	"aos_communicationmanager/cmserver"
 	"aos_communicationmanager/config"
 	"aos_communicationmanager/unitstatushandler"
 )
@@ -73,8 +75,8 @@ func TestSendInitialStatus(t *testing.T) {
 	sender := unitstatushandler.NewTestSender()
 
 	statusHandler, err := unitstatushandler.New(
-		cfg, boardConfigUpdater, fotaUpdater, sotaUpdater, unitstatushandler.NewTestDownloader(),
-		unitstatushandler.NewTestStorage(), sender)
+		cfg, boardConfigUpdater, fotaUpdater, sotaUpdater, nil, unitstatushandler.NewTestDownloader(),
+		unitstatushandler.NewTestStorage(), sender, nil, nil)
 	if err != nil {
 		t.Fatalf("Can't create unit status handler: %s", err)
 	}
@@ -102,8 +104,8 @@ func TestUpdateBoardConfig(t *testing.T) {
 	sender := unitstatushandler.NewTestSender()
 
 	statusHandler, err := unitstatushandler.New(
-		cfg, boardConfigUpdater, fotaUpdater, sotaUpdater, unitstatushandler.NewTestDownloader(),
-		unitstatushandler.NewTestStorage(), sender)
+		cfg, boardConfigUpdater, fotaUpdater, sotaUpdater, nil, unitstatushandler.NewTestDownloader(),
+		unitstatushandler.NewTestStorage(), sender, nil, nil)
 	if err != nil {
 		t.Fatalf("Can't create unit status handler: %s", err)
 	}
@@ -160,6 +162,31 @@ func TestUpdateBoardConfig(t *testing.T) {
 	if err = compareUnitStatus(receivedUnitStatus, expectedUnitStatus); err != nil {
 		t.Errorf("Wrong unit status received: %v, expected: %v", receivedUnitStatus, expectedUnitStatus)
 	}
+
+	// failed check should report status of each node
+
+	boardConfigUpdater.UpdateVersion = "1.3"
+	boardConfigUpdater.UpdateError = nil
+	boardConfigUpdater.CheckError = aoserrors.New("invalid board config")
+	boardConfigUpdater.NodesInfo = []cloudprotocol.NodeBoardConfigInfo{
+		{NodeID: "sm1", Status: cloudprotocol.PendingStatus},
+		{NodeID: "sm2", Status: cloudprotocol.ErrorStatus, Error: "invalid device"},
+	}
+
+	boardConfigUpdater.BoardConfigInfo = cloudprotocol.BoardConfigInfo{
+		VendorVersion: "1.3", Status: cloudprotocol.ErrorStatus, Error: boardConfigUpdater.CheckError.Error(),
+		Nodes: boardConfigUpdater.NodesInfo}
+	expectedUnitStatus.BoardConfig = append(expectedUnitStatus.BoardConfig, boardConfigUpdater.BoardConfigInfo)
+
+	statusHandler.ProcessDesiredStatus(cloudprotocol.DecodedDesiredStatus{BoardConfig: json.RawMessage("{}")})
+
+	if receivedUnitStatus, err = sender.WaitForStatus(waitStatusTimeout); err != nil {
+		t.Fatalf("Can't receive unit status: %s", err)
+	}
+
+	if err = compareUnitStatus(receivedUnitStatus, expectedUnitStatus); err != nil {
+		t.Errorf("Wrong unit status received: %v, expected: %v", receivedUnitStatus, expectedUnitStatus)
+	}
 }
 
 func TestUpdateComponents(t *testing.T) {
@@ -174,8 +201,8 @@ func TestUpdateComponents(t *testing.T) {
 	sender := unitstatushandler.NewTestSender()
 
 	statusHandler, err := unitstatushandler.New(cfg,
-		boardConfigUpdater, firmwareUpdater, softwareUpdater, unitstatushandler.NewTestDownloader(),
-		unitstatushandler.NewTestStorage(), sender)
+		boardConfigUpdater, firmwareUpdater, softwareUpdater, nil, unitstatushandler.NewTestDownloader(),
+		unitstatushandler.NewTestStorage(), sender, nil, nil)
 	if err != nil {
 		t.Fatalf("Can't create unit status handler: %s", err)
 	}
@@ -267,8 +294,8 @@ func TestUpdateLayers(t *testing.T) {
 	sender := unitstatushandler.NewTestSender()
 
 	statusHandler, err := unitstatushandler.New(
-		cfg, boardConfigUpdater, firmwareUpdater, softwareUpdater, unitstatushandler.NewTestDownloader(),
-		unitstatushandler.NewTestStorage(), sender)
+		cfg, boardConfigUpdater, firmwareUpdater, softwareUpdater, nil, unitstatushandler.NewTestDownloader(),
+		unitstatushandler.NewTestStorage(), sender, nil, nil)
 	if err != nil {
 		t.Fatalf("Can't create unit status handler: %s", err)
 	}
@@ -381,8 +408,8 @@ func TestUpdateServices(t *testing.T) {
 	sender := unitstatushandler.NewTestSender()
 
 	statusHandler, err := unitstatushandler.New(
-		cfg, boardConfigUpdater, firmwareUpdater, softwareUpdater, unitstatushandler.NewTestDownloader(),
-		unitstatushandler.NewTestStorage(), sender)
+		cfg, boardConfigUpdater, firmwareUpdater, softwareUpdater, nil, unitstatushandler.NewTestDownloader(),
+		unitstatushandler.NewTestStorage(), sender, nil, nil)
 	if err != nil {
 		t.Fatalf("Can't create unit status handler: %s", err)
 	}
@@ -505,8 +532,8 @@ func TestUpdateCachedSOTA(t *testing.T) {
 	downloader := unitstatushandler.NewTestDownloader()
 
 	statusHandler, err := unitstatushandler.New(
-		cfg, boardConfigUpdater, firmwareUpdater, softwareUpdater, downloader,
-		unitstatushandler.NewTestStorage(), sender)
+		cfg, boardConfigUpdater, firmwareUpdater, softwareUpdater, nil, downloader,
+		unitstatushandler.NewTestStorage(), sender, nil, nil)
 	if err != nil {
 		t.Fatalf("Can't create unit status handler: %s", err)
 	}
@@ -595,10 +622,251 @@ func TestUpdateCachedSOTA(t *testing.T) {
 	}
 }
 
+func TestDryRun(t *testing.T) {
+	boardConfigUpdater := unitstatushandler.NewTestBoardConfigUpdater(
+		cloudprotocol.BoardConfigInfo{VendorVersion: "1.0", Status: cloudprotocol.InstalledStatus})
+	boardConfigUpdater.UpdateVersion = "2.0"
+	firmwareUpdater := unitstatushandler.NewTestFirmwareUpdater([]cloudprotocol.ComponentInfo{
+		{ID: "comp0", VendorVersion: "1.0", Status: cloudprotocol.InstalledStatus},
+		{ID: "comp1", VendorVersion: "1.0", Status: cloudprotocol.InstalledStatus},
+	})
+	softwareUpdater := unitstatushandler.NewTestSoftwareUpdater([]cloudprotocol.ServiceInfo{
+		{ID: "service0", AosVersion: 0, Status: cloudprotocol.InstalledStatus},
+	}, []cloudprotocol.LayerInfo{
+		{ID: "layer0", Digest: "digest0", AosVersion: 0, Status: cloudprotocol.InstalledStatus},
+	})
+	softwareUpdater.AllServices = []cloudprotocol.ServiceInfo{
+		{ID: "service1", AosVersion: 0, Status: cloudprotocol.InstalledStatus},
+	}
+	softwareUpdater.AllLayers = []cloudprotocol.LayerInfo{
+		{ID: "layer1", Digest: "digest1", AosVersion: 0, Status: cloudprotocol.InstalledStatus},
+	}
+
+	statusHandler, err := unitstatushandler.New(
+		cfg, boardConfigUpdater, firmwareUpdater, softwareUpdater, nil, unitstatushandler.NewTestDownloader(),
+		unitstatushandler.NewTestStorage(), unitstatushandler.NewTestSender(), nil, nil)
+	if err != nil {
+		t.Fatalf("Can't create unit status handler: %s", err)
+	}
+	defer statusHandler.Close()
+
+	// Time slot tomorrow from 10:00 till 12:00
+	tomorrow := time.Now().Add(24 * time.Hour)
+
+	dayOfWeek := uint(tomorrow.Weekday())
+	if dayOfWeek == 0 {
+		dayOfWeek = 7
+	}
+
+	expectedStart := time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 10, 0, 0, 0, time.Local)
+
+	plan, err := statusHandler.DryRun(cloudprotocol.DecodedDesiredStatus{
+		BoardConfig: json.RawMessage(`{"vendorVersion": "2.0"}`),
+		Components: []cloudprotocol.ComponentInfoFromCloud{
+			{
+				ID: "comp0", VersionFromCloud: cloudprotocol.VersionFromCloud{VendorVersion: "2.0"},
+				DecryptDataStruct: cloudprotocol.DecryptDataStruct{URLs: []string{"comp0"}, Size: 100},
+			},
+			{ID: "comp1", VersionFromCloud: cloudprotocol.VersionFromCloud{VendorVersion: "1.0"}},
+		},
+		Services: []cloudprotocol.ServiceInfoFromCloud{
+			{
+				ID: "service1", VersionFromCloud: cloudprotocol.VersionFromCloud{AosVersion: 0},
+				DecryptDataStruct: cloudprotocol.DecryptDataStruct{URLs: []string{"service1"}, Size: 200},
+			},
+			{
+				ID: "service2", VersionFromCloud: cloudprotocol.VersionFromCloud{AosVersion: 0},
+				DecryptDataStruct: cloudprotocol.DecryptDataStruct{URLs: []string{"service2"}, Size: 300},
+			},
+		},
+		Layers: []cloudprotocol.LayerInfoFromCloud{
+			{
+				ID: "layer1", Digest: "digest1", VersionFromCloud: cloudprotocol.VersionFromCloud{AosVersion: 0},
+				DecryptDataStruct: cloudprotocol.DecryptDataStruct{URLs: []string{"layer1"}, Size: 400},
+			},
+		},
+		FOTASchedule: cloudprotocol.ScheduleRule{
+			Type: cloudprotocol.TimetableUpdate, TTL: 3600,
+			Timetable: []cloudprotocol.TimetableEntry{{DayOfWeek: dayOfWeek, TimeSlots: []cloudprotocol.TimeSlot{{
+				Start:  cloudprotocol.Time{Time: time.Date(0, 1, 1, 10, 0, 0, 0, time.Local)},
+				Finish: cloudprotocol.Time{Time: time.Date(0, 1, 1, 12, 0, 0, 0, time.Local)},
+			}}}},
+		},
+		SOTASchedule: cloudprotocol.ScheduleRule{Type: cloudprotocol.TriggerUpdate},
+	})
+	if err != nil {
+		t.Fatalf("Can't dry run desired status: %s", err)
+	}
+
+	// FOTA plan
+
+	if len(plan.FOTA.Components) != 1 || plan.FOTA.Components[0].ID != "comp0" ||
+		plan.FOTA.Components[0].VendorVersion != "2.0" || plan.FOTA.Components[0].UMID != "umcomp0" ||
+		plan.FOTA.Components[0].Error != "" {
+		t.Errorf("Wrong components plan: %v", plan.FOTA.Components)
+	}
+
+	if !reflect.DeepEqual(plan.FOTA.Downloads, []cmserver.DownloadPlan{
+		{Type: cmserver.DownloadComponent, ID: "comp0", Size: 100}}) {
+		t.Errorf("Wrong FOTA downloads: %v", plan.FOTA.Downloads)
+	}
+
+	if plan.FOTA.BoardConfig == nil || plan.FOTA.BoardConfig.CurrentVersion != "1.0" ||
+		plan.FOTA.BoardConfig.NewVersion != "2.0" {
+		t.Errorf("Wrong board config plan: %v", plan.FOTA.BoardConfig)
+	}
+
+	if plan.FOTA.Schedule.Error != "" || plan.FOTA.Schedule.TTL != time.Hour ||
+		!plan.FOTA.Schedule.EarliestStart.Equal(expectedStart) {
+		t.Errorf("Wrong FOTA schedule: %v", plan.FOTA.Schedule)
+	}
+
+	// SOTA plan
+
+	if !reflect.DeepEqual(plan.SOTA.Downloads, []cmserver.DownloadPlan{
+		{Type: cmserver.DownloadService, ID: "service1", Size: 200, Cached: true},
+		{Type: cmserver.DownloadService, ID: "service2", Size: 300},
+		{Type: cmserver.DownloadLayer, ID: "digest1", Size: 400, Cached: true},
+	}) {
+		t.Errorf("Wrong SOTA downloads: %v", plan.SOTA.Downloads)
+	}
+
+	if len(plan.SOTA.InstallServices) != 2 || len(plan.SOTA.RemoveServices) != 1 ||
+		plan.SOTA.RemoveServices[0].ID != "service0" {
+		t.Errorf("Wrong services plan: install %v, remove %v", plan.SOTA.InstallServices, plan.SOTA.RemoveServices)
+	}
+
+	if len(plan.SOTA.InstallLayers) != 1 || len(plan.SOTA.RemoveLayers) != 1 ||
+		plan.SOTA.RemoveLayers[0].Digest != "digest0" {
+		t.Errorf("Wrong layers plan: install %v, remove %v", plan.SOTA.InstallLayers, plan.SOTA.RemoveLayers)
+	}
+
+	if plan.SOTA.Schedule.Type != cloudprotocol.TriggerUpdate || !plan.SOTA.Schedule.EarliestStart.IsZero() {
+		t.Errorf("Wrong SOTA schedule: %v", plan.SOTA.Schedule)
+	}
+
+	// Nothing should be started
+
+	if state := statusHandler.GetFOTAStatus().State; state != cmserver.NoUpdate {
+		t.Errorf("Wrong FOTA state: %d", state)
+	}
+
+	if state := statusHandler.GetSOTAStatus().State; state != cmserver.NoUpdate {
+		t.Errorf("Wrong SOTA state: %d", state)
+	}
+}
+
+func TestUpdateConsent(t *testing.T) {
+	firmwareUpdater := unitstatushandler.NewTestFirmwareUpdater([]cloudprotocol.ComponentInfo{
+		{ID: "comp0", VendorVersion: "1.0", Status: cloudprotocol.InstalledStatus},
+	})
+	sender := unitstatushandler.NewTestSender()
+
+	statusHandler, err := unitstatushandler.New(cfg,
+		unitstatushandler.NewTestBoardConfigUpdater(cloudprotocol.BoardConfigInfo{
+			VendorVersion: "1.0", Status: cloudprotocol.InstalledStatus}),
+		firmwareUpdater, unitstatushandler.NewTestSoftwareUpdater(nil, nil), nil,
+		unitstatushandler.NewTestDownloader(), unitstatushandler.NewTestStorage(), sender, nil, nil)
+	if err != nil {
+		t.Fatalf("Can't create unit status handler: %s", err)
+	}
+	defer statusHandler.Close()
+
+	go handleUpdateStatus(statusHandler)
+
+	if err = statusHandler.SendUnitStatus(); err != nil {
+		t.Fatalf("Can't send unit status: %s", err)
+	}
+
+	if _, err = sender.WaitForStatus(waitStatusTimeout); err != nil {
+		t.Fatalf("Can't receive unit status: %s", err)
+	}
+
+	if err = statusHandler.PostponeFOTAUpdate("driving", time.Hour); err == nil {
+		t.Error("Error expected for postponing without update")
+	}
+
+	statusHandler.ProcessDesiredStatus(cloudprotocol.DecodedDesiredStatus{
+		Components: []cloudprotocol.ComponentInfoFromCloud{
+			{ID: "comp0", VersionFromCloud: cloudprotocol.VersionFromCloud{VendorVersion: "2.0"}},
+		},
+		FOTASchedule: cloudprotocol.ScheduleRule{Type: cloudprotocol.TriggerUpdate, MaxPostponements: 1},
+	})
+
+	if err = waitFOTAState(statusHandler, cmserver.ReadyToUpdate); err != nil {
+		t.Fatalf("Wait FOTA state error: %s", err)
+	}
+
+	if err = statusHandler.PostponeFOTAUpdate("driving", time.Hour); err != nil {
+		t.Fatalf("Can't postpone FOTA update: %s", err)
+	}
+
+	if err = statusHandler.PostponeFOTAUpdate("driving", time.Hour); err == nil {
+		t.Error("Error expected for exceeding max postponements")
+	}
+
+	consent, err := waitConsent(sender, cloudprotocol.ConsentPostponed)
+	if err != nil {
+		t.Fatalf("Wait consent error: %s", err)
+	}
+
+	if consent.UpdateType != cloudprotocol.FOTAUpdate || consent.Reason != "driving" || consent.Postponements != 1 ||
+		consent.PostponedTill.IsZero() {
+		t.Errorf("Wrong consent: %v", consent)
+	}
+
+	if err = statusHandler.DeclineFOTAUpdate("not now"); err != nil {
+		t.Fatalf("Can't decline FOTA update: %s", err)
+	}
+
+	if consent, err = waitConsent(sender, cloudprotocol.ConsentDeclined); err != nil {
+		t.Fatalf("Wait consent error: %s", err)
+	}
+
+	if consent.UpdateType != cloudprotocol.FOTAUpdate || consent.Reason != "not now" || consent.Postponements != 1 {
+		t.Errorf("Wrong consent: %v", consent)
+	}
+
+	if state := statusHandler.GetFOTAStatus().State; state != cmserver.NoUpdate {
+		t.Errorf("Wrong FOTA state: %d", state)
+	}
+}
+
 /***********************************************************************************************************************
  * Private
  **********************************************************************************************************************/
 
+func waitFOTAState(handler *unitstatushandler.Instance, state cmserver.UpdateState) (err error) {
+	timeout := time.After(waitStatusTimeout)
+
+	for handler.GetFOTAStatus().State != state {
+		select {
+		case <-timeout:
+			return aoserrors.Errorf("wait for FOTA %s state timeout", state)
+
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	return nil
+}
+
+func waitConsent(
+	sender *unitstatushandler.TestSender, decision string) (consent cloudprotocol.UpdateConsent, err error) {
+	for {
+		unitStatus, err := sender.WaitForStatus(waitStatusTimeout)
+		if err != nil {
+			return consent, aoserrors.Wrap(err)
+		}
+
+		for _, consent := range unitStatus.Consents {
+			if consent.Decision == decision {
+				return consent, nil
+			}
+		}
+	}
+}
+
 func compareStatus(len1, len2 int, compare func(index1, index2 int) bool) (err error) {
 	if len1 != len2 {
 		return aoserrors.New("data mismatch")
@@ -640,7 +908,7 @@ func compareStatus(len1, len2 int, compare func(index1, index2 int) bool) (err e
 func compareUnitStatus(status1, status2 cloudprotocol.UnitStatus) (err error) {
 	if err = compareStatus(len(status1.BoardConfig), len(status2.BoardConfig),
 		func(index1, index2 int) (result bool) {
-			return status1.BoardConfig[index1] == status2.BoardConfig[index2]
+			return reflect.DeepEqual(status1.BoardConfig[index1], status2.BoardConfig[index2])
 		}); err != nil {
 		return err
 	}
@@ -661,7 +929,7 @@ func compareUnitStatus(status1, status2 cloudprotocol.UnitStatus) (err error) {
 
 	if err = compareStatus(len(status1.Services), len(status2.Services),
 		func(index1, index2 int) (result bool) {
-			return status1.Services[index1] == status2.Services[index2]
+			return reflect.DeepEqual(status1.Services[index1], status2.Services[index2])
 		}); err != nil {
 		return err
 	}
@@ -42,6 +42,8 @@ const (
 	stateUpdating      = "updating"
 )
 
+const defaultPreconditionsCheckPeriod = 10 * time.Second
+
 const (
 	eventStartDownload  = "startDownload"
 	eventFinishDownload = "finishDownload"
@@ -61,10 +63,13 @@ type updateStateMachine struct {
 	wg         sync.WaitGroup
 	cancelFunc context.CancelFunc
 
-	updateTimer *time.Timer
-	ttlTimer    *time.Timer
+	updateTimer        *time.Timer
+	ttlTimer           *time.Timer
+	preconditionsTimer *time.Timer
 
-	defaultTTL time.Duration
+	defaultTTL          time.Duration
+	preconditions       PreconditionChecker
+	preconditionsPeriod time.Duration
 }
 
 type updateManager interface {
@@ -92,11 +97,18 @@ var updateSynchronizer = newSyncExecutor()
  * Interface
  **********************************************************************************************************************/
 
-func newUpdateStateMachine(initState string, events []fsm.EventDesc,
-	manager updateManager, defaultTTL time.Duration) (stateMachine *updateStateMachine) {
+func newUpdateStateMachine(initState string, events []fsm.EventDesc, manager updateManager,
+	defaultTTL time.Duration, preconditions PreconditionChecker,
+	preconditionsPeriod time.Duration) (stateMachine *updateStateMachine) {
 	stateMachine = &updateStateMachine{
-		manager:    manager,
-		defaultTTL: defaultTTL,
+		manager:             manager,
+		defaultTTL:          defaultTTL,
+		preconditions:       preconditions,
+		preconditionsPeriod: preconditionsPeriod,
+	}
+
+	if stateMachine.preconditionsPeriod == 0 {
+		stateMachine.preconditionsPeriod = defaultPreconditionsCheckPeriod
 	}
 
 	stateMachine.fsm = fsm.NewFSM(
@@ -156,26 +168,55 @@ func (stateMachine *updateStateMachine) sendEvent(event string, managerErr strin
 	return nil
 }
 
-func (stateMachine *updateStateMachine) scheduleUpdate(schedule cloudprotocol.ScheduleRule) {
+// scheduleUpdate schedules update start according to the schedule rule and user consent. Postponed update is not
+// started before postponed time and, if deadline is set, update is started not later than deadline.
+func (stateMachine *updateStateMachine) scheduleUpdate(
+	schedule cloudprotocol.ScheduleRule, consent *cloudprotocol.UpdateConsent) {
 	var updateTime time.Duration
 
+	stateMachine.stopUpdateTimer()
+	stateMachine.stopPreconditionsTimer()
+
+	fromTime := time.Now()
+
+	if consent != nil && consent.Decision == cloudprotocol.ConsentPostponed && consent.PostponedTill.After(fromTime) {
+		fromTime = consent.PostponedTill
+	}
+
 	switch schedule.Type {
 	case cloudprotocol.TriggerUpdate:
-		log.Debug("Wait for update trigger")
-		return
+		if schedule.Deadline.IsZero() {
+			log.Debug("Wait for update trigger")
+			return
+		}
+
+		updateTime = time.Until(schedule.Deadline)
+
+		log.WithFields(log.Fields{"in": updateTime}).Debug("Wait for update trigger till deadline")
 
 	case cloudprotocol.TimetableUpdate:
-		updateTime, _ = getAvailableTimetableTime(time.Now(), schedule.Timetable)
+		timetableTime, _ := getAvailableTimetableTime(fromTime, schedule.Timetable)
+		updateTime = time.Until(fromTime.Add(timetableTime))
 
 		log.WithFields(log.Fields{"in": updateTime}).Debug("Schedule timetable update")
 
 	default:
 		// Schedule forces update by default
-		updateTime = 0
+		updateTime = time.Until(fromTime)
 
 		log.WithFields(log.Fields{"in": updateTime}).Debug("Schedule forced update")
 	}
 
+	if !schedule.Deadline.IsZero() && schedule.Deadline.Before(time.Now().Add(updateTime)) {
+		updateTime = time.Until(schedule.Deadline)
+
+		log.WithFields(log.Fields{"in": updateTime}).Debug("Update is limited by deadline")
+	}
+
+	if updateTime < 0 {
+		updateTime = 0
+	}
+
 	stateMachine.updateTimer = time.AfterFunc(updateTime, func() {
 		if err := stateMachine.manager.startUpdate(); err != nil {
 			log.Errorf("Can't start update: %s", err)
@@ -183,6 +224,30 @@ func (stateMachine *updateStateMachine) scheduleUpdate(schedule cloudprotocol.Sc
 	})
 }
 
+// checkPreconditions returns unsatisfied update preconditions. If some preconditions are not satisfied, update start is
+// retried after preconditions check period. TTL timer is not affected.
+func (stateMachine *updateStateMachine) checkPreconditions() (unsatisfied []string) {
+	stateMachine.stopPreconditionsTimer()
+
+	if stateMachine.preconditions == nil {
+		return nil
+	}
+
+	if unsatisfied = stateMachine.preconditions.CheckPreconditions(); len(unsatisfied) == 0 {
+		return nil
+	}
+
+	log.WithField("preconditions", unsatisfied).Debug("Update is blocked by preconditions")
+
+	stateMachine.preconditionsTimer = time.AfterFunc(stateMachine.preconditionsPeriod, func() {
+		if err := stateMachine.manager.startUpdate(); err != nil {
+			log.Errorf("Can't start update: %s", err)
+		}
+	})
+
+	return unsatisfied
+}
+
 func (stateMachine *updateStateMachine) finishOperation(ctx context.Context, finishEvent string, operationErr string) {
 	// Do nothing if context canceled
 	if ctx.Err() != nil {
@@ -297,17 +362,29 @@ func (stateMachine *updateStateMachine) onStateUpdating(event *fsm.Event) {
 }
 
 func (stateMachine *updateStateMachine) resetTimers() {
-	// Reset update timer
-	if stateMachine.updateTimer != nil {
-		stateMachine.updateTimer.Stop()
-		stateMachine.updateTimer = nil
-	}
+	stateMachine.stopUpdateTimer()
 
 	// Reset TTL timer
 	if stateMachine.ttlTimer != nil {
 		stateMachine.ttlTimer.Stop()
 		stateMachine.ttlTimer = nil
 	}
+
+	stateMachine.stopPreconditionsTimer()
+}
+
+func (stateMachine *updateStateMachine) stopUpdateTimer() {
+	if stateMachine.updateTimer != nil {
+		stateMachine.updateTimer.Stop()
+		stateMachine.updateTimer = nil
+	}
+}
+
+func (stateMachine *updateStateMachine) stopPreconditionsTimer() {
+	if stateMachine.preconditionsTimer != nil {
+		stateMachine.preconditionsTimer.Stop()
+		stateMachine.preconditionsTimer = nil
+	}
 }
 
 /***********************************************************************************************************************
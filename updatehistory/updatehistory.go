@@ -0,0 +1,209 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package updatehistory provides persistent history of performed FOTA/SOTA updates
+package updatehistory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aoscloud/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Update item types
+const (
+	ComponentItem   = "component"
+	BoardConfigItem = "boardConfig"
+	ServiceItem     = "service"
+	LayerItem       = "layer"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// Item updated item
+type Item struct {
+	Type        string `json:"type"`
+	ID          string `json:"id"`
+	Digest      string `json:"digest,omitempty"`
+	FromVersion string `json:"fromVersion,omitempty"`
+	ToVersion   string `json:"toVersion,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Error       string `json:"error,omitempty"`
+	Reverted    bool   `json:"reverted,omitempty"`
+}
+
+// Phase update phase
+type Phase struct {
+	State     string    `json:"state"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Record update history record
+type Record struct {
+	ID                uint64    `json:"id"`
+	UpdateType        string    `json:"updateType"`
+	DesiredStatusHash string    `json:"desiredStatusHash,omitempty"`
+	Items             []Item    `json:"items,omitempty"`
+	Phases            []Phase   `json:"phases,omitempty"`
+	Started           time.Time `json:"started"`
+	Finished          time.Time `json:"finished,omitempty"`
+	Error             string    `json:"error,omitempty"`
+}
+
+// Filter update history records filter. Records are returned starting from the latest one.
+type Filter struct {
+	UpdateType string
+	From       time.Time
+	Till       time.Time
+	Offset     uint64
+	Limit      uint64
+}
+
+// Storage update history storage
+type Storage interface {
+	SetUpdateHistoryRecord(record Record) (err error)
+	GetUpdateHistoryRecords(filter Filter) (records []Record, err error)
+	RemoveUpdateHistoryRecords(beforeID uint64, startedBefore time.Time) (err error)
+}
+
+// History update history instance
+type History struct {
+	sync.Mutex
+
+	storage    Storage
+	lastID     uint64
+	maxRecords uint64
+	maxAge     time.Duration
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// New creates new update history instance
+func New(cfg *config.Config, storage Storage) (history *History, err error) {
+	log.Debug("Create update history")
+
+	history = &History{
+		storage: storage, maxRecords: cfg.UpdateHistory.MaxRecords, maxAge: cfg.UpdateHistory.MaxAge.Duration,
+	}
+
+	records, err := history.storage.GetUpdateHistoryRecords(Filter{Limit: 1})
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	if len(records) != 0 {
+		history.lastID = records[0].ID
+	}
+
+	if err = history.removeOutdatedRecords(); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return history, nil
+}
+
+// AddRecord assigns ID to the new record and stores it
+func (history *History) AddRecord(record *Record) (err error) {
+	history.Lock()
+	defer history.Unlock()
+
+	record.ID = history.lastID + 1
+
+	log.WithFields(log.Fields{
+		"id":         record.ID,
+		"updateType": record.UpdateType,
+		"hash":       record.DesiredStatusHash}).Debug("Add update history record")
+
+	if err = history.storage.SetUpdateHistoryRecord(*record); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	history.lastID = record.ID
+
+	if err = history.removeOutdatedRecords(); err != nil {
+		log.Errorf("Can't remove outdated update history records: %s", err)
+	}
+
+	return nil
+}
+
+// UpdateRecord updates existing record
+func (history *History) UpdateRecord(record Record) (err error) {
+	history.Lock()
+	defer history.Unlock()
+
+	if record.ID == 0 || record.ID > history.lastID {
+		return aoserrors.Errorf("record %d not found", record.ID)
+	}
+
+	if err = history.storage.SetUpdateHistoryRecord(record); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+// GetRecords returns update history records
+func (history *History) GetRecords(filter Filter) (records []Record, err error) {
+	if records, err = history.storage.GetUpdateHistoryRecords(filter); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return records, nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// removeOutdatedRecords removes records exceeding max records count or older than max age
+func (history *History) removeOutdatedRecords() (err error) {
+	var (
+		beforeID      uint64
+		startedBefore time.Time
+	)
+
+	if history.maxRecords != 0 && history.lastID > history.maxRecords {
+		beforeID = history.lastID - history.maxRecords + 1
+	}
+
+	if history.maxAge != 0 {
+		startedBefore = time.Now().Add(-history.maxAge)
+	}
+
+	if beforeID == 0 && startedBefore.IsZero() {
+		return nil
+	}
+
+	if err = history.storage.RemoveUpdateHistoryRecords(beforeID, startedBefore); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2021 Renesas Electronics Corporation.
+// Copyright (C) 2021 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package updatehistory_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"aos_communicationmanager/config"
+	"aos_communicationmanager/updatehistory"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type testStorage struct {
+	records []updatehistory.Record
+}
+
+/***********************************************************************************************************************
+ * Init
+ **********************************************************************************************************************/
+
+func init() {
+	log.SetFormatter(&log.TextFormatter{
+		DisableTimestamp: false,
+		TimestampFormat:  "2006-01-02 15:04:05.000",
+		FullTimestamp:    true})
+	log.SetLevel(log.DebugLevel)
+	log.SetOutput(os.Stdout)
+}
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestRecords(t *testing.T) {
+	storage := &testStorage{}
+
+	history, err := updatehistory.New(&config.Config{}, storage)
+	if err != nil {
+		t.Fatalf("Can't create update history: %s", err)
+	}
+
+	record := updatehistory.Record{UpdateType: "fota", Started: time.Now()}
+
+	if err = history.AddRecord(&record); err != nil {
+		t.Fatalf("Can't add record: %s", err)
+	}
+
+	if record.ID != 1 {
+		t.Errorf("Wrong record ID: %d", record.ID)
+	}
+
+	record.Error = "update failed"
+
+	if err = history.UpdateRecord(record); err != nil {
+		t.Fatalf("Can't update record: %s", err)
+	}
+
+	if err = history.UpdateRecord(updatehistory.Record{ID: 2}); err == nil {
+		t.Error("Unknown record should not be updated")
+	}
+
+	// Recreate history to check it continues existing IDs
+
+	if history, err = updatehistory.New(&config.Config{}, storage); err != nil {
+		t.Fatalf("Can't create update history: %s", err)
+	}
+
+	record = updatehistory.Record{UpdateType: "sota", Started: time.Now()}
+
+	if err = history.AddRecord(&record); err != nil {
+		t.Fatalf("Can't add record: %s", err)
+	}
+
+	if record.ID != 2 {
+		t.Errorf("Wrong record ID: %d", record.ID)
+	}
+
+	records, err := history.GetRecords(updatehistory.Filter{})
+	if err != nil {
+		t.Fatalf("Can't get records: %s", err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("Wrong records count: %d", len(records))
+	}
+
+	if records[0].ID != 2 || records[1].ID != 1 || records[1].Error != "update failed" {
+		t.Errorf("Wrong records: %v", records)
+	}
+}
+
+func TestRemoveOutdatedRecords(t *testing.T) {
+	storage := &testStorage{}
+
+	history, err := updatehistory.New(&config.Config{
+		UpdateHistory: config.UpdateHistory{MaxRecords: 3, MaxAge: config.Duration{Duration: time.Hour}},
+	}, storage)
+	if err != nil {
+		t.Fatalf("Can't create update history: %s", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		record := updatehistory.Record{UpdateType: "fota", Started: time.Now()}
+
+		if i == 3 {
+			record.Started = time.Now().Add(-2 * time.Hour)
+		}
+
+		if err = history.AddRecord(&record); err != nil {
+			t.Fatalf("Can't add record: %s", err)
+		}
+	}
+
+	records, err := history.GetRecords(updatehistory.Filter{})
+	if err != nil {
+		t.Fatalf("Can't get records: %s", err)
+	}
+
+	if len(records) != 2 || records[0].ID != 5 || records[1].ID != 3 {
+		t.Errorf("Wrong records: %v", records)
+	}
+}
+
+/***********************************************************************************************************************
+ * Interfaces
+ **********************************************************************************************************************/
+
+func (storage *testStorage) SetUpdateHistoryRecord(record updatehistory.Record) (err error) {
+	for i := range storage.records {
+		if storage.records[i].ID == record.ID {
+			storage.records[i] = record
+
+			return nil
+		}
+	}
+
+	storage.records = append(storage.records, record)
+
+	return nil
+}
+
+func (storage *testStorage) GetUpdateHistoryRecords(
+	filter updatehistory.Filter) (records []updatehistory.Record, err error) {
+	for i := len(storage.records) - 1; i >= 0; i-- {
+		if filter.Limit != 0 && uint64(len(records)) >= filter.Limit {
+			break
+		}
+
+		records = append(records, storage.records[i])
+	}
+
+	return records, nil
+}
+
+func (storage *testStorage) RemoveUpdateHistoryRecords(beforeID uint64, startedBefore time.Time) (err error) {
+	var records []updatehistory.Record
+
+	for _, record := range storage.records {
+		if (beforeID != 0 && record.ID < beforeID) || record.Started.Before(startedBefore) {
+			continue
+		}
+
+		records = append(records, record)
+	}
+
+	storage.records = records
+
+	return nil
+}